@@ -22,7 +22,8 @@ const DataList = ({ entity, onSelectData, refresh }) => {
   const [inputSearch, setInputSearch] = useState('');
   const [searchTerm, setSearchTerm] = useState('');
   const [page, setPage] = useState(0);
-  const [totalPages, setTotalPages] = useState(1000);
+  const [cursors, setCursors] = useState(['']);
+  const [totalPages, setTotalPages] = useState(1);
   const [sortField, setSortField] = useState('created_at');
   const [sortOrder, setSortOrder] = useState('desc');
 
@@ -33,21 +34,27 @@ const DataList = ({ entity, onSelectData, refresh }) => {
     if (!endpoint) return;
     let url = `${BASE_URL}${endpoint}`;
     if (entity === 'products' || entity === 'blog-posts' || entity === 'reviews') {
-      const skip = page * limit;
       const params = new URLSearchParams({
         limit: limit,
         sortField: sortField,
         sortOrder: sortOrder,
-        skip: skip,
       });
+      if (cursors[page]) params.append('cursor', cursors[page]);
       if (searchTerm) params.append('search', searchTerm);
       url = `${url}?${params.toString()}`;
     }
     fetch(url, { credentials: 'include' })
       .then((res) => res.json())
       .then((json) => {
-        setData(json.data || json);
+        setData(json.items || json);
         if (json.total) setTotalPages(Math.ceil(json.total / limit));
+        if (json.next_cursor) {
+          setCursors((prev) => {
+            const next = prev.slice(0, page + 1);
+            next[page + 1] = json.next_cursor;
+            return next;
+          });
+        }
         setStatus('succeeded');
       })
       .catch(() => setStatus('failed'));
@@ -57,6 +64,17 @@ const DataList = ({ entity, onSelectData, refresh }) => {
     fetchData();
   }, [entity, refresh, page, searchTerm, sortField, sortOrder]);
 
+  // Cursors are only valid for the sort and search they were issued for.
+  useEffect(() => {
+    setPage(0);
+    setCursors(['']);
+  }, [entity, searchTerm, sortField, sortOrder]);
+
+  // Pages can only be reached through the cursor handed out by the previous one.
+  const handlePageChange = (target) => {
+    if (target < cursors.length) setPage(target);
+  };
+
   const handleFetchClick = () => {
     setPage(0);
     setSearchTerm(inputSearch);
@@ -79,8 +97,8 @@ const DataList = ({ entity, onSelectData, refresh }) => {
           <button onClick={handleFetchClick}>Fetch</button>
           <select value={sortField} onChange={(e) => setSortField(e.target.value)}>
             <option value="created_at">Created At</option>
-            <option value="price">Price</option>
-            <option value="model_name">Model Name</option>
+            {entity === 'products' && <option value="price">Price</option>}
+            {entity === 'products' && <option value="model_name">Model Name</option>}
           </select>
           <select value={sortOrder} onChange={(e) => setSortOrder(e.target.value)}>
             <option value="asc">Ascending</option>
@@ -106,7 +124,7 @@ const DataList = ({ entity, onSelectData, refresh }) => {
         ))}
       </ul>
       {entity === 'products' && (
-        <Pagination currentPage={page} totalPages={totalPages} onPageChange={setPage} />
+        <Pagination currentPage={page} totalPages={totalPages} onPageChange={handlePageChange} />
       )}
     </div>
   );
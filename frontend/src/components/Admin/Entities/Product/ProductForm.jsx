@@ -42,9 +42,9 @@ const ProductForm = ({ selectedData, onActionSuccess }) => {
   const [categories, setCategories] = useState([]);
 
   useEffect(() => {
-    fetch(`${BASE_URL}products/brands`, { credentials: 'include' })
+    fetch(`${BASE_URL}products/brands?limit=100`, { credentials: 'include' })
       .then(res => res.json())
-      .then(data => setBrands(data))
+      .then(data => setBrands(data.items))
       .catch(err => console.error(err));
     fetch(`${BASE_URL}products/types`, { credentials: 'include' })
       .then(res => res.json())
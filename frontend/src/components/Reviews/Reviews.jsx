@@ -109,8 +109,8 @@ const Reviews = ({ productId }) => {
         <Loader />
       ) : (
         <div className="reviews-list">
-          {reviewsData?.reviews && reviewsData.reviews.length > 0 ? (
-            reviewsData.reviews.map((review, idx) => (
+          {reviewsData?.items && reviewsData.items.length > 0 ? (
+            reviewsData.items.map((review, idx) => (
               <div className="review-item" key={idx}>
                 <div className="review-header">
                   <span className="reviewer">{review.reviewer || 'Anonymous'}</span>
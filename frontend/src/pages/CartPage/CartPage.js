@@ -13,12 +13,10 @@ const CartPage = () => {
   const { itemsCount, totalAmount } = useSelector((state) => state.cart);
 
   const handleCheckout = () => {
+    // prices are resolved by the payment service from the catalogue
     const paymentItems = carts.map(cart => ({
       id: cart.id,
-      model_name: cart.model_name,
-      price: Math.ceil(cart.discountedPrice)*100,
-      quantity: cart.quantity,
-      currency: "KZT"
+      quantity: cart.quantity
     }));
     dispatch(createCheckoutSession(paymentItems))
       .unwrap()
@@ -46,7 +46,7 @@ const postSlice = createSlice({
 export const fetchAsyncPosts = createAsyncThunk('posts/fetch', async(limit) => {
     const response = await fetch(`${BASE_URL}blogs/blog-posts?limit=${limit}`);
     const data = await response.json();
-    return data;
+    return data.items;
 });
 
 export const fetchAsyncPostSingle = createAsyncThunk('post-single/fetch', async(id) => {
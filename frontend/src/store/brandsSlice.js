@@ -44,15 +44,15 @@ const brandSlice = createSlice({
 });
 
 export const fetchAsyncBrands = createAsyncThunk('brands/fetch', async() => {
-    const response = await fetch(`${BASE_URL}products/brands`);
+    const response = await fetch(`${BASE_URL}products/brands?limit=100`);
     const data = await response.json();
-    return data.map(brand => brand.brand_name);
+    return data.items.map(brand => brand.brand_name);
 });
 
 export const fetchAsyncProductsOfBrand = createAsyncThunk('brand-products/fetch', async(brand) => {
     const response = await fetch(`${BASE_URL}products/products?limit=100`);
     const data = await response.json();
-    return data.filter(product => product.brand === brand);
+    return data.items.filter(product => product.brand === brand);
 });
 
 export const getAllBrands = (state) => state.brand.brands;
@@ -46,7 +46,7 @@ const productSlice = createSlice({
 export const fetchAsyncProducts = createAsyncThunk('products/fetch', async(limit) => {
     const response = await fetch(`${BASE_URL}products/products?limit=${limit}`);
     const data = await response.json();
-    return data;
+    return data.items;
 });
 
 export const fetchAsyncProductSingle = createAsyncThunk('product-single/fetch', async(id) => {
@@ -13,7 +13,7 @@ const initialState = {
 export const fetchAsyncReviews = createAsyncThunk('reviews/fetch', async () => {
   const response = await fetch(`${BASE_URL}reviews/reviews`);
   const data = await response.json();
-  return data;
+  return data.items;
 });
 
 export const fetchAsyncReviewById = createAsyncThunk('review-id/fetch', async (id) => {
@@ -25,7 +25,7 @@ export const fetchAsyncReviewById = createAsyncThunk('review-id/fetch', async (i
 export const fetchAsyncReviewsOfUser = createAsyncThunk('reviews-user/fetch', async (user_id) => {
   const response = await fetch(`${BASE_URL}reviews/reviews/customer/${user_id}`);
   const data = await response.json();
-  return data;
+  return data.items;
 });
 
 export const fetchAsyncReviewsOfProduct = createAsyncThunk('reviews-product/fetch', async (product_id) => {
@@ -100,8 +100,8 @@ const reviewSlice = createSlice({
       })
       .addCase(createAsyncReview.fulfilled, (state, action) => {
         state.reviewCreateStatus = STATUS.SUCCEEDED;
-        if (state.reviewProducts && state.reviewProducts.reviews) {
-          state.reviewProducts.reviews.unshift(action.payload);
+        if (state.reviewProducts && state.reviewProducts.items) {
+          state.reviewProducts.items.unshift(action.payload);
         }
       })
       .addCase(createAsyncReview.rejected, (state) => {
@@ -35,7 +35,7 @@ const searchSlice = createSlice({
 export const fetchAsyncSearchProduct = createAsyncThunk('product-search/fetch', async(searchTerm) => {
     const response = await fetch(`${BASE_URL}products/products?limit=100&search=${searchTerm}`);
     const data = await response.json();
-    return data;
+    return data.items;
 });
 
 export const { setSearchTerm, clearSearch } = searchSlice.actions;
@@ -52,7 +52,7 @@ export const fetchAsyncTypes = createAsyncThunk('types/fetch', async() => {
 export const fetchAsyncProductsOfType = createAsyncThunk('type-products/fetch', async(type) => {
     const response = await fetch(`${BASE_URL}products/products?limit=100`);
     const data = await response.json();
-    return data.filter(product => product.type === type);
+    return data.items.filter(product => product.type === type);
 });
 
 export const getAllTypes = (state) => state.type.types;
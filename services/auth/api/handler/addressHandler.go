@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	domain "github.com/mephirious/group-project/services/auth/domain"
+)
+
+func (s *ApiServer) getAddressesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := s.svc.GetAddresses(context.Background(), domain.AddressesInput{UserID: user.UserID})
+	if err != nil {
+		writeJSON(w, addressErrorStatus(err), map[string]any{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *ApiServer) createAddressHandler(w http.ResponseWriter, r *http.Request) {
+	s.saveAddress(w, r, "", http.StatusCreated)
+}
+
+func (s *ApiServer) updateAddressHandler(w http.ResponseWriter, r *http.Request) {
+	s.saveAddress(w, r, r.PathValue("id"), http.StatusOK)
+}
+
+func (s *ApiServer) saveAddress(w http.ResponseWriter, r *http.Request, addressID string, status int) {
+	user, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	var address domain.AddressSchema
+	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "Invalid JSON body"})
+		return
+	}
+
+	response, err := s.svc.SaveAddress(context.Background(), domain.AddressInput{
+		UserID:    user.UserID,
+		AddressID: addressID,
+		Address:   address,
+	})
+	if err != nil {
+		writeJSON(w, addressErrorStatus(err), map[string]any{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, status, response)
+}
+
+func (s *ApiServer) deleteAddressHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := s.svc.DeleteAddress(context.Background(), domain.AddressInput{
+		UserID:    user.UserID,
+		AddressID: r.PathValue("id"),
+	})
+	if err != nil {
+		writeJSON(w, addressErrorStatus(err), map[string]any{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// authenticate returns the user of the request's access token, answering
+// 401 when there is none or it is not valid.
+func (s *ApiServer) authenticate(w http.ResponseWriter, r *http.Request) (*domain.ValidateResponse, bool) {
+	accessToken, err := r.Cookie("access_token")
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "Missing access token"})
+		return nil, false
+	}
+	user, err := s.svc.ValidateAccessToken(context.Background(), domain.LogoutInput{
+		AccessToken: accessToken.Value,
+	})
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{"error": err.Error()})
+		return nil, false
+	}
+	return user, true
+}
+
+func addressErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrAddressNotFound), errors.Is(err, domain.ErrCustomerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidAddress):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -34,6 +34,10 @@ func (s *ApiServer) Start(listenAddr string, prefix string) error {
 	http.HandleFunc("POST "+prefix+"/login", s.loginHandler)
 	http.HandleFunc("GET "+prefix+"/logout", s.logoutHandler)
 	http.HandleFunc("GET "+prefix+"/refresh", s.refreshHandler)
+	http.HandleFunc("GET "+prefix+"/addresses", s.getAddressesHandler)
+	http.HandleFunc("POST "+prefix+"/addresses", s.createAddressHandler)
+	http.HandleFunc("PUT "+prefix+"/addresses/{id}", s.updateAddressHandler)
+	http.HandleFunc("DELETE "+prefix+"/addresses/{id}", s.deleteAddressHandler)
 	// http.HandleFunc(prefix+"/email/verify/{verification_code}", s.verifyEmailHandler)
 	return s.srv.ListenAndServe()
 }
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mephirious/group-project/services/auth/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (db *DB) GetCustomerAddresses(ctx context.Context, customerID string) ([]domain.AddressSchema, error) {
+	collection := db.DB.Collection("customers")
+	filter := bson.M{"_id": customerID}
+	opts := options.FindOne().SetProjection(bson.M{"addresses": 1})
+
+	var customer domain.CustomerSchema
+	err := collection.FindOne(ctx, filter, opts).Decode(&customer)
+	if err != nil {
+		return nil, err
+	}
+
+	return customer.Addresses, nil
+}
+
+// AddCustomerAddress appends address to the customer's address book unless it
+// already holds limit addresses. It is false when nothing was added.
+func (db *DB) AddCustomerAddress(ctx context.Context, customerID string, address domain.AddressSchema, limit int) (bool, error) {
+	collection := db.DB.Collection("customers")
+	filter := bson.M{
+		"_id":   customerID,
+		"$expr": bson.M{"$lt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$addresses", bson.A{}}}}, limit}},
+	}
+	update := bson.M{
+		"$push": bson.M{"addresses": address},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// UpdateCustomerAddress overwrites the fields of the address with address.ID
+// in place. Whether it is the default is left alone; SetDefaultAddress moves
+// the default. It is false when the customer has no such address.
+func (db *DB) UpdateCustomerAddress(ctx context.Context, customerID string, address domain.AddressSchema) (bool, error) {
+	data, err := bson.Marshal(address)
+	if err != nil {
+		return false, err
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(data, &fields); err != nil {
+		return false, err
+	}
+
+	set := bson.M{"updated_at": time.Now()}
+	for name, value := range fields {
+		if name != "_id" && name != "default" {
+			set["addresses.$."+name] = value
+		}
+	}
+	// optional fields left out of the address are cleared
+	unset := bson.M{}
+	for _, name := range []string{"label", "line2", "state", "phone"} {
+		if _, ok := fields[name]; !ok {
+			unset["addresses.$."+name] = ""
+		}
+	}
+
+	collection := db.DB.Collection("customers")
+	filter := bson.M{"_id": customerID, "addresses._id": address.ID}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// SetDefaultAddress makes addressID the customer's only default address. It
+// is false when the customer has no such address.
+func (db *DB) SetDefaultAddress(ctx context.Context, customerID, addressID string) (bool, error) {
+	collection := db.DB.Collection("customers")
+	filter := bson.M{"_id": customerID, "addresses._id": addressID}
+	update := bson.M{"$set": bson.M{
+		"addresses.$[address].default": true,
+		"addresses.$[other].default":   false,
+		"updated_at":                   time.Now(),
+	}}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{Filters: bson.A{
+		bson.M{"address._id": addressID},
+		bson.M{"other._id": bson.M{"$ne": addressID}},
+	}})
+
+	result, err := collection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// RemoveCustomerAddress deletes addressID from the customer's address book.
+// When that leaves no default, the first remaining address becomes the
+// default. It is false when the customer has no such address.
+func (db *DB) RemoveCustomerAddress(ctx context.Context, customerID, addressID string) (bool, error) {
+	collection := db.DB.Collection("customers")
+	filter := bson.M{"_id": customerID, "addresses._id": addressID}
+	update := bson.M{
+		"$pull": bson.M{"addresses": bson.M{"_id": addressID}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	if result.ModifiedCount == 0 {
+		return false, nil
+	}
+
+	filter = bson.M{
+		"_id":               customerID,
+		"addresses.0":       bson.M{"$exists": true},
+		"addresses.default": bson.M{"$ne": true},
+	}
+	update = bson.M{"$set": bson.M{"addresses.0.default": true}}
+	if _, err := collection.UpdateOne(ctx, filter, update); err != nil {
+		return true, err
+	}
+	return true, nil
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// MaxAddresses is how many addresses a customer's address book can hold.
+const MaxAddresses = 20
+
+var (
+	ErrAddressNotFound  = errors.New("address not found")
+	ErrCustomerNotFound = errors.New("customer not found")
+	ErrInvalidAddress   = errors.New("invalid address")
+)
+
+// AddressSchema is an entry of a customer's address book. Its fields match
+// the shipping and billing address of a payment-service checkout.
+type AddressSchema struct {
+	ID         string `bson:"_id" json:"id"`
+	Label      string `bson:"label,omitempty" json:"label,omitempty"`
+	Name       string `bson:"name" json:"name"`
+	Line1      string `bson:"line1" json:"line1"`
+	Line2      string `bson:"line2,omitempty" json:"line2,omitempty"`
+	City       string `bson:"city" json:"city"`
+	State      string `bson:"state,omitempty" json:"state,omitempty"`
+	PostalCode string `bson:"postal_code" json:"postal_code"`
+	Country    string `bson:"country" json:"country"`
+	Phone      string `bson:"phone,omitempty" json:"phone,omitempty"`
+	Default    bool   `bson:"default" json:"default"`
+}
+
+func (a *AddressSchema) Validate() error {
+	a.Name = strings.TrimSpace(a.Name)
+	a.Line1 = strings.TrimSpace(a.Line1)
+	a.City = strings.TrimSpace(a.City)
+	a.PostalCode = strings.TrimSpace(a.PostalCode)
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+
+	if a.Name == "" {
+		return errors.New("name is required")
+	}
+	if a.Line1 == "" {
+		return errors.New("line1 is required")
+	}
+	if a.City == "" {
+		return errors.New("city is required")
+	}
+	if a.PostalCode == "" {
+		return errors.New("postal_code is required")
+	}
+	if len(a.Country) != 2 {
+		return errors.New("country must be a two-letter ISO code")
+	}
+
+	return nil
+}
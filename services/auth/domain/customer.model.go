@@ -16,15 +16,16 @@ type CustomerView struct {
 }
 
 type CustomerSchema struct {
-	ID        string    `bson:"_id"`
-	Email     string    `bson:"email"`
-	Username  string    `bson:"username"`
-	Password  string    `bson:"password"`
-	Role      string    `bson:"role"`
-	FirstName string    `bson:"first_name,omitempty"`
-	LastName  string    `bson:"last_name,omitempty"`
-	Phone     string    `bson:"phone,omitempty"`
-	Verified  bool      `bson:"verified"`
-	CreatedAt time.Time `bson:"created_at"`
-	UpdatedAt time.Time `bson:"updated_at,omitempty"`
+	ID        string          `bson:"_id"`
+	Email     string          `bson:"email"`
+	Username  string          `bson:"username"`
+	Password  string          `bson:"password"`
+	Role      string          `bson:"role"`
+	FirstName string          `bson:"first_name,omitempty"`
+	LastName  string          `bson:"last_name,omitempty"`
+	Phone     string          `bson:"phone,omitempty"`
+	Verified  bool            `bson:"verified"`
+	Addresses []AddressSchema `bson:"addresses,omitempty"`
+	CreatedAt time.Time       `bson:"created_at"`
+	UpdatedAt time.Time       `bson:"updated_at,omitempty"`
 }
@@ -42,6 +42,17 @@ type (
 		UserID string `json:"user_id"`
 		Role   string `json:"role"`
 	}
+	AddressesInput struct {
+		UserID string
+	}
+	AddressInput struct {
+		UserID    string
+		AddressID string
+		Address   AddressSchema
+	}
+	AddressesResponse struct {
+		Addresses []AddressSchema `json:"addresses"`
+	}
 )
 
 type List[T any] struct {
@@ -55,6 +66,9 @@ type Service interface {
 	Logout(context.Context, LogoutInput) (*LogoutResponse, error)
 	RefreshUserAccessToken(context.Context, RefreshInput) (*LoginResponse, error)
 	ValidateAccessToken(context.Context, LogoutInput) (*ValidateResponse, error)
+	GetAddresses(context.Context, AddressesInput) (*AddressesResponse, error)
+	SaveAddress(context.Context, AddressInput) (*AddressesResponse, error)
+	DeleteAddress(context.Context, AddressInput) (*AddressesResponse, error)
 }
 
 func (i *LoginInput) Validate() error {
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mephirious/group-project/services/auth/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (s *AuthService) GetAddresses(ctx context.Context, input domain.AddressesInput) (*domain.AddressesResponse, error) {
+	addresses, err := s.DB.GetCustomerAddresses(ctx, input.UserID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %v", err)
+	}
+
+	return &domain.AddressesResponse{Addresses: nonNil(addresses)}, nil
+}
+
+// SaveAddress adds the address to the customer's address book, or replaces
+// the one with input.AddressID. The first address is the default one, and
+// making another address the default takes it from the previous one. Each
+// change is a single update of the address book, so concurrent saves do not
+// overwrite each other.
+func (s *AuthService) SaveAddress(ctx context.Context, input domain.AddressInput) (*domain.AddressesResponse, error) {
+	address := input.Address
+	if err := address.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidAddress, err)
+	}
+
+	response, err := s.GetAddresses(ctx, domain.AddressesInput{UserID: input.UserID})
+	if err != nil {
+		return nil, err
+	}
+
+	if input.AddressID == "" {
+		address.ID = primitive.NewObjectID().Hex()
+		address.Default = address.Default || len(response.Addresses) == 0
+		added, err := s.DB.AddCustomerAddress(ctx, input.UserID, address, domain.MaxAddresses)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save address: %v", err)
+		}
+		if !added {
+			return nil, fmt.Errorf("%w: at most %d addresses can be saved", domain.ErrInvalidAddress, domain.MaxAddresses)
+		}
+	} else {
+		address.ID = input.AddressID
+		updated, err := s.DB.UpdateCustomerAddress(ctx, input.UserID, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save address: %v", err)
+		}
+		if !updated {
+			return nil, domain.ErrAddressNotFound
+		}
+	}
+
+	// the default can only be moved to another address, not dropped
+	if address.Default {
+		moved, err := s.DB.SetDefaultAddress(ctx, input.UserID, address.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save address: %v", err)
+		}
+		if !moved {
+			return nil, domain.ErrAddressNotFound
+		}
+	}
+
+	return s.GetAddresses(ctx, domain.AddressesInput{UserID: input.UserID})
+}
+
+// DeleteAddress removes input.AddressID from the customer's address book.
+// When it was the default, the first remaining address becomes the default.
+func (s *AuthService) DeleteAddress(ctx context.Context, input domain.AddressInput) (*domain.AddressesResponse, error) {
+	removed, err := s.DB.RemoveCustomerAddress(ctx, input.UserID, input.AddressID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete address: %v", err)
+	}
+	if !removed {
+		if _, err := s.GetAddresses(ctx, domain.AddressesInput{UserID: input.UserID}); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrAddressNotFound
+	}
+
+	return s.GetAddresses(ctx, domain.AddressesInput{UserID: input.UserID})
+}
+
+func nonNil(addresses []domain.AddressSchema) []domain.AddressSchema {
+	if addresses == nil {
+		return []domain.AddressSchema{}
+	}
+	return addresses
+}
@@ -104,3 +104,54 @@ func (s *LoggingService) ValidateAccessToken(ctx context.Context, input domain.L
 	}()
 	return s.next.ValidateAccessToken(ctx, input)
 }
+
+func (s *LoggingService) GetAddresses(ctx context.Context, input domain.AddressesInput) (response *domain.AddressesResponse, err error) {
+	start := time.Now()
+	defer func() {
+		logger := s.logger
+		if response != nil {
+			logger = s.logger.With(slog.Int("addresses", len(response.Addresses)))
+		} else {
+			logger = s.logger.With(slog.Any("err", err))
+		}
+		logger.Info(
+			"GetAddresses",
+			"took", time.Since(start).String(),
+		)
+	}()
+	return s.next.GetAddresses(ctx, input)
+}
+
+func (s *LoggingService) SaveAddress(ctx context.Context, input domain.AddressInput) (response *domain.AddressesResponse, err error) {
+	start := time.Now()
+	defer func() {
+		logger := s.logger
+		if response != nil {
+			logger = s.logger.With(slog.Int("addresses", len(response.Addresses)))
+		} else {
+			logger = s.logger.With(slog.Any("err", err))
+		}
+		logger.Info(
+			"SaveAddress",
+			"took", time.Since(start).String(),
+		)
+	}()
+	return s.next.SaveAddress(ctx, input)
+}
+
+func (s *LoggingService) DeleteAddress(ctx context.Context, input domain.AddressInput) (response *domain.AddressesResponse, err error) {
+	start := time.Now()
+	defer func() {
+		logger := s.logger
+		if response != nil {
+			logger = s.logger.With(slog.Int("addresses", len(response.Addresses)))
+		} else {
+			logger = s.logger.With(slog.Any("err", err))
+		}
+		logger.Info(
+			"DeleteAddress",
+			"took", time.Since(start).String(),
+		)
+	}()
+	return s.next.DeleteAddress(ctx, input)
+}
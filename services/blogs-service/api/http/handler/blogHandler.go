@@ -4,11 +4,11 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"github.com/mephirious/group-project/services/products-service/usecase"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -19,6 +19,12 @@ type BlogPostRequest struct {
 	Image   string `json:"image"`
 }
 
+var blogPostSort = pagination.Sort{
+	Fields:       []string{"created_at", "updated_at", "title"},
+	DefaultField: "created_at",
+	DefaultOrder: "desc",
+}
+
 type BlogPostHandler struct {
 	useCase usecase.BlogPostUseCase
 }
@@ -35,12 +41,14 @@ func NewBlogPostHandler(router *gin.Engine, useCase usecase.BlogPostUseCase) {
 }
 
 func (h *BlogPostHandler) GetAllBlogPosts(g *gin.Context) {
-	limit, _ := strconv.Atoi(g.DefaultQuery("limit", "10"))
-	skip, _ := strconv.Atoi(g.DefaultQuery("skip", "0"))
-	sortField := g.DefaultQuery("sortField", "created_at")
-	sortOrder := g.DefaultQuery("sortOrder", "desc")
+	params, err := pagination.FromQuery(g, blogPostSort)
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
 
-	posts, err := h.useCase.GetAllBlogPosts(g.Request.Context(), limit, skip, sortField, sortOrder)
+	posts, err := h.useCase.GetAllBlogPosts(g.Request.Context(), params)
 	if err != nil {
 		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
@@ -5,14 +5,14 @@ import (
 	"time"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type BlogPostRepository interface {
-	GetAllBlogPosts(ctx context.Context, limit, skip int, sortField, sortOrder string) ([]domain.BlogPost, error)
+	GetAllBlogPosts(ctx context.Context, params pagination.Params) (*pagination.Page[domain.BlogPost], error)
 	GetBlogPostByID(ctx context.Context, id primitive.ObjectID) (*domain.BlogPost, error)
 	GetBlogPostByTitle(ctx context.Context, title string) (*domain.BlogPost, error)
 	CreateBlogPost(ctx context.Context, post *domain.BlogPost) error
@@ -30,22 +30,16 @@ func NewBlogPostRepository(db *mongo.Database) *blogPostRepository {
 	}
 }
 
-func (r *blogPostRepository) GetAllBlogPosts(ctx context.Context, limit, skip int, sortField, sortOrder string) ([]domain.BlogPost, error) {
+func (r *blogPostRepository) GetAllBlogPosts(ctx context.Context, params pagination.Params) (*pagination.Page[domain.BlogPost], error) {
 	var posts []domain.BlogPost
 
-	sortValue := 1
-	if sortOrder == "desc" {
-		sortValue = -1
+	filter := bson.M{}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
 
-	sortOptions := bson.D{{Key: sortField, Value: sortValue}}
-
-	findOptions := options.Find()
-	findOptions.SetLimit(int64(limit))
-	findOptions.SetSkip(int64(skip))
-	findOptions.SetSort(sortOptions)
-
-	cursor, err := r.collection.Find(ctx, bson.D{}, findOptions)
+	cursor, err := r.collection.Find(ctx, params.Query(filter), params.FindOptions())
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +50,7 @@ func (r *blogPostRepository) GetAllBlogPosts(ctx context.Context, limit, skip in
 		return nil, err
 	}
 
-	return posts, nil
+	return pagination.NewPage(posts, total, params)
 }
 
 func (r *blogPostRepository) GetBlogPostByID(ctx context.Context, id primitive.ObjectID) (*domain.BlogPost, error) {
@@ -5,12 +5,13 @@ import (
 	"errors"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"github.com/mephirious/group-project/services/products-service/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type BlogPostUseCase interface {
-	GetAllBlogPosts(ctx context.Context, limit, skip int, sortField, sortOrder string) ([]domain.BlogPost, error)
+	GetAllBlogPosts(ctx context.Context, params pagination.Params) (*pagination.Page[domain.BlogPost], error)
 	GetBlogPostByID(ctx context.Context, id primitive.ObjectID) (*domain.BlogPost, error)
 	GetBlogPostByTitle(ctx context.Context, title string) (*domain.BlogPost, error)
 	CreateBlogPost(ctx context.Context, post *domain.BlogPost) error
@@ -28,8 +29,8 @@ func NewBlogPostUseCase(repository repository.BlogPostRepository) *blogPostUseCa
 	}
 }
 
-func (u *blogPostUseCase) GetAllBlogPosts(ctx context.Context, limit, skip int, sortField, sortOrder string) ([]domain.BlogPost, error) {
-	return u.blogPostRepository.GetAllBlogPosts(ctx, limit, skip, sortField, sortOrder)
+func (u *blogPostUseCase) GetAllBlogPosts(ctx context.Context, params pagination.Params) (*pagination.Page[domain.BlogPost], error) {
+	return u.blogPostRepository.GetAllBlogPosts(ctx, params)
 }
 
 func (u *blogPostUseCase) GetBlogPostByID(ctx context.Context, id primitive.ObjectID) (*domain.BlogPost, error) {
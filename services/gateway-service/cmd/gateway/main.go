@@ -6,8 +6,6 @@ import (
 	"os"
 
 	"github.com/mephirious/group-project/services/gateway-service/config"
-	"github.com/mephirious/group-project/services/gateway-service/internal/middleware"
-	"github.com/mephirious/group-project/services/gateway-service/internal/proxy"
 )
 
 func main() {
@@ -43,35 +41,7 @@ func main() {
 
 	go cfg.HealthCheckLoop()
 
-	http.Handle("/auth/", middleware.CORS(middleware.Logging(proxy.ReverseProxyHandler(authServiceURL))))
-	http.Handle("/products/", middleware.CORS(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL))))
-	http.Handle("/blogs/", middleware.CORS(middleware.Logging(proxy.ReverseProxyHandler(blogsServiceURL))))
-	http.Handle("/reviews/", middleware.CORS(middleware.Logging(proxy.ReverseProxyHandler(reviewsServiceURL))))
-	http.Handle("/payment/", middleware.CORS(middleware.Logging(proxy.ReverseProxyHandler(paymentServiceURL))))
-
-	brandPermissions := map[string]string{
-		"GET":    "",
-		"POST":   "admin",
-		"PUT":    "admin",
-		"DELETE": "admin",
-	}
-
-	http.Handle("/products/brands", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
-	http.Handle("/products/categories", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
-	http.Handle("/products/inventory", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
-	http.Handle("/products/products", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
-	http.Handle("/products/types", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
-
-	http.Handle("/blogs/blog-posts", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(blogsServiceURL)), brandPermissions)))
-
-	reviewPermissions := map[string]string{
-		"GET":    "",
-		"POST":   "user",
-		"PUT":    "admin",
-		"DELETE": "admin",
-	}
-
-	http.Handle("/reviews/reviews", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(reviewsServiceURL)), reviewPermissions)))
+	registerRoutes(http.DefaultServeMux, authServiceURL, productsServiceURL, blogsServiceURL, reviewsServiceURL, paymentServiceURL)
 
 	// Start Gateway Server
 	log.Printf("Gateway running on %s", PORT)
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/mephirious/group-project/services/gateway-service/internal/middleware"
+	"github.com/mephirious/group-project/services/gateway-service/internal/proxy"
+)
+
+// registerRoutes proxies each service's paths through mux. Paths without a
+// guard of their own fall through to the service's catch-all, so every
+// subtree with admin-only writes needs both the exact path and the one
+// ending in a slash.
+func registerRoutes(mux *http.ServeMux, authServiceURL, productsServiceURL, blogsServiceURL, reviewsServiceURL, paymentServiceURL string) {
+	mux.Handle("/auth/", middleware.CORS(middleware.Logging(proxy.ReverseProxyHandler(authServiceURL))))
+	mux.Handle("/products/", middleware.CORS(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL))))
+	mux.Handle("/blogs/", middleware.CORS(middleware.Logging(proxy.ReverseProxyHandler(blogsServiceURL))))
+	mux.Handle("/reviews/", middleware.CORS(middleware.Logging(proxy.ReverseProxyHandler(reviewsServiceURL))))
+	mux.Handle("/payment/", middleware.CORS(middleware.Logging(proxy.ReverseProxyHandler(paymentServiceURL))))
+
+	brandPermissions := map[string]string{
+		"GET":    "",
+		"POST":   "admin",
+		"PUT":    "admin",
+		"DELETE": "admin",
+	}
+
+	mux.Handle("/products/brands", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/brands/", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/categories", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/categories/", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/inventory", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/inventories", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/inventories/", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/locations", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/locations/", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/transfers", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/transfers/", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/products", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/products/", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/types", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/types/", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/currencies", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+	mux.Handle("/products/currencies/", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(productsServiceURL)), brandPermissions)))
+
+	// The stock movements of checkout, shipping and refunds are for
+	// payment-service, which calls products-service directly.
+	mux.Handle("/products/payment/", middleware.CORS(middleware.Logging(http.NotFound)))
+
+	mux.Handle("/blogs/blog-posts", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(blogsServiceURL)), brandPermissions)))
+
+	reviewPermissions := map[string]string{
+		"GET":    "",
+		"POST":   "user",
+		"PUT":    "admin",
+		"DELETE": "admin",
+	}
+
+	mux.Handle("/reviews/reviews", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(reviewsServiceURL)), reviewPermissions)))
+
+	orderPermissions := map[string]string{
+		"GET":  "user",
+		"POST": "user",
+	}
+
+	mux.Handle("/payment/create-checkout-session", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(paymentServiceURL)), orderPermissions)))
+	mux.Handle("/payment/orders", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(paymentServiceURL)), orderPermissions)))
+	mux.Handle("/payment/orders/", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(paymentServiceURL)), orderPermissions)))
+
+	// Carts work for anonymous shoppers too; checkout is refused by
+	// payment-service unless the user is signed in.
+	mux.Handle("/payment/cart", middleware.CORS(middleware.OptionalAuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(paymentServiceURL)))))
+	mux.Handle("/payment/cart/", middleware.CORS(middleware.OptionalAuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(paymentServiceURL)))))
+	mux.Handle("/payment/shipping/", middleware.CORS(middleware.OptionalAuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(paymentServiceURL)))))
+
+	adminPermissions := map[string]string{
+		"GET":    "admin",
+		"POST":   "admin",
+		"PUT":    "admin",
+		"DELETE": "admin",
+	}
+
+	mux.Handle("/payment/admin/", middleware.CORS(middleware.AuthMiddleware(middleware.Logging(proxy.ReverseProxyHandler(paymentServiceURL)), adminPermissions)))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnonymousCascadeDeleteIsRefused(t *testing.T) {
+	t.Setenv("CORS_URLS", "http://localhost:3000")
+
+	hits := 0
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer backend.Close()
+
+	mux := http.NewServeMux()
+	registerRoutes(mux, backend.URL, backend.URL, backend.URL, backend.URL, backend.URL)
+
+	for _, path := range []string{
+		"/products/brands/64b7f0c2a1e4d3f5b6c7d8e9",
+		"/products/categories/64b7f0c2a1e4d3f5b6c7d8e9",
+		"/products/types/64b7f0c2a1e4d3f5b6c7d8e9",
+	} {
+		t.Run(path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, path+"?strategy=cascade", nil))
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+
+	// reads stay public
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/products/brands/64b7f0c2a1e4d3f5b6c7d8e9", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("GET status = %d, want it proxied", rec.Code)
+	}
+	if hits != 1 {
+		t.Errorf("backend hit %d times, want only by the GET", hits)
+	}
+}
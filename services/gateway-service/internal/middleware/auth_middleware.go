@@ -10,6 +10,13 @@ import (
 	"time"
 )
 
+// Headers the gateway sets on proxied requests to identify the verified user.
+// Any client-supplied values are stripped by the reverse proxy.
+const (
+	UserIDHeader   = "X-User-ID"
+	UserRoleHeader = "X-User-Role"
+)
+
 // UserClaims stores verified user data
 type UserClaims struct {
 	UserID string `json:"user_id"`
@@ -81,6 +88,34 @@ func AuthMiddleware(next http.Handler, requiredRoles map[string]string) http.Han
 	})
 }
 
+// OptionalAuthMiddleware identifies the user when the request carries a
+// valid access token and otherwise lets it through anonymously, for routes
+// that serve both signed-in and anonymous users.
+func OptionalAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("access_token")
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := ValidateToken(cookie.Value)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "user", claims)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ClaimsFromContext returns the claims stored by AuthMiddleware, if any.
+func ClaimsFromContext(ctx context.Context) (*UserClaims, bool) {
+	claims, ok := ctx.Value("user").(*UserClaims)
+	return claims, ok
+}
+
 func hasPermission(userRole, requiredRole string) bool {
 	roleHierarchy := map[string]int{"": 0, "user": 1, "admin": 2}
 	return roleHierarchy[userRole] >= roleHierarchy[requiredRole]
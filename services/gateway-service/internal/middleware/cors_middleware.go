@@ -28,7 +28,7 @@ func CORS(next http.Handler) http.Handler {
 		if allowOrigin != "" {
 			w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Cookie")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Cookie, Idempotency-Key")
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 		}
 
@@ -6,6 +6,8 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"strings"
+
+	"github.com/mephirious/group-project/services/gateway-service/internal/middleware"
 )
 
 // ReverseProxyHandler forwards requests to the target service
@@ -27,6 +29,14 @@ func ReverseProxyHandler(target string) http.HandlerFunc {
 		r.URL.Path = strings.TrimPrefix(r.URL.Path, "/blogs")
 		r.URL.Path = strings.TrimPrefix(r.URL.Path, "/payment")
 
+		// Only forward identity verified by AuthMiddleware
+		r.Header.Del(middleware.UserIDHeader)
+		r.Header.Del(middleware.UserRoleHeader)
+		if claims, ok := middleware.ClaimsFromContext(r.Context()); ok {
+			r.Header.Set(middleware.UserIDHeader, claims.UserID)
+			r.Header.Set(middleware.UserRoleHeader, claims.Role)
+		}
+
 		// Ensure the proxy forwards the correct host
 		r.Host = targetURL.Host
 		r.URL.Host = targetURL.Host
@@ -0,0 +1,286 @@
+package invoices
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+)
+
+// Page layout in points, on A4 paper.
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	marginLeft   = 50.0
+	marginRight  = pageWidth - 50
+	marginTop    = pageHeight - 60
+	marginBottom = 60.0
+	fontSize     = 9.0
+	lineHeight   = 13.0
+)
+
+// columns are the right edges of the line table's number columns; the
+// description takes the space left of them.
+var columns = struct{ quantity, unitPrice, discount, tax, total float64 }{300, 375, 440, 495, marginRight}
+
+// RenderPDF lays the invoice out on as many A4 pages as its lines need.
+// The PDF uses the standard Helvetica fonts, which need not be embedded but
+// only cover Latin-1; other characters are printed as "?". The HTML
+// rendering has no such limit.
+func (r *Renderer) RenderPDF(invoice *domain.Invoice) ([]byte, error) {
+	doc := &pdfDocument{}
+	doc.newPage()
+
+	doc.text(marginLeft, doc.y, 20, true, strings.ToUpper(title(invoice)))
+	details := []string{"No. " + invoice.Number, "Date " + invoice.IssuedAt.Format("2006-01-02"), "Order " + invoice.OrderID.Hex()}
+	if invoice.CreditedInvoice != "" {
+		details = append(details, "Credits invoice "+invoice.CreditedInvoice)
+	}
+	for n, line := range details {
+		doc.textRight(marginRight, doc.y-float64(n)*lineHeight, fontSize, false, line)
+	}
+	doc.y -= float64(len(details)+1) * lineHeight
+
+	seller := addressLines(&invoice.Seller.Address)
+	if invoice.Seller.TaxID != "" {
+		seller = append(seller, "Tax ID "+invoice.Seller.TaxID)
+	}
+	if invoice.Seller.Email != "" {
+		seller = append(seller, invoice.Seller.Email)
+	}
+	doc.block(
+		partyBlock{x: marginLeft, heading: invoice.Seller.Name, lines: seller},
+		partyBlock{x: 230, heading: "Bill to", lines: addressLines(invoice.BillTo)},
+		partyBlock{x: 410, heading: "Ship to", lines: addressLines(invoice.ShipTo)},
+	)
+
+	header := func() {
+		doc.text(marginLeft, doc.y, fontSize, true, "Description")
+		doc.textRight(columns.quantity, doc.y, fontSize, true, "Qty")
+		doc.textRight(columns.unitPrice, doc.y, fontSize, true, "Unit price")
+		doc.textRight(columns.discount, doc.y, fontSize, true, "Discount")
+		doc.textRight(columns.tax, doc.y, fontSize, true, "Tax")
+		doc.textRight(columns.total, doc.y, fontSize, true, "Total ("+strings.ToUpper(invoice.Currency)+")")
+		doc.rule(doc.y - 4)
+		doc.y -= lineHeight + 4
+	}
+	header()
+	for _, line := range invoice.Lines {
+		if doc.y < marginBottom {
+			doc.newPage()
+			header()
+		}
+		descriptionWidth := columns.quantity - 40 - marginLeft
+		doc.text(marginLeft, doc.y, fontSize, false, truncate(line.Description, fontSize, descriptionWidth))
+		doc.textRight(columns.quantity, doc.y, fontSize, false, strconv.FormatInt(line.Quantity, 10))
+		doc.textRight(columns.unitPrice, doc.y, fontSize, false, amount(line.UnitPrice, invoice.Currency))
+		if line.Discount != 0 {
+			doc.textRight(columns.discount, doc.y, fontSize, false, "-"+amount(line.Discount, invoice.Currency))
+		}
+		doc.textRight(columns.tax, doc.y, fontSize, false, amount(line.Tax, invoice.Currency))
+		doc.textRight(columns.total, doc.y, fontSize, false, amount(line.Total, invoice.Currency))
+		doc.y -= lineHeight
+	}
+	doc.rule(doc.y + lineHeight - 4)
+	doc.y -= 6
+
+	totals := [][2]string{{"Subtotal", domain.FormatAmount(invoice.Subtotal, invoice.Currency)}}
+	if invoice.Discount != 0 {
+		totals = append(totals, [2]string{"Discount", "-" + domain.FormatAmount(invoice.Discount, invoice.Currency)})
+	}
+	if invoice.ShippingName != "" || invoice.Shipping != 0 {
+		label := "Shipping"
+		if invoice.ShippingName != "" {
+			label += " (" + invoice.ShippingName + ")"
+		}
+		totals = append(totals, [2]string{label, domain.FormatAmount(invoice.Shipping, invoice.Currency)})
+	}
+	for _, tax := range invoice.Taxes {
+		totals = append(totals, [2]string{tax.Name + " " + rate(tax.Rate), domain.FormatAmount(tax.Amount, invoice.Currency)})
+	}
+	taxLabel := "Tax"
+	if invoice.TaxInclusive {
+		taxLabel = "Tax included"
+	}
+	totals = append(totals, [2]string{taxLabel, domain.FormatAmount(invoice.Tax, invoice.Currency)})
+	totalLabel := "Total"
+	if invoice.Kind == domain.InvoiceKindCreditNote {
+		totalLabel = "Total credited"
+	}
+	totals = append(totals, [2]string{totalLabel, domain.FormatAmount(invoice.Total, invoice.Currency)})
+
+	if doc.y-float64(len(totals)+3)*lineHeight < marginBottom {
+		doc.newPage()
+	}
+	for n, row := range totals {
+		last := n == len(totals)-1
+		if last {
+			doc.rule(doc.y + lineHeight - 4)
+		}
+		doc.text(columns.discount-60, doc.y, fontSize, last, truncate(row[0], fontSize, 140))
+		doc.textRight(columns.total, doc.y, fontSize, last, row[1])
+		doc.y -= lineHeight
+	}
+
+	doc.y -= lineHeight
+	if invoice.Reason != "" {
+		doc.text(marginLeft, doc.y, fontSize, false, truncate("Reason: "+invoice.Reason, fontSize, marginRight-marginLeft))
+		doc.y -= lineHeight
+	}
+	if invoice.TaxInclusive {
+		doc.text(marginLeft, doc.y, fontSize, false, "Prices include tax.")
+	}
+
+	return doc.bytes(), nil
+}
+
+// partyBlock is a column of the block naming the seller and the customer.
+type partyBlock struct {
+	x       float64
+	heading string
+	lines   []string
+}
+
+// block writes the parties side by side and moves below the longest.
+func (d *pdfDocument) block(parties ...partyBlock) {
+	rows := 0
+	for _, party := range parties {
+		if len(party.lines) == 0 {
+			continue
+		}
+		d.text(party.x, d.y, fontSize, true, truncate(party.heading, fontSize, 170))
+		for n, line := range party.lines {
+			d.text(party.x, d.y-float64(n+1)*lineHeight, fontSize, false, truncate(line, fontSize, 170))
+		}
+		rows = max(rows, len(party.lines)+1)
+	}
+	d.y -= float64(rows+1) * lineHeight
+}
+
+// pdfDocument builds the pages of a PDF as content streams, writing down
+// from y on the current page.
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = marginTop
+}
+
+func (d *pdfDocument) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *pdfDocument) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfText(s))
+}
+
+func (d *pdfDocument) textRight(right, y, size float64, bold bool, s string) {
+	d.text(right-textWidth(s, size), y, size, bold, s)
+}
+
+// rule draws a thin line across the page.
+func (d *pdfDocument) rule(y float64) {
+	fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", marginLeft, y, marginRight, y)
+}
+
+// bytes writes the document: the catalogue, the page tree and the two
+// fonts, then a page and its content stream for each page.
+func (d *pdfDocument) bytes() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // the page tree, once the pages are numbered
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+	var kids []string
+	for _, content := range d.pages {
+		page := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, page+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for n, object := range objects {
+		offsets[n] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", n+1, object)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// pdfText escapes s for a PDF string in WinAnsiEncoding, which matches
+// Latin-1 for the characters written as octal escapes.
+func pdfText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// helveticaWidths are the widths of the printable ASCII characters in
+// Helvetica, in thousandths of the font size. The bold face is close enough
+// for aligning the few bold labels.
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// textWidth returns how wide s is printed at size, in points.
+func textWidth(s string, size float64) float64 {
+	width := 0
+	for _, r := range s {
+		if r >= ' ' && r <= '~' {
+			width += helveticaWidths[r-' ']
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// truncate shortens s with an ellipsis to fit in width points at size.
+func truncate(s string, size, width float64) string {
+	if textWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
@@ -0,0 +1,129 @@
+// Package invoices renders invoices and credit notes as HTML pages and PDF
+// documents.
+package invoices
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+)
+
+type Renderer struct {
+	page *template.Template
+}
+
+func NewRenderer() *Renderer {
+	return &Renderer{
+		page: template.Must(template.New("invoice").Funcs(template.FuncMap{
+			"title":   title,
+			"amount":  amount,
+			"money":   domain.FormatAmount,
+			"rate":    rate,
+			"date":    func(invoice *domain.Invoice) string { return invoice.IssuedAt.Format("2006-01-02") },
+			"address": addressLines,
+			"upper":   strings.ToUpper,
+		}).Parse(pageTemplate)),
+	}
+}
+
+// RenderHTML renders the invoice as a standalone page, ready to print.
+func (r *Renderer) RenderHTML(invoice *domain.Invoice) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.page.Execute(&buf, invoice); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// title names the kind of document.
+func title(invoice *domain.Invoice) string {
+	if invoice.Kind == domain.InvoiceKindCreditNote {
+		return "Credit note"
+	}
+	return "Invoice"
+}
+
+// amount writes a minor-unit amount in major units without the currency
+// code, for table cells under a heading that names the currency.
+func amount(minor int64, currency string) string {
+	return strings.TrimSuffix(domain.FormatAmount(minor, currency), " "+strings.ToUpper(currency))
+}
+
+// rate writes a rate in basis points as a percentage, such as "12%" or
+// "12.5%".
+func rate(basisPoints int64) string {
+	whole, frac := basisPoints/100, basisPoints%100
+	if frac == 0 {
+		return fmt.Sprintf("%d%%", whole)
+	}
+	return strings.TrimRight(fmt.Sprintf("%d.%02d", whole, frac), "0") + "%"
+}
+
+// addressLines lays out an address as the lines of a letter, leaving out
+// the parts it does not have.
+func addressLines(address *domain.Address) []string {
+	if address == nil {
+		return nil
+	}
+	city := strings.TrimSpace(address.PostalCode + " " + address.City)
+	if address.State != "" {
+		city = strings.TrimPrefix(city+", "+address.State, ", ")
+	}
+	var lines []string
+	for _, line := range []string{address.Name, address.Line1, address.Line2, city, address.Country, address.Phone} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+const pageTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{title .}} {{.Number}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 13px; color: #222; max-width: 800px; margin: 40px auto; }
+h1 { font-size: 26px; margin: 0 0 4px; }
+table { width: 100%; border-collapse: collapse; }
+th, td { padding: 6px 4px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.lines th { border-bottom: 1px solid #222; }
+.lines td { border-bottom: 1px solid #ddd; }
+.parties td { vertical-align: top; text-align: left; width: 33%; padding: 0 0 24px; }
+.totals { width: 45%; margin-left: auto; margin-top: 16px; }
+.total td { font-weight: bold; border-top: 1px solid #222; }
+.note { margin-top: 24px; color: #555; }
+</style>
+</head>
+<body>
+<h1>{{title .}}</h1>
+<p>No. {{.Number}}<br>Date {{date .}}<br>Order {{.OrderID.Hex}}{{if .CreditedInvoice}}<br>Credits invoice {{.CreditedInvoice}}{{end}}</p>
+<table class="parties">
+<tr>
+<td><strong>{{.Seller.Name}}</strong><br>{{range address .Seller.Address}}{{.}}<br>{{end}}{{if .Seller.TaxID}}Tax ID {{.Seller.TaxID}}<br>{{end}}{{.Seller.Email}}</td>
+<td>{{with .BillTo}}<strong>Bill to</strong><br>{{range address .}}{{.}}<br>{{end}}{{end}}</td>
+<td>{{with .ShipTo}}<strong>Ship to</strong><br>{{range address .}}{{.}}<br>{{end}}{{end}}</td>
+</tr>
+</table>
+<table class="lines">
+<tr><th>Description</th><th>Qty</th><th>Unit price</th><th>Discount</th><th>Tax</th><th>Total ({{upper .Currency}})</th></tr>
+{{range .Lines}}<tr><td>{{.Description}}</td><td>{{.Quantity}}</td><td>{{amount .UnitPrice $.Currency}}</td><td>{{if .Discount}}-{{amount .Discount $.Currency}}{{end}}</td><td>{{amount .Tax $.Currency}}</td><td>{{amount .Total $.Currency}}</td></tr>
+{{end}}</table>
+<table class="totals">
+<tr><td>Subtotal</td><td>{{money .Subtotal .Currency}}</td></tr>
+{{if .Discount}}<tr><td>Discount</td><td>-{{money .Discount .Currency}}</td></tr>
+{{end}}{{if or .ShippingName .Shipping}}<tr><td>Shipping{{with .ShippingName}} ({{.}}){{end}}</td><td>{{money .Shipping .Currency}}</td></tr>
+{{end}}{{range .Taxes}}<tr><td>{{.Name}} {{rate .Rate}}</td><td>{{money .Amount $.Currency}}</td></tr>
+{{end}}<tr><td>{{if .TaxInclusive}}Tax included{{else}}Tax{{end}}</td><td>{{money .Tax .Currency}}</td></tr>
+<tr class="total"><td>{{if eq .Kind "credit_note"}}Total credited{{else}}Total{{end}}</td><td>{{money .Total .Currency}}</td></tr>
+</table>
+{{if .Reason}}<p class="note">Reason: {{.Reason}}</p>{{end}}
+{{if .TaxInclusive}}<p class="note">Prices include tax.</p>{{end}}
+</body>
+</html>
+`
@@ -0,0 +1,94 @@
+package invoices
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testInvoice(lines int) *domain.Invoice {
+	invoice := &domain.Invoice{
+		Kind:     domain.InvoiceKindInvoice,
+		Number:   "INV-000007",
+		OrderID:  primitive.NewObjectID(),
+		Seller:   domain.Seller{Name: "RESTInRehab LLP", TaxID: "123456789012", Address: domain.Address{Line1: "1 Abay Ave", City: "Almaty", PostalCode: "050000", Country: "KZ"}},
+		BillTo:   &domain.Address{Name: "Zoë <b>Müller</b>", Line1: "2 (Main) St", City: "Almaty", PostalCode: "050010", Country: "KZ"},
+		Currency: "kzt",
+		Taxes:    []domain.AppliedTax{{Name: "VAT", Rate: 1200, Amount: 8035714}},
+		Tax:      8035714,
+		Total:    74999999,
+		IssuedAt: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+	}
+	for n := 0; n < lines; n++ {
+		invoice.Lines = append(invoice.Lines, domain.InvoiceLine{Description: fmt.Sprintf("ThinkPad X1 Carbon #%d", n), Quantity: 1, UnitPrice: 74999999, Tax: 8035714, Total: 74999999})
+	}
+	invoice.Subtotal = int64(lines) * 74999999
+	return invoice
+}
+
+func TestRenderPDF(t *testing.T) {
+	pdf, err := NewRenderer().RenderPDF(testInvoice(120))
+	if err != nil {
+		t.Fatalf("RenderPDF: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF: starts %q", pdf[:min(len(pdf), 20)])
+	}
+
+	// every cross-reference entry must point at its object
+	xref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if xref == nil {
+		t.Fatal("no startxref")
+	}
+	start, _ := strconv.Atoi(string(xref[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[start:], -1)
+	for n, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", n+1); !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", n+1, pdf[offset:min(len(pdf), offset+10)])
+		}
+	}
+
+	// 120 lines do not fit on one page
+	if pages := bytes.Count(pdf, []byte("/Type /Page ")); pages < 2 {
+		t.Errorf("%d pages, want the lines to run onto a second", pages)
+	}
+	for _, want := range []string{`(INVOICE)`, `(No. INV-000007)`, `(2 \(Main\) St)`, `(Zo\353 <b>M\374ller</b>)`, `(VAT 12%)`, `(749999.99)`} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF does not show %s", want)
+		}
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	invoice := testInvoice(1)
+	invoice.Kind = domain.InvoiceKindCreditNote
+	invoice.Number = "CN-000002"
+	invoice.CreditedInvoice = "INV-000007"
+
+	html, err := NewRenderer().RenderHTML(invoice)
+	if err != nil {
+		t.Fatalf("RenderHTML: %v", err)
+	}
+	page := string(html)
+	for _, want := range []string{"<h1>Credit note</h1>", "No. CN-000002", "Credits invoice INV-000007", "Zoë &lt;b&gt;Müller&lt;/b&gt;", "VAT 12%", "749999.99 KZT", "Total credited"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML does not show %q", want)
+		}
+	}
+}
+
+func TestRate(t *testing.T) {
+	for basisPoints, want := range map[int64]string{1200: "12%", 1250: "12.5%", 997: "9.97%", 0: "0%"} {
+		if got := rate(basisPoints); got != want {
+			t.Errorf("rate(%d) = %q, want %q", basisPoints, got, want)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+)
+
+// fakeSignatureHeader carries the HMAC of a fake provider webhook.
+const fakeSignatureHeader = "Fake-Signature"
+
+var (
+	ErrSessionNotFound = errors.New("checkout session not found")
+	ErrSessionClosed   = errors.New("checkout session is no longer open")
+)
+
+// fakeProvider is an in-process payment provider that takes no real money.
+// Checkouts stay open until Pay, Decline or Expire is called, and each of
+// those sends a signed webhook to webhookURL just as a real provider would.
+// Sessions are kept in memory and lost on restart.
+type fakeProvider struct {
+	checkoutURL string
+	webhookURL  string
+	sessionTTL  time.Duration
+	secret      []byte
+	httpClient  *http.Client
+
+	mu       sync.Mutex
+	sessions map[string]*fakeSession
+	refunds  map[string]*domain.RefundResult
+}
+
+type fakeSession struct {
+	domain.CheckoutStatus
+	OrderID   string
+	ExpiresAt time.Time
+	Refunded  int64
+}
+
+// fakeEvent is the webhook body the fake provider sends.
+type fakeEvent struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	SessionID       string `json:"session_id,omitempty"`
+	OrderID         string `json:"order_id,omitempty"`
+	PaymentIntentID string `json:"payment_intent_id,omitempty"`
+	Paid            bool   `json:"paid"`
+	Amount          int64  `json:"amount"`
+	Currency        string `json:"currency,omitempty"`
+	AmountRefunded  int64  `json:"amount_refunded,omitempty"`
+	FailureMessage  string `json:"failure_message,omitempty"`
+}
+
+// NewFakeProvider returns a provider whose checkout pages are served under
+// checkoutURL and whose webhooks are posted to webhookURL. Webhooks are
+// signed with a key generated per process.
+func NewFakeProvider(checkoutURL, webhookURL string, sessionTTL time.Duration) *fakeProvider {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return &fakeProvider{
+		checkoutURL: strings.TrimSuffix(checkoutURL, "/"),
+		webhookURL:  webhookURL,
+		sessionTTL:  sessionTTL,
+		secret:      secret,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		sessions:    map[string]*fakeSession{},
+		refunds:     map[string]*domain.RefundResult{},
+	}
+}
+
+func (f *fakeProvider) CreateCheckout(ctx context.Context, order *domain.Order) (*domain.CheckoutSession, error) {
+	session := &fakeSession{
+		CheckoutStatus: domain.CheckoutStatus{
+			SessionID: newFakeID("cs_fake"),
+			Status:    domain.CheckoutOpen,
+			Amount:    order.Total,
+			Currency:  order.Currency,
+		},
+		OrderID:   order.ID.Hex(),
+		ExpiresAt: time.Now().Add(f.sessionTTL),
+	}
+
+	f.mu.Lock()
+	f.sessions[session.SessionID] = session
+	f.mu.Unlock()
+
+	return &domain.CheckoutSession{
+		ID:        session.SessionID,
+		URL:       f.checkoutURL + "/" + session.SessionID,
+		ExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// ParseWebhook accepts only webhooks signed by this provider.
+func (f *fakeProvider) ParseWebhook(payload []byte, header http.Header) (domain.PaymentEvent, bool, error) {
+	signature, err := hex.DecodeString(header.Get(fakeSignatureHeader))
+	if err != nil || !hmac.Equal(signature, f.sign(payload)) {
+		return domain.PaymentEvent{}, false, errors.New("invalid webhook signature")
+	}
+
+	var event fakeEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return domain.PaymentEvent{}, false, errors.New("failed to parse event")
+	}
+	return domain.PaymentEvent(event), true, nil
+}
+
+// Refund returns part or all of a paid session and sends a refund webhook
+// with the session's total refunded so far.
+func (f *fakeProvider) Refund(ctx context.Context, req domain.RefundRequest) (*domain.RefundResult, error) {
+	f.mu.Lock()
+	if result, ok := f.refunds[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		f.mu.Unlock()
+		return result, nil
+	}
+
+	var session *fakeSession
+	for _, s := range f.sessions {
+		if s.Paid && s.PaymentIntentID == req.PaymentIntentID {
+			session = s
+		}
+	}
+	if session == nil {
+		f.mu.Unlock()
+		return nil, fmt.Errorf("no captured payment %s", req.PaymentIntentID)
+	}
+	if req.Amount <= 0 || session.Refunded+req.Amount > session.Amount {
+		f.mu.Unlock()
+		return nil, fmt.Errorf("cannot refund %d of %d with %d already refunded", req.Amount, session.Amount, session.Refunded)
+	}
+	session.Refunded += req.Amount
+	event := f.event(domain.PaymentRefunded, session)
+	event.AmountRefunded = session.Refunded
+
+	result := &domain.RefundResult{ID: newFakeID("re_fake"), Status: "succeeded"}
+	if req.IdempotencyKey != "" {
+		f.refunds[req.IdempotencyKey] = result
+	}
+	f.mu.Unlock()
+
+	if err := f.send(ctx, event); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (f *fakeProvider) CheckoutStatus(ctx context.Context, sessionID string) (*domain.CheckoutStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	session, ok := f.sessions[sessionID]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	status := session.CheckoutStatus
+	return &status, nil
+}
+
+// Pay simulates a successful card payment for an open session.
+func (f *fakeProvider) Pay(ctx context.Context, sessionID string) error {
+	return f.settle(ctx, sessionID, func(session *fakeSession) fakeEvent {
+		session.Status = domain.CheckoutComplete
+		session.Paid = true
+		return f.event(domain.PaymentCheckoutCompleted, session)
+	})
+}
+
+// Decline simulates a failed card payment. Like a real checkout, the
+// session stays open so the customer can try again.
+func (f *fakeProvider) Decline(ctx context.Context, sessionID, message string) error {
+	if message == "" {
+		message = "Your card was declined."
+	}
+	return f.settle(ctx, sessionID, func(session *fakeSession) fakeEvent {
+		event := f.event(domain.PaymentFailed, session)
+		event.FailureMessage = message
+		return event
+	})
+}
+
+// Expire closes an open session without payment.
+func (f *fakeProvider) Expire(ctx context.Context, sessionID string) error {
+	return f.settle(ctx, sessionID, func(session *fakeSession) fakeEvent {
+		session.Status = domain.CheckoutExpired
+		return f.event(domain.PaymentCheckoutExpired, session)
+	})
+}
+
+// settle applies change to an open session and sends the event it returns.
+func (f *fakeProvider) settle(ctx context.Context, sessionID string, change func(*fakeSession) fakeEvent) error {
+	f.mu.Lock()
+	session, ok := f.sessions[sessionID]
+	if !ok {
+		f.mu.Unlock()
+		return ErrSessionNotFound
+	}
+	if session.Status != domain.CheckoutOpen {
+		f.mu.Unlock()
+		return ErrSessionClosed
+	}
+	if session.PaymentIntentID == "" {
+		session.PaymentIntentID = newFakeID("pi_fake")
+	}
+	event := change(session)
+	f.mu.Unlock()
+
+	return f.send(ctx, event)
+}
+
+func (f *fakeProvider) event(eventType string, session *fakeSession) fakeEvent {
+	return fakeEvent{
+		ID:              newFakeID("evt_fake"),
+		Type:            eventType,
+		SessionID:       session.SessionID,
+		OrderID:         session.OrderID,
+		PaymentIntentID: session.PaymentIntentID,
+		Paid:            session.Paid,
+		Amount:          session.Amount,
+		Currency:        session.Currency,
+	}
+}
+
+// send posts a signed webhook and fails unless it is acknowledged.
+func (f *fakeProvider) send(ctx context.Context, event fakeEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(fakeSignatureHeader, hex.EncodeToString(f.sign(payload)))
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("deliver %s webhook: %w", event.Type, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("deliver %s webhook: %s", event.Type, resp.Status)
+	}
+	return nil
+}
+
+func (f *fakeProvider) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func newFakeID(prefix string) string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return prefix + "_" + hex.EncodeToString(b)
+}
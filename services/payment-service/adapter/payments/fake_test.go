@@ -0,0 +1,131 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTestFake returns a fake provider whose webhooks are verified with the
+// provider itself and collected in the returned slice.
+func newTestFake(t *testing.T) (*fakeProvider, *[]domain.PaymentEvent) {
+	t.Helper()
+	var events []domain.PaymentEvent
+	var provider *fakeProvider
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		event, _, err := provider.ParseWebhook(payload, r.Header)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		events = append(events, event)
+	}))
+	t.Cleanup(server.Close)
+
+	provider = NewFakeProvider("http://shop.test/fake-checkout/", server.URL, 30*time.Minute)
+	return provider, &events
+}
+
+func testOrder() *domain.Order {
+	return &domain.Order{ID: primitive.NewObjectID(), Currency: "kzt", Total: 74999999}
+}
+
+func TestFakeProviderPayAndRefund(t *testing.T) {
+	ctx := context.Background()
+	provider, events := newTestFake(t)
+	order := testOrder()
+
+	session, err := provider.CreateCheckout(ctx, order)
+	if err != nil {
+		t.Fatalf("CreateCheckout: %v", err)
+	}
+	if session.URL != "http://shop.test/fake-checkout/"+session.ID {
+		t.Errorf("checkout URL = %s", session.URL)
+	}
+
+	if err := provider.Decline(ctx, session.ID, ""); err != nil {
+		t.Fatalf("Decline: %v", err)
+	}
+	if err := provider.Pay(ctx, session.ID); err != nil {
+		t.Fatalf("Pay after a decline: %v", err)
+	}
+	if err := provider.Pay(ctx, session.ID); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("second Pay: err = %v, want ErrSessionClosed", err)
+	}
+
+	if len(*events) != 2 {
+		t.Fatalf("got %d webhooks, want 2", len(*events))
+	}
+	declined, paid := (*events)[0], (*events)[1]
+	if declined.Type != domain.PaymentFailed || declined.FailureMessage == "" || declined.OrderID != order.ID.Hex() {
+		t.Errorf("decline webhook = %+v", declined)
+	}
+	if paid.Type != domain.PaymentCheckoutCompleted || !paid.Paid || paid.Amount != order.Total || paid.SessionID != session.ID {
+		t.Errorf("payment webhook = %+v", paid)
+	}
+	if paid.PaymentIntentID == "" || paid.PaymentIntentID != declined.PaymentIntentID {
+		t.Errorf("payment intents %q and %q, want the same one", declined.PaymentIntentID, paid.PaymentIntentID)
+	}
+
+	status, err := provider.CheckoutStatus(ctx, session.ID)
+	if err != nil || status.Status != domain.CheckoutComplete || !status.Paid {
+		t.Errorf("status = %+v, %v; want complete and paid", status, err)
+	}
+
+	refund := domain.RefundRequest{PaymentIntentID: paid.PaymentIntentID, Amount: 1000, IdempotencyKey: "r1"}
+	first, err := provider.Refund(ctx, refund)
+	if err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+	if again, err := provider.Refund(ctx, refund); err != nil || again.ID != first.ID {
+		t.Errorf("retried refund = %v, %v; want the first refund", again, err)
+	}
+	if len(*events) != 3 || (*events)[2].Type != domain.PaymentRefunded || (*events)[2].AmountRefunded != 1000 {
+		t.Errorf("refund webhooks = %+v, want one for 1000", (*events)[2:])
+	}
+	if _, err := provider.Refund(ctx, domain.RefundRequest{PaymentIntentID: paid.PaymentIntentID, Amount: order.Total}); err == nil {
+		t.Error("refunding more than was paid: want error")
+	}
+}
+
+func TestFakeProviderExpire(t *testing.T) {
+	ctx := context.Background()
+	provider, events := newTestFake(t)
+
+	session, _ := provider.CreateCheckout(ctx, testOrder())
+	if err := provider.Expire(ctx, session.ID); err != nil {
+		t.Fatalf("Expire: %v", err)
+	}
+	if len(*events) != 1 || (*events)[0].Type != domain.PaymentCheckoutExpired {
+		t.Errorf("webhooks = %+v, want one expiry", *events)
+	}
+	if err := provider.Pay(ctx, session.ID); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("Pay after expiry: err = %v, want ErrSessionClosed", err)
+	}
+	if err := provider.Pay(ctx, "cs_missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("unknown session: err = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestFakeProviderRejectsUnsignedWebhooks(t *testing.T) {
+	provider, _ := newTestFake(t)
+	payload := []byte(`{"id":"evt_1","type":"checkout_completed","paid":true}`)
+
+	header := http.Header{}
+	header.Set(fakeSignatureHeader, "deadbeef")
+	if _, _, err := provider.ParseWebhook(payload, header); err == nil {
+		t.Error("forged signature: want error")
+	}
+	if _, _, err := provider.ParseWebhook(payload, http.Header{}); err == nil {
+		t.Error("unsigned: want error")
+	}
+}
@@ -0,0 +1,222 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/client"
+	"github.com/stripe/stripe-go/v76/webhook"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+)
+
+// stripeProvider takes payments through Stripe Checkout. It implements
+// usecase.PaymentProvider.
+type stripeProvider struct {
+	api           *client.API
+	webhookSecret string
+	successURL    string
+	cancelURL     string
+	sessionTTL    time.Duration
+}
+
+func NewStripeProvider(secretKey, webhookSecret, successURL, cancelURL string, sessionTTL time.Duration) *stripeProvider {
+	return &stripeProvider{
+		api:           client.New(secretKey, nil),
+		webhookSecret: webhookSecret,
+		successURL:    successURL,
+		cancelURL:     cancelURL,
+		sessionTTL:    sessionTTL,
+	}
+}
+
+// CreateCheckout opens a Checkout session charging the order's lines. The
+// order ID is sent as the client reference and as payment metadata so
+// webhooks can be matched to the order.
+//
+// Discounts are charged as line-level prices: a discounted line is sent at
+// its discounted unit price, split in two when its total does not divide
+// evenly between its units, so Stripe charges exactly the order total. Tax
+// added at checkout is in the line totals the same way, and the shipping fee
+// is a line of its own.
+func (s *stripeProvider) CreateCheckout(ctx context.Context, order *domain.Order) (*domain.CheckoutSession, error) {
+	var lineItems []*stripe.CheckoutSessionLineItemParams
+	for _, item := range order.Items {
+		product := &stripe.CheckoutSessionLineItemPriceDataProductDataParams{Name: stripe.String(item.Name)}
+		var notes []string
+		if item.Discount > 0 {
+			notes = append(notes, "Discounted from "+domain.FormatAmount(item.UnitPrice, order.Currency)+" each")
+		}
+		if item.Tax > 0 {
+			notes = append(notes, "Line includes "+domain.FormatAmount(item.Tax, order.Currency)+" tax")
+		}
+		if len(notes) > 0 {
+			product.Description = stripe.String(strings.Join(notes, "; "))
+		}
+		for _, charge := range item.Charges() {
+			lineItems = append(lineItems, &stripe.CheckoutSessionLineItemParams{
+				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+					Currency:    stripe.String(order.Currency),
+					ProductData: product,
+					UnitAmount:  stripe.Int64(charge.UnitPrice),
+				},
+				Quantity: stripe.Int64(charge.Quantity),
+			})
+		}
+	}
+
+	if order.Shipping != nil && order.Shipping.Amount > 0 {
+		lineItems = append(lineItems, &stripe.CheckoutSessionLineItemParams{
+			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+				Currency:    stripe.String(order.Currency),
+				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{Name: stripe.String("Shipping: " + order.Shipping.Name)},
+				UnitAmount:  stripe.Int64(order.Shipping.Amount),
+			},
+			Quantity: stripe.Int64(1),
+		})
+	}
+
+	expiresAt := time.Now().Add(s.sessionTTL)
+	params := &stripe.CheckoutSessionParams{
+		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
+		LineItems:          lineItems,
+		Mode:               stripe.String(string(stripe.CheckoutSessionModePayment)),
+		ClientReferenceID:  stripe.String(order.ID.Hex()),
+		PaymentIntentData: &stripe.CheckoutSessionPaymentIntentDataParams{
+			Metadata: map[string]string{"order_id": order.ID.Hex()},
+		},
+		ExpiresAt:  stripe.Int64(expiresAt.Unix()),
+		SuccessURL: stripe.String(s.successURL),
+		CancelURL:  stripe.String(s.cancelURL),
+	}
+	params.Context = ctx
+	params.AddMetadata("order_id", order.ID.Hex())
+	// one session per order, even if the Stripe call is retried
+	params.SetIdempotencyKey("checkout-" + order.ID.Hex())
+
+	session, err := s.api.CheckoutSessions.New(params)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.CheckoutSession{ID: session.ID, URL: session.URL, ExpiresAt: expiresAt}, nil
+}
+
+// ParseWebhook verifies the Stripe-Signature header before trusting the
+// event.
+func (s *stripeProvider) ParseWebhook(payload []byte, header http.Header) (domain.PaymentEvent, bool, error) {
+	event, err := webhook.ConstructEventWithOptions(payload, header.Get("Stripe-Signature"), s.webhookSecret,
+		webhook.ConstructEventOptions{IgnoreAPIVersionMismatch: true})
+	if err != nil {
+		return domain.PaymentEvent{}, false, err
+	}
+	return paymentEventFromStripe(event)
+}
+
+func (s *stripeProvider) Refund(ctx context.Context, req domain.RefundRequest) (*domain.RefundResult, error) {
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(req.PaymentIntentID),
+		Amount:        stripe.Int64(req.Amount),
+		Reason:        stripe.String(string(stripe.RefundReasonRequestedByCustomer)),
+	}
+	params.Context = ctx
+	if req.Reason != "" {
+		params.AddMetadata("reason", req.Reason)
+	}
+	if req.IdempotencyKey != "" {
+		params.SetIdempotencyKey(req.IdempotencyKey)
+	}
+
+	refund, err := s.api.Refunds.New(params)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.RefundResult{ID: refund.ID, Status: string(refund.Status)}, nil
+}
+
+func (s *stripeProvider) CheckoutStatus(ctx context.Context, sessionID string) (*domain.CheckoutStatus, error) {
+	params := &stripe.CheckoutSessionParams{}
+	params.Context = ctx
+
+	session, err := s.api.CheckoutSessions.Get(sessionID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &domain.CheckoutStatus{
+		SessionID: session.ID,
+		Status:    string(session.Status),
+		Paid:      session.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid,
+		Amount:    session.AmountTotal,
+		Currency:  string(session.Currency),
+	}
+	if session.PaymentIntent != nil {
+		status.PaymentIntentID = session.PaymentIntent.ID
+	}
+	return status, nil
+}
+
+// paymentEventFromStripe reduces the Stripe events orders react to. ok is
+// false for every other event type.
+func paymentEventFromStripe(event stripe.Event) (domain.PaymentEvent, bool, error) {
+	paymentEvent := domain.PaymentEvent{ID: event.ID}
+
+	switch event.Type {
+	case stripe.EventTypeCheckoutSessionCompleted, stripe.EventTypeCheckoutSessionExpired:
+		var s stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &s); err != nil {
+			return paymentEvent, false, errors.New("failed to parse checkout session")
+		}
+		paymentEvent.Type = domain.PaymentCheckoutCompleted
+		if event.Type == stripe.EventTypeCheckoutSessionExpired {
+			paymentEvent.Type = domain.PaymentCheckoutExpired
+		}
+		paymentEvent.SessionID = s.ID
+		paymentEvent.OrderID = s.ClientReferenceID
+		if paymentEvent.OrderID == "" {
+			paymentEvent.OrderID = s.Metadata["order_id"]
+		}
+		if s.PaymentIntent != nil {
+			paymentEvent.PaymentIntentID = s.PaymentIntent.ID
+		}
+		paymentEvent.Paid = s.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid
+		paymentEvent.Amount = s.AmountTotal
+		paymentEvent.Currency = string(s.Currency)
+
+	case stripe.EventTypePaymentIntentPaymentFailed:
+		var pi stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+			return paymentEvent, false, errors.New("failed to parse payment intent")
+		}
+		paymentEvent.Type = domain.PaymentFailed
+		paymentEvent.OrderID = pi.Metadata["order_id"]
+		paymentEvent.PaymentIntentID = pi.ID
+		paymentEvent.FailureMessage = "payment failed"
+		if pi.LastPaymentError != nil && pi.LastPaymentError.Msg != "" {
+			paymentEvent.FailureMessage = pi.LastPaymentError.Msg
+		}
+
+	case stripe.EventTypeChargeRefunded:
+		var ch stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &ch); err != nil {
+			return paymentEvent, false, errors.New("failed to parse charge")
+		}
+		paymentEvent.Type = domain.PaymentRefunded
+		paymentEvent.OrderID = ch.Metadata["order_id"]
+		if ch.PaymentIntent != nil {
+			paymentEvent.PaymentIntentID = ch.PaymentIntent.ID
+		}
+		paymentEvent.Amount = ch.Amount
+		paymentEvent.Currency = string(ch.Currency)
+		paymentEvent.AmountRefunded = ch.AmountRefunded
+
+	default:
+		return paymentEvent, false, nil
+	}
+
+	return paymentEvent, true, nil
+}
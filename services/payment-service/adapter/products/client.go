@@ -0,0 +1,264 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/usecase"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Client calls products-service for catalogue prices and stock
+// reservations. It implements usecase.Catalog.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// orderLine and reservationRequest mirror the order body of products-service's
+// /payment endpoints.
+type orderLine struct {
+	ID            string   `json:"_id"`
+	VariantID     string   `json:"variant_id,omitempty"`
+	Quantity      int64    `json:"quantity"`
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+}
+
+type reservationRequest struct {
+	ReservationID string      `json:"reservation_id,omitempty"`
+	OrderID       string      `json:"order_id,omitempty"`
+	ShipmentID    string      `json:"shipment_id,omitempty"`
+	CustomerID    string      `json:"customer_id,omitempty"`
+	Products      []orderLine `json:"products"`
+}
+
+// GetProduct returns the product with its current availability, priced in
+// currency, or nil, nil when products-service has no such product. It fails
+// with usecase.ErrUnsupportedCurrency when products-service has no exchange
+// rate for currency.
+func (c *Client) GetProduct(ctx context.Context, id, currency string) (*domain.CatalogProduct, error) {
+	query := url.Values{"include": {"availability"}, "currency": {currency}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/products/"+url.PathEscape(id)+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("products-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadRequest:
+		// products-service reports invalid IDs and currencies alike
+		if message := errorMessage(resp); strings.HasPrefix(message, "unsupported currency") {
+			return nil, fmt.Errorf("%w: products-service: %s", usecase.ErrUnsupportedCurrency, message)
+		}
+		return nil, nil
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, responseError(resp)
+	}
+
+	var product domain.CatalogProduct
+	if err := json.NewDecoder(resp.Body).Decode(&product); err != nil {
+		return nil, fmt.Errorf("products-service: invalid product response: %w", err)
+	}
+	return &product, nil
+}
+
+// ReserveProducts reserves stock for every item and returns the reservation
+// ID. It fails with usecase.ErrInsufficientStock when any line is short.
+func (c *Client) ReserveProducts(ctx context.Context, customerID string, items []domain.OrderItem) (string, error) {
+	resp, err := c.post(ctx, "/payment/start", reservationRequest{CustomerID: customerID, Products: orderLines(items)})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return "", fmt.Errorf("%w: %s", usecase.ErrInsufficientStock, errorMessage(resp))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp)
+	}
+
+	var body struct {
+		ReservationID string `json:"reservation_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.ReservationID == "" {
+		return "", fmt.Errorf("products-service: invalid reservation response")
+	}
+	return body.ReservationID, nil
+}
+
+// CancelReservation returns the reservation's units to stock.
+func (c *Client) CancelReservation(ctx context.Context, customerID, reservationID string, items []domain.OrderItem) error {
+	resp, err := c.post(ctx, "/payment/cancel", reservationRequest{ReservationID: reservationID, CustomerID: customerID, Products: orderLines(items)})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// CommitReservation ties the reservation of a paid order to it, so that its
+// units stay reserved until they ship.
+func (c *Client) CommitReservation(ctx context.Context, customerID, reservationID, orderID string) error {
+	resp, err := c.post(ctx, "/payment/commit", reservationRequest{ReservationID: reservationID, OrderID: orderID, CustomerID: customerID, Products: []orderLine{}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// ShipProducts sells the reservation's units that leave in shipment and
+// fills in the serial numbers of lines that did not pick any. It fails with
+// usecase.ErrInsufficientStock when a unit is not reserved for the order.
+func (c *Client) ShipProducts(ctx context.Context, customerID, reservationID, orderID string, shipment *domain.Shipment) error {
+	products := make([]orderLine, len(shipment.Lines))
+	for n, line := range shipment.Lines {
+		products[n] = orderLine{ID: line.ProductID, VariantID: line.VariantID, Quantity: line.Quantity, SerialNumbers: line.SerialNumbers}
+	}
+
+	resp, err := c.post(ctx, "/payment/ship", reservationRequest{ReservationID: reservationID, OrderID: orderID, ShipmentID: shipment.ID, CustomerID: customerID, Products: products})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return fmt.Errorf("%w: %s", usecase.ErrInsufficientStock, errorMessage(resp))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	var body struct {
+		Units []struct {
+			ProductID    string `json:"product_id"`
+			VariantID    string `json:"variant_id"`
+			SerialNumber string `json:"serial_number"`
+		} `json:"units"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("products-service: invalid shipment response: %w", err)
+	}
+
+	named := map[string]bool{}
+	for _, line := range shipment.Lines {
+		for _, serial := range line.SerialNumbers {
+			named[serial] = true
+		}
+	}
+	// products-service sends the zero ObjectID for units without a variant
+	picked := map[string][]string{}
+	for _, unit := range body.Units {
+		if named[unit.SerialNumber] {
+			continue
+		}
+		if unit.VariantID == primitive.NilObjectID.Hex() {
+			unit.VariantID = ""
+		}
+		key := unit.ProductID + "/" + unit.VariantID
+		picked[key] = append(picked[key], unit.SerialNumber)
+	}
+	for n := range shipment.Lines {
+		line := &shipment.Lines[n]
+		key := line.ProductID + "/" + line.VariantID
+		if len(line.SerialNumbers) == 0 && int64(len(picked[key])) >= line.Quantity {
+			line.SerialNumbers, picked[key] = picked[key][:line.Quantity], picked[key][line.Quantity:]
+		}
+	}
+	return nil
+}
+
+// ReturnProducts moves refunded units of a sold reservation to returned.
+// Lines with serial numbers return exactly those units.
+func (c *Client) ReturnProducts(ctx context.Context, customerID, reservationID string, lines []domain.RefundLine) error {
+	products := make([]orderLine, len(lines))
+	for n, line := range lines {
+		products[n] = orderLine{ID: line.ProductID, VariantID: line.VariantID, Quantity: line.Quantity, SerialNumbers: line.SerialNumbers}
+	}
+
+	resp, err := c.post(ctx, "/payment/return", reservationRequest{ReservationID: reservationID, CustomerID: customerID, Products: products})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func (c *Client) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("products-service: %w", err)
+	}
+	return resp, nil
+}
+
+func orderLines(items []domain.OrderItem) []orderLine {
+	lines := make([]orderLine, len(items))
+	for n, item := range items {
+		lines[n] = orderLine{ID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity}
+	}
+	return lines
+}
+
+func responseError(resp *http.Response) error {
+	return fmt.Errorf("products-service: %s: %s", resp.Status, errorMessage(resp))
+}
+
+// errorMessage returns the "error" field of a JSON error body, or the raw
+// body when it is not one.
+func errorMessage(resp *http.Response) string {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		return body.Error
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,203 @@
+package products
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+	"github.com/mephirious/group-project/services/payment-service/usecase"
+)
+
+// stubProductsAPI serves the products-service endpoints checkout uses. Units
+// in stock per product ID are taken from stock.
+func stubProductsAPI(t *testing.T, stock map[string]int64) (*httptest.Server, *[]reservationRequest) {
+	t.Helper()
+	var calls []reservationRequest
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /products/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("id") {
+		case "p1":
+			w.Write([]byte(`{"id":"p1","model_name":"ThinkPad X1 Carbon","price":749999.99,"variants":[],"specifications":{"ram":16}}`))
+		case "p2":
+			if r.URL.Query().Get("include") != "availability" {
+				t.Errorf("product requested without availability: %s", r.URL)
+			}
+			switch r.URL.Query().Get("currency") {
+			case "usd":
+				w.Write([]byte(`{"id":"p2","model_name":"MacBook Air","price":1200,"money":{"amount":120000,"currency":"USD"},"variants":[{"id":"v1","sku":"MBA-16-512","price":1600,"money":{"amount":160000,"currency":"USD"}}]}`))
+				return
+			case "eur":
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"unsupported currency: EUR"}`))
+				return
+			}
+			w.Write([]byte(`{"id":"p2","model_name":"MacBook Air","price":599990,"variants":[{"id":"v1","sku":"MBA-16-512","price":799990.5}],"availability":{"status":"low_stock","quantity":2}}`))
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"database unavailable"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"Product not found"}`))
+		}
+	})
+	mux.HandleFunc("POST /payment/start", func(w http.ResponseWriter, r *http.Request) {
+		var req reservationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode reservation: %v", err)
+		}
+		calls = append(calls, req)
+		for _, line := range req.Products {
+			if stock[line.ID] < line.Quantity {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"error":"insufficient stock: product ` + line.ID + `"}`))
+				return
+			}
+		}
+		w.Write([]byte(`{"message":"Products reserved","reservation_id":"r1","expires_at":"2030-01-01T00:00:00Z"}`))
+	})
+	mux.HandleFunc("POST /payment/cancel", func(w http.ResponseWriter, r *http.Request) {
+		var req reservationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode cancel: %v", err)
+		}
+		calls = append(calls, req)
+		w.Write([]byte(`{"message":"Reservation canceled"}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestGetProduct(t *testing.T) {
+	server, _ := stubProductsAPI(t, nil)
+	client := NewClient(server.URL+"/", time.Second)
+	ctx := context.Background()
+
+	product, err := client.GetProduct(ctx, "p2", "kzt")
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if product.ModelName != "MacBook Air" || len(product.Variants) != 1 || product.Variants[0].Price != 799990.5 {
+		t.Errorf("got %+v", product)
+	}
+	if product.Available() != 2 {
+		t.Errorf("available = %d, want 2", product.Available())
+	}
+
+	product, err = client.GetProduct(ctx, "p2", "usd")
+	if err != nil {
+		t.Fatalf("GetProduct in usd: %v", err)
+	}
+	if name, price, err := product.Line("v1", "usd"); err != nil || price != 160000 || name != "MacBook Air (MBA-16-512)" {
+		t.Errorf("usd variant line = %q at %d, %v; want MacBook Air (MBA-16-512) at 160000", name, price, err)
+	}
+	if _, err := client.GetProduct(ctx, "p2", "eur"); !errors.Is(err, usecase.ErrUnsupportedCurrency) {
+		t.Errorf("eur: err = %v, want ErrUnsupportedCurrency", err)
+	}
+
+	if product, err := client.GetProduct(ctx, "missing", "kzt"); product != nil || err != nil {
+		t.Errorf("missing product = %v, %v; want nil, nil", product, err)
+	}
+	if _, err := client.GetProduct(ctx, "broken", "kzt"); err == nil {
+		t.Error("server error: want error")
+	}
+}
+
+func TestReserveProducts(t *testing.T) {
+	server, calls := stubProductsAPI(t, map[string]int64{"p1": 1})
+	client := NewClient(server.URL, time.Second)
+	ctx := context.Background()
+
+	items := []domain.OrderItem{{ProductID: "p1", Name: "ThinkPad X1 Carbon", UnitPrice: 74999999, Quantity: 1}}
+	id, err := client.ReserveProducts(ctx, "alice", items)
+	if err != nil || id != "r1" {
+		t.Fatalf("ReserveProducts = %q, %v; want r1", id, err)
+	}
+	got := (*calls)[0]
+	if got.CustomerID != "alice" || len(got.Products) != 1 || got.Products[0].ID != "p1" || got.Products[0].Quantity != 1 {
+		t.Errorf("reservation request = %+v", got)
+	}
+
+	items[0].Quantity = 2
+	if _, err := client.ReserveProducts(ctx, "alice", items); !errors.Is(err, usecase.ErrInsufficientStock) {
+		t.Errorf("short stock: err = %v, want ErrInsufficientStock", err)
+	}
+
+	if err := client.CancelReservation(ctx, "alice", "r1", items); err != nil {
+		t.Fatalf("CancelReservation: %v", err)
+	}
+	if got := (*calls)[2]; got.ReservationID != "r1" {
+		t.Errorf("cancel request = %+v, want reservation r1", got)
+	}
+}
+
+// TestCheckoutAgainstStubAPI prices an order through the real client, so the
+// float catalogue prices go through the same JSON decoding as in production.
+func TestCheckoutAgainstStubAPI(t *testing.T) {
+	server, _ := stubProductsAPI(t, map[string]int64{"p1": 5, "p2": 5})
+	uc := usecase.NewOrderUseCase(&memoryOrders{}, nil, NewClient(server.URL, time.Second), noPromotions{}, noShipping{}, noTaxes{}, nil, nil, domain.NewCurrencies("kzt"))
+	ctx := context.Background()
+
+	items := []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}, {ProductID: "p2", VariantID: "v1", Quantity: 2}}
+	order, err := uc.PlaceOrder(ctx, "alice", domain.CheckoutInput{Items: items})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if order.Total != 74999999+2*79999050 || order.ReservationID != "r1" {
+		t.Errorf("total %d with reservation %q, want %d with r1", order.Total, order.ReservationID, 74999999+2*79999050)
+	}
+
+	cheap := int64(1)
+	_, err = uc.PlaceOrder(ctx, "alice", domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 1, Price: &cheap, Currency: "kzt"}}})
+	if !errors.Is(err, usecase.ErrPriceMismatch) {
+		t.Errorf("1 tiyn laptop: err = %v, want ErrPriceMismatch", err)
+	}
+}
+
+// memoryOrders stores nothing; the checkout test only needs CreateOrder.
+type memoryOrders struct {
+	repository.OrderRepository
+}
+
+func (m *memoryOrders) CreateOrder(ctx context.Context, order *domain.Order) error {
+	return nil
+}
+
+// noPromotions applies no discounts.
+type noPromotions struct{}
+
+func (noPromotions) ApplyPromotions(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct, codes []string) error {
+	return nil
+}
+
+func (noPromotions) RedeemPromotions(ctx context.Context, order *domain.Order) error {
+	return nil
+}
+
+func (noPromotions) ReleasePromotions(ctx context.Context, order *domain.Order) {}
+
+// noShipping ships nothing.
+type noShipping struct{}
+
+func (noShipping) QuoteShipping(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct) ([]domain.ShippingQuote, error) {
+	return nil, nil
+}
+
+func (noShipping) ApplyShipping(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct, methodID string) error {
+	return nil
+}
+
+// noTaxes charges no tax.
+type noTaxes struct{}
+
+func (noTaxes) ApplyTax(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct) error {
+	return nil
+}
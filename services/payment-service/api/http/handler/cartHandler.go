@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/usecase"
+)
+
+// cartCookie holds the token of a shopper's anonymous cart.
+const cartCookie = "cart_id"
+
+type CartItemRequest struct {
+	ProductID string `json:"product_id" binding:"required"`
+	VariantID string `json:"variant_id"`
+	Quantity  int64  `json:"quantity"`
+}
+
+type CartQuantityRequest struct {
+	Quantity *int64 `json:"quantity" binding:"required"`
+}
+
+type CartCurrencyRequest struct {
+	Currency string `json:"currency" binding:"required"`
+}
+
+type CartCheckoutRequest struct {
+	ShippingAddress  *domain.Address `json:"shipping_address"`
+	BillingAddress   *domain.Address `json:"billing_address"`
+	CouponCodes      []string        `json:"coupon_codes"`
+	ShippingMethodID string          `json:"shipping_method_id"`
+}
+
+type CartShippingRequest struct {
+	ShippingAddress *domain.Address `json:"shipping_address" binding:"required"`
+}
+
+type CartHandler struct {
+	useCase     usecase.CartUseCase
+	idempotency usecase.IdempotencyUseCase
+}
+
+// NewCartHandler serves the shopper's cart. Signed-in shoppers use their
+// own cart; anonymous shoppers get one tied to a cookie, which is merged
+// into their own cart on their first request after signing in. Variants are
+// named with the variant_id query parameter. The cart is priced, and checked
+// out, in the currency last set with PUT /cart/currency, and shipping
+// methods to choose from at checkout are quoted by POST
+// /cart/shipping-options. Checkout takes an Idempotency-Key header like
+// /create-checkout-session does.
+func NewCartHandler(router *gin.Engine, useCase usecase.CartUseCase, idempotency usecase.IdempotencyUseCase) {
+	handler := &CartHandler{useCase: useCase, idempotency: idempotency}
+
+	cart := router.Group("/cart", handler.mergeOnSignIn)
+	cart.GET("", handler.GetCart)
+	cart.POST("/items", handler.AddItem)
+	cart.PUT("/items/:product_id", handler.UpdateItem)
+	cart.DELETE("/items/:product_id", handler.RemoveItem)
+	cart.PUT("/currency", handler.SetCurrency)
+	cart.POST("/shipping-options", handler.ShippingOptions)
+	cart.POST("/checkout", requireUser, handler.Checkout)
+}
+
+// mergeOnSignIn moves a signed-in shopper's anonymous cart into their own
+// and forgets its cookie. A failed merge leaves the cookie for the next
+// request to try again.
+func (h *CartHandler) mergeOnSignIn(c *gin.Context) {
+	customerID := c.GetHeader(userIDHeader)
+	token := cartToken(c)
+	if customerID == "" || token == "" {
+		c.Next()
+		return
+	}
+
+	if err := h.useCase.MergeAnonymousCart(c.Request.Context(), token, customerID); err != nil {
+		slog.Error(fmt.Sprintf("Failed to merge anonymous cart into cart of %s: %s", customerID, err))
+	} else {
+		setCartCookie(c, "", -1)
+	}
+	c.Next()
+}
+
+func (h *CartHandler) GetCart(c *gin.Context) {
+	view, err := h.useCase.GetCart(c.Request.Context(), cartOwner(c, false))
+	if err != nil {
+		c.JSON(cartErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+// AddItem adds to the quantity of a line already in the cart. The quantity
+// defaults to one.
+func (h *CartHandler) AddItem(c *gin.Context) {
+	var req CartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	view, err := h.useCase.AddItem(c.Request.Context(), cartOwner(c, true), req.ProductID, req.VariantID, req.Quantity)
+	h.respond(c, view, err)
+}
+
+// UpdateItem sets the quantity of a line. Zero removes it.
+func (h *CartHandler) UpdateItem(c *gin.Context) {
+	var req CartQuantityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	view, err := h.useCase.SetItemQuantity(c.Request.Context(), cartOwner(c, true), c.Param("product_id"), c.Query("variant_id"), *req.Quantity)
+	h.respond(c, view, err)
+}
+
+func (h *CartHandler) RemoveItem(c *gin.Context) {
+	view, err := h.useCase.RemoveItem(c.Request.Context(), cartOwner(c, true), c.Param("product_id"), c.Query("variant_id"))
+	h.respond(c, view, err)
+}
+
+func (h *CartHandler) SetCurrency(c *gin.Context) {
+	var req CartCurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	view, err := h.useCase.SetCurrency(c.Request.Context(), cartOwner(c, true), req.Currency)
+	h.respond(c, view, err)
+}
+
+// ShippingOptions quotes the shipping methods for the cart to the address
+// given, for the shopper to choose from at checkout.
+func (h *CartHandler) ShippingOptions(c *gin.Context) {
+	var req CartShippingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	quotes, err := h.useCase.ShippingOptions(c.Request.Context(), cartOwner(c, false), req.ShippingAddress)
+	if err != nil {
+		c.JSON(cartErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, quotes)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+// Checkout turns the signed-in shopper's cart into an order with its stock
+// reserved, and returns the payment page to send them to. With an
+// Idempotency-Key header, a repeated checkout gets the first response back
+// instead of placing another order.
+func (h *CartHandler) Checkout(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxCheckoutBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	customerID := c.GetHeader(userIDHeader)
+	idempotent(c, h.idempotency, customerID, body, func() (int, gin.H) {
+		return h.checkout(c.Request.Context(), customerID, body)
+	})
+}
+
+// checkout places the order for the cart and opens its payment session,
+// returning the response to send.
+func (h *CartHandler) checkout(ctx context.Context, customerID string, body []byte) (int, gin.H) {
+	var req CartCheckoutRequest
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			return http.StatusBadRequest, gin.H{"error": err.Error()}
+		}
+	}
+
+	order, session, err := h.useCase.Checkout(ctx, customerID, domain.CheckoutInput{
+		ShippingAddress:  req.ShippingAddress,
+		BillingAddress:   req.BillingAddress,
+		CouponCodes:      req.CouponCodes,
+		ShippingMethodID: req.ShippingMethodID,
+	})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to check out cart of customer %s: %s", customerID, err))
+		return cartErrorStatus(err), gin.H{"error": err.Error()}
+	}
+
+	return http.StatusOK, gin.H{"url": session.URL, "order_id": order.ID.Hex()}
+}
+
+func (h *CartHandler) respond(c *gin.Context, view *domain.CartView, err error) {
+	if err != nil {
+		c.JSON(cartErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+// cartOwner returns whose cart the request is for. An anonymous shopper
+// without a cart cookie is given one when create is set, ahead of the
+// change that creates their cart.
+func cartOwner(c *gin.Context, create bool) domain.CartOwner {
+	if customerID := c.GetHeader(userIDHeader); customerID != "" {
+		return domain.CartOwner{CustomerID: customerID}
+	}
+
+	token := cartToken(c)
+	if token == "" && create {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+		token = hex.EncodeToString(b)
+	}
+	if token != "" {
+		// refreshed on every use, like the cart's own expiry
+		setCartCookie(c, token, int(usecase.AnonymousCartTTL.Seconds()))
+	}
+	return domain.CartOwner{Token: token}
+}
+
+// cartToken returns the cart cookie's token, ignoring values this service
+// could not have set.
+func cartToken(c *gin.Context) string {
+	token, err := c.Cookie(cartCookie)
+	if err != nil || len(token) != 32 {
+		return ""
+	}
+	if _, err := hex.DecodeString(token); err != nil {
+		return ""
+	}
+	return token
+}
+
+func setCartCookie(c *gin.Context, token string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     cartCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   os.Getenv("SERVICE_ENV") == "production",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func cartErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidCart):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrCartNotReady):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrPaymentProvider):
+		return http.StatusBadGateway
+	}
+	return checkoutErrorStatus(err)
+}
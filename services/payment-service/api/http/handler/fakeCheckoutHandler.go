@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/payment-service/domain"
+)
+
+// FakeCheckout is the fake payment provider's control surface. Each action
+// sends the matching webhook before returning.
+type FakeCheckout interface {
+	CheckoutStatus(ctx context.Context, sessionID string) (*domain.CheckoutStatus, error)
+	Pay(ctx context.Context, sessionID string) error
+	Decline(ctx context.Context, sessionID, message string) error
+	Expire(ctx context.Context, sessionID string) error
+}
+
+var fakeCheckoutPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Test checkout</title></head>
+<body>
+<h1>Test checkout</h1>
+<p>No real payment is taken. Session {{.SessionID}} for {{.Amount}} {{.Currency}} (minor units) is {{.Status}}.</p>
+{{if eq .Status "open"}}
+<form method="post" action="{{.SessionID}}/pay"><button>Pay</button></form>
+<form method="post" action="{{.SessionID}}/decline"><button>Decline card</button></form>
+<form method="post" action="{{.SessionID}}/expire"><button>Let it expire</button></form>
+{{end}}
+</body>
+</html>
+`))
+
+type FakeCheckoutHandler struct {
+	checkout   FakeCheckout
+	successURL string
+	cancelURL  string
+}
+
+// NewFakeCheckoutHandler serves the hosted checkout page of the fake payment
+// provider. It must only be registered when that provider is configured.
+func NewFakeCheckoutHandler(router *gin.Engine, checkout FakeCheckout, successURL, cancelURL string) {
+	handler := &FakeCheckoutHandler{checkout: checkout, successURL: successURL, cancelURL: cancelURL}
+
+	group := router.Group("/fake-checkout")
+	group.GET("/:id", handler.ShowCheckout)
+	group.POST("/:id/pay", handler.Pay)
+	group.POST("/:id/decline", handler.Decline)
+	group.POST("/:id/expire", handler.Expire)
+}
+
+func (f *FakeCheckoutHandler) ShowCheckout(c *gin.Context) {
+	status, err := f.checkout.CheckoutStatus(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := fakeCheckoutPage.Execute(c.Writer, status); err != nil {
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+	}
+}
+
+func (f *FakeCheckoutHandler) Pay(c *gin.Context) {
+	f.settle(c, f.checkout.Pay(c.Request.Context(), c.Param("id")), f.successURL)
+}
+
+// Decline returns to the checkout page, which stays open for another try.
+func (f *FakeCheckoutHandler) Decline(c *gin.Context) {
+	f.settle(c, f.checkout.Decline(c.Request.Context(), c.Param("id"), c.PostForm("message")), "../"+c.Param("id"))
+}
+
+func (f *FakeCheckoutHandler) Expire(c *gin.Context) {
+	f.settle(c, f.checkout.Expire(c.Request.Context(), c.Param("id")), f.cancelURL)
+}
+
+func (f *FakeCheckoutHandler) settle(c *gin.Context, err error, redirectTo string) {
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	c.Redirect(http.StatusSeeOther, redirectTo)
+}
@@ -1,115 +1,241 @@
 package handler
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/stripe/stripe-go/v76"
-	"github.com/stripe/stripe-go/v76/checkout/session"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
-
-	"github.com/mephirious/group-project/services/payment-service/config"
 	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/usecase"
 )
 
 type Handler struct {
-	MongoClient *mongo.Client
-	Config      *config.Config
+	OrderUseCase       usecase.OrderUseCase
+	IdempotencyUseCase usecase.IdempotencyUseCase
+	PaymentProvider    usecase.PaymentProvider
 }
 
-func NewHandler(mongoClient *mongo.Client, config *config.Config) *Handler {
+func NewHandler(orderUseCase usecase.OrderUseCase, idempotencyUseCase usecase.IdempotencyUseCase, paymentProvider usecase.PaymentProvider) *Handler {
 	return &Handler{
-		MongoClient: mongoClient,
-		Config:      config,
+		OrderUseCase:       orderUseCase,
+		IdempotencyUseCase: idempotencyUseCase,
+		PaymentProvider:    paymentProvider,
+	}
+}
+
+// CheckoutRequest is the body of a checkout. A bare JSON array of items is
+// also accepted for clients that send no addresses or coupons and pay in
+// the default currency.
+type CheckoutRequest struct {
+	Items           []domain.CheckoutItem `json:"items"`
+	Currency        string                `json:"currency"`
+	ShippingAddress *domain.Address       `json:"shipping_address"`
+	BillingAddress  *domain.Address       `json:"billing_address"`
+	CouponCodes     []string              `json:"coupon_codes"`
+	// ShippingMethodID is one of the methods ShippingOptions returned for
+	// the same request.
+	ShippingMethodID string `json:"shipping_method_id"`
+}
+
+// input returns the checkout input the request describes.
+func (r *CheckoutRequest) input() domain.CheckoutInput {
+	return domain.CheckoutInput{
+		Items:            r.Items,
+		Currency:         r.Currency,
+		ShippingAddress:  r.ShippingAddress,
+		BillingAddress:   r.BillingAddress,
+		CouponCodes:      r.CouponCodes,
+		ShippingMethodID: r.ShippingMethodID,
+	}
+}
+
+func (r *CheckoutRequest) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return json.Unmarshal(trimmed, &r.Items)
 	}
+	type plain CheckoutRequest
+	return json.Unmarshal(data, (*plain)(r))
 }
 
+const (
+	// maxCheckoutBytes caps the size of a checkout request.
+	maxCheckoutBytes = 1 << 20
+	// maxIdempotencyKeyLength caps the Idempotency-Key header.
+	maxIdempotencyKeyLength = 255
+)
+
+// CreateCheckoutSession creates an order and its payment session. With an
+// Idempotency-Key header, repeats of the same request get the first
+// response back instead of creating another order.
 func (h *Handler) CreateCheckoutSession(c *gin.Context) {
-	var products []domain.Product
-	if err := c.ShouldBindJSON(&products); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product data"})
+	customerID := c.GetHeader(userIDHeader)
+	if customerID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign in to check out"})
 		return
 	}
 
-	var totalAmount int64
-	for _, product := range products {
-		totalAmount += product.Price * product.Quantity
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxCheckoutBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
 	}
 
-	var lineItems []*stripe.CheckoutSessionLineItemParams
-	for _, product := range products {
-		lineItems = append(lineItems, &stripe.CheckoutSessionLineItemParams{
-			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-				Currency: stripe.String(product.Currency),
-				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-					Name: stripe.String(product.Name),
-				},
-				UnitAmount: stripe.Int64(product.Price),
-			},
-			Quantity: stripe.Int64(product.Quantity),
-		})
+	idempotent(c, h.IdempotencyUseCase, customerID, body, func() (int, gin.H) {
+		return h.checkout(c.Request.Context(), customerID, body)
+	})
+}
+
+// idempotent sends the response respond makes. With an Idempotency-Key
+// header, a repeat of the same request by the same customer is sent the first
+// response instead, and respond is not run again. A key reused for another
+// route or body is refused.
+func idempotent(c *gin.Context, idempotency usecase.IdempotencyUseCase, customerID string, body []byte, respond func() (int, gin.H)) {
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" {
+		status, response := respond()
+		c.JSON(status, response)
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+		return
 	}
 
-	params := &stripe.CheckoutSessionParams{
-		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
-		LineItems:          lineItems,
-		Mode:               stripe.String(string(stripe.CheckoutSessionModePayment)),
-		SuccessURL:         stripe.String("http://localhost:3000/success"),
-		CancelURL:          stripe.String("http://localhost:3000/cancel"),
+	// keys are per customer so one customer cannot replay another's response
+	key = customerID + ":" + key
+	sum := sha256.Sum256(append([]byte(c.FullPath()+"\n"), body...))
+	previous, err := idempotency.BeginRequest(c.Request.Context(), key, hex.EncodeToString(sum[:]))
+	switch {
+	case errors.Is(err, usecase.ErrIdempotencyKeyReused):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, usecase.ErrRequestInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key"})
+		slog.Error(fmt.Sprintf("Failed to claim idempotency key: %s", err))
+		return
+	case previous != nil:
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(previous.ResponseStatus, "application/json; charset=utf-8", previous.ResponseBody)
+		return
 	}
 
-	s, err := session.New(params)
+	status, response := respond()
+	data, _ := json.Marshal(response)
+
+	ctx := context.WithoutCancel(c.Request.Context())
+	if status >= http.StatusInternalServerError {
+		err = idempotency.AbandonRequest(ctx, key)
+	} else {
+		err = idempotency.CompleteRequest(ctx, key, status, data)
+	}
 	if err != nil {
-		log.Printf("Error creating checkout session: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create checkout session"})
-		return
+		slog.Error(fmt.Sprintf("Failed to record idempotent response: %s", err))
 	}
 
-	order := domain.Order{
-		Products:  products,
-		Amount:    totalAmount,
-		Status:    "pending",
-		CreatedAt: time.Now(),
+	c.Data(status, "application/json; charset=utf-8", data)
+}
+
+// checkout places the order and opens its payment session, returning the
+// response to send.
+func (h *Handler) checkout(ctx context.Context, customerID string, body []byte) (int, gin.H) {
+	var req CheckoutRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, gin.H{"error": "Invalid product data"}
 	}
 
-	collection := h.MongoClient.Database(h.Config.Database.Name).Collection("orders")
-	_, err = collection.InsertOne(context.Background(), order)
+	order, session, err := h.OrderUseCase.Checkout(ctx, customerID, req.input())
+	if errors.Is(err, usecase.ErrPaymentProvider) {
+		slog.Error(fmt.Sprintf("Error creating checkout session: %s", err))
+		return http.StatusBadGateway, gin.H{"error": "Failed to create checkout session"}
+	}
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to create order: %s", err))
+		return checkoutErrorStatus(err), gin.H{"error": err.Error()}
+	}
+
+	return http.StatusOK, gin.H{"url": session.URL, "order_id": order.ID.Hex()}
+}
+
+// ShippingOptions quotes the shipping methods for a checkout request, for
+// the shopper to choose from before checking out.
+func (h *Handler) ShippingOptions(c *gin.Context) {
+	var req CheckoutRequest
+	if err := json.NewDecoder(http.MaxBytesReader(c.Writer, c.Request.Body, maxCheckoutBytes)).Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product data"})
+		return
+	}
+
+	quotes, err := h.OrderUseCase.ShippingOptions(c.Request.Context(), c.GetHeader(userIDHeader), req.input())
 	if err != nil {
-		log.Printf("Failed to save order: %v", err)
+		c.JSON(checkoutErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Failed to quote shipping: %s", err))
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"url": s.URL})
+	c.JSON(http.StatusOK, quotes)
 }
 
+// maxWebhookBytes caps the size of a webhook payload.
+const maxWebhookBytes = 65536
+
+// HandleWebhook verifies the event with the payment provider before trusting
+// it. Events that cannot be applied because no order matches or the order
+// has already moved on are acknowledged so the provider stops redelivering
+// them; other failures return 500 so it retries.
 func (h *Handler) HandleWebhook(c *gin.Context) {
-	var event stripe.Event
-	if err := c.ShouldBindJSON(&event); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
 		return
 	}
 
-	switch event.Type {
-	case "checkout.session.completed":
-		var session stripe.CheckoutSession
-		err := json.Unmarshal(event.Data.Raw, &session)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse session"})
-			return
-		}
+	event, ok, err := h.PaymentProvider.ParseWebhook(payload, c.Request.Header)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook"})
+		slog.Warn(fmt.Sprintf("Rejected webhook: %s", err))
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		return
+	}
 
-		collection := h.MongoClient.Database(h.Config.Database.Name).Collection("orders")
-		filter := bson.M{"status": "pending"}
-		update := bson.M{"$set": bson.M{"status": "paid"}}
-		_, err = collection.UpdateOne(context.Background(), filter, update)
-		if err != nil {
-			log.Printf("Failed to update order status: %v", err)
-		}
+	err = h.OrderUseCase.HandlePaymentEvent(c.Request.Context(), event)
+	if errors.Is(err, usecase.ErrDuplicateEvent) {
+		c.JSON(http.StatusOK, gin.H{"status": "duplicate"})
+		return
+	}
+	if errors.Is(err, usecase.ErrOrderNotFound) || errors.Is(err, usecase.ErrInvalidTransition) {
+		slog.Warn(fmt.Sprintf("Ignoring %s event %s: %s", event.Type, event.ID, err))
+		c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process event"})
+		slog.Error(fmt.Sprintf("Webhook %s failed: %s", event.ID, err))
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "success"})
 }
+
+func checkoutErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidOrder), errors.Is(err, usecase.ErrInvalidCoupon), errors.Is(err, usecase.ErrUnsupportedCurrency), errors.Is(err, usecase.ErrInvalidShipping):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrPriceMismatch), errors.Is(err, usecase.ErrInsufficientStock):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/payment-service/adapter/payments"
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/usecase"
+	"github.com/stripe/stripe-go/v76/webhook"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// recordingOrders is an OrderUseCase that records the payment events it is
+// given.
+type recordingOrders struct {
+	usecase.OrderUseCase
+
+	events []domain.PaymentEvent
+	err    error
+}
+
+func (r *recordingOrders) HandlePaymentEvent(ctx context.Context, event domain.PaymentEvent) error {
+	r.events = append(r.events, event)
+	return r.err
+}
+
+func postWebhook(t *testing.T, orders *recordingOrders, payload []byte, signature string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	provider := payments.NewStripeProvider("", testWebhookSecret, "", "", 0)
+	router := gin.New()
+	router.POST("/webhook", NewHandler(orders, nil, provider).HandleWebhook)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(payload))
+	if signature != "" {
+		req.Header.Set("Stripe-Signature", signature)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func sign(payload []byte, secret string) string {
+	return webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: secret}).Header
+}
+
+const completedEvent = `{
+	"id": "evt_1",
+	"object": "event",
+	"type": "checkout.session.completed",
+	"data": {"object": {
+		"id": "cs_1",
+		"object": "checkout.session",
+		"client_reference_id": "65f000000000000000000001",
+		"payment_intent": "pi_1",
+		"payment_status": "paid",
+		"amount_total": 74999999,
+		"currency": "kzt"
+	}}
+}`
+
+func TestWebhookRejectsBadSignatures(t *testing.T) {
+	payload := []byte(completedEvent)
+	for name, signature := range map[string]string{
+		"unsigned":     "",
+		"wrong secret": sign(payload, "whsec_other"),
+		"garbage":      "t=1,v1=deadbeef",
+	} {
+		orders := &recordingOrders{}
+		if w := postWebhook(t, orders, payload, signature); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", name, w.Code)
+		}
+		if len(orders.events) != 0 {
+			t.Errorf("%s: unverified event reached the order use case", name)
+		}
+	}
+}
+
+func TestWebhookAppliesVerifiedEvents(t *testing.T) {
+	payload := []byte(completedEvent)
+	orders := &recordingOrders{}
+	if w := postWebhook(t, orders, payload, sign(payload, testWebhookSecret)); w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200: %s", w.Code, w.Body)
+	}
+
+	want := domain.PaymentEvent{
+		ID:              "evt_1",
+		Type:            domain.PaymentCheckoutCompleted,
+		SessionID:       "cs_1",
+		OrderID:         "65f000000000000000000001",
+		PaymentIntentID: "pi_1",
+		Paid:            true,
+		Amount:          74999999,
+		Currency:        "kzt",
+	}
+	if len(orders.events) != 1 || orders.events[0] != want {
+		t.Errorf("events = %+v, want %+v", orders.events, want)
+	}
+
+	// a redelivered event for an order that has moved on is acknowledged
+	orders.err = usecase.ErrInvalidTransition
+	if w := postWebhook(t, orders, payload, sign(payload, testWebhookSecret)); w.Code != http.StatusOK {
+		t.Errorf("redelivery: status %d, want 200", w.Code)
+	}
+
+	orders.err = usecase.ErrDuplicateEvent
+	if w := postWebhook(t, orders, payload, sign(payload, testWebhookSecret)); w.Code != http.StatusOK {
+		t.Errorf("duplicate: status %d, want 200", w.Code)
+	}
+}
+
+func TestWebhookIgnoresOtherEvents(t *testing.T) {
+	payload := []byte(`{"id": "evt_2", "object": "event", "type": "customer.created", "data": {"object": {"id": "cus_1"}}}`)
+	orders := &recordingOrders{}
+	if w := postWebhook(t, orders, payload, sign(payload, testWebhookSecret)); w.Code != http.StatusOK {
+		t.Errorf("status %d, want 200", w.Code)
+	}
+	if len(orders.events) != 0 {
+		t.Errorf("ignored event reached the order use case: %+v", orders.events)
+	}
+}
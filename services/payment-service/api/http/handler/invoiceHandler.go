@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/usecase"
+)
+
+// invoiceContentTypes are the content types of the invoice formats.
+var invoiceContentTypes = map[string]string{
+	domain.InvoiceFormatHTML: "text/html; charset=utf-8",
+	domain.InvoiceFormatPDF:  "application/pdf",
+}
+
+type InvoiceHandler struct {
+	orders   usecase.OrderUseCase
+	invoices usecase.InvoiceUseCase
+}
+
+// NewInvoiceHandler serves the invoices and credit notes of orders to their
+// customers and to admins. An invoice is JSON unless ?format=html or
+// ?format=pdf asks for the document.
+func NewInvoiceHandler(router *gin.Engine, orders usecase.OrderUseCase, invoices usecase.InvoiceUseCase) {
+	handler := &InvoiceHandler{orders: orders, invoices: invoices}
+
+	customer := router.Group("/orders", requireUser)
+	customer.GET("/:id/invoices", handler.GetMyInvoices)
+	customer.GET("/:id/invoices/:invoice_id", handler.GetMyInvoice)
+
+	admin := router.Group("/admin/orders", requireAdmin)
+	admin.GET("/:id/invoices", handler.GetOrderInvoices)
+	admin.GET("/:id/invoices/:invoice_id", handler.GetOrderInvoice)
+}
+
+func (i *InvoiceHandler) GetMyInvoices(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	invoices, err := i.orders.GetCustomerInvoices(c.Request.Context(), c.GetHeader(userIDHeader), objID)
+	i.respondList(c, invoices, err)
+}
+
+func (i *InvoiceHandler) GetOrderInvoices(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	invoices, err := i.orders.GetOrderInvoices(c.Request.Context(), objID)
+	i.respondList(c, invoices, err)
+}
+
+func (i *InvoiceHandler) GetMyInvoice(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	invoice, err := i.orders.GetCustomerInvoice(c.Request.Context(), c.GetHeader(userIDHeader), objID, c.Param("invoice_id"))
+	i.respond(c, invoice, err)
+}
+
+func (i *InvoiceHandler) GetOrderInvoice(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	invoice, err := i.orders.GetOrderInvoice(c.Request.Context(), objID, c.Param("invoice_id"))
+	i.respond(c, invoice, err)
+}
+
+func (i *InvoiceHandler) respondList(c *gin.Context, invoices []domain.Invoice, err error) {
+	if err != nil {
+		c.JSON(invoiceErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if invoices == nil {
+		invoices = []domain.Invoice{}
+	}
+
+	c.JSON(http.StatusOK, invoices)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+// respond writes the invoice as JSON, or renders it in the format asked
+// for. PDFs are downloaded, HTML pages shown in the browser.
+func (i *InvoiceHandler) respond(c *gin.Context, invoice *domain.Invoice, err error) {
+	if err != nil {
+		c.JSON(invoiceErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		c.JSON(http.StatusOK, invoice)
+		slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+		return
+	}
+
+	document, err := i.invoices.RenderInvoice(invoice, format)
+	if err != nil {
+		c.JSON(invoiceErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	disposition := "inline"
+	if format == domain.InvoiceFormatPDF {
+		disposition = "attachment"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, invoice.Number+"."+format))
+	c.Data(http.StatusOK, invoiceContentTypes[format], document)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func invoiceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvoiceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvoiceFormat):
+		return http.StatusBadRequest
+	}
+	return orderErrorStatus(err)
+}
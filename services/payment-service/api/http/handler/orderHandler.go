@@ -0,0 +1,372 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/pkg/pagination"
+	"github.com/mephirious/group-project/services/payment-service/usecase"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Headers set by the gateway on requests it has authenticated.
+const (
+	userIDHeader   = "X-User-ID"
+	userRoleHeader = "X-User-Role"
+)
+
+type OrderStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// RefundRequest refunds the listed lines, or the rest of the order when
+// there are none. Units that had not shipped go back to stock; Restock also
+// returns the shipped ones to inventory as returned.
+type RefundRequest struct {
+	Lines []struct {
+		ProductID     string   `json:"product_id" binding:"required"`
+		VariantID     string   `json:"variant_id"`
+		Quantity      int64    `json:"quantity" binding:"required"`
+		SerialNumbers []string `json:"serial_numbers"`
+	} `json:"lines"`
+	Reason  string `json:"reason"`
+	Restock bool   `json:"restock"`
+}
+
+// ShipmentRequest packs the listed lines into a shipment, or everything
+// still to ship when there are none. Serial numbers pick exact units.
+type ShipmentRequest struct {
+	Lines []struct {
+		ProductID     string   `json:"product_id" binding:"required"`
+		VariantID     string   `json:"variant_id"`
+		Quantity      int64    `json:"quantity" binding:"required"`
+		SerialNumbers []string `json:"serial_numbers"`
+	} `json:"lines"`
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+	TrackingURL    string `json:"tracking_url"`
+}
+
+// ShipmentUpdateRequest moves a shipment to shipped or delivered and sets
+// its carrier details.
+type ShipmentUpdateRequest struct {
+	Status         string `json:"status"`
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+	TrackingURL    string `json:"tracking_url"`
+}
+
+var orderSort = pagination.Sort{
+	Fields:       []string{"created_at", "updated_at", "total", "status"},
+	DefaultField: "created_at",
+	DefaultOrder: "desc",
+}
+
+type OrderHandler struct {
+	useCase usecase.OrderUseCase
+}
+
+func NewOrderHandler(router *gin.Engine, useCase usecase.OrderUseCase) {
+	handler := &OrderHandler{useCase: useCase}
+
+	customer := router.Group("/orders", requireUser)
+	customer.GET("", handler.GetMyOrders)
+	customer.GET("/:id", handler.GetMyOrder)
+	customer.POST("/:id/cancel", handler.CancelMyOrder)
+	customer.GET("/:id/tracking", handler.GetMyOrderTracking)
+
+	admin := router.Group("/admin/orders", requireAdmin)
+	admin.GET("", handler.GetAllOrders)
+	admin.GET("/:id", handler.GetOrderByID)
+	admin.POST("/:id/status", handler.UpdateOrderStatus)
+	admin.POST("/:id/sync-payment", handler.SyncPayment)
+	admin.POST("/:id/refunds", handler.RefundOrder)
+	admin.POST("/:id/shipments", handler.CreateShipment)
+	admin.PUT("/:id/shipments/:shipment_id", handler.UpdateShipment)
+}
+
+// requireUser rejects requests the gateway did not authenticate.
+func requireUser(c *gin.Context) {
+	if c.GetHeader(userIDHeader) == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	c.Next()
+}
+
+func requireAdmin(c *gin.Context) {
+	if c.GetHeader(userIDHeader) == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	if c.GetHeader(userRoleHeader) != "admin" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin role required"})
+		return
+	}
+	c.Next()
+}
+
+func (o *OrderHandler) GetMyOrders(c *gin.Context) {
+	o.listOrders(c, domain.OrderFilter{CustomerID: c.GetHeader(userIDHeader), Status: c.Query("status")})
+}
+
+func (o *OrderHandler) GetAllOrders(c *gin.Context) {
+	o.listOrders(c, domain.OrderFilter{CustomerID: c.Query("customer_id"), Status: c.Query("status")})
+}
+
+func (o *OrderHandler) listOrders(c *gin.Context, filter domain.OrderFilter) {
+	params, err := pagination.FromQuery(c, orderSort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if filter.Status != "" && !domain.IsOrderStatus(filter.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown order status"})
+		slog.Error(fmt.Sprintf("Method %s failed: unknown order status %q", c.Request.Method, filter.Status))
+		return
+	}
+
+	orders, err := o.useCase.GetAllOrders(c.Request.Context(), filter, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (o *OrderHandler) GetMyOrder(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	order, err := o.useCase.GetCustomerOrder(c.Request.Context(), c.GetHeader(userIDHeader), objID)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (o *OrderHandler) GetOrderByID(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	order, err := o.useCase.GetOrderByID(c.Request.Context(), objID)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (o *OrderHandler) CancelMyOrder(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	order, err := o.useCase.CancelCustomerOrder(c.Request.Context(), c.GetHeader(userIDHeader), objID)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (o *OrderHandler) UpdateOrderStatus(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	var req OrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	order, err := o.useCase.UpdateOrderStatus(c.Request.Context(), objID, req.Status, c.GetHeader(userIDHeader), req.Reason)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+// SyncPayment applies the payment provider's view of a pending order's
+// checkout, for when its webhook never arrived.
+func (o *OrderHandler) SyncPayment(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	order, err := o.useCase.SyncPayment(c.Request.Context(), objID)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (o *OrderHandler) RefundOrder(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	var req RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	input := domain.RefundInput{Reason: req.Reason, Restock: req.Restock}
+	for _, line := range req.Lines {
+		input.Lines = append(input.Lines, domain.RefundLine{
+			ProductID:     line.ProductID,
+			VariantID:     line.VariantID,
+			Quantity:      line.Quantity,
+			SerialNumbers: line.SerialNumbers,
+		})
+	}
+
+	order, err := o.useCase.RefundOrder(c.Request.Context(), objID, input, c.GetHeader(userIDHeader))
+	if err != nil {
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (o *OrderHandler) GetMyOrderTracking(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	tracking, err := o.useCase.GetCustomerTracking(c.Request.Context(), c.GetHeader(userIDHeader), objID)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tracking)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (o *OrderHandler) CreateShipment(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	var req ShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	input := domain.ShipmentInput{Carrier: req.Carrier, TrackingNumber: req.TrackingNumber, TrackingURL: req.TrackingURL}
+	for _, line := range req.Lines {
+		input.Lines = append(input.Lines, domain.ShipmentLine{
+			ProductID:     line.ProductID,
+			VariantID:     line.VariantID,
+			Quantity:      line.Quantity,
+			SerialNumbers: line.SerialNumbers,
+		})
+	}
+
+	order, err := o.useCase.CreateShipment(c.Request.Context(), objID, input, c.GetHeader(userIDHeader))
+	if err != nil {
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (o *OrderHandler) UpdateShipment(c *gin.Context) {
+	objID, ok := orderID(c)
+	if !ok {
+		return
+	}
+
+	var req ShipmentUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	update := domain.ShipmentUpdate{Status: req.Status, Carrier: req.Carrier, TrackingNumber: req.TrackingNumber, TrackingURL: req.TrackingURL}
+	order, err := o.useCase.UpdateShipment(c.Request.Context(), objID, c.Param("shipment_id"), update, c.GetHeader(userIDHeader))
+	if err != nil {
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func orderID(c *gin.Context) (primitive.ObjectID, bool) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return objID, false
+	}
+	return objID, true
+}
+
+func orderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrOrderNotFound), errors.Is(err, usecase.ErrShipmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidTransition):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrInvalidRefund), errors.Is(err, usecase.ErrInvalidShipment):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrPaymentProvider):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
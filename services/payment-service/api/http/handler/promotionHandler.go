@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/pkg/pagination"
+	"github.com/mephirious/group-project/services/payment-service/usecase"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var promotionSort = pagination.Sort{
+	Fields:       []string{"created_at", "name", "uses"},
+	DefaultField: "created_at",
+	DefaultOrder: "desc",
+}
+
+type PromotionHandler struct {
+	useCase usecase.PromotionUseCase
+}
+
+// NewPromotionHandler lets admins manage coupons and automatic promotions.
+func NewPromotionHandler(router *gin.Engine, useCase usecase.PromotionUseCase) {
+	handler := &PromotionHandler{useCase: useCase}
+
+	admin := router.Group("/admin/promotions", requireAdmin)
+	admin.GET("", handler.GetAllPromotions)
+	admin.GET("/:id", handler.GetPromotionByID)
+	admin.POST("", handler.CreatePromotion)
+	admin.PUT("/:id", handler.UpdatePromotion)
+	admin.DELETE("/:id", handler.DeletePromotion)
+}
+
+func (p *PromotionHandler) GetAllPromotions(c *gin.Context) {
+	params, err := pagination.FromQuery(c, promotionSort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	promotions, err := p.useCase.GetAllPromotions(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, promotions)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (p *PromotionHandler) GetPromotionByID(c *gin.Context) {
+	objID, ok := promotionID(c)
+	if !ok {
+		return
+	}
+
+	promotion, err := p.useCase.GetPromotionByID(c.Request.Context(), objID)
+	if err != nil {
+		c.JSON(promotionErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (p *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var promotion domain.Promotion
+	if err := c.ShouldBindJSON(&promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	if err := p.useCase.CreatePromotion(c.Request.Context(), &promotion); err != nil {
+		c.JSON(promotionErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (p *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	objID, ok := promotionID(c)
+	if !ok {
+		return
+	}
+
+	var promotion domain.Promotion
+	if err := c.ShouldBindJSON(&promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	promotion.ID = objID
+
+	if err := p.useCase.UpdatePromotion(c.Request.Context(), &promotion); err != nil {
+		c.JSON(promotionErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	updated, err := p.useCase.GetPromotionByID(c.Request.Context(), objID)
+	if err != nil {
+		c.JSON(promotionErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (p *PromotionHandler) DeletePromotion(c *gin.Context) {
+	objID, ok := promotionID(c)
+	if !ok {
+		return
+	}
+
+	if err := p.useCase.DeletePromotion(c.Request.Context(), objID); err != nil {
+		c.JSON(promotionErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deleted"})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func promotionID(c *gin.Context) (primitive.ObjectID, bool) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return objID, false
+	}
+	return objID, true
+}
+
+func promotionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrPromotionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidPromotion):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/usecase"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ShippingHandler struct {
+	useCase usecase.ShippingUseCase
+}
+
+// NewShippingHandler lets admins manage shipping zones and their methods.
+func NewShippingHandler(router *gin.Engine, useCase usecase.ShippingUseCase) {
+	handler := &ShippingHandler{useCase: useCase}
+
+	admin := router.Group("/admin/shipping-zones", requireAdmin)
+	admin.GET("", handler.GetAllShippingZones)
+	admin.GET("/:id", handler.GetShippingZoneByID)
+	admin.POST("", handler.CreateShippingZone)
+	admin.PUT("/:id", handler.UpdateShippingZone)
+	admin.DELETE("/:id", handler.DeleteShippingZone)
+}
+
+func (s *ShippingHandler) GetAllShippingZones(c *gin.Context) {
+	zones, err := s.useCase.GetAllShippingZones(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, zones)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (s *ShippingHandler) GetShippingZoneByID(c *gin.Context) {
+	objID, ok := shippingZoneID(c)
+	if !ok {
+		return
+	}
+
+	zone, err := s.useCase.GetShippingZoneByID(c.Request.Context(), objID)
+	if err != nil {
+		c.JSON(shippingErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, zone)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (s *ShippingHandler) CreateShippingZone(c *gin.Context) {
+	var zone domain.ShippingZone
+	if err := c.ShouldBindJSON(&zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	if err := s.useCase.CreateShippingZone(c.Request.Context(), &zone); err != nil {
+		c.JSON(shippingErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, zone)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (s *ShippingHandler) UpdateShippingZone(c *gin.Context) {
+	objID, ok := shippingZoneID(c)
+	if !ok {
+		return
+	}
+
+	var zone domain.ShippingZone
+	if err := c.ShouldBindJSON(&zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	zone.ID = objID
+
+	if err := s.useCase.UpdateShippingZone(c.Request.Context(), &zone); err != nil {
+		c.JSON(shippingErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	updated, err := s.useCase.GetShippingZoneByID(c.Request.Context(), objID)
+	if err != nil {
+		c.JSON(shippingErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (s *ShippingHandler) DeleteShippingZone(c *gin.Context) {
+	objID, ok := shippingZoneID(c)
+	if !ok {
+		return
+	}
+
+	if err := s.useCase.DeleteShippingZone(c.Request.Context(), objID); err != nil {
+		c.JSON(shippingErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shipping zone deleted"})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func shippingZoneID(c *gin.Context) (primitive.ObjectID, bool) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipping zone ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return objID, false
+	}
+	return objID, true
+}
+
+func shippingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrShippingZoneNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidShippingZone):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
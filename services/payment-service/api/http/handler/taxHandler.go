@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/usecase"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TaxHandler struct {
+	useCase usecase.TaxUseCase
+}
+
+// NewTaxHandler lets admins manage the tax table.
+func NewTaxHandler(router *gin.Engine, useCase usecase.TaxUseCase) {
+	handler := &TaxHandler{useCase: useCase}
+
+	admin := router.Group("/admin/tax-rates", requireAdmin)
+	admin.GET("", handler.GetAllTaxRates)
+	admin.GET("/:id", handler.GetTaxRateByID)
+	admin.POST("", handler.CreateTaxRate)
+	admin.PUT("/:id", handler.UpdateTaxRate)
+	admin.DELETE("/:id", handler.DeleteTaxRate)
+}
+
+func (t *TaxHandler) GetAllTaxRates(c *gin.Context) {
+	rates, err := t.useCase.GetAllTaxRates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (t *TaxHandler) GetTaxRateByID(c *gin.Context) {
+	objID, ok := taxRateID(c)
+	if !ok {
+		return
+	}
+
+	rate, err := t.useCase.GetTaxRateByID(c.Request.Context(), objID)
+	if err != nil {
+		c.JSON(taxErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (t *TaxHandler) CreateTaxRate(c *gin.Context) {
+	var rate domain.TaxRate
+	if err := c.ShouldBindJSON(&rate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	if err := t.useCase.CreateTaxRate(c.Request.Context(), &rate); err != nil {
+		c.JSON(taxErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (t *TaxHandler) UpdateTaxRate(c *gin.Context) {
+	objID, ok := taxRateID(c)
+	if !ok {
+		return
+	}
+
+	var rate domain.TaxRate
+	if err := c.ShouldBindJSON(&rate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	rate.ID = objID
+
+	if err := t.useCase.UpdateTaxRate(c.Request.Context(), &rate); err != nil {
+		c.JSON(taxErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	updated, err := t.useCase.GetTaxRateByID(c.Request.Context(), objID)
+	if err != nil {
+		c.JSON(taxErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (t *TaxHandler) DeleteTaxRate(c *gin.Context) {
+	objID, ok := taxRateID(c)
+	if !ok {
+		return
+	}
+
+	if err := t.useCase.DeleteTaxRate(c.Request.Context(), objID); err != nil {
+		c.JSON(taxErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tax rate deleted"})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func taxRateID(c *gin.Context) (primitive.ObjectID, bool) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tax rate ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return objID, false
+	}
+	return objID, true
+}
+
+func taxErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrTaxRateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidTaxRate):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -6,10 +6,15 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/payment-service/adapter/invoices"
 	"github.com/mephirious/group-project/services/payment-service/adapter/mongo"
+	"github.com/mephirious/group-project/services/payment-service/adapter/payments"
+	"github.com/mephirious/group-project/services/payment-service/adapter/products"
 	"github.com/mephirious/group-project/services/payment-service/api/http/handler"
 	"github.com/mephirious/group-project/services/payment-service/config"
-	"github.com/stripe/stripe-go/v76"
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+	"github.com/mephirious/group-project/services/payment-service/usecase"
 )
 
 func main() {
@@ -18,8 +23,6 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	stripe.Key = cfg.StripeSecretKey
-
 	ctx := context.Background()
 	mongoClient, err := mongo.ConnectToMongoDB(ctx, cfg.Database.URI)
 	if err != nil {
@@ -27,12 +30,67 @@ func main() {
 	}
 	defer mongo.DisconnectFromMongoDB(ctx, mongoClient)
 
+	database := mongoClient.Database(cfg.Database.Name)
+	orderRepository := repository.NewOrderRepository(database)
+	eventRepository := repository.NewEventRepository(database)
+	idempotencyRepository := repository.NewIdempotencyRepository(database)
+	cartRepository := repository.NewCartRepository(database)
+	promotionRepository := repository.NewPromotionRepository(database)
+	taxRateRepository := repository.NewTaxRateRepository(database)
+	shippingZoneRepository := repository.NewShippingZoneRepository(database)
+	invoiceRepository := repository.NewInvoiceRepository(database)
+	for _, r := range []interface{ EnsureIndexes(context.Context) error }{orderRepository, eventRepository, idempotencyRepository, cartRepository, promotionRepository, taxRateRepository, invoiceRepository} {
+		if err := r.EnsureIndexes(ctx); err != nil {
+			log.Fatalf("Failed to create indexes: %v", err)
+		}
+	}
+
 	r := gin.Default()
 
-	h := handler.NewHandler(mongoClient, cfg)
+	var paymentProvider usecase.PaymentProvider
+	if cfg.Payments.Provider == "fake" {
+		fakeProvider := payments.NewFakeProvider(cfg.Payments.FakeCheckoutURL, cfg.Payments.FakeWebhookURL, cfg.Checkout.SessionTTL)
+		handler.NewFakeCheckoutHandler(r, fakeProvider, cfg.Checkout.SuccessURL, cfg.Checkout.CancelURL)
+		paymentProvider = fakeProvider
+		log.Printf("Using the fake payment provider, no real payments will be taken")
+	} else {
+		paymentProvider = payments.NewStripeProvider(cfg.StripeSecretKey, cfg.StripeWebhookSecret, cfg.Checkout.SuccessURL, cfg.Checkout.CancelURL, cfg.Checkout.SessionTTL)
+	}
+
+	productsClient := products.NewClient(cfg.Products.URL, cfg.Products.Timeout)
+	promotionUseCase := usecase.NewPromotionUseCase(promotionRepository)
+	shippingUseCase := usecase.NewShippingUseCase(shippingZoneRepository)
+	taxUseCase := usecase.NewTaxUseCase(taxRateRepository, usecase.NewRateTable(taxRateRepository), cfg.Tax.PricesIncludeTax, cfg.Tax.DefaultCountry)
+	seller := domain.Seller{
+		Name:  cfg.Invoice.SellerName,
+		TaxID: cfg.Invoice.SellerTaxID,
+		Email: cfg.Invoice.SellerEmail,
+		Address: domain.Address{
+			Line1:      cfg.Invoice.SellerLine1,
+			Line2:      cfg.Invoice.SellerLine2,
+			City:       cfg.Invoice.SellerCity,
+			State:      cfg.Invoice.SellerState,
+			PostalCode: cfg.Invoice.SellerPostalCode,
+			Country:    cfg.Invoice.SellerCountry,
+		},
+	}
+	invoiceUseCase := usecase.NewInvoiceUseCase(invoiceRepository, invoices.NewRenderer(), seller)
+	currencies := domain.NewCurrencies(cfg.Checkout.Currency, cfg.Checkout.Currencies...)
+	orderUseCase := usecase.NewOrderUseCase(orderRepository, eventRepository, productsClient, promotionUseCase, shippingUseCase, taxUseCase, invoiceUseCase, paymentProvider, currencies)
+	idempotencyUseCase := usecase.NewIdempotencyUseCase(idempotencyRepository)
+	cartUseCase := usecase.NewCartUseCase(cartRepository, productsClient, orderUseCase, currencies)
+
+	h := handler.NewHandler(orderUseCase, idempotencyUseCase, paymentProvider)
 
 	r.POST("/create-checkout-session", h.CreateCheckoutSession)
 	r.POST("/webhook", h.HandleWebhook)
+	r.POST("/shipping/options", h.ShippingOptions)
+	handler.NewOrderHandler(r, orderUseCase)
+	handler.NewInvoiceHandler(r, orderUseCase, invoiceUseCase)
+	handler.NewCartHandler(r, cartUseCase, idempotencyUseCase)
+	handler.NewPromotionHandler(r, promotionUseCase)
+	handler.NewTaxHandler(r, taxUseCase)
+	handler.NewShippingHandler(r, shippingUseCase)
 
 	serverAddr := ":" + strconv.Itoa(cfg.Server.Port)
 	log.Printf("Backend running on port %s...\n", serverAddr)
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -20,7 +23,56 @@ type Config struct {
 	Logging struct {
 		Level string
 	}
-	StripeSecretKey string
+	Payments struct {
+		// Provider is "stripe" or "fake". The fake provider takes no real
+		// payments and is for tests and offline development.
+		Provider string
+		// FakeCheckoutURL is where the fake provider's checkout page is
+		// reachable by the browser; FakeWebhookURL is where it sends
+		// webhooks, normally this service's own /webhook.
+		FakeCheckoutURL string
+		FakeWebhookURL  string
+	}
+	StripeSecretKey     string
+	StripeWebhookSecret string
+	Products            struct {
+		URL     string
+		Timeout time.Duration
+	}
+	Checkout struct {
+		// Currency is the default currency of orders. Currencies are the
+		// others shoppers may choose; products-service needs an exchange
+		// rate for each of them.
+		Currency   string
+		Currencies []string
+		// SessionTTL is how long a checkout stays payable. products-service
+		// RESERVATION_TTL should be longer so stock is still held when a
+		// late payment completes.
+		SessionTTL time.Duration
+		SuccessURL string
+		CancelURL  string
+	}
+	Tax struct {
+		// PricesIncludeTax is set when catalogue prices already include
+		// tax, as VAT is shown to consumers, rather than having it added
+		// at checkout like US sales tax.
+		PricesIncludeTax bool
+		// DefaultCountry is where orders without an address are taxed.
+		DefaultCountry string
+	}
+	Invoice struct {
+		// Seller details printed on invoices and credit notes. Invoices
+		// keep the details they were issued with when these change.
+		SellerName       string
+		SellerTaxID      string
+		SellerEmail      string
+		SellerLine1      string
+		SellerLine2      string
+		SellerCity       string
+		SellerState      string
+		SellerPostalCode string
+		SellerCountry    string
+	}
 }
 
 func LoadConfig() (*Config, error) {
@@ -40,7 +92,66 @@ func LoadConfig() (*Config, error) {
 	config.Database.URI = os.Getenv("DATABASE_URI")
 	config.Database.Name = os.Getenv("DATABASE_NAME")
 	config.Logging.Level = os.Getenv("LOGGING_LEVEL")
+	config.Payments.Provider = stringFromEnv("PAYMENT_PROVIDER", "stripe")
 	config.StripeSecretKey = os.Getenv("STRIPE_SECRET_KEY")
+	config.StripeWebhookSecret = os.Getenv("STRIPE_WEBHOOK_SECRET")
+	switch config.Payments.Provider {
+	case "stripe":
+		if config.StripeWebhookSecret == "" {
+			return nil, errors.New("STRIPE_WEBHOOK_SECRET is not set")
+		}
+	case "fake":
+		config.Payments.FakeCheckoutURL = stringFromEnv("FAKE_CHECKOUT_URL", "http://localhost:8080/payment/fake-checkout")
+		config.Payments.FakeWebhookURL = stringFromEnv("FAKE_WEBHOOK_URL", fmt.Sprintf("http://localhost:%d/webhook", port))
+	default:
+		return nil, fmt.Errorf("unknown PAYMENT_PROVIDER %q", config.Payments.Provider)
+	}
+	config.Products.URL = os.Getenv("PRODUCTS_SERVICE_URL")
+	if config.Products.URL == "" {
+		return nil, errors.New("PRODUCTS_SERVICE_URL is not set")
+	}
+	config.Products.Timeout = durationFromEnv("PRODUCTS_SERVICE_TIMEOUT", 5*time.Second)
+	config.Checkout.Currency = stringFromEnv("CHECKOUT_CURRENCY", "kzt")
+	config.Checkout.Currencies = strings.Split(os.Getenv("CHECKOUT_CURRENCIES"), ",")
+	// Stripe rejects checkout sessions that expire in under 30 minutes
+	config.Checkout.SessionTTL = durationFromEnv("CHECKOUT_SESSION_TTL", 30*time.Minute)
+	if config.Checkout.SessionTTL < 30*time.Minute {
+		config.Checkout.SessionTTL = 30 * time.Minute
+	}
+	config.Checkout.SuccessURL = stringFromEnv("CHECKOUT_SUCCESS_URL", "http://localhost:3000/success")
+	config.Checkout.CancelURL = stringFromEnv("CHECKOUT_CANCEL_URL", "http://localhost:3000/cancel")
+	config.Tax.PricesIncludeTax, err = strconv.ParseBool(stringFromEnv("TAX_PRICES_INCLUDE_TAX", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TAX_PRICES_INCLUDE_TAX: %w", err)
+	}
+	config.Tax.DefaultCountry = stringFromEnv("TAX_DEFAULT_COUNTRY", "KZ")
+	config.Invoice.SellerName = os.Getenv("INVOICE_SELLER_NAME")
+	if config.Invoice.SellerName == "" {
+		return nil, errors.New("INVOICE_SELLER_NAME is not set")
+	}
+	config.Invoice.SellerTaxID = os.Getenv("INVOICE_SELLER_TAX_ID")
+	config.Invoice.SellerEmail = os.Getenv("INVOICE_SELLER_EMAIL")
+	config.Invoice.SellerLine1 = os.Getenv("INVOICE_SELLER_LINE1")
+	config.Invoice.SellerLine2 = os.Getenv("INVOICE_SELLER_LINE2")
+	config.Invoice.SellerCity = os.Getenv("INVOICE_SELLER_CITY")
+	config.Invoice.SellerState = os.Getenv("INVOICE_SELLER_STATE")
+	config.Invoice.SellerPostalCode = os.Getenv("INVOICE_SELLER_POSTAL_CODE")
+	config.Invoice.SellerCountry = stringFromEnv("INVOICE_SELLER_COUNTRY", config.Tax.DefaultCountry)
 
 	return config, nil
 }
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+func stringFromEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Limits on what a cart may hold.
+const (
+	MaxCartLines        = 50
+	MaxCartLineQuantity = 99
+)
+
+// Cart is a shopper's saved selection. A signed-in customer's cart is keyed
+// by their user ID; an anonymous cart is keyed by the token in the shopper's
+// cart cookie and expires at ExpiresAt unless it is used again. Carts store
+// no prices: they are looked up in the catalogue whenever the cart is shown,
+// in the Currency the shopper chose, or the default one when it is empty.
+type Cart struct {
+	ID         string     `bson:"_id" json:"-"`
+	CustomerID string     `bson:"customer_id,omitempty" json:"customer_id,omitempty"`
+	Currency   string     `bson:"currency,omitempty" json:"currency,omitempty"`
+	Items      []CartItem `bson:"items" json:"items"`
+	ExpiresAt  *time.Time `bson:"expires_at,omitempty" json:"-"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+type CartItem struct {
+	ProductID string    `bson:"product_id" json:"product_id"`
+	VariantID string    `bson:"variant_id,omitempty" json:"variant_id,omitempty"`
+	Quantity  int64     `bson:"quantity" json:"quantity"`
+	AddedAt   time.Time `bson:"added_at" json:"added_at"`
+}
+
+// CartOwner identifies whose cart is meant: the signed-in customer's when
+// CustomerID is set, otherwise the anonymous cart of the cookie Token.
+type CartOwner struct {
+	CustomerID string
+	Token      string
+}
+
+func (o CartOwner) Anonymous() bool { return o.CustomerID == "" }
+
+// CartID returns the ID of the owner's cart. The prefixes keep a cookie
+// token from ever naming a customer's cart.
+func (o CartOwner) CartID() string {
+	if o.Anonymous() {
+		return "anonymous:" + o.Token
+	}
+	return "customer:" + o.CustomerID
+}
+
+// FindItem returns the index of the line for a product and variant, or -1.
+func (c *Cart) FindItem(productID, variantID string) int {
+	for n, item := range c.Items {
+		if item.ProductID == productID && item.VariantID == variantID {
+			return n
+		}
+	}
+	return -1
+}
+
+// SetQuantity sets the quantity of a line, adding it when it is new and
+// removing it when quantity is zero.
+func (c *Cart) SetQuantity(productID, variantID string, quantity int64, now time.Time) error {
+	if productID == "" {
+		return errors.New("product_id is required")
+	}
+	if quantity < 0 || quantity > MaxCartLineQuantity {
+		return fmt.Errorf("quantity must be between 0 and %d", MaxCartLineQuantity)
+	}
+
+	n := c.FindItem(productID, variantID)
+	switch {
+	case n < 0 && quantity == 0:
+	case n < 0:
+		if len(c.Items) >= MaxCartLines {
+			return errors.New("cart is full")
+		}
+		c.Items = append(c.Items, CartItem{ProductID: productID, VariantID: variantID, Quantity: quantity, AddedAt: now})
+	case quantity == 0:
+		c.Items = append(c.Items[:n], c.Items[n+1:]...)
+	default:
+		c.Items[n].Quantity = quantity
+	}
+	return nil
+}
+
+// Merge adds the lines of other to the cart. Quantities of lines in both
+// carts are added up, capped at MaxCartLineQuantity, and lines that do not
+// fit in a full cart are dropped. A currency chosen in other, the cart the
+// shopper was just using, replaces the cart's own.
+func (c *Cart) Merge(other *Cart) {
+	if other.Currency != "" {
+		c.Currency = other.Currency
+	}
+	for _, item := range other.Items {
+		if n := c.FindItem(item.ProductID, item.VariantID); n >= 0 {
+			c.Items[n].Quantity = min(c.Items[n].Quantity+item.Quantity, MaxCartLineQuantity)
+			continue
+		}
+		if len(c.Items) < MaxCartLines {
+			c.Items = append(c.Items, item)
+		}
+	}
+}
+
+// CartView is a cart priced and checked against the catalogue as it is
+// now. Lines with a Problem cannot be checked out and are left out of the
+// subtotal; Ready is true when the cart has lines and none has a problem.
+type CartView struct {
+	Items     []CartLine `json:"items"`
+	Currency  string     `json:"currency"`
+	Subtotal  int64      `json:"subtotal"`
+	ItemCount int64      `json:"item_count"`
+	Ready     bool       `json:"ready"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// CartLine is a cart line with its current name, unit price and stock.
+// Available is the number of units that can be ordered, or -1 when
+// unknown.
+type CartLine struct {
+	ProductID    string `json:"product_id"`
+	VariantID    string `json:"variant_id,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Quantity     int64  `json:"quantity"`
+	UnitPrice    int64  `json:"unit_price"`
+	Total        int64  `json:"total"`
+	Availability string `json:"availability,omitempty"`
+	Available    int64  `json:"available"`
+	Problem      string `json:"problem,omitempty"`
+}
@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CatalogProduct is the part of a products-service product that checkout
+// needs. Price is in major units; Money is the same price in minor units
+// with its currency, and is missing from older products-service responses.
+// Availability is only set when it was asked for. CategoryIDs holds the
+// product's category and its ancestors. TaxClass is empty for products taxed
+// at the standard rate. WeightGrams is missing when the product has no
+// numeric weight.
+type CatalogProduct struct {
+	ID           string               `json:"id"`
+	ModelName    string               `json:"model_name"`
+	Price        float64              `json:"price"`
+	Money        *CatalogMoney        `json:"money,omitempty"`
+	BrandID      string               `json:"brand_id"`
+	CategoryIDs  []string             `json:"category_ids"`
+	TaxClass     string               `json:"tax_class"`
+	WeightGrams  *int64               `json:"weight_grams,omitempty"`
+	Variants     []CatalogVariant     `json:"variants"`
+	Availability *CatalogAvailability `json:"availability,omitempty"`
+}
+
+// Availability statuses reported by products-service.
+const (
+	AvailabilityInStock    = "in_stock"
+	AvailabilityLowStock   = "low_stock"
+	AvailabilityOutOfStock = "out_of_stock"
+	AvailabilityBackorder  = "backorder"
+)
+
+// CatalogAvailability is a product's stock as products-service reports it.
+// Quantity counts units of all the product's variants.
+type CatalogAvailability struct {
+	Status   string `json:"status"`
+	Quantity int64  `json:"quantity"`
+}
+
+// Available reports how many units of the product can be ordered, or -1
+// when its stock is unknown. Checkout reserves units in stock, so products
+// on backorder are limited to their stock as well.
+func (p *CatalogProduct) Available() int64 {
+	if p.Availability == nil {
+		return -1
+	}
+	if p.Availability.Quantity < 0 {
+		return 0
+	}
+	return p.Availability.Quantity
+}
+
+// CatalogVariant is a variant with the parent product's price already
+// applied.
+type CatalogVariant struct {
+	ID          string        `json:"id"`
+	SKU         string        `json:"sku"`
+	Price       float64       `json:"price"`
+	Money       *CatalogMoney `json:"money,omitempty"`
+	WeightGrams *int64        `json:"weight_grams,omitempty"`
+}
+
+// CatalogMoney is a products-service price in minor units.
+type CatalogMoney struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Line returns the name and the minor-unit price in currency to charge for
+// the product, or for one of its variants when variantID is set. A price
+// without Money is taken to be in currency already.
+func (p *CatalogProduct) Line(variantID, currency string) (string, int64, error) {
+	if variantID == "" {
+		if len(p.Variants) > 0 {
+			return "", 0, errors.New("variant_id is required for products with variants")
+		}
+		amount, err := linePrice(p.Price, p.Money, currency)
+		return p.ModelName, amount, err
+	}
+	for _, v := range p.Variants {
+		if v.ID == variantID {
+			amount, err := linePrice(v.Price, v.Money, currency)
+			return p.ModelName + " (" + v.SKU + ")", amount, err
+		}
+	}
+	return "", 0, errors.New("variant not found for this product")
+}
+
+// Weight returns the weight in grams of one unit of the product, or of one
+// of its variants when variantID is set. It is false when the weight is
+// unknown.
+func (p *CatalogProduct) Weight(variantID string) (int64, bool) {
+	weight := p.WeightGrams
+	for _, v := range p.Variants {
+		if v.ID == variantID && v.WeightGrams != nil {
+			weight = v.WeightGrams
+		}
+	}
+	if weight == nil {
+		return 0, false
+	}
+	return *weight, true
+}
+
+func linePrice(price float64, money *CatalogMoney, currency string) (int64, error) {
+	if money == nil {
+		return ToMinorUnits(price, currency)
+	}
+	if !strings.EqualFold(money.Currency, currency) {
+		return 0, fmt.Errorf("priced in %s, not %s", strings.ToUpper(money.Currency), strings.ToUpper(currency))
+	}
+	if money.Amount < 0 {
+		return 0, errors.New("price must be a non-negative number")
+	}
+	return money.Amount, nil
+}
+
+// Currencies are the currencies orders can be placed in, the default one
+// first. Codes are lower case, as Stripe takes them.
+type Currencies []string
+
+// NewCurrencies returns def followed by the other accepted currencies.
+func NewCurrencies(def string, others ...string) Currencies {
+	currencies := Currencies{strings.ToLower(def)}
+	for _, code := range others {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code != "" && !slices.Contains(currencies, code) {
+			currencies = append(currencies, code)
+		}
+	}
+	return currencies
+}
+
+func (c Currencies) Default() string {
+	return c[0]
+}
+
+// Resolve returns the currency to price in for a requested code: the
+// default for an empty code, and false for one that is not accepted.
+func (c Currencies) Resolve(code string) (string, bool) {
+	if code == "" {
+		return c.Default(), true
+	}
+	code = strings.ToLower(code)
+	return code, slices.Contains(c, code)
+}
+
+// zeroDecimalCurrencies have no minor unit, so amounts are charged as is.
+var zeroDecimalCurrencies = map[string]bool{
+	"bif": true, "clp": true, "djf": true, "gnf": true, "jpy": true, "kmf": true,
+	"krw": true, "mga": true, "pyg": true, "rwf": true, "ugx": true, "vnd": true,
+	"vuv": true, "xaf": true, "xof": true, "xpf": true,
+}
+
+// ToMinorUnits converts a major-unit amount to the currency's minor unit,
+// rounding half up. It works on the shortest decimal form of amount, the one
+// the price was entered as, so 1.005 becomes 101 even though the nearest
+// float is slightly below 1.005.
+func ToMinorUnits(amount float64, currency string) (int64, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) || amount < 0 {
+		return 0, errors.New("price must be a non-negative number")
+	}
+	decimals := 2
+	if zeroDecimalCurrencies[strings.ToLower(currency)] {
+		decimals = 0
+	}
+
+	whole, frac, _ := strings.Cut(strconv.FormatFloat(amount, 'f', -1, 64), ".")
+	frac += strings.Repeat("0", decimals+1)
+	minor, err := strconv.ParseInt(whole+frac[:decimals], 10, 64)
+	if err != nil {
+		return 0, errors.New("price is too large")
+	}
+	if frac[decimals] >= '5' {
+		minor++
+	}
+	return minor, nil
+}
+
+// FormatAmount writes a minor-unit amount in major units with the currency
+// code, such as "749999.99 KZT".
+func FormatAmount(amount int64, currency string) string {
+	code := strings.ToUpper(currency)
+	if zeroDecimalCurrencies[strings.ToLower(currency)] {
+		return strconv.FormatInt(amount, 10) + " " + code
+	}
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/100, amount%100, code)
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "testing"
+
+func TestToMinorUnits(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     int64
+	}{
+		{19.99, "usd", 1999},
+		{0.29, "usd", 29},
+		{1.005, "usd", 101},
+		{749999.99, "KZT", 74999999},
+		{500, "jpy", 500},
+		{0, "usd", 0},
+		{2.5, "jpy", 3},
+		{1e-7, "usd", 0},
+	}
+	for _, tt := range tests {
+		got, err := ToMinorUnits(tt.amount, tt.currency)
+		if err != nil || got != tt.want {
+			t.Errorf("ToMinorUnits(%v, %s) = %d, %v; want %d", tt.amount, tt.currency, got, err, tt.want)
+		}
+	}
+
+	if _, err := ToMinorUnits(-1, "usd"); err == nil {
+		t.Error("negative amount: want error")
+	}
+}
@@ -1,18 +1,25 @@
 package domain
 
-import "time"
-
-type Product struct {
-	ID       string `json:"id" bson:"_id"`
-	Name     string `json:"model_name" bson:"model_name"`
-	Price    int64  `json:"price" bson:"price"`
-	Quantity int64  `json:"quantity" bson:"quantity"`
-	Currency string `json:"currency" bson:"currency"`
+// CheckoutItem is one line of a checkout request. Only the product, variant
+// and quantity are trusted; when a client still sends Price or Currency they
+// must match the catalogue or the checkout is rejected.
+type CheckoutItem struct {
+	ProductID string `json:"id"`
+	VariantID string `json:"variant_id,omitempty"`
+	Quantity  int64  `json:"quantity"`
+	Price     *int64 `json:"price,omitempty"`
+	Currency  string `json:"currency,omitempty"`
 }
 
-type Order struct {
-	Products  []Product `bson:"products" json:"products"`
-	Amount    int64     `bson:"amount" json:"amount"`
-	Status    string    `bson:"status" json:"status"`
-	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+// CheckoutInput is what a customer submits to place an order. Coupon codes
+// are matched without regard to case. An empty Currency means the default
+// checkout currency. ShippingMethodID is one of the methods quoted for the
+// shipping address.
+type CheckoutInput struct {
+	Items            []CheckoutItem
+	Currency         string
+	ShippingAddress  *Address
+	BillingAddress   *Address
+	CouponCodes      []string
+	ShippingMethodID string
 }
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// Processing states shared by processed events and idempotent requests.
+const (
+	ClaimProcessing = "processing"
+	ClaimCompleted  = "completed"
+)
+
+// ProcessedEvent records a payment provider event that has been claimed, so
+// a redelivered event is applied at most once.
+type ProcessedEvent struct {
+	ID          string     `bson:"_id" json:"id"`
+	Type        string     `bson:"type" json:"type"`
+	Status      string     `bson:"status" json:"status"`
+	ClaimedAt   time.Time  `bson:"claimed_at" json:"claimed_at"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// IdempotentRequest is a request made with an Idempotency-Key and, once it
+// has finished, the response to replay for repeats of it. Fingerprint
+// identifies the request body so a key cannot be reused for another request.
+type IdempotentRequest struct {
+	Key            string     `bson:"_id" json:"key"`
+	Fingerprint    string     `bson:"fingerprint" json:"fingerprint"`
+	Status         string     `bson:"status" json:"status"`
+	ResponseStatus int        `bson:"response_status,omitempty" json:"response_status,omitempty"`
+	ResponseBody   []byte     `bson:"response_body,omitempty" json:"-"`
+	CreatedAt      time.Time  `bson:"created_at" json:"created_at"`
+	CompletedAt    *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
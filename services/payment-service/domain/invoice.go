@@ -0,0 +1,234 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invoice kinds. A credit note reverses the part of an order's invoice that
+// a refund paid back.
+const (
+	InvoiceKindInvoice    = "invoice"
+	InvoiceKindCreditNote = "credit_note"
+)
+
+// Formats invoices are rendered in.
+const (
+	InvoiceFormatHTML = "html"
+	InvoiceFormatPDF  = "pdf"
+)
+
+// invoicePrefixes start the numbers of each kind of invoice, which are
+// numbered in separate sequences.
+var invoicePrefixes = map[string]string{
+	InvoiceKindInvoice:    "INV",
+	InvoiceKindCreditNote: "CN",
+}
+
+// FormatInvoiceNumber returns the number of the seq-th invoice of a kind,
+// such as "INV-000042".
+func FormatInvoiceNumber(kind string, seq int64) string {
+	return fmt.Sprintf("%s-%06d", invoicePrefixes[kind], seq)
+}
+
+// Seller is the business that issues invoices.
+type Seller struct {
+	Name    string  `bson:"name" json:"name"`
+	TaxID   string  `bson:"tax_id,omitempty" json:"tax_id,omitempty"`
+	Email   string  `bson:"email,omitempty" json:"email,omitempty"`
+	Address Address `bson:"address" json:"address"`
+}
+
+// Invoice is an invoice or credit note of an order. It is a snapshot taken
+// when it is issued, so later changes to the order or the seller's details
+// do not alter it. Amounts are in the minor unit of Currency and are
+// positive on credit notes too. Shipping is the delivery fee charged, or
+// refunded, as is. Number is empty only between the invoice being stored
+// and it being numbered; IssuedAt is set along with it. RefundID and
+// CreditedInvoice name the refund a credit note is for and the number of
+// the invoice it credits.
+type Invoice struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Kind            string             `bson:"kind" json:"kind"`
+	Number          string             `bson:"number,omitempty" json:"number"`
+	OrderID         primitive.ObjectID `bson:"order_id" json:"order_id"`
+	RefundID        string             `bson:"refund_id" json:"refund_id,omitempty"`
+	CreditedInvoice string             `bson:"credited_invoice,omitempty" json:"credited_invoice,omitempty"`
+	CustomerID      string             `bson:"customer_id" json:"customer_id"`
+	Seller          Seller             `bson:"seller" json:"seller"`
+	BillTo          *Address           `bson:"bill_to,omitempty" json:"bill_to,omitempty"`
+	ShipTo          *Address           `bson:"ship_to,omitempty" json:"ship_to,omitempty"`
+	Currency        string             `bson:"currency" json:"currency"`
+	Lines           []InvoiceLine      `bson:"lines" json:"lines"`
+	Subtotal        int64              `bson:"subtotal" json:"subtotal"`
+	Discount        int64              `bson:"discount" json:"discount"`
+	ShippingName    string             `bson:"shipping_name,omitempty" json:"shipping_name,omitempty"`
+	Shipping        int64              `bson:"shipping" json:"shipping"`
+	Tax             int64              `bson:"tax" json:"tax"`
+	TaxInclusive    bool               `bson:"tax_inclusive" json:"tax_inclusive"`
+	Taxes           []AppliedTax       `bson:"taxes,omitempty" json:"taxes,omitempty"`
+	Total           int64              `bson:"total" json:"total"`
+	Reason          string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	IssuedAt        time.Time          `bson:"issued_at,omitempty" json:"issued_at"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// InvoiceLine is a line of an invoice. Total is what was charged for it,
+// or refunded, tax included.
+type InvoiceLine struct {
+	ProductID   string `bson:"product_id,omitempty" json:"product_id,omitempty"`
+	VariantID   string `bson:"variant_id,omitempty" json:"variant_id,omitempty"`
+	Description string `bson:"description" json:"description"`
+	Quantity    int64  `bson:"quantity" json:"quantity"`
+	UnitPrice   int64  `bson:"unit_price" json:"unit_price"`
+	Discount    int64  `bson:"discount,omitempty" json:"discount,omitempty"`
+	Tax         int64  `bson:"tax,omitempty" json:"tax,omitempty"`
+	Total       int64  `bson:"total" json:"total"`
+}
+
+// NewInvoice returns the invoice of a paid order, not yet numbered.
+func NewInvoice(order *Order, seller Seller) *Invoice {
+	invoice := newOrderInvoice(order, seller, InvoiceKindInvoice)
+	for _, item := range order.Items {
+		invoice.Lines = append(invoice.Lines, InvoiceLine{
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			Description: item.Name,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Discount:    item.Discount,
+			Tax:         item.Tax,
+			Total:       item.Total,
+		})
+	}
+	invoice.Subtotal = order.Subtotal
+	invoice.Discount = order.Discount
+	if order.Shipping != nil {
+		invoice.ShippingName = order.Shipping.Name
+		invoice.Shipping = order.Shipping.Amount
+	}
+	invoice.Tax = order.Tax
+	invoice.Taxes = order.Taxes
+	invoice.Total = order.Total
+	return invoice
+}
+
+// NewCreditNote returns the credit note of one of the order's refunds, not
+// yet numbered, crediting the invoice numbered invoiceNumber. Each refunded
+// unit takes its share of its line's discount and tax, as it does of the
+// line's total when the refund is priced. What the refund paid back beyond
+// its lines is the shipping fee, or, for a refund without lines such as one
+// made at the payment provider, a share of the whole order.
+func NewCreditNote(order *Order, refund *Refund, invoiceNumber string, seller Seller) (*Invoice, error) {
+	note := newOrderInvoice(order, seller, InvoiceKindCreditNote)
+	note.RefundID = refund.ID
+	note.CreditedInvoice = invoiceNumber
+	note.Reason = refund.Reason
+
+	// units refunded by earlier refunds have had their share already
+	refunded := map[*OrderItem]int64{}
+	for _, earlier := range order.Refunds {
+		if earlier.ID == refund.ID {
+			break
+		}
+		if earlier.Status == RefundFailed {
+			continue
+		}
+		for _, line := range earlier.Lines {
+			if item, err := order.FindItem(line.ProductID, line.VariantID); err == nil {
+				refunded[item] += line.Quantity
+			}
+		}
+	}
+
+	var linesTotal int64
+	for _, line := range refund.Lines {
+		item, err := order.FindItem(line.ProductID, line.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		before := refunded[item]
+		refunded[item] += line.Quantity
+		note.Lines = append(note.Lines, InvoiceLine{
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			Description: item.Name,
+			Quantity:    line.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Discount:    item.unitShare(item.Discount, before, line.Quantity),
+			Tax:         item.unitShare(item.Tax, before, line.Quantity),
+			Total:       line.Amount,
+		})
+		linesTotal += line.Amount
+	}
+
+	switch rest := refund.Amount - linesTotal; {
+	case rest > 0 && len(refund.Lines) > 0 && order.Shipping != nil:
+		note.ShippingName = order.Shipping.Name
+		note.Shipping = rest
+	case rest != 0:
+		// refunds without lines, and lines capped by an earlier refund
+		// made at the provider
+		line := InvoiceLine{Description: "Refund", Quantity: 1, UnitPrice: rest, Total: rest}
+		if refund.Reason != "" {
+			line.Description = "Refund: " + refund.Reason
+		}
+		if len(refund.Lines) == 0 && order.Total > 0 {
+			line.Tax = order.Tax * rest / order.Total
+			if !order.TaxInclusive {
+				line.UnitPrice -= line.Tax
+			}
+		}
+		note.Lines = append(note.Lines, line)
+	}
+
+	for _, line := range note.Lines {
+		note.Subtotal += line.UnitPrice * line.Quantity
+		note.Discount += line.Discount
+		note.Tax += line.Tax
+	}
+	note.Taxes = shareTaxes(order.Taxes, order.Tax, note.Tax)
+	note.Total = refund.Amount
+	return note, nil
+}
+
+func newOrderInvoice(order *Order, seller Seller, kind string) *Invoice {
+	invoice := &Invoice{
+		Kind:         kind,
+		OrderID:      order.ID,
+		CustomerID:   order.CustomerID,
+		Seller:       seller,
+		BillTo:       order.BillingAddress,
+		ShipTo:       order.ShippingAddress,
+		Currency:     order.Currency,
+		TaxInclusive: order.TaxInclusive,
+		CreatedAt:    time.Now(),
+	}
+	if invoice.BillTo == nil {
+		invoice.BillTo = order.ShippingAddress
+	}
+	return invoice
+}
+
+// shareTaxes splits part of an order's tax total over the taxes it is made
+// of, in proportion to their amounts. The last tax takes the rounding
+// remainder so the shares add up to part.
+func shareTaxes(taxes []AppliedTax, total, part int64) []AppliedTax {
+	if total <= 0 || part == 0 || len(taxes) == 0 {
+		return nil
+	}
+	shared := make([]AppliedTax, len(taxes))
+	left := part
+	for n, tax := range taxes {
+		shared[n] = tax
+		if n == len(taxes)-1 {
+			shared[n].Amount = left
+			break
+		}
+		shared[n].Amount = tax.Amount * part / total
+		left -= shared[n].Amount
+	}
+	return shared
+}
@@ -0,0 +1,255 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Order statuses.
+const (
+	OrderPending   = "pending"
+	OrderPaid      = "paid"
+	OrderFulfilled = "fulfilled"
+	OrderShipped   = "shipped"
+	OrderDelivered = "delivered"
+	OrderCancelled = "cancelled"
+	OrderRefunded  = "refunded"
+)
+
+// orderTransitions lists the statuses an order may move to from each status.
+// cancelled and refunded are terminal; a paid order is refunded rather than
+// cancelled.
+var orderTransitions = map[string][]string{
+	OrderPending:   {OrderPaid, OrderCancelled},
+	OrderPaid:      {OrderFulfilled, OrderRefunded},
+	OrderFulfilled: {OrderShipped, OrderRefunded},
+	OrderShipped:   {OrderDelivered, OrderRefunded},
+	OrderDelivered: {OrderRefunded},
+	OrderCancelled: {},
+	OrderRefunded:  {},
+}
+
+// IsOrderStatus reports whether status is one of the known order statuses.
+func IsOrderStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return ok
+}
+
+// CanTransitionOrder reports whether an order may move from one status to
+// another.
+func CanTransitionOrder(from, to string) bool {
+	for _, s := range orderTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Order is a customer's purchase. Items snapshot the product name and price
+// at checkout so later catalogue changes do not alter past orders. Amounts
+// are in the minor unit of Currency. Subtotal is before discounts, Discount
+// is the sum of the line discounts, and Discounts lists the promotions they
+// came from. Shipping is the delivery fee, charged as is. Tax is the sum of
+// the line taxes, already in the prices when TaxInclusive is set and added
+// to them otherwise, and Taxes breaks it down. Total is what is charged.
+// AmountRefunded is the sum of the refunds that have not failed. Shipments
+// are the parcels the paid order has been fulfilled in so far.
+type Order struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CustomerID        string             `bson:"customer_id" json:"customer_id"`
+	Items             []OrderItem        `bson:"items" json:"items"`
+	Currency          string             `bson:"currency" json:"currency"`
+	Subtotal          int64              `bson:"subtotal" json:"subtotal"`
+	Discount          int64              `bson:"discount" json:"discount"`
+	Discounts         []AppliedDiscount  `bson:"discounts,omitempty" json:"discounts,omitempty"`
+	Shipping          *OrderShipping     `bson:"shipping,omitempty" json:"shipping,omitempty"`
+	Tax               int64              `bson:"tax" json:"tax"`
+	TaxInclusive      bool               `bson:"tax_inclusive" json:"tax_inclusive"`
+	Taxes             []AppliedTax       `bson:"taxes,omitempty" json:"taxes,omitempty"`
+	Total             int64              `bson:"total" json:"total"`
+	ShippingAddress   *Address           `bson:"shipping_address,omitempty" json:"shipping_address,omitempty"`
+	BillingAddress    *Address           `bson:"billing_address,omitempty" json:"billing_address,omitempty"`
+	Status            string             `bson:"status" json:"status"`
+	History           []StatusChange     `bson:"history" json:"history"`
+	ReservationID     string             `bson:"reservation_id,omitempty" json:"reservation_id,omitempty"`
+	CheckoutSessionID string             `bson:"checkout_session_id,omitempty" json:"-"`
+	PaymentIntentID   string             `bson:"payment_intent_id,omitempty" json:"payment_intent_id,omitempty"`
+	PaymentError      string             `bson:"payment_error,omitempty" json:"payment_error,omitempty"`
+	AmountRefunded    int64              `bson:"amount_refunded" json:"amount_refunded"`
+	Refunds           []Refund           `bson:"refunds,omitempty" json:"refunds,omitempty"`
+	Shipments         []Shipment         `bson:"shipments,omitempty" json:"shipments,omitempty"`
+	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// OrderItem is an order line. Discount is the part of the order's
+// discounts that falls on the line, Tax the line's tax, and Total what is
+// charged for it, tax included.
+type OrderItem struct {
+	ProductID string `bson:"product_id" json:"product_id"`
+	VariantID string `bson:"variant_id,omitempty" json:"variant_id,omitempty"`
+	Name      string `bson:"name" json:"name"`
+	UnitPrice int64  `bson:"unit_price" json:"unit_price"`
+	Quantity  int64  `bson:"quantity" json:"quantity"`
+	Discount  int64  `bson:"discount,omitempty" json:"discount,omitempty"`
+	Tax       int64  `bson:"tax,omitempty" json:"tax,omitempty"`
+	Total     int64  `bson:"total" json:"total"`
+}
+
+// UnitCharge is a number of units charged at one price.
+type UnitCharge struct {
+	UnitPrice int64
+	Quantity  int64
+}
+
+// Charges splits the line's discounted total into whole-unit prices, for
+// payment providers that charge a unit price per line: units are charged
+// the total divided by the quantity, and a remainder is spread one minor
+// unit at a time over the first units.
+func (i *OrderItem) Charges() []UnitCharge {
+	if i.Quantity < 1 {
+		return nil
+	}
+	unit, extra := i.Total/i.Quantity, i.Total%i.Quantity
+	if extra == 0 {
+		return []UnitCharge{{UnitPrice: unit, Quantity: i.Quantity}}
+	}
+	return []UnitCharge{{UnitPrice: unit + 1, Quantity: extra}, {UnitPrice: unit, Quantity: i.Quantity - extra}}
+}
+
+// RefundAmount returns what refunding quantity more units of the line is
+// worth when refunded units have been refunded already. Each unit is worth
+// an even share of the line's discounted total, and the shares of all the
+// line's units add up to that total exactly.
+func (i *OrderItem) RefundAmount(refunded, quantity int64) int64 {
+	return i.unitShare(i.Total, refunded, quantity)
+}
+
+// unitShare returns the part of a line amount that falls on quantity more
+// units when refunded units have had theirs already.
+func (i *OrderItem) unitShare(amount, refunded, quantity int64) int64 {
+	if i.Quantity < 1 {
+		return 0
+	}
+	return amount*(refunded+quantity)/i.Quantity - amount*refunded/i.Quantity
+}
+
+// StatusChange is an entry in an order's status history. From is empty for
+// the entry that records the order's creation.
+type StatusChange struct {
+	From   string    `bson:"from,omitempty" json:"from,omitempty"`
+	To     string    `bson:"to" json:"to"`
+	Actor  string    `bson:"actor" json:"actor"`
+	Reason string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	At     time.Time `bson:"at" json:"at"`
+}
+
+type Address struct {
+	Name       string `bson:"name" json:"name"`
+	Line1      string `bson:"line1" json:"line1"`
+	Line2      string `bson:"line2,omitempty" json:"line2,omitempty"`
+	City       string `bson:"city" json:"city"`
+	State      string `bson:"state,omitempty" json:"state,omitempty"`
+	PostalCode string `bson:"postal_code" json:"postal_code"`
+	Country    string `bson:"country" json:"country"`
+	Phone      string `bson:"phone,omitempty" json:"phone,omitempty"`
+}
+
+// OrderFilter narrows an order listing. Empty fields match every order.
+type OrderFilter struct {
+	CustomerID string
+	Status     string
+}
+
+func (a *Address) Validate() error {
+	if strings.TrimSpace(a.Name) == "" || strings.TrimSpace(a.Line1) == "" || strings.TrimSpace(a.City) == "" || strings.TrimSpace(a.PostalCode) == "" {
+		return errors.New("address requires name, line1, city and postal_code")
+	}
+	if len(a.Country) != 2 {
+		return errors.New("address country must be a two-letter ISO code")
+	}
+	a.Country = strings.ToUpper(a.Country)
+	return nil
+}
+
+// Validate checks the items and addresses and fills in the line, subtotal,
+// tax and total amounts.
+func (o *Order) Validate() error {
+	if o.CustomerID == "" {
+		return errors.New("customer is required")
+	}
+	if len(o.Items) == 0 {
+		return errors.New("order must have at least one item")
+	}
+	if o.Currency == "" {
+		return errors.New("currency is required")
+	}
+	o.Currency = strings.ToLower(o.Currency)
+
+	o.Subtotal, o.Discount, o.Tax = 0, 0, 0
+	for n := range o.Items {
+		item := &o.Items[n]
+		if item.ProductID == "" || item.Name == "" {
+			return errors.New("every item needs a product ID and name")
+		}
+		if item.Quantity < 1 {
+			return errors.New("item quantity must be at least 1")
+		}
+		if item.UnitPrice < 0 {
+			return errors.New("item price cannot be negative")
+		}
+		if item.Discount < 0 || item.Discount > item.UnitPrice*item.Quantity {
+			return errors.New("item discount must be between zero and the item's price")
+		}
+		item.Total = item.UnitPrice*item.Quantity - item.Discount
+		if item.Tax < 0 || (o.TaxInclusive && item.Tax > item.Total) {
+			return errors.New("item tax must be between zero and the item's price")
+		}
+		if !o.TaxInclusive {
+			item.Total += item.Tax
+		}
+		o.Subtotal += item.UnitPrice * item.Quantity
+		o.Discount += item.Discount
+		o.Tax += item.Tax
+	}
+	o.Total = o.Subtotal - o.Discount
+	if !o.TaxInclusive {
+		o.Total += o.Tax
+	}
+	if o.Shipping != nil {
+		if o.Shipping.Amount < 0 {
+			return errors.New("shipping fee cannot be negative")
+		}
+		o.Total += o.Shipping.Amount
+	}
+
+	if o.ShippingAddress != nil {
+		if err := o.ShippingAddress.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.BillingAddress != nil {
+		if err := o.BillingAddress.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetTax records the tax on each item, in the order of the items, and
+// recomputes the order's amounts.
+func (o *Order) SetTax(result *TaxResult, inclusive bool) error {
+	if len(result.Lines) != len(o.Items) {
+		return errors.New("tax result does not match the order's items")
+	}
+	for n := range o.Items {
+		o.Items[n].Tax = result.Lines[n]
+	}
+	o.TaxInclusive = inclusive
+	o.Taxes = result.Taxes
+	return o.Validate()
+}
@@ -0,0 +1,69 @@
+package domain
+
+import "time"
+
+// Payment event types, independent of the payment provider.
+const (
+	PaymentCheckoutCompleted = "checkout_completed"
+	PaymentCheckoutExpired   = "checkout_expired"
+	PaymentFailed            = "payment_failed"
+	PaymentRefunded          = "refunded"
+)
+
+// PaymentEvent is a payment provider webhook reduced to what orders need.
+// An event is matched to its order by SessionID, then by OrderID, the
+// reference the checkout was created with, then by PaymentIntentID.
+type PaymentEvent struct {
+	ID              string
+	Type            string
+	SessionID       string
+	OrderID         string
+	PaymentIntentID string
+	// Paid is false for a completed checkout whose payment has not yet been
+	// captured.
+	Paid           bool
+	Amount         int64
+	Currency       string
+	AmountRefunded int64
+	FailureMessage string
+}
+
+// Checkout session statuses, as reported by the payment provider.
+const (
+	CheckoutOpen     = "open"
+	CheckoutComplete = "complete"
+	CheckoutExpired  = "expired"
+)
+
+// CheckoutSession is the provider's hosted payment page for an order.
+type CheckoutSession struct {
+	ID        string
+	URL       string
+	ExpiresAt time.Time
+}
+
+// CheckoutStatus is the provider's current view of a checkout session, used
+// to catch up on webhooks that never arrived.
+type CheckoutStatus struct {
+	SessionID       string
+	Status          string
+	PaymentIntentID string
+	Paid            bool
+	Amount          int64
+	Currency        string
+}
+
+// RefundRequest returns Amount of a captured payment to the customer.
+// Requests with the same IdempotencyKey refund at most once.
+type RefundRequest struct {
+	PaymentIntentID string
+	Amount          int64
+	Reason          string
+	IdempotencyKey  string
+}
+
+// RefundResult is the provider's record of a refund.
+type RefundResult struct {
+	ID     string
+	Status string
+}
@@ -0,0 +1,182 @@
+package domain
+
+import (
+	"errors"
+	"math/bits"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Discount types of a promotion.
+const (
+	DiscountPercentage = "percentage"
+	DiscountFixed      = "fixed"
+)
+
+// Promotion is a discount on orders. Promotions with a Code are coupons the
+// customer enters at checkout; those without one apply to every order that
+// qualifies. Value is a percentage from 1 to 100, or for fixed discounts an
+// amount in the minor unit of Currency. MinOrderValue is compared with the
+// order subtotal before any discount, and zero limits mean unlimited. A
+// promotion that is not Stackable is only ever applied on its own.
+type Promotion struct {
+	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name               string             `bson:"name" json:"name"`
+	Code               string             `bson:"code,omitempty" json:"code,omitempty"`
+	Type               string             `bson:"type" json:"type"`
+	Value              int64              `bson:"value" json:"value"`
+	Currency           string             `bson:"currency,omitempty" json:"currency,omitempty"`
+	Scope              PromotionScope     `bson:"scope" json:"scope"`
+	MinOrderValue      int64              `bson:"min_order_value" json:"min_order_value"`
+	MaxUses            int64              `bson:"max_uses" json:"max_uses"`
+	MaxUsesPerCustomer int64              `bson:"max_uses_per_customer" json:"max_uses_per_customer"`
+	Uses               int64              `bson:"uses" json:"uses"`
+	StartsAt           *time.Time         `bson:"starts_at,omitempty" json:"starts_at,omitempty"`
+	EndsAt             *time.Time         `bson:"ends_at,omitempty" json:"ends_at,omitempty"`
+	Stackable          bool               `bson:"stackable" json:"stackable"`
+	Active             bool               `bson:"active" json:"active"`
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt          time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// PromotionScope limits a promotion to some products. An item is in scope
+// when it matches any of the lists; an empty scope covers the whole order.
+// CategoryIDs include every category below them.
+type PromotionScope struct {
+	ProductIDs  []string `bson:"product_ids,omitempty" json:"product_ids,omitempty"`
+	BrandIDs    []string `bson:"brand_ids,omitempty" json:"brand_ids,omitempty"`
+	CategoryIDs []string `bson:"category_ids,omitempty" json:"category_ids,omitempty"`
+}
+
+// AppliedDiscount records a promotion applied to an order and the amount it
+// took off.
+type AppliedDiscount struct {
+	PromotionID primitive.ObjectID `bson:"promotion_id" json:"promotion_id"`
+	Code        string             `bson:"code,omitempty" json:"code,omitempty"`
+	Name        string             `bson:"name" json:"name"`
+	Amount      int64              `bson:"amount" json:"amount"`
+}
+
+// NormalizeCouponCode returns code in the form codes are stored in.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks the promotion's settings and normalises its code and
+// currency.
+func (p *Promotion) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("name is required")
+	}
+	p.Code = NormalizeCouponCode(p.Code)
+	if strings.ContainsAny(p.Code, " \t") || len(p.Code) > 64 {
+		return errors.New("code must be at most 64 characters without spaces")
+	}
+	p.Currency = strings.ToLower(p.Currency)
+
+	switch p.Type {
+	case DiscountPercentage:
+		if p.Value < 1 || p.Value > 100 {
+			return errors.New("percentage must be between 1 and 100")
+		}
+		p.Currency = ""
+	case DiscountFixed:
+		if p.Value < 1 {
+			return errors.New("fixed discount must be positive")
+		}
+		if p.Currency == "" {
+			return errors.New("fixed discount requires a currency")
+		}
+	default:
+		return errors.New("type must be percentage or fixed")
+	}
+
+	if p.MinOrderValue < 0 || p.MaxUses < 0 || p.MaxUsesPerCustomer < 0 {
+		return errors.New("limits cannot be negative")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// ActiveAt reports whether the promotion is switched on and within its
+// validity window at t.
+func (p *Promotion) ActiveAt(t time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	return p.EndsAt == nil || t.Before(*p.EndsAt)
+}
+
+// Covers reports whether a product of the given brand and categories is in
+// the promotion's scope. categoryIDs must include the ancestors of the
+// product's category.
+func (s PromotionScope) Covers(productID, brandID string, categoryIDs []string) bool {
+	if len(s.ProductIDs) == 0 && len(s.BrandIDs) == 0 && len(s.CategoryIDs) == 0 {
+		return true
+	}
+	for _, id := range s.ProductIDs {
+		if id == productID {
+			return true
+		}
+	}
+	for _, id := range s.BrandIDs {
+		if id == brandID {
+			return true
+		}
+	}
+	for _, id := range s.CategoryIDs {
+		for _, categoryID := range categoryIDs {
+			if id == categoryID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Allocate splits amount across weights in proportion to them, handing the
+// units lost to rounding to the largest remainders first. amount is capped
+// at the sum of the weights, so no part exceeds its weight, and the parts
+// add up to it exactly.
+func Allocate(amount int64, weights []int64) []int64 {
+	parts := make([]int64, len(weights))
+	var total int64
+	for _, w := range weights {
+		total += max(w, 0)
+	}
+	if total == 0 || amount <= 0 {
+		return parts
+	}
+	amount = min(amount, total)
+
+	remainders := make([]uint64, len(weights))
+	allocated := int64(0)
+	for n, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		// amount * w can exceed int64, the quotient cannot
+		hi, lo := bits.Mul64(uint64(amount), uint64(w))
+		quotient, remainder := bits.Div64(hi, lo, uint64(total))
+		parts[n], remainders[n] = int64(quotient), remainder+1
+		allocated += parts[n]
+	}
+	for ; allocated < amount; allocated++ {
+		largest := 0
+		for n := range remainders {
+			if remainders[n] > remainders[largest] {
+				largest = n
+			}
+		}
+		parts[largest]++
+		remainders[largest] = 0
+	}
+	return parts
+}
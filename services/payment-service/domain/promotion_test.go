@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		amount  int64
+		weights []int64
+		want    []int64
+	}{
+		{100, []int64{300, 300, 300}, []int64{34, 33, 33}},
+		{10, []int64{0, 50, 150}, []int64{0, 3, 7}},
+		{500, []int64{100, 200}, []int64{100, 200}},
+		{0, []int64{1, 2}, []int64{0, 0}},
+	}
+	for _, tt := range tests {
+		if got := Allocate(tt.amount, tt.weights); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Allocate(%d, %v) = %v, want %v", tt.amount, tt.weights, got, tt.want)
+		}
+	}
+}
+
+func TestDiscountedItemCharges(t *testing.T) {
+	item := OrderItem{UnitPrice: 1000, Quantity: 3, Discount: 200, Total: 2800}
+
+	want := []UnitCharge{{UnitPrice: 934, Quantity: 1}, {UnitPrice: 933, Quantity: 2}}
+	if got := item.Charges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Charges() = %v, want %v", got, want)
+	}
+
+	refunded := item.RefundAmount(0, 1) + item.RefundAmount(1, 1) + item.RefundAmount(2, 1)
+	if refunded != item.Total {
+		t.Errorf("unit refunds add up to %d, want %d", refunded, item.Total)
+	}
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Refund statuses. A refund is pending from the moment it is requested until
+// the provider confirms it; a failed refund no longer counts towards the
+// order's refunded amount.
+const (
+	RefundPending   = "pending"
+	RefundSucceeded = "succeeded"
+	RefundFailed    = "failed"
+)
+
+// Refund is money returned on an order. Lines are empty for a refund that was
+// not tied to items, such as one made directly at the payment provider.
+type Refund struct {
+	ID               string       `bson:"id" json:"id"`
+	ProviderRefundID string       `bson:"provider_refund_id,omitempty" json:"provider_refund_id,omitempty"`
+	Amount           int64        `bson:"amount" json:"amount"`
+	Lines            []RefundLine `bson:"lines,omitempty" json:"lines,omitempty"`
+	Reason           string       `bson:"reason,omitempty" json:"reason,omitempty"`
+	Status           string       `bson:"status" json:"status"`
+	Actor            string       `bson:"actor" json:"actor"`
+	// Restocked is set once the refunded units that had shipped are back in
+	// inventory as returned.
+	Restocked bool      `bson:"restocked" json:"restocked"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// RefundLine is a refund of units of an order line. Released is how many of
+// them had not shipped yet; they go back to stock rather than being returned.
+type RefundLine struct {
+	ProductID     string   `bson:"product_id" json:"product_id"`
+	VariantID     string   `bson:"variant_id,omitempty" json:"variant_id,omitempty"`
+	Quantity      int64    `bson:"quantity" json:"quantity"`
+	Amount        int64    `bson:"amount" json:"amount"`
+	SerialNumbers []string `bson:"serial_numbers,omitempty" json:"serial_numbers,omitempty"`
+	Released      int64    `bson:"released,omitempty" json:"released,omitempty"`
+}
+
+// RefundInput is an admin's refund of an order. Without lines the rest of
+// the order is refunded. Restock returns the refunded units that had shipped
+// to inventory; units that had not are always released back to stock.
+type RefundInput struct {
+	Lines   []RefundLine
+	Reason  string
+	Restock bool
+}
+
+// RefundedQuantity returns how many units of an order line have been refunded
+// by refunds that have not failed.
+func (o *Order) RefundedQuantity(productID, variantID string) int64 {
+	var n int64
+	for _, refund := range o.Refunds {
+		if refund.Status == RefundFailed {
+			continue
+		}
+		for _, line := range refund.Lines {
+			if line.ProductID == productID && line.VariantID == variantID {
+				n += line.Quantity
+			}
+		}
+	}
+	return n
+}
+
+// FindItem returns the order line for a product and variant.
+func (o *Order) FindItem(productID, variantID string) (*OrderItem, error) {
+	for n := range o.Items {
+		if o.Items[n].ProductID == productID && o.Items[n].VariantID == variantID {
+			return &o.Items[n], nil
+		}
+	}
+	return nil, errors.New("order has no line for product " + productID)
+}
@@ -0,0 +1,169 @@
+package domain
+
+import "time"
+
+// Shipment statuses. Units are picked and sold when a shipment is created
+// in packing; it then leaves with a carrier and is delivered.
+const (
+	ShipmentPacking   = "packing"
+	ShipmentShipped   = "shipped"
+	ShipmentDelivered = "delivered"
+)
+
+// shipmentTransitions lists the statuses a shipment may move to from each
+// status. delivered is terminal.
+var shipmentTransitions = map[string][]string{
+	ShipmentPacking:   {ShipmentShipped},
+	ShipmentShipped:   {ShipmentDelivered},
+	ShipmentDelivered: {},
+}
+
+// CanTransitionShipment reports whether a shipment may move from one status
+// to another.
+func CanTransitionShipment(from, to string) bool {
+	for _, s := range shipmentTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Shipment is a parcel of an order's units. An order may ship in several.
+// Lines name the serial numbers of the units picked for it.
+type Shipment struct {
+	ID             string         `bson:"id" json:"id"`
+	Lines          []ShipmentLine `bson:"lines" json:"lines"`
+	Status         string         `bson:"status" json:"status"`
+	Carrier        string         `bson:"carrier,omitempty" json:"carrier,omitempty"`
+	TrackingNumber string         `bson:"tracking_number,omitempty" json:"tracking_number,omitempty"`
+	TrackingURL    string         `bson:"tracking_url,omitempty" json:"tracking_url,omitempty"`
+	Actor          string         `bson:"actor" json:"actor"`
+	CreatedAt      time.Time      `bson:"created_at" json:"created_at"`
+	ShippedAt      *time.Time     `bson:"shipped_at,omitempty" json:"shipped_at,omitempty"`
+	DeliveredAt    *time.Time     `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	UpdatedAt      time.Time      `bson:"updated_at" json:"updated_at"`
+}
+
+type ShipmentLine struct {
+	ProductID     string   `bson:"product_id" json:"product_id"`
+	VariantID     string   `bson:"variant_id,omitempty" json:"variant_id,omitempty"`
+	Quantity      int64    `bson:"quantity" json:"quantity"`
+	SerialNumbers []string `bson:"serial_numbers,omitempty" json:"serial_numbers,omitempty"`
+}
+
+// ShipmentInput is an admin's shipment of an order. Without lines every
+// unit still to ship goes in it. Serial numbers, when given for a line,
+// pick exactly those units.
+type ShipmentInput struct {
+	Lines          []ShipmentLine
+	Carrier        string
+	TrackingNumber string
+	TrackingURL    string
+}
+
+// ShipmentUpdate moves a shipment to Status, when set, and records the
+// carrier details given. A shipment needs a carrier and tracking number to
+// be shipped.
+type ShipmentUpdate struct {
+	Status         string
+	Carrier        string
+	TrackingNumber string
+	TrackingURL    string
+}
+
+// OrderTracking is what a customer sees of an order's fulfillment: its
+// shipments and the units still waiting to ship.
+type OrderTracking struct {
+	OrderID   string         `json:"order_id"`
+	Status    string         `json:"status"`
+	Shipments []Shipment     `json:"shipments"`
+	Unshipped []ShipmentLine `json:"unshipped"`
+}
+
+// ShippedQuantity returns how many units of an order line are in shipments.
+func (o *Order) ShippedQuantity(productID, variantID string) int64 {
+	var n int64
+	for _, shipment := range o.Shipments {
+		for _, line := range shipment.Lines {
+			if line.ProductID == productID && line.VariantID == variantID {
+				n += line.Quantity
+			}
+		}
+	}
+	return n
+}
+
+// ReleasedQuantity returns how many units of an order line were refunded
+// before they shipped and went back to stock, by refunds that have not
+// failed.
+func (o *Order) ReleasedQuantity(productID, variantID string) int64 {
+	var n int64
+	for _, refund := range o.Refunds {
+		if refund.Status == RefundFailed {
+			continue
+		}
+		for _, line := range refund.Lines {
+			if line.ProductID == productID && line.VariantID == variantID {
+				n += line.Released
+			}
+		}
+	}
+	return n
+}
+
+// UnshippedQuantity returns how many units of an order line are still to
+// ship: those neither shipped nor released, and no more than have not been
+// refunded.
+func (o *Order) UnshippedQuantity(item *OrderItem) int64 {
+	left := item.Quantity - o.ShippedQuantity(item.ProductID, item.VariantID) - o.ReleasedQuantity(item.ProductID, item.VariantID)
+	if paid := item.Quantity - o.RefundedQuantity(item.ProductID, item.VariantID); paid < left {
+		left = paid
+	}
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// Unshipped lists the units of the order still to ship.
+func (o *Order) Unshipped() []ShipmentLine {
+	lines := []ShipmentLine{}
+	for n := range o.Items {
+		item := &o.Items[n]
+		if left := o.UnshippedQuantity(item); left > 0 {
+			lines = append(lines, ShipmentLine{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: left})
+		}
+	}
+	return lines
+}
+
+// FulfillmentStatus returns the status an order has reached through its
+// shipments: fulfilled once every unit is in a shipment, shipped once they
+// have all left and delivered once they have all arrived. It is empty while
+// units are still to ship or when nothing was shipped.
+func (o *Order) FulfillmentStatus() string {
+	if len(o.Shipments) == 0 || len(o.Unshipped()) > 0 {
+		return ""
+	}
+	status := OrderDelivered
+	for _, shipment := range o.Shipments {
+		switch shipment.Status {
+		case ShipmentPacking:
+			return OrderFulfilled
+		case ShipmentShipped:
+			status = OrderShipped
+		}
+	}
+	return status
+}
+
+// FindShipment returns the order's shipment with id, or nil.
+func (o *Order) FindShipment(id string) *Shipment {
+	for n := range o.Shipments {
+		if o.Shipments[n].ID == id {
+			return &o.Shipments[n]
+		}
+	}
+	return nil
+}
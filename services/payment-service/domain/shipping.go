@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// What the rates of a shipping method are based on.
+const (
+	ShippingByWeight = "weight"
+	ShippingByPrice  = "price"
+)
+
+// ShippingZone is a set of destination countries and the shipping methods
+// that deliver there. A zone without countries covers every country no
+// other zone lists.
+type ShippingZone struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Countries []string           `bson:"countries" json:"countries"`
+	Methods   []ShippingMethod   `bson:"methods" json:"methods"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// ShippingMethod is a delivery option of a zone. Its fee is that of the
+// first rate whose range holds the order's weight in grams or, for methods
+// based on price, its discounted value in the minor unit of Currency. An
+// order outside every range cannot use the method.
+type ShippingMethod struct {
+	ID       primitive.ObjectID `bson:"_id" json:"id"`
+	Name     string             `bson:"name" json:"name"`
+	Basis    string             `bson:"basis" json:"basis"`
+	Currency string             `bson:"currency" json:"currency"`
+	Rates    []ShippingRate     `bson:"rates" json:"rates"`
+	Active   bool               `bson:"active" json:"active"`
+}
+
+// ShippingRate is a fee for orders from Min up to, but not including, Max.
+// A Max of zero has no upper bound.
+type ShippingRate struct {
+	Min    int64 `bson:"min" json:"min"`
+	Max    int64 `bson:"max,omitempty" json:"max,omitempty"`
+	Amount int64 `bson:"amount" json:"amount"`
+}
+
+// ShippingQuote is what a shipping method costs for an order.
+type ShippingQuote struct {
+	MethodID primitive.ObjectID `json:"method_id"`
+	Name     string             `json:"name"`
+	Amount   int64              `json:"amount"`
+	Currency string             `json:"currency"`
+}
+
+// OrderShipping is the shipping method chosen for an order and its fee.
+type OrderShipping struct {
+	MethodID primitive.ObjectID `bson:"method_id" json:"method_id"`
+	Name     string             `bson:"name" json:"name"`
+	Amount   int64              `bson:"amount" json:"amount"`
+}
+
+// Validate checks the zone and its methods, normalises country codes and
+// currencies, and gives new methods an ID.
+func (z *ShippingZone) Validate() error {
+	if strings.TrimSpace(z.Name) == "" {
+		return errors.New("name is required")
+	}
+	seen := make(map[string]bool, len(z.Countries))
+	for n, country := range z.Countries {
+		if len(country) != 2 {
+			return errors.New("countries must be two-letter ISO codes")
+		}
+		country = strings.ToUpper(country)
+		if seen[country] {
+			return fmt.Errorf("country %s is listed twice", country)
+		}
+		seen[country] = true
+		z.Countries[n] = country
+	}
+	if z.Countries == nil {
+		z.Countries = []string{}
+	}
+
+	for n := range z.Methods {
+		method := &z.Methods[n]
+		if err := method.Validate(); err != nil {
+			return fmt.Errorf("method %q: %w", method.Name, err)
+		}
+		if method.ID.IsZero() {
+			method.ID = primitive.NewObjectID()
+		}
+	}
+	return nil
+}
+
+func (m *ShippingMethod) Validate() error {
+	if strings.TrimSpace(m.Name) == "" {
+		return errors.New("name is required")
+	}
+	if m.Basis != ShippingByWeight && m.Basis != ShippingByPrice {
+		return errors.New("basis must be weight or price")
+	}
+	if m.Currency == "" {
+		return errors.New("currency is required")
+	}
+	m.Currency = strings.ToLower(m.Currency)
+	if len(m.Rates) == 0 {
+		return errors.New("at least one rate is required")
+	}
+	for _, rate := range m.Rates {
+		if rate.Min < 0 || rate.Amount < 0 {
+			return errors.New("rates cannot be negative")
+		}
+		if rate.Max != 0 && rate.Max <= rate.Min {
+			return errors.New("rate max must be above its min")
+		}
+	}
+	return nil
+}
+
+// Covers reports whether the zone lists country. Zones without countries
+// list none.
+func (z *ShippingZone) Covers(country string) bool {
+	for _, c := range z.Countries {
+		if strings.EqualFold(c, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fee returns the method's fee for an order of weight grams and value
+// minor units. It is false when the method is switched off or no rate
+// covers the order.
+func (m *ShippingMethod) Fee(weight, value int64) (int64, bool) {
+	if !m.Active {
+		return 0, false
+	}
+	measure := value
+	if m.Basis == ShippingByWeight {
+		measure = weight
+	}
+	for _, rate := range m.Rates {
+		if measure >= rate.Min && (rate.Max == 0 || measure < rate.Max) {
+			return rate.Amount, true
+		}
+	}
+	return 0, false
+}
+
+// ZoneFor returns the zone serving country: the zone listing it, else the
+// zone without countries, else nil.
+func ZoneFor(zones []ShippingZone, country string) *ShippingZone {
+	var rest *ShippingZone
+	for n := range zones {
+		if zones[n].Covers(country) {
+			return &zones[n]
+		}
+		if len(zones[n].Countries) == 0 && rest == nil {
+			rest = &zones[n]
+		}
+	}
+	return rest
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaxClassStandard is the tax class of products that name none.
+const TaxClassStandard = "standard"
+
+// maxTaxRate is 100% in basis points.
+const maxTaxRate = 10000
+
+// TaxRate is an entry of the built-in tax table. It applies to products of
+// TaxClass shipped to Country and, when State is set, only to that state or
+// region of it. Rate is in basis points, 1200 for 12%. Every rate matching a
+// line is charged on it side by side, such as a federal and a provincial
+// sales tax.
+type TaxRate struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Country   string             `bson:"country" json:"country"`
+	State     string             `bson:"state,omitempty" json:"state,omitempty"`
+	TaxClass  string             `bson:"tax_class" json:"tax_class"`
+	Rate      int64              `bson:"rate" json:"rate"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// NormalizeTaxClass returns class in the form tax classes are compared in.
+func NormalizeTaxClass(class string) string {
+	class = strings.ToLower(strings.TrimSpace(class))
+	if class == "" {
+		return TaxClassStandard
+	}
+	return class
+}
+
+// Validate checks the rate and normalises its country, state and class.
+func (r *TaxRate) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Country) != 2 {
+		return errors.New("country must be a two-letter ISO code")
+	}
+	r.Country = strings.ToUpper(r.Country)
+	r.State = strings.ToUpper(strings.TrimSpace(r.State))
+	r.TaxClass = NormalizeTaxClass(r.TaxClass)
+	if r.Rate < 0 || r.Rate > maxTaxRate {
+		return errors.New("rate must be between 0 and 10000 basis points")
+	}
+	return nil
+}
+
+// Applies reports whether the rate is charged on products of taxClass
+// shipped to destination.
+func (r *TaxRate) Applies(destination Address, taxClass string) bool {
+	if !strings.EqualFold(r.Country, destination.Country) || r.TaxClass != NormalizeTaxClass(taxClass) {
+		return false
+	}
+	return r.State == "" || strings.EqualFold(r.State, strings.TrimSpace(destination.State))
+}
+
+// TaxRequest asks for the tax on an order's lines. Line amounts are the
+// discounted line totals in the minor unit of Currency, with the tax
+// already in them when PricesIncludeTax is set.
+type TaxRequest struct {
+	Currency         string
+	Destination      Address
+	PricesIncludeTax bool
+	Lines            []TaxLine
+}
+
+type TaxLine struct {
+	ProductID string
+	TaxClass  string
+	Quantity  int64
+	Amount    int64
+}
+
+// TaxResult is the tax on each line of a TaxRequest, in the order of its
+// lines, and the taxes that make it up.
+type TaxResult struct {
+	Lines []int64
+	Taxes []AppliedTax
+}
+
+// AppliedTax is one tax charged on an order, with its rate in basis points
+// and the amount it came to over all the lines.
+type AppliedTax struct {
+	Name    string `bson:"name" json:"name"`
+	Country string `bson:"country,omitempty" json:"country,omitempty"`
+	State   string `bson:"state,omitempty" json:"state,omitempty"`
+	Rate    int64  `bson:"rate" json:"rate"`
+	Amount  int64  `bson:"amount" json:"amount"`
+}
+
+// LineTaxes returns what each of rates charges on a line amount, rounded
+// half up. An inclusive amount already holds the tax of all the rates, so
+// it is taken out at their combined rate and split between them.
+func LineTaxes(amount int64, rates []int64, inclusive bool) []int64 {
+	taxes := make([]int64, len(rates))
+	if amount <= 0 {
+		return taxes
+	}
+	if !inclusive {
+		for n, rate := range rates {
+			taxes[n] = (amount*rate + maxTaxRate/2) / maxTaxRate
+		}
+		return taxes
+	}
+
+	var combined int64
+	for _, rate := range rates {
+		combined += rate
+	}
+	net := (amount*maxTaxRate + (maxTaxRate+combined)/2) / (maxTaxRate + combined)
+	tax := amount - net
+	// weighting by rate times tax keeps Allocate from capping the tax at the
+	// sum of the rates
+	weights := make([]int64, len(rates))
+	for n, rate := range rates {
+		weights[n] = rate * tax
+	}
+	return Allocate(tax, weights)
+}
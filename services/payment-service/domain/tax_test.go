@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLineTaxes(t *testing.T) {
+	tests := []struct {
+		amount    int64
+		rates     []int64
+		inclusive bool
+		want      []int64
+	}{
+		{10000, []int64{1200}, false, []int64{1200}},
+		{11200, []int64{1200}, true, []int64{1200}},
+		{999, []int64{2000}, false, []int64{200}},
+		{11497, []int64{500, 997}, true, []int64{500, 997}},
+		{10000, []int64{500, 997}, false, []int64{500, 997}},
+		{10000, nil, true, []int64{}},
+		{0, []int64{1200}, false, []int64{0}},
+	}
+	for _, tt := range tests {
+		if got := LineTaxes(tt.amount, tt.rates, tt.inclusive); !slices.Equal(got, tt.want) {
+			t.Errorf("LineTaxes(%d, %v, %v) = %v, want %v", tt.amount, tt.rates, tt.inclusive, got, tt.want)
+		}
+	}
+}
+
+func TestTaxRateApplies(t *testing.T) {
+	rate := TaxRate{Name: "QST", Country: "ca", State: "qc", Rate: 997}
+	if err := rate.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if !rate.Applies(Address{Country: "CA", State: "QC"}, "") {
+		t.Error("standard rate does not apply to a product without a tax class")
+	}
+	if rate.Applies(Address{Country: "CA", State: "ON"}, "") || rate.Applies(Address{Country: "CA", State: "QC"}, "reduced") {
+		t.Error("rate applies outside its state or class")
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CartRepository interface {
+	GetCart(ctx context.Context, id string) (*domain.Cart, error)
+	SaveCart(ctx context.Context, cart *domain.Cart) error
+	DeleteCart(ctx context.Context, id string) error
+	DeleteCartIfUnchanged(ctx context.Context, id string, updatedAt time.Time) (bool, error)
+}
+
+type cartRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCartRepository(db *mongo.Database) *cartRepository {
+	return &cartRepository{
+		collection: db.Collection("carts"),
+	}
+}
+
+// EnsureIndexes expires anonymous carts at their expires_at. Customer carts
+// have no expiry and are kept.
+func (c *cartRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := c.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// GetCart returns nil, nil when the cart does not exist.
+func (c *cartRepository) GetCart(ctx context.Context, id string) (*domain.Cart, error) {
+	var cart domain.Cart
+	err := c.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&cart)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
+
+// SaveCart writes the whole cart, creating it if needed. Concurrent changes
+// to one cart are last write wins.
+func (c *cartRepository) SaveCart(ctx context.Context, cart *domain.Cart) error {
+	_, err := c.collection.ReplaceOne(ctx, bson.M{"_id": cart.ID}, cart, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *cartRepository) DeleteCart(ctx context.Context, id string) error {
+	_, err := c.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteCartIfUnchanged deletes the cart unless it has been saved since
+// updatedAt. It is false when nothing was deleted.
+func (c *cartRepository) DeleteCartIfUnchanged(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	result, err := c.collection.DeleteOne(ctx, bson.M{"_id": id, "updated_at": updatedAt})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
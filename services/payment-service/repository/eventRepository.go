@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// processedEventTTL is how long processed event IDs are kept. Stripe stops
+// redelivering an event after three days.
+const processedEventTTL = 30 * 24 * time.Hour
+
+type EventRepository interface {
+	ClaimEvent(ctx context.Context, id, eventType string, staleBefore time.Time) (bool, error)
+	CompleteEvent(ctx context.Context, id string) error
+	ReleaseEvent(ctx context.Context, id string) error
+}
+
+type eventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewEventRepository(db *mongo.Database) *eventRepository {
+	return &eventRepository{
+		collection: db.Collection("processed_events"),
+	}
+}
+
+func (e *eventRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := e.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "claimed_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(processedEventTTL.Seconds())),
+	})
+	return err
+}
+
+// ClaimEvent marks the event as being processed. It returns false when the
+// event has already been processed, or is being processed by a claim made
+// after staleBefore. The event ID is the document _id, so two concurrent
+// claims cannot both insert it.
+func (e *eventRepository) ClaimEvent(ctx context.Context, id, eventType string, staleBefore time.Time) (bool, error) {
+	filter := bson.M{"_id": id, "status": domain.ClaimProcessing, "claimed_at": bson.M{"$lt": staleBefore}}
+	update := bson.M{"$set": bson.M{"type": eventType, "status": domain.ClaimProcessing, "claimed_at": time.Now()}}
+
+	_, err := e.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (e *eventRepository) CompleteEvent(ctx context.Context, id string) error {
+	_, err := e.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"status": domain.ClaimCompleted, "completed_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseEvent forgets a claim so a redelivery of the event is processed.
+func (e *eventRepository) ReleaseEvent(ctx context.Context, id string) error {
+	_, err := e.collection.DeleteOne(ctx, bson.M{"_id": id, "status": domain.ClaimProcessing})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
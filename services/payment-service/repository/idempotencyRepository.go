@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// idempotencyKeyTTL is how long a key and its response are kept.
+const idempotencyKeyTTL = 24 * time.Hour
+
+type IdempotencyRepository interface {
+	ClaimKey(ctx context.Context, key, fingerprint string, staleBefore time.Time) (*domain.IdempotentRequest, bool, error)
+	CompleteKey(ctx context.Context, key string, status int, body []byte) error
+	ReleaseKey(ctx context.Context, key string) error
+}
+
+type idempotencyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewIdempotencyRepository(db *mongo.Database) *idempotencyRepository {
+	return &idempotencyRepository{
+		collection: db.Collection("idempotency_keys"),
+	}
+}
+
+func (i *idempotencyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := i.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(idempotencyKeyTTL.Seconds())),
+	})
+	return err
+}
+
+// ClaimKey starts a request under key. It returns true when the caller now
+// owns the key, either because it is new or because the previous attempt
+// was claimed before staleBefore and never finished. Otherwise it returns the
+// stored request.
+func (i *idempotencyRepository) ClaimKey(ctx context.Context, key, fingerprint string, staleBefore time.Time) (*domain.IdempotentRequest, bool, error) {
+	filter := bson.M{"_id": key, "status": domain.ClaimProcessing, "created_at": bson.M{"$lt": staleBefore}}
+	update := bson.M{"$set": bson.M{"fingerprint": fingerprint, "status": domain.ClaimProcessing, "created_at": time.Now()}}
+
+	_, err := i.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err == nil {
+		return nil, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, false, err
+	}
+
+	var existing domain.IdempotentRequest
+	err = i.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		// released between the claim and the lookup; let the client retry
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &existing, false, nil
+}
+
+func (i *idempotencyRepository) CompleteKey(ctx context.Context, key string, status int, body []byte) error {
+	update := bson.M{"$set": bson.M{
+		"status":          domain.ClaimCompleted,
+		"response_status": status,
+		"response_body":   body,
+		"completed_at":    time.Now(),
+	}}
+
+	_, err := i.collection.UpdateOne(ctx, bson.M{"_id": key}, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseKey forgets an unfinished request so the key can be retried.
+func (i *idempotencyRepository) ReleaseKey(ctx context.Context, key string) error {
+	_, err := i.collection.DeleteOne(ctx, bson.M{"_id": key, "status": domain.ClaimProcessing})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvoiceRepository interface {
+	GetOrderInvoices(ctx context.Context, orderID primitive.ObjectID) ([]domain.Invoice, error)
+	CreateInvoice(ctx context.Context, invoice *domain.Invoice) (bool, error)
+	NextInvoiceSequence(ctx context.Context, kind string) (int64, error)
+	NumberInvoice(ctx context.Context, id primitive.ObjectID, number string, issuedAt time.Time) (bool, error)
+}
+
+type invoiceRepository struct {
+	collection *mongo.Collection
+	counters   *mongo.Collection
+}
+
+func NewInvoiceRepository(db *mongo.Database) *invoiceRepository {
+	return &invoiceRepository{
+		collection: db.Collection("invoices"),
+		counters:   db.Collection("invoice_counters"),
+	}
+}
+
+// EnsureIndexes allows one invoice per order and one credit note per
+// refund, the invoice having an empty refund ID, and makes numbers unique.
+func (i *invoiceRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := i.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "order_id", Value: 1}, {Key: "refund_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "number", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"number": bson.M{"$type": "string"}}),
+		},
+	})
+	return err
+}
+
+// GetOrderInvoices returns the invoice and credit notes of an order in the
+// order they were created.
+func (i *invoiceRepository) GetOrderInvoices(ctx context.Context, orderID primitive.ObjectID) ([]domain.Invoice, error) {
+	var invoices []domain.Invoice
+
+	cursor, err := i.collection.Find(ctx, bson.M{"order_id": orderID}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &invoices)
+	if err != nil {
+		return nil, err
+	}
+
+	return invoices, nil
+}
+
+// CreateInvoice stores a new invoice. It returns false when the order
+// already has the invoice, or the refund its credit note.
+func (i *invoiceRepository) CreateInvoice(ctx context.Context, invoice *domain.Invoice) (bool, error) {
+	invoice.ID = primitive.NewObjectID()
+
+	_, err := i.collection.InsertOne(ctx, invoice)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// NextInvoiceSequence takes the next number in the sequence of a kind of
+// invoice, starting from 1.
+func (i *invoiceRepository) NextInvoiceSequence(ctx context.Context, kind string) (int64, error) {
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := i.counters.FindOneAndUpdate(ctx, bson.M{"_id": kind}, bson.M{"$inc": bson.M{"seq": 1}}, opts).Decode(&counter)
+	if err != nil {
+		return 0, err
+	}
+
+	return counter.Seq, nil
+}
+
+// NumberInvoice issues an invoice under number. It returns false when the
+// invoice was numbered already.
+func (i *invoiceRepository) NumberInvoice(ctx context.Context, id primitive.ObjectID, number string, issuedAt time.Time) (bool, error) {
+	filter := bson.M{"_id": id, "number": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"number": number, "issued_at": issuedAt}}
+
+	result, err := i.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OrderRepository interface {
+	GetAllOrders(ctx context.Context, filter domain.OrderFilter, params pagination.Params) (*pagination.Page[domain.Order], error)
+	GetOrderByID(ctx context.Context, id primitive.ObjectID) (*domain.Order, error)
+	GetOrderByCheckoutSessionID(ctx context.Context, sessionID string) (*domain.Order, error)
+	GetOrderByPaymentIntentID(ctx context.Context, paymentIntentID string) (*domain.Order, error)
+	CreateOrder(ctx context.Context, order *domain.Order) error
+	SetCheckoutSessionID(ctx context.Context, id primitive.ObjectID, sessionID string) error
+	SetPaymentDetails(ctx context.Context, id primitive.ObjectID, paymentIntentID, paymentError string) error
+	TransitionOrder(ctx context.Context, id primitive.ObjectID, change domain.StatusChange) (*domain.Order, error)
+	AddRefund(ctx context.Context, id primitive.ObjectID, refundedBefore int64, refund domain.Refund) (*domain.Order, error)
+	UpdateRefund(ctx context.Context, id primitive.ObjectID, refund domain.Refund) (*domain.Order, error)
+	AddShipment(ctx context.Context, id primitive.ObjectID, shipment domain.Shipment) (*domain.Order, error)
+	UpdateShipment(ctx context.Context, id primitive.ObjectID, from string, shipment domain.Shipment) (*domain.Order, error)
+}
+
+type orderRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOrderRepository(db *mongo.Database) *orderRepository {
+	return &orderRepository{
+		collection: db.Collection("orders"),
+	}
+}
+
+// EnsureIndexes makes the payment references unique, so a checkout session,
+// payment or stock reservation can never be attached to two orders.
+func (o *orderRepository) EnsureIndexes(ctx context.Context) error {
+	var models []mongo.IndexModel
+	for _, field := range []string{"checkout_session_id", "payment_intent_id", "reservation_id"} {
+		models = append(models, mongo.IndexModel{
+			Keys: bson.D{{Key: field, Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{field: bson.M{"$type": "string"}}),
+		})
+	}
+	models = append(models, mongo.IndexModel{Keys: bson.D{{Key: "customer_id", Value: 1}, {Key: "created_at", Value: -1}}})
+
+	_, err := o.collection.Indexes().CreateMany(ctx, models)
+	return err
+}
+
+func (o *orderRepository) GetAllOrders(ctx context.Context, filter domain.OrderFilter, params pagination.Params) (*pagination.Page[domain.Order], error) {
+	var orders []domain.Order
+
+	query := bson.M{}
+	if filter.CustomerID != "" {
+		query["customer_id"] = filter.CustomerID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	total, err := o.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := o.collection.Find(ctx, params.Query(query), params.FindOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &orders)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(orders, total, params)
+}
+
+func (o *orderRepository) GetOrderByID(ctx context.Context, id primitive.ObjectID) (*domain.Order, error) {
+	var order domain.Order
+
+	err := o.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+func (o *orderRepository) GetOrderByCheckoutSessionID(ctx context.Context, sessionID string) (*domain.Order, error) {
+	var order domain.Order
+
+	err := o.collection.FindOne(ctx, bson.M{"checkout_session_id": sessionID}).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+func (o *orderRepository) GetOrderByPaymentIntentID(ctx context.Context, paymentIntentID string) (*domain.Order, error) {
+	var order domain.Order
+
+	err := o.collection.FindOne(ctx, bson.M{"payment_intent_id": paymentIntentID}).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// CreateOrder stores a new order, giving it an ID unless it already has one.
+func (o *orderRepository) CreateOrder(ctx context.Context, order *domain.Order) error {
+	if order.ID.IsZero() {
+		order.ID = primitive.NewObjectID()
+	}
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = order.CreatedAt
+
+	_, err := o.collection.InsertOne(ctx, order)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (o *orderRepository) SetCheckoutSessionID(ctx context.Context, id primitive.ObjectID, sessionID string) error {
+	_, err := o.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"checkout_session_id": sessionID, "updated_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetPaymentDetails records the provider's payment for the order and its
+// latest failure, if any. An empty paymentIntentID leaves the stored one.
+func (o *orderRepository) SetPaymentDetails(ctx context.Context, id primitive.ObjectID, paymentIntentID, paymentError string) error {
+	set := bson.M{"payment_error": paymentError, "updated_at": time.Now()}
+	if paymentIntentID != "" {
+		set["payment_intent_id"] = paymentIntentID
+	}
+
+	_, err := o.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TransitionOrder moves the order from change.From to change.To and appends
+// change to its history. It returns nil, nil when the order is no longer in
+// change.From, so concurrent updates cannot both apply.
+func (o *orderRepository) TransitionOrder(ctx context.Context, id primitive.ObjectID, change domain.StatusChange) (*domain.Order, error) {
+	update := bson.M{
+		"$set":  bson.M{"status": change.To, "updated_at": change.At},
+		"$push": bson.M{"history": change},
+	}
+
+	var order domain.Order
+	err := o.collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "status": change.From}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// AddRefund appends refund to the order and adds its amount to the refunded
+// total. It returns nil, nil when the order's refunded total is no longer
+// refundedBefore, so two refunds cannot both spend the same balance.
+func (o *orderRepository) AddRefund(ctx context.Context, id primitive.ObjectID, refundedBefore int64, refund domain.Refund) (*domain.Order, error) {
+	filter := bson.M{"_id": id, "amount_refunded": refundedBefore}
+	if refundedBefore == 0 {
+		// orders from before refunds existed have no amount_refunded
+		filter["amount_refunded"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	update := bson.M{
+		"$set":  bson.M{"updated_at": time.Now()},
+		"$inc":  bson.M{"amount_refunded": refund.Amount},
+		"$push": bson.M{"refunds": refund},
+	}
+
+	var order domain.Order
+	err := o.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// UpdateRefund replaces the order's refund with the same ID. Marking a
+// refund failed also takes its amount off the refunded total; a refund that
+// has already failed is left alone, and nil, nil is returned.
+func (o *orderRepository) UpdateRefund(ctx context.Context, id primitive.ObjectID, refund domain.Refund) (*domain.Order, error) {
+	filter := bson.M{
+		"_id":     id,
+		"refunds": bson.M{"$elemMatch": bson.M{"id": refund.ID, "status": bson.M{"$ne": domain.RefundFailed}}},
+	}
+	update := bson.M{"$set": bson.M{"refunds.$": refund, "updated_at": time.Now()}}
+	if refund.Status == domain.RefundFailed {
+		update["$inc"] = bson.M{"amount_refunded": -refund.Amount}
+	}
+
+	var order domain.Order
+	err := o.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// AddShipment appends shipment to the order.
+func (o *orderRepository) AddShipment(ctx context.Context, id primitive.ObjectID, shipment domain.Shipment) (*domain.Order, error) {
+	update := bson.M{
+		"$set":  bson.M{"updated_at": time.Now()},
+		"$push": bson.M{"shipments": shipment},
+	}
+
+	var order domain.Order
+	err := o.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// UpdateShipment replaces the order's shipment with the same ID. It returns
+// nil, nil when the shipment is no longer in status from, so concurrent
+// updates cannot both apply.
+func (o *orderRepository) UpdateShipment(ctx context.Context, id primitive.ObjectID, from string, shipment domain.Shipment) (*domain.Order, error) {
+	filter := bson.M{
+		"_id":       id,
+		"shipments": bson.M{"$elemMatch": bson.M{"id": shipment.ID, "status": from}},
+	}
+	update := bson.M{"$set": bson.M{"shipments.$": shipment, "updated_at": time.Now()}}
+
+	var order domain.Order
+	err := o.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &order, nil
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PromotionRepository interface {
+	GetAllPromotions(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Promotion], error)
+	GetPromotionByID(ctx context.Context, id primitive.ObjectID) (*domain.Promotion, error)
+	GetPromotionByCode(ctx context.Context, code string) (*domain.Promotion, error)
+	GetAutomaticPromotions(ctx context.Context, now time.Time) ([]domain.Promotion, error)
+	CreatePromotion(ctx context.Context, promotion *domain.Promotion) error
+	UpdatePromotion(ctx context.Context, promotion *domain.Promotion) error
+	DeletePromotion(ctx context.Context, id primitive.ObjectID) error
+	RedeemPromotion(ctx context.Context, promotion *domain.Promotion, customerID string, orderID primitive.ObjectID) (bool, error)
+	ReleasePromotion(ctx context.Context, promotionID primitive.ObjectID, customerID string, orderID primitive.ObjectID) error
+}
+
+type promotionRepository struct {
+	collection  *mongo.Collection
+	redemptions *mongo.Collection
+}
+
+func NewPromotionRepository(db *mongo.Database) *promotionRepository {
+	return &promotionRepository{
+		collection:  db.Collection("promotions"),
+		redemptions: db.Collection("promotion_redemptions"),
+	}
+}
+
+// EnsureIndexes makes coupon codes unique. Automatic promotions have no code.
+func (p *promotionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := p.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"code": bson.M{"$type": "string"}}),
+	})
+	return err
+}
+
+func (p *promotionRepository) GetAllPromotions(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Promotion], error) {
+	var promotions []domain.Promotion
+
+	total, err := p.collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := p.collection.Find(ctx, params.Query(bson.M{}), params.FindOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &promotions)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(promotions, total, params)
+}
+
+func (p *promotionRepository) GetPromotionByID(ctx context.Context, id primitive.ObjectID) (*domain.Promotion, error) {
+	var promotion domain.Promotion
+
+	err := p.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+// GetPromotionByCode returns nil, nil when no promotion has the code.
+func (p *promotionRepository) GetPromotionByCode(ctx context.Context, code string) (*domain.Promotion, error) {
+	var promotion domain.Promotion
+
+	err := p.collection.FindOne(ctx, bson.M{"code": code}).Decode(&promotion)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+// GetAutomaticPromotions returns the active promotions without a code whose
+// validity window includes now.
+func (p *promotionRepository) GetAutomaticPromotions(ctx context.Context, now time.Time) ([]domain.Promotion, error) {
+	var promotions []domain.Promotion
+
+	filter := bson.M{
+		"active": true,
+		"code":   bson.M{"$exists": false},
+		"$and": bson.A{
+			bson.M{"$or": bson.A{bson.M{"starts_at": bson.M{"$exists": false}}, bson.M{"starts_at": bson.M{"$lte": now}}}},
+			bson.M{"$or": bson.A{bson.M{"ends_at": bson.M{"$exists": false}}, bson.M{"ends_at": bson.M{"$gt": now}}}},
+		},
+	}
+	cursor, err := p.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &promotions)
+	if err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+func (p *promotionRepository) CreatePromotion(ctx context.Context, promotion *domain.Promotion) error {
+	promotion.ID = primitive.NewObjectID()
+	promotion.Uses = 0
+	promotion.CreatedAt = time.Now()
+	promotion.UpdatedAt = promotion.CreatedAt
+
+	_, err := p.collection.InsertOne(ctx, promotion)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePromotion replaces the promotion's settings. Its use count and
+// creation time are kept. It returns mongo.ErrNoDocuments when there is no
+// such promotion.
+func (p *promotionRepository) UpdatePromotion(ctx context.Context, promotion *domain.Promotion) error {
+	promotion.UpdatedAt = time.Now()
+	set := bson.M{
+		"name":                  promotion.Name,
+		"type":                  promotion.Type,
+		"value":                 promotion.Value,
+		"scope":                 promotion.Scope,
+		"min_order_value":       promotion.MinOrderValue,
+		"max_uses":              promotion.MaxUses,
+		"max_uses_per_customer": promotion.MaxUsesPerCustomer,
+		"stackable":             promotion.Stackable,
+		"active":                promotion.Active,
+		"updated_at":            promotion.UpdatedAt,
+	}
+	unset := bson.M{}
+	for field, value := range map[string]any{"code": promotion.Code, "currency": promotion.Currency} {
+		if value == "" {
+			unset[field] = ""
+		} else {
+			set[field] = value
+		}
+	}
+	for field, value := range map[string]*time.Time{"starts_at": promotion.StartsAt, "ends_at": promotion.EndsAt} {
+		if value == nil {
+			unset[field] = ""
+		} else {
+			set[field] = value
+		}
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result, err := p.collection.UpdateOne(ctx, bson.M{"_id": promotion.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (p *promotionRepository) DeletePromotion(ctx context.Context, id primitive.ObjectID) error {
+	result, err := p.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// RedeemPromotion counts one use of the promotion by the customer for an
+// order. It returns false, changing nothing, when the promotion has reached
+// its overall limit or the customer their own. Both limits are checked in
+// the update that counts the use, so concurrent checkouts cannot exceed
+// them.
+func (p *promotionRepository) RedeemPromotion(ctx context.Context, promotion *domain.Promotion, customerID string, orderID primitive.ObjectID) (bool, error) {
+	filter := bson.M{"_id": promotion.ID, "$or": bson.A{
+		bson.M{"max_uses": 0},
+		bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$max_uses"}}},
+	}}
+	result, err := p.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"uses": 1}})
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount == 0 {
+		return false, nil
+	}
+
+	// a customer at their limit fails the filter, and the upsert then
+	// collides with their existing document
+	redemption := bson.M{"_id": redemptionID(promotion.ID, customerID)}
+	if promotion.MaxUsesPerCustomer > 0 {
+		redemption["count"] = bson.M{"$lt": promotion.MaxUsesPerCustomer}
+	}
+	update := bson.M{
+		"$inc":         bson.M{"count": 1},
+		"$push":        bson.M{"order_ids": orderID},
+		"$setOnInsert": bson.M{"promotion_id": promotion.ID, "customer_id": customerID},
+	}
+	_, err = p.redemptions.UpdateOne(ctx, redemption, update, options.Update().SetUpsert(true))
+	if err == nil {
+		return true, nil
+	}
+
+	if _, undoErr := p.collection.UpdateOne(ctx, bson.M{"_id": promotion.ID}, bson.M{"$inc": bson.M{"uses": -1}}); undoErr != nil {
+		return false, undoErr
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// ReleasePromotion gives back the use counted for an order. Releasing an
+// order that holds no use of the promotion does nothing, so it is safe to
+// repeat.
+func (p *promotionRepository) ReleasePromotion(ctx context.Context, promotionID primitive.ObjectID, customerID string, orderID primitive.ObjectID) error {
+	filter := bson.M{"_id": redemptionID(promotionID, customerID), "order_ids": orderID}
+	update := bson.M{"$inc": bson.M{"count": -1}, "$pull": bson.M{"order_ids": orderID}}
+	result, err := p.redemptions.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return nil
+	}
+
+	_, err = p.collection.UpdateOne(ctx, bson.M{"_id": promotionID}, bson.M{"$inc": bson.M{"uses": -1}})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func redemptionID(promotionID primitive.ObjectID, customerID string) string {
+	return promotionID.Hex() + ":" + customerID
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ShippingZoneRepository interface {
+	GetAllShippingZones(ctx context.Context) ([]domain.ShippingZone, error)
+	GetShippingZoneByID(ctx context.Context, id primitive.ObjectID) (*domain.ShippingZone, error)
+	CreateShippingZone(ctx context.Context, zone *domain.ShippingZone) error
+	UpdateShippingZone(ctx context.Context, zone *domain.ShippingZone) error
+	DeleteShippingZone(ctx context.Context, id primitive.ObjectID) error
+}
+
+type shippingZoneRepository struct {
+	collection *mongo.Collection
+}
+
+func NewShippingZoneRepository(db *mongo.Database) *shippingZoneRepository {
+	return &shippingZoneRepository{collection: db.Collection("shipping_zones")}
+}
+
+// GetAllShippingZones returns the zones in the order they were created.
+// There are few enough of them to match destinations in memory.
+func (s *shippingZoneRepository) GetAllShippingZones(ctx context.Context) ([]domain.ShippingZone, error) {
+	zones := []domain.ShippingZone{}
+
+	cursor, err := s.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &zones)
+	if err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+func (s *shippingZoneRepository) GetShippingZoneByID(ctx context.Context, id primitive.ObjectID) (*domain.ShippingZone, error) {
+	var zone domain.ShippingZone
+
+	err := s.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&zone)
+	if err != nil {
+		return nil, err
+	}
+
+	return &zone, nil
+}
+
+func (s *shippingZoneRepository) CreateShippingZone(ctx context.Context, zone *domain.ShippingZone) error {
+	zone.ID = primitive.NewObjectID()
+	zone.CreatedAt = time.Now()
+	zone.UpdatedAt = zone.CreatedAt
+
+	_, err := s.collection.InsertOne(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateShippingZone replaces the zone's countries and methods, keeping its
+// creation time. It returns mongo.ErrNoDocuments when there is no such
+// zone.
+func (s *shippingZoneRepository) UpdateShippingZone(ctx context.Context, zone *domain.ShippingZone) error {
+	zone.UpdatedAt = time.Now()
+	update := bson.M{"$set": bson.M{
+		"name":       zone.Name,
+		"countries":  zone.Countries,
+		"methods":    zone.Methods,
+		"updated_at": zone.UpdatedAt,
+	}}
+
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": zone.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (s *shippingZoneRepository) DeleteShippingZone(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
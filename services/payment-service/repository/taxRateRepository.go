@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TaxRateRepository interface {
+	GetAllTaxRates(ctx context.Context) ([]domain.TaxRate, error)
+	GetTaxRateByID(ctx context.Context, id primitive.ObjectID) (*domain.TaxRate, error)
+	GetTaxRatesByCountry(ctx context.Context, country string) ([]domain.TaxRate, error)
+	CreateTaxRate(ctx context.Context, rate *domain.TaxRate) error
+	UpdateTaxRate(ctx context.Context, rate *domain.TaxRate) error
+	DeleteTaxRate(ctx context.Context, id primitive.ObjectID) error
+}
+
+type taxRateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTaxRateRepository(db *mongo.Database) *taxRateRepository {
+	return &taxRateRepository{collection: db.Collection("tax_rates")}
+}
+
+// EnsureIndexes indexes rates by the country they are looked up by.
+func (t *taxRateRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := t.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "country", Value: 1}, {Key: "state", Value: 1}},
+	})
+	return err
+}
+
+// GetAllTaxRates returns the whole table ordered by country and state.
+func (t *taxRateRepository) GetAllTaxRates(ctx context.Context) ([]domain.TaxRate, error) {
+	return t.find(ctx, bson.M{})
+}
+
+func (t *taxRateRepository) GetTaxRateByID(ctx context.Context, id primitive.ObjectID) (*domain.TaxRate, error) {
+	var rate domain.TaxRate
+
+	err := t.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&rate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+// GetTaxRatesByCountry returns the rates of every state and tax class of
+// country.
+func (t *taxRateRepository) GetTaxRatesByCountry(ctx context.Context, country string) ([]domain.TaxRate, error) {
+	return t.find(ctx, bson.M{"country": country})
+}
+
+func (t *taxRateRepository) find(ctx context.Context, filter bson.M) ([]domain.TaxRate, error) {
+	rates := []domain.TaxRate{}
+
+	sort := bson.D{{Key: "country", Value: 1}, {Key: "state", Value: 1}, {Key: "_id", Value: 1}}
+	cursor, err := t.collection.Find(ctx, filter, options.Find().SetSort(sort))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &rates)
+	if err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+func (t *taxRateRepository) CreateTaxRate(ctx context.Context, rate *domain.TaxRate) error {
+	rate.ID = primitive.NewObjectID()
+	rate.CreatedAt = time.Now()
+	rate.UpdatedAt = rate.CreatedAt
+
+	_, err := t.collection.InsertOne(ctx, rate)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateTaxRate replaces the rate, keeping its creation time. It returns
+// mongo.ErrNoDocuments when there is no such rate.
+func (t *taxRateRepository) UpdateTaxRate(ctx context.Context, rate *domain.TaxRate) error {
+	rate.UpdatedAt = time.Now()
+	set := bson.M{
+		"name":       rate.Name,
+		"country":    rate.Country,
+		"tax_class":  rate.TaxClass,
+		"rate":       rate.Rate,
+		"updated_at": rate.UpdatedAt,
+	}
+	update := bson.M{"$set": set}
+	if rate.State == "" {
+		update["$unset"] = bson.M{"state": ""}
+	} else {
+		set["state"] = rate.State
+	}
+
+	result, err := t.collection.UpdateOne(ctx, bson.M{"_id": rate.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (t *taxRateRepository) DeleteTaxRate(ctx context.Context, id primitive.ObjectID) error {
+	result, err := t.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
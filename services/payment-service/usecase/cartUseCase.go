@@ -0,0 +1,386 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+)
+
+var (
+	// ErrInvalidCart is returned when a cart change names an unknown product
+	// or variant or breaks the cart's limits.
+	ErrInvalidCart = errors.New("invalid cart")
+	// ErrCartNotReady is returned when checking out an empty cart or one
+	// with lines that cannot be ordered as they are.
+	ErrCartNotReady = errors.New("cart cannot be checked out")
+)
+
+// AnonymousCartTTL is how long an anonymous cart is kept after its last
+// change.
+const AnonymousCartTTL = 30 * 24 * time.Hour
+
+type CartUseCase interface {
+	GetCart(ctx context.Context, owner domain.CartOwner) (*domain.CartView, error)
+	AddItem(ctx context.Context, owner domain.CartOwner, productID, variantID string, quantity int64) (*domain.CartView, error)
+	SetItemQuantity(ctx context.Context, owner domain.CartOwner, productID, variantID string, quantity int64) (*domain.CartView, error)
+	RemoveItem(ctx context.Context, owner domain.CartOwner, productID, variantID string) (*domain.CartView, error)
+	SetCurrency(ctx context.Context, owner domain.CartOwner, currency string) (*domain.CartView, error)
+	MergeAnonymousCart(ctx context.Context, token, customerID string) error
+	ShippingOptions(ctx context.Context, owner domain.CartOwner, address *domain.Address) ([]domain.ShippingQuote, error)
+	Checkout(ctx context.Context, customerID string, input domain.CheckoutInput) (*domain.Order, *domain.CheckoutSession, error)
+}
+
+type cartUseCase struct {
+	repo       repository.CartRepository
+	catalog    Catalog
+	orders     OrderUseCase
+	currencies domain.Currencies
+}
+
+// NewCartUseCase prices carts in any of currencies, which must be the
+// currencies orders are placed in.
+func NewCartUseCase(repo repository.CartRepository, catalog Catalog, orders OrderUseCase, currencies domain.Currencies) *cartUseCase {
+	return &cartUseCase{
+		repo:       repo,
+		catalog:    catalog,
+		orders:     orders,
+		currencies: currencies,
+	}
+}
+
+// GetCart returns the owner's cart priced against the catalogue. An owner
+// without a cart gets an empty one.
+func (c *cartUseCase) GetCart(ctx context.Context, owner domain.CartOwner) (*domain.CartView, error) {
+	cart, err := c.loadCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	return c.view(ctx, cart)
+}
+
+// AddItem adds quantity units of a product to the cart, on top of any
+// already in it.
+func (c *cartUseCase) AddItem(ctx context.Context, owner domain.CartOwner, productID, variantID string, quantity int64) (*domain.CartView, error) {
+	if quantity < 1 {
+		return nil, fmt.Errorf("%w: quantity must be at least 1", ErrInvalidCart)
+	}
+	return c.change(ctx, owner, productID, variantID, func(current int64) int64 { return current + quantity })
+}
+
+// SetItemQuantity replaces the quantity of a line. Zero removes it.
+func (c *cartUseCase) SetItemQuantity(ctx context.Context, owner domain.CartOwner, productID, variantID string, quantity int64) (*domain.CartView, error) {
+	return c.change(ctx, owner, productID, variantID, func(int64) int64 { return quantity })
+}
+
+func (c *cartUseCase) RemoveItem(ctx context.Context, owner domain.CartOwner, productID, variantID string) (*domain.CartView, error) {
+	return c.change(ctx, owner, productID, variantID, func(int64) int64 { return 0 })
+}
+
+// SetCurrency switches the currency the cart is priced and checked out in.
+func (c *cartUseCase) SetCurrency(ctx context.Context, owner domain.CartOwner, currency string) (*domain.CartView, error) {
+	code, ok := c.currencies.Resolve(currency)
+	if !ok || currency == "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, strings.ToUpper(currency))
+	}
+
+	cart, err := c.loadCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	cart.Currency = code
+	if err := c.saveCart(ctx, owner, cart, time.Now()); err != nil {
+		return nil, err
+	}
+	return c.view(ctx, cart)
+}
+
+// change sets a line to the quantity computed from its current one. Lines
+// that are added or grow are checked against the catalogue first, so a
+// cart only ever gains products that exist and are in stock.
+func (c *cartUseCase) change(ctx context.Context, owner domain.CartOwner, productID, variantID string, quantity func(current int64) int64) (*domain.CartView, error) {
+	cart, err := c.loadCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	current := int64(0)
+	if n := cart.FindItem(productID, variantID); n >= 0 {
+		current = cart.Items[n].Quantity
+	}
+	next := quantity(current)
+	if next > current {
+		if err := c.checkItem(ctx, cart, productID, variantID, next-current); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if err := cart.SetQuantity(productID, variantID, next, now); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCart, err)
+	}
+	if err := c.saveCart(ctx, owner, cart, now); err != nil {
+		return nil, err
+	}
+	return c.view(ctx, cart)
+}
+
+// checkItem makes sure the product and variant exist and that the cart's
+// units of the product, with added more, are in stock.
+func (c *cartUseCase) checkItem(ctx context.Context, cart *domain.Cart, productID, variantID string, added int64) error {
+	product, err := c.catalog.GetProduct(ctx, productID, c.currency(cart))
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return fmt.Errorf("%w: product %s not found", ErrInvalidCart, productID)
+	}
+	if _, _, err := product.Line(variantID, c.currency(cart)); err != nil {
+		return fmt.Errorf("%w: product %s: %s", ErrInvalidCart, productID, err)
+	}
+
+	wanted := added
+	for _, item := range cart.Items {
+		if item.ProductID == productID {
+			wanted += item.Quantity
+		}
+	}
+	if available := product.Available(); available >= 0 && wanted > available {
+		return fmt.Errorf("%w: only %d of %s in stock", ErrInsufficientStock, available, product.ModelName)
+	}
+	return nil
+}
+
+// MergeAnonymousCart moves the lines of the anonymous cart with token into
+// the customer's cart, for a shopper who has just signed in, and deletes
+// the anonymous cart.
+func (c *cartUseCase) MergeAnonymousCart(ctx context.Context, token, customerID string) error {
+	anonymous, err := c.repo.GetCart(ctx, domain.CartOwner{Token: token}.CartID())
+	if err != nil || anonymous == nil {
+		return err
+	}
+
+	owner := domain.CartOwner{CustomerID: customerID}
+	cart, err := c.loadCart(ctx, owner)
+	if err != nil {
+		return err
+	}
+	cart.Merge(anonymous)
+	if err := c.saveCart(ctx, owner, cart, time.Now()); err != nil {
+		return err
+	}
+	return c.repo.DeleteCart(ctx, anonymous.ID)
+}
+
+// Checkout places an order for everything in the customer's cart and opens
+// its payment session. The cart must be ready: its lines are re-priced and
+// their stock re-checked first, so the shopper is told what changed rather
+// than charged for it. The ordered lines are taken out of the cart once the
+// order is placed. The addresses and coupons come from input; its items are
+// replaced by the cart's lines, and its currency is the cart's.
+func (c *cartUseCase) Checkout(ctx context.Context, customerID string, input domain.CheckoutInput) (*domain.Order, *domain.CheckoutSession, error) {
+	owner := domain.CartOwner{CustomerID: customerID}
+	cart, err := c.loadCart(ctx, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	view, err := c.view(ctx, cart)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !view.Ready {
+		return nil, nil, fmt.Errorf("%w: %s", ErrCartNotReady, cartProblems(view))
+	}
+
+	input.Currency = view.Currency
+	input.Items = checkoutItems(view)
+	order, session, err := c.orders.Checkout(ctx, customerID, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.removeOrdered(context.WithoutCancel(ctx), owner, cart, order); err != nil {
+		slog.Error(fmt.Sprintf("Failed to empty cart of customer %s after order %s: %s", customerID, order.ID.Hex(), err))
+	}
+	return order, session, nil
+}
+
+// removeOrdered takes the order's lines out of cart. The cart is deleted
+// when it is as it was checked out; lines added to it since are kept.
+func (c *cartUseCase) removeOrdered(ctx context.Context, owner domain.CartOwner, cart *domain.Cart, order *domain.Order) error {
+	deleted, err := c.repo.DeleteCartIfUnchanged(ctx, cart.ID, cart.UpdatedAt)
+	if err != nil || deleted {
+		return err
+	}
+
+	current, err := c.loadCart(ctx, owner)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, item := range order.Items {
+		n := current.FindItem(item.ProductID, item.VariantID)
+		if n < 0 {
+			continue
+		}
+		if err := current.SetQuantity(item.ProductID, item.VariantID, max(current.Items[n].Quantity-item.Quantity, 0), now); err != nil {
+			return err
+		}
+	}
+	return c.saveCart(ctx, owner, current, now)
+}
+
+// ShippingOptions returns the shipping methods that can deliver the cart to
+// address and what they cost.
+func (c *cartUseCase) ShippingOptions(ctx context.Context, owner domain.CartOwner, address *domain.Address) ([]domain.ShippingQuote, error) {
+	cart, err := c.loadCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	view, err := c.view(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+	if len(view.Items) == 0 {
+		return nil, fmt.Errorf("%w: the cart is empty", ErrCartNotReady)
+	}
+	return c.orders.ShippingOptions(ctx, owner.CustomerID, domain.CheckoutInput{Items: checkoutItems(view), Currency: view.Currency, ShippingAddress: address})
+}
+
+// checkoutItems lists the cart's lines at the prices the shopper was shown,
+// so checkout fails rather than charging a price that changed since.
+func checkoutItems(view *domain.CartView) []domain.CheckoutItem {
+	items := make([]domain.CheckoutItem, len(view.Items))
+	for n, line := range view.Items {
+		price := line.UnitPrice
+		items[n] = domain.CheckoutItem{ProductID: line.ProductID, VariantID: line.VariantID, Quantity: line.Quantity, Price: &price}
+	}
+	return items
+}
+
+// currency returns the currency the cart is priced in: the shopper's choice
+// while orders can still be placed in it, the default otherwise.
+func (c *cartUseCase) currency(cart *domain.Cart) string {
+	if code, ok := c.currencies.Resolve(cart.Currency); ok {
+		return code
+	}
+	return c.currencies.Default()
+}
+
+// view prices the cart's lines and checks their stock, looking each
+// product up once.
+func (c *cartUseCase) view(ctx context.Context, cart *domain.Cart) (*domain.CartView, error) {
+	view := &domain.CartView{Items: []domain.CartLine{}, Currency: c.currency(cart), UpdatedAt: cart.UpdatedAt}
+
+	products := map[string]*domain.CatalogProduct{}
+	wanted := map[string]int64{}
+	for _, item := range cart.Items {
+		if _, ok := products[item.ProductID]; !ok {
+			product, err := c.catalog.GetProduct(ctx, item.ProductID, view.Currency)
+			if err != nil {
+				return nil, err
+			}
+			products[item.ProductID] = product
+		}
+		wanted[item.ProductID] += item.Quantity
+	}
+
+	for _, item := range cart.Items {
+		line := domain.CartLine{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity, Available: -1}
+		view.ItemCount += item.Quantity
+
+		product := products[item.ProductID]
+		if product == nil {
+			line.Problem = "no longer available"
+			view.Items = append(view.Items, line)
+			continue
+		}
+
+		name, price, err := product.Line(item.VariantID, view.Currency)
+		if err == nil {
+			line.Name, line.UnitPrice = name, price
+		}
+		if err != nil {
+			line.Name = product.ModelName
+			line.Problem = err.Error()
+		}
+
+		if product.Availability != nil {
+			line.Availability = product.Availability.Status
+		}
+		line.Available = product.Available()
+		if line.Problem == "" && line.Available >= 0 && wanted[item.ProductID] > line.Available {
+			if line.Available == 0 {
+				line.Problem = "out of stock"
+			} else {
+				line.Problem = fmt.Sprintf("only %d in stock", line.Available)
+			}
+		}
+
+		if line.Problem == "" {
+			line.Total = line.UnitPrice * line.Quantity
+			view.Subtotal += line.Total
+		}
+		view.Items = append(view.Items, line)
+	}
+
+	view.Ready = len(view.Items) > 0
+	for _, line := range view.Items {
+		if line.Problem != "" {
+			view.Ready = false
+		}
+	}
+	return view, nil
+}
+
+func (c *cartUseCase) loadCart(ctx context.Context, owner domain.CartOwner) (*domain.Cart, error) {
+	if owner.Anonymous() && owner.Token == "" {
+		return &domain.Cart{ID: owner.CartID()}, nil
+	}
+	cart, err := c.repo.GetCart(ctx, owner.CartID())
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil {
+		cart = &domain.Cart{ID: owner.CartID(), CustomerID: owner.CustomerID}
+	}
+	return cart, nil
+}
+
+// saveCart stores the cart, pushing back the expiry of an anonymous one.
+func (c *cartUseCase) saveCart(ctx context.Context, owner domain.CartOwner, cart *domain.Cart, now time.Time) error {
+	if owner.Anonymous() {
+		if owner.Token == "" {
+			return errors.New("anonymous cart has no token")
+		}
+		expiresAt := now.Add(AnonymousCartTTL)
+		cart.ExpiresAt = &expiresAt
+	}
+	if cart.CreatedAt.IsZero() {
+		cart.CreatedAt = now
+	}
+	cart.UpdatedAt = now
+	return c.repo.SaveCart(ctx, cart)
+}
+
+func cartProblems(view *domain.CartView) string {
+	if len(view.Items) == 0 {
+		return "cart is empty"
+	}
+	var problems []string
+	for _, line := range view.Items {
+		if line.Problem != "" {
+			name := line.Name
+			if name == "" {
+				name = "product " + line.ProductID
+			}
+			problems = append(problems, name+": "+line.Problem)
+		}
+	}
+	return strings.Join(problems, "; ")
+}
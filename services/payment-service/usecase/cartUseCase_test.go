@@ -0,0 +1,245 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+)
+
+// memoryCarts is an in-memory CartRepository.
+type memoryCarts struct {
+	carts map[string]domain.Cart
+}
+
+func (m *memoryCarts) GetCart(ctx context.Context, id string) (*domain.Cart, error) {
+	cart, ok := m.carts[id]
+	if !ok {
+		return nil, nil
+	}
+	cart.Items = append([]domain.CartItem(nil), cart.Items...)
+	return &cart, nil
+}
+
+func (m *memoryCarts) SaveCart(ctx context.Context, cart *domain.Cart) error {
+	stored := *cart
+	stored.Items = append([]domain.CartItem(nil), cart.Items...)
+	m.carts[cart.ID] = stored
+	return nil
+}
+
+func (m *memoryCarts) DeleteCart(ctx context.Context, id string) error {
+	delete(m.carts, id)
+	return nil
+}
+
+func (m *memoryCarts) DeleteCartIfUnchanged(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	cart, ok := m.carts[id]
+	if !ok || !cart.UpdatedAt.Equal(updatedAt) {
+		return false, nil
+	}
+	delete(m.carts, id)
+	return true, nil
+}
+
+func newTestCartUseCase() (*cartUseCase, *orderUseCase, *memoryCatalog) {
+	orders, catalog := newTestUseCase()
+	return NewCartUseCase(&memoryCarts{carts: map[string]domain.Cart{}}, catalog, orders, domain.NewCurrencies("KZT", "usd")), orders, catalog
+}
+
+func TestCartChanges(t *testing.T) {
+	ctx := context.Background()
+	uc, _, catalog := newTestCartUseCase()
+	catalog.products["p1"].Availability = &domain.CatalogAvailability{Status: domain.AvailabilityLowStock, Quantity: 3}
+	shopper := domain.CartOwner{Token: "t1"}
+
+	if _, err := uc.AddItem(ctx, shopper, "p1", "", 2); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	if _, err := uc.AddItem(ctx, shopper, "p2", "v2", 1); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	view, err := uc.AddItem(ctx, shopper, "p1", "", 1)
+	if err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	if len(view.Items) != 2 || view.Items[0].Quantity != 3 || view.ItemCount != 4 {
+		t.Fatalf("cart lines = %+v, want 3 ThinkPads and a MacBook", view.Items)
+	}
+	if view.Subtotal != 3*74999999+79999050 || !view.Ready || view.Currency != "kzt" {
+		t.Errorf("subtotal %d in %s, ready %v; want %d in kzt and ready", view.Subtotal, view.Currency, view.Ready, 3*74999999+79999050)
+	}
+
+	tests := []struct {
+		name      string
+		productID string
+		variantID string
+		want      error
+	}{
+		{"unknown product", "p9", "", ErrInvalidCart},
+		{"missing variant", "p2", "", ErrInvalidCart},
+		{"more than in stock", "p1", "", ErrInsufficientStock},
+	}
+	for _, tt := range tests {
+		if _, err := uc.AddItem(ctx, shopper, tt.productID, tt.variantID, 1); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := uc.SetItemQuantity(ctx, shopper, "p1", "", 1); err != nil {
+		t.Fatalf("SetItemQuantity: %v", err)
+	}
+	view, err = uc.RemoveItem(ctx, shopper, "p2", "v2")
+	if err != nil {
+		t.Fatalf("RemoveItem: %v", err)
+	}
+	if len(view.Items) != 1 || view.Items[0].Quantity != 1 {
+		t.Errorf("cart lines = %+v, want one ThinkPad", view.Items)
+	}
+
+	// other shoppers see their own carts
+	if view, _ := uc.GetCart(ctx, domain.CartOwner{Token: "t2"}); len(view.Items) != 0 || view.Ready {
+		t.Errorf("another shopper's cart = %+v, want empty", view)
+	}
+}
+
+func TestCartRevalidatesAgainstCatalog(t *testing.T) {
+	ctx := context.Background()
+	uc, _, catalog := newTestCartUseCase()
+	shopper := domain.CartOwner{CustomerID: "alice"}
+
+	uc.AddItem(ctx, shopper, "p1", "", 2)
+	uc.AddItem(ctx, shopper, "p2", "v1", 1)
+
+	catalog.products["p1"].Price = 700000
+	catalog.products["p1"].Availability = &domain.CatalogAvailability{Status: domain.AvailabilityLowStock, Quantity: 1}
+	delete(catalog.products, "p2")
+
+	view, err := uc.GetCart(ctx, shopper)
+	if err != nil {
+		t.Fatalf("GetCart: %v", err)
+	}
+	if view.Ready || view.Items[0].UnitPrice != 70000000 || view.Items[0].Problem != "only 1 in stock" || view.Items[1].Problem != "no longer available" {
+		t.Errorf("cart = %+v, want the new price and both lines flagged", view)
+	}
+	if view.Subtotal != 0 {
+		t.Errorf("subtotal = %d, want lines with problems left out", view.Subtotal)
+	}
+
+	if _, _, err := uc.Checkout(ctx, "alice", domain.CheckoutInput{}); !errors.Is(err, ErrCartNotReady) {
+		t.Errorf("checkout: err = %v, want ErrCartNotReady", err)
+	}
+	if len(catalog.reserved) != 0 {
+		t.Errorf("%d reservations made for a cart that is not ready", len(catalog.reserved))
+	}
+}
+
+func TestCartMergeAndCheckout(t *testing.T) {
+	ctx := context.Background()
+	uc, orders, catalog := newTestCartUseCase()
+	carts := uc.repo.(*memoryCarts)
+
+	uc.AddItem(ctx, domain.CartOwner{CustomerID: "alice"}, "p1", "", 1)
+	uc.AddItem(ctx, domain.CartOwner{Token: "t1"}, "p1", "", 2)
+	uc.AddItem(ctx, domain.CartOwner{Token: "t1"}, "p2", "v1", 1)
+
+	if err := uc.MergeAnonymousCart(ctx, "t1", "alice"); err != nil {
+		t.Fatalf("MergeAnonymousCart: %v", err)
+	}
+	if _, ok := carts.carts[domain.CartOwner{Token: "t1"}.CartID()]; ok {
+		t.Error("anonymous cart kept after merge")
+	}
+
+	order, session, err := uc.Checkout(ctx, "alice", domain.CheckoutInput{})
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if session.URL == "" || len(order.Items) != 2 || order.Items[0].Quantity != 3 || order.Total != 3*74999999+59999000 {
+		t.Errorf("order = %+v, want 3 ThinkPads and a MacBook", order)
+	}
+	if stored, _ := orders.GetOrderByID(ctx, order.ID); stored.ReservationID == "" || len(catalog.reserved) != 1 {
+		t.Errorf("order stored with reservation %q, %d reservations; want one", stored.ReservationID, len(catalog.reserved))
+	}
+	if view, _ := uc.GetCart(ctx, domain.CartOwner{CustomerID: "alice"}); len(view.Items) != 0 {
+		t.Errorf("cart after checkout = %+v, want empty", view.Items)
+	}
+	if _, _, err := uc.Checkout(ctx, "alice", domain.CheckoutInput{}); !errors.Is(err, ErrCartNotReady) {
+		t.Errorf("checking out an empty cart: err = %v, want ErrCartNotReady", err)
+	}
+}
+
+func TestCartCurrencyFlowsToCheckout(t *testing.T) {
+	ctx := context.Background()
+	uc, orders, _ := newTestCartUseCase()
+	anonymous := domain.CartOwner{Token: "t1"}
+
+	if _, err := uc.SetCurrency(ctx, anonymous, "EUR"); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("EUR: err = %v, want ErrUnsupportedCurrency", err)
+	}
+	uc.AddItem(ctx, anonymous, "p1", "", 1)
+	view, err := uc.SetCurrency(ctx, anonymous, "USD")
+	if err != nil {
+		t.Fatalf("SetCurrency: %v", err)
+	}
+	// 74999999 tiyn at 0.002
+	if view.Currency != "usd" || view.Subtotal != 150000 {
+		t.Errorf("cart in %s with subtotal %d, want usd with 150000", view.Currency, view.Subtotal)
+	}
+
+	if err := uc.MergeAnonymousCart(ctx, "t1", "alice"); err != nil {
+		t.Fatalf("MergeAnonymousCart: %v", err)
+	}
+	order, _, err := uc.Checkout(ctx, "alice", domain.CheckoutInput{})
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	stored, _ := orders.GetOrderByID(ctx, order.ID)
+	if stored.Currency != "usd" || stored.Total != 150000 {
+		t.Errorf("order of %d %s, want 150000 usd", stored.Total, stored.Currency)
+	}
+}
+
+// changingCarts runs change just before the cart is deleted, as a request
+// changing the cart during checkout would.
+type changingCarts struct {
+	*memoryCarts
+	change func()
+}
+
+func (c changingCarts) DeleteCartIfUnchanged(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	c.change()
+	return c.memoryCarts.DeleteCartIfUnchanged(ctx, id, updatedAt)
+}
+
+func TestCartCheckoutKeepsLinesAddedMeanwhile(t *testing.T) {
+	ctx := context.Background()
+	orders, catalog := newTestUseCase()
+	alice := domain.CartOwner{CustomerID: "alice"}
+	var uc *cartUseCase
+	carts := changingCarts{memoryCarts: &memoryCarts{carts: map[string]domain.Cart{}}, change: func() {
+		uc.AddItem(ctx, alice, "p1", "", 1)
+		uc.AddItem(ctx, alice, "p2", "v1", 1)
+	}}
+	uc = NewCartUseCase(carts, catalog, orders, domain.NewCurrencies("kzt"))
+
+	if _, err := uc.AddItem(ctx, alice, "p1", "", 2); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	order, _, err := uc.Checkout(ctx, "alice", domain.CheckoutInput{})
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if len(order.Items) != 1 || order.Items[0].Quantity != 2 {
+		t.Fatalf("order = %+v, want the 2 ThinkPads checked out", order.Items)
+	}
+
+	view, err := uc.GetCart(ctx, alice)
+	if err != nil {
+		t.Fatalf("GetCart: %v", err)
+	}
+	if len(view.Items) != 2 || view.Items[0].ProductID != "p1" || view.Items[0].Quantity != 1 || view.Items[1].ProductID != "p2" {
+		t.Errorf("cart after checkout = %+v, want the ThinkPad and MacBook added during checkout", view.Items)
+	}
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a
+	// different request body.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+	// ErrRequestInProgress is returned when the first request with a key has
+	// not finished yet.
+	ErrRequestInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// requestLease is how long an unfinished request holds its key before a
+// retry may take it over, in case the first attempt died mid-way.
+const requestLease = 2 * time.Minute
+
+type IdempotencyUseCase interface {
+	BeginRequest(ctx context.Context, key, fingerprint string) (*domain.IdempotentRequest, error)
+	CompleteRequest(ctx context.Context, key string, status int, body []byte) error
+	AbandonRequest(ctx context.Context, key string) error
+}
+
+type idempotencyUseCase struct {
+	repo repository.IdempotencyRepository
+}
+
+func NewIdempotencyUseCase(repo repository.IdempotencyRepository) *idempotencyUseCase {
+	return &idempotencyUseCase{
+		repo: repo,
+	}
+}
+
+// BeginRequest claims key for a request. It returns nil when the caller
+// should process the request, or the finished request whose response must be
+// replayed instead.
+func (i *idempotencyUseCase) BeginRequest(ctx context.Context, key, fingerprint string) (*domain.IdempotentRequest, error) {
+	existing, claimed, err := i.repo.ClaimKey(ctx, key, fingerprint, time.Now().Add(-requestLease))
+	if err != nil {
+		return nil, err
+	}
+	if claimed {
+		return nil, nil
+	}
+	if existing == nil {
+		return nil, ErrRequestInProgress
+	}
+	if existing.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if existing.Status != domain.ClaimCompleted {
+		return nil, ErrRequestInProgress
+	}
+	return existing, nil
+}
+
+// CompleteRequest stores the response to replay for key.
+func (i *idempotencyUseCase) CompleteRequest(ctx context.Context, key string, status int, body []byte) error {
+	return i.repo.CompleteKey(ctx, key, status, body)
+}
+
+// AbandonRequest frees key after a failure that a retry might not repeat.
+func (i *idempotencyUseCase) AbandonRequest(ctx context.Context, key string) error {
+	return i.repo.ReleaseKey(ctx, key)
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+)
+
+// memoryKeys is an in-memory IdempotencyRepository.
+type memoryKeys struct {
+	requests map[string]*domain.IdempotentRequest
+}
+
+func (m *memoryKeys) ClaimKey(ctx context.Context, key, fingerprint string, staleBefore time.Time) (*domain.IdempotentRequest, bool, error) {
+	existing, ok := m.requests[key]
+	if ok && (existing.Status != domain.ClaimProcessing || !existing.CreatedAt.Before(staleBefore)) {
+		return existing, false, nil
+	}
+	m.requests[key] = &domain.IdempotentRequest{Key: key, Fingerprint: fingerprint, Status: domain.ClaimProcessing, CreatedAt: time.Now()}
+	return nil, true, nil
+}
+
+func (m *memoryKeys) CompleteKey(ctx context.Context, key string, status int, body []byte) error {
+	request := m.requests[key]
+	request.Status = domain.ClaimCompleted
+	request.ResponseStatus = status
+	request.ResponseBody = body
+	return nil
+}
+
+func (m *memoryKeys) ReleaseKey(ctx context.Context, key string) error {
+	delete(m.requests, key)
+	return nil
+}
+
+func TestIdempotentRequests(t *testing.T) {
+	ctx := context.Background()
+	keys := &memoryKeys{requests: map[string]*domain.IdempotentRequest{}}
+	uc := NewIdempotencyUseCase(keys)
+
+	if previous, err := uc.BeginRequest(ctx, "alice:k1", "body-a"); previous != nil || err != nil {
+		t.Fatalf("first request = %v, %v; want nil, nil", previous, err)
+	}
+	if _, err := uc.BeginRequest(ctx, "alice:k1", "body-a"); !errors.Is(err, ErrRequestInProgress) {
+		t.Errorf("concurrent retry: err = %v, want ErrRequestInProgress", err)
+	}
+
+	if err := uc.CompleteRequest(ctx, "alice:k1", http.StatusOK, []byte(`{"order_id":"o1"}`)); err != nil {
+		t.Fatalf("CompleteRequest: %v", err)
+	}
+	previous, err := uc.BeginRequest(ctx, "alice:k1", "body-a")
+	if err != nil || previous == nil || string(previous.ResponseBody) != `{"order_id":"o1"}` {
+		t.Errorf("retry after completion = %+v, %v; want the stored response", previous, err)
+	}
+	if _, err := uc.BeginRequest(ctx, "alice:k1", "body-b"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("different body: err = %v, want ErrIdempotencyKeyReused", err)
+	}
+
+	// an abandoned request frees its key for the next attempt
+	if _, err := uc.BeginRequest(ctx, "alice:k2", "body-a"); err != nil {
+		t.Fatalf("BeginRequest: %v", err)
+	}
+	if err := uc.AbandonRequest(ctx, "alice:k2"); err != nil {
+		t.Fatalf("AbandonRequest: %v", err)
+	}
+	if previous, err := uc.BeginRequest(ctx, "alice:k2", "body-a"); previous != nil || err != nil {
+		t.Errorf("retry after abandon = %v, %v; want nil, nil", previous, err)
+	}
+}
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInvoiceNotFound is returned when an order has no invoice or credit
+	// note with the given ID.
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	// ErrInvoiceFormat is returned when an invoice is asked for in a format
+	// it cannot be rendered in.
+	ErrInvoiceFormat = errors.New("unsupported invoice format")
+)
+
+// invoicedStatuses are the statuses of an order that was paid for, and so
+// has an invoice.
+var invoicedStatuses = map[string]bool{
+	domain.OrderPaid:      true,
+	domain.OrderFulfilled: true,
+	domain.OrderShipped:   true,
+	domain.OrderDelivered: true,
+	domain.OrderRefunded:  true,
+}
+
+// InvoiceRenderer lays invoices out as documents to download.
+type InvoiceRenderer interface {
+	RenderHTML(invoice *domain.Invoice) ([]byte, error)
+	RenderPDF(invoice *domain.Invoice) ([]byte, error)
+}
+
+type InvoiceUseCase interface {
+	IssueInvoices(ctx context.Context, order *domain.Order) ([]domain.Invoice, error)
+	RenderInvoice(invoice *domain.Invoice, format string) ([]byte, error)
+}
+
+type invoiceUseCase struct {
+	repo     repository.InvoiceRepository
+	renderer InvoiceRenderer
+	seller   domain.Seller
+}
+
+// NewInvoiceUseCase issues invoices in the name of seller.
+func NewInvoiceUseCase(repo repository.InvoiceRepository, renderer InvoiceRenderer, seller domain.Seller) *invoiceUseCase {
+	return &invoiceUseCase{
+		repo:     repo,
+		renderer: renderer,
+		seller:   seller,
+	}
+}
+
+// IssueInvoices issues the invoice of a paid order and a credit note for
+// each of its refunds that succeeded, unless they were issued already, and
+// returns all of the order's invoices. Invoices are numbered once stored,
+// so a number is only taken for an invoice that will keep it, and the
+// invoice is numbered before any credit note that refers to it.
+func (i *invoiceUseCase) IssueInvoices(ctx context.Context, order *domain.Order) ([]domain.Invoice, error) {
+	invoices, err := i.repo.GetOrderInvoices(ctx, order.ID)
+	if err != nil || !invoicedStatuses[order.Status] {
+		return invoices, err
+	}
+
+	invoice := findInvoice(invoices, "")
+	if invoice == nil || invoice.Number == "" {
+		if invoice == nil {
+			if _, err := i.repo.CreateInvoice(ctx, domain.NewInvoice(order, i.seller)); err != nil {
+				return nil, err
+			}
+		}
+		invoices, err = i.numberInvoices(ctx, order.ID)
+		if err != nil {
+			return nil, err
+		}
+		invoice = findInvoice(invoices, "")
+	}
+
+	unnumbered := false
+	for n := range order.Refunds {
+		refund := &order.Refunds[n]
+		if refund.Status != domain.RefundSucceeded || findInvoice(invoices, refund.ID) != nil {
+			continue
+		}
+		note, err := domain.NewCreditNote(order, refund, invoice.Number, i.seller)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := i.repo.CreateInvoice(ctx, note); err != nil {
+			return nil, err
+		}
+		unnumbered = true
+	}
+	for _, stored := range invoices {
+		if stored.Number == "" {
+			unnumbered = true
+		}
+	}
+	if !unnumbered {
+		return invoices, nil
+	}
+	return i.numberInvoices(ctx, order.ID)
+}
+
+// numberInvoices numbers the order's invoices that have no number yet, in
+// the order they were stored, and returns them all. A number taken for an
+// invoice numbered concurrently goes unused.
+func (i *invoiceUseCase) numberInvoices(ctx context.Context, orderID primitive.ObjectID) ([]domain.Invoice, error) {
+	invoices, err := i.repo.GetOrderInvoices(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	raced := false
+	for n := range invoices {
+		invoice := &invoices[n]
+		if invoice.Number != "" {
+			continue
+		}
+		seq, err := i.repo.NextInvoiceSequence(ctx, invoice.Kind)
+		if err != nil {
+			return nil, err
+		}
+		number, now := domain.FormatInvoiceNumber(invoice.Kind, seq), time.Now()
+		numbered, err := i.repo.NumberInvoice(ctx, invoice.ID, number, now)
+		if err != nil {
+			return nil, err
+		}
+		if !numbered {
+			slog.Warn(fmt.Sprintf("Invoice number %s skipped, invoice %s was numbered concurrently", number, invoice.ID.Hex()))
+			raced = true
+			continue
+		}
+		invoice.Number, invoice.IssuedAt = number, now
+	}
+
+	if raced {
+		return i.repo.GetOrderInvoices(ctx, orderID)
+	}
+	return invoices, nil
+}
+
+// findInvoice returns the credit note of refundID among invoices, or the
+// invoice itself for an empty refundID.
+func findInvoice(invoices []domain.Invoice, refundID string) *domain.Invoice {
+	for n := range invoices {
+		if invoices[n].RefundID == refundID {
+			return &invoices[n]
+		}
+	}
+	return nil
+}
+
+func (i *invoiceUseCase) RenderInvoice(invoice *domain.Invoice, format string) ([]byte, error) {
+	switch format {
+	case domain.InvoiceFormatHTML:
+		return i.renderer.RenderHTML(invoice)
+	case domain.InvoiceFormatPDF:
+		return i.renderer.RenderPDF(invoice)
+	}
+	return nil, fmt.Errorf("%w %q, want html or pdf", ErrInvoiceFormat, format)
+}
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryInvoices is an in-memory InvoiceRepository.
+type memoryInvoices struct {
+	repository.InvoiceRepository
+
+	invoices []domain.Invoice
+	counters map[string]int64
+}
+
+func (m *memoryInvoices) GetOrderInvoices(ctx context.Context, orderID primitive.ObjectID) ([]domain.Invoice, error) {
+	var invoices []domain.Invoice
+	for _, invoice := range m.invoices {
+		if invoice.OrderID == orderID {
+			invoices = append(invoices, invoice)
+		}
+	}
+	return invoices, nil
+}
+
+func (m *memoryInvoices) CreateInvoice(ctx context.Context, invoice *domain.Invoice) (bool, error) {
+	for _, stored := range m.invoices {
+		if stored.OrderID == invoice.OrderID && stored.RefundID == invoice.RefundID {
+			return false, nil
+		}
+	}
+	invoice.ID = primitive.NewObjectID()
+	m.invoices = append(m.invoices, *invoice)
+	return true, nil
+}
+
+func (m *memoryInvoices) NextInvoiceSequence(ctx context.Context, kind string) (int64, error) {
+	m.counters[kind]++
+	return m.counters[kind], nil
+}
+
+func (m *memoryInvoices) NumberInvoice(ctx context.Context, id primitive.ObjectID, number string, issuedAt time.Time) (bool, error) {
+	for n := range m.invoices {
+		if m.invoices[n].ID == id && m.invoices[n].Number == "" {
+			m.invoices[n].Number, m.invoices[n].IssuedAt = number, issuedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var testSeller = domain.Seller{Name: "RESTInRehab LLP", TaxID: "123456789012", Address: domain.Address{Line1: "1 Abay Ave", City: "Almaty", PostalCode: "050000", Country: "KZ"}}
+
+func newTestInvoiceUseCase() *invoiceUseCase {
+	return NewInvoiceUseCase(&memoryInvoices{counters: map[string]int64{}}, nil, testSeller)
+}
+
+func TestInvoicesFollowPaymentsAndRefunds(t *testing.T) {
+	ctx := context.Background()
+	uc := newTaxedOrderUseCase(true, testTaxRates...)
+
+	pending := placeTestOrder(t, uc, "alice")
+	if invoices, err := uc.GetOrderInvoices(ctx, pending.ID); err != nil || len(invoices) != 0 {
+		t.Errorf("pending order invoices = %+v, %v; want none", invoices, err)
+	}
+
+	order := paidTestOrder(t, uc)
+	invoices, err := uc.GetCustomerInvoices(ctx, "alice", order.ID)
+	if err != nil {
+		t.Fatalf("GetCustomerInvoices: %v", err)
+	}
+	if len(invoices) != 1 {
+		t.Fatalf("got %d invoices, want the one issued on payment", len(invoices))
+	}
+	invoice := invoices[0]
+	if invoice.Kind != domain.InvoiceKindInvoice || invoice.Number != "INV-000001" || invoice.IssuedAt.IsZero() {
+		t.Errorf("invoice %s %q issued %v, want invoice INV-000001", invoice.Kind, invoice.Number, invoice.IssuedAt)
+	}
+	if len(invoice.Lines) != 2 || invoice.Total != order.Total || invoice.Tax != order.Tax || invoice.Tax == 0 || invoice.Seller.Name != testSeller.Name {
+		t.Errorf("invoice = %+v, want the order's lines, totals and tax from %s", invoice, testSeller.Name)
+	}
+	if _, err := uc.GetCustomerInvoices(ctx, "bob", order.ID); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("another customer's order: err = %v, want ErrOrderNotFound", err)
+	}
+	if _, err := uc.GetCustomerInvoice(ctx, "alice", order.ID, primitive.NewObjectID().Hex()); !errors.Is(err, ErrInvoiceNotFound) {
+		t.Errorf("unknown invoice: err = %v, want ErrInvoiceNotFound", err)
+	}
+
+	// one laptop, then the rest; each refund is credited in turn
+	one := domain.RefundInput{Lines: []domain.RefundLine{{ProductID: "p1", Quantity: 1}}, Reason: "damaged"}
+	if _, err := uc.RefundOrder(ctx, order.ID, one, "admin"); err != nil {
+		t.Fatalf("refund one: %v", err)
+	}
+	if _, err := uc.RefundOrder(ctx, order.ID, domain.RefundInput{}, "admin"); err != nil {
+		t.Fatalf("refund the rest: %v", err)
+	}
+
+	invoices, err = uc.GetOrderInvoices(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("GetOrderInvoices: %v", err)
+	}
+	if len(invoices) != 3 {
+		t.Fatalf("got %d invoices, want the invoice and two credit notes", len(invoices))
+	}
+	var credited, creditedTax int64
+	for n, note := range invoices[1:] {
+		want := domain.FormatInvoiceNumber(domain.InvoiceKindCreditNote, int64(n+1))
+		if note.Kind != domain.InvoiceKindCreditNote || note.Number != want || note.CreditedInvoice != "INV-000001" {
+			t.Errorf("credit note %d = %s %q crediting %q, want %s crediting INV-000001", n, note.Kind, note.Number, note.CreditedInvoice, want)
+		}
+		var taxes int64
+		for _, tax := range note.Taxes {
+			taxes += tax.Amount
+		}
+		if taxes != note.Tax {
+			t.Errorf("credit note %s taxes add up to %d, want %d", note.Number, taxes, note.Tax)
+		}
+		credited += note.Total
+		creditedTax += note.Tax
+	}
+	if first := invoices[1]; first.Reason != "damaged" || len(first.Lines) != 1 || first.Lines[0].Quantity != 1 || first.Total != order.Items[0].Total/2 {
+		t.Errorf("first credit note = %+v, want one ThinkPad refunded as damaged", first)
+	}
+	if credited != order.Total || creditedTax != order.Tax {
+		t.Errorf("credited %d with %d tax, want the order's %d with %d tax", credited, creditedTax, order.Total, order.Tax)
+	}
+
+	// asking again issues nothing new
+	again, err := uc.GetOrderInvoices(ctx, order.ID)
+	if err != nil || len(again) != 3 {
+		t.Errorf("invoices asked for again = %d, %v; want the same 3", len(again), err)
+	}
+}
+
+func TestProviderRefundCreditNote(t *testing.T) {
+	ctx := context.Background()
+	uc := newTaxedOrderUseCase(true, testTaxRates...)
+	order := paidTestOrder(t, uc)
+
+	refunded := domain.PaymentEvent{Type: domain.PaymentRefunded, PaymentIntentID: order.PaymentIntentID, Amount: order.Total, AmountRefunded: 1000}
+	if err := uc.HandlePaymentEvent(ctx, refunded); err != nil {
+		t.Fatalf("refund event: %v", err)
+	}
+
+	invoices, err := uc.GetOrderInvoices(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("GetOrderInvoices: %v", err)
+	}
+	if len(invoices) != 2 {
+		t.Fatalf("got %d invoices, want the invoice and a credit note", len(invoices))
+	}
+	note := invoices[1]
+	if len(note.Lines) != 1 || note.Total != 1000 || note.Lines[0].Total != 1000 || note.Tax != order.Tax*1000/order.Total {
+		t.Errorf("credit note = %+v, want one line of 1000 carrying its share of tax", note)
+	}
+}
+
+func TestRenderInvoiceFormat(t *testing.T) {
+	uc := newTestInvoiceUseCase()
+	if _, err := uc.RenderInvoice(&domain.Invoice{}, "docx"); !errors.Is(err, ErrInvoiceFormat) {
+		t.Errorf("docx: err = %v, want ErrInvoiceFormat", err)
+	}
+}
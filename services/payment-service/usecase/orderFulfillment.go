@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrShipmentNotFound is returned when an order has no shipment with
+	// the given ID.
+	ErrShipmentNotFound = errors.New("shipment not found")
+	// ErrInvalidShipment is returned when a shipment asks for more than the
+	// order has left to ship, names lines or units the order does not have,
+	// or is shipped without carrier details.
+	ErrInvalidShipment = errors.New("invalid shipment")
+)
+
+// fulfillmentSteps is the path of a paid order to its customer. Orders move
+// along it as their shipments do.
+var fulfillmentSteps = []string{domain.OrderPaid, domain.OrderFulfilled, domain.OrderShipped, domain.OrderDelivered}
+
+// CreateShipment packs units of a paid order into a new shipment. The units
+// are sold to the order as they are picked, so a unit that is not reserved
+// for the order fails the whole shipment. The order moves to fulfilled once
+// every unit is in a shipment.
+func (o *orderUseCase) CreateShipment(ctx context.Context, id primitive.ObjectID, input domain.ShipmentInput, actor string) (*domain.Order, error) {
+	order, err := o.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !refundableStatuses[order.Status] {
+		return nil, fmt.Errorf("%w: a %s order cannot be shipped", ErrInvalidTransition, order.Status)
+	}
+
+	shipment, err := newShipment(order, input, actor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidShipment, err)
+	}
+
+	if order.ReservationID != "" {
+		err := o.catalog.ShipProducts(ctx, order.CustomerID, order.ReservationID, order.ID.Hex(), shipment)
+		if errors.Is(err, ErrInsufficientStock) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidShipment, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the units are sold, so the shipment must be recorded
+	ctx = context.WithoutCancel(ctx)
+	updated, err := o.repo.AddShipment(ctx, order.ID, *shipment)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to record shipment %s of order %s after selling its units %v: %s", shipment.ID, order.ID.Hex(), shipment.Lines, err))
+		return nil, err
+	}
+	if updated == nil {
+		return nil, ErrOrderNotFound
+	}
+	return o.advanceFulfillment(ctx, updated, actor)
+}
+
+// newShipment takes the shipment's lines from the order. Without input
+// lines it holds every unit still to ship.
+func newShipment(order *domain.Order, input domain.ShipmentInput, actor string) (*domain.Shipment, error) {
+	now := time.Now()
+	shipment := &domain.Shipment{
+		ID:             primitive.NewObjectID().Hex(),
+		Status:         domain.ShipmentPacking,
+		Carrier:        input.Carrier,
+		TrackingNumber: input.TrackingNumber,
+		TrackingURL:    input.TrackingURL,
+		Actor:          actor,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if len(input.Lines) == 0 {
+		shipment.Lines = order.Unshipped()
+		if len(shipment.Lines) == 0 {
+			return nil, errors.New("order has nothing left to ship")
+		}
+		return shipment, nil
+	}
+
+	requested := map[*domain.OrderItem]int64{}
+	picked := map[string]bool{}
+	for _, line := range input.Lines {
+		item, err := order.FindItem(line.ProductID, line.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		if line.Quantity < 1 {
+			return nil, errors.New("shipment quantity must be at least 1")
+		}
+		if len(line.SerialNumbers) > 0 && int64(len(line.SerialNumbers)) != line.Quantity {
+			return nil, fmt.Errorf("%d serial numbers given for %d units of %s", len(line.SerialNumbers), line.Quantity, item.Name)
+		}
+		for _, serial := range line.SerialNumbers {
+			if picked[serial] {
+				return nil, fmt.Errorf("serial number %s is listed twice", serial)
+			}
+			picked[serial] = true
+		}
+		requested[item] += line.Quantity
+		if left := order.UnshippedQuantity(item); requested[item] > left {
+			return nil, fmt.Errorf("only %d of %s are still to ship", left, item.Name)
+		}
+		shipment.Lines = append(shipment.Lines, line)
+	}
+	return shipment, nil
+}
+
+// UpdateShipment records carrier details on a shipment and moves it to the
+// status asked for. Once all of an order's shipments have left it is
+// shipped, and once they have all arrived it is delivered.
+func (o *orderUseCase) UpdateShipment(ctx context.Context, id primitive.ObjectID, shipmentID string, update domain.ShipmentUpdate, actor string) (*domain.Order, error) {
+	order, err := o.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	stored := order.FindShipment(shipmentID)
+	if stored == nil {
+		return nil, ErrShipmentNotFound
+	}
+
+	shipment := *stored
+	if update.Carrier != "" {
+		shipment.Carrier = update.Carrier
+	}
+	if update.TrackingNumber != "" {
+		shipment.TrackingNumber = update.TrackingNumber
+	}
+	if update.TrackingURL != "" {
+		shipment.TrackingURL = update.TrackingURL
+	}
+
+	now := time.Now()
+	if update.Status != "" && update.Status != shipment.Status {
+		if !domain.CanTransitionShipment(shipment.Status, update.Status) {
+			return nil, fmt.Errorf("%w: shipment %s -> %s", ErrInvalidTransition, shipment.Status, update.Status)
+		}
+		shipment.Status = update.Status
+		switch update.Status {
+		case domain.ShipmentShipped:
+			if shipment.Carrier == "" || shipment.TrackingNumber == "" {
+				return nil, fmt.Errorf("%w: a carrier and tracking number are required to ship", ErrInvalidShipment)
+			}
+			shipment.ShippedAt = &now
+		case domain.ShipmentDelivered:
+			shipment.DeliveredAt = &now
+		}
+	}
+	shipment.UpdatedAt = now
+
+	updated, err := o.repo.UpdateShipment(ctx, order.ID, stored.Status, shipment)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, fmt.Errorf("%w: shipment changed concurrently", ErrInvalidTransition)
+	}
+	return o.advanceFulfillment(ctx, updated, actor)
+}
+
+// GetCustomerTracking returns the shipments of a customer's own order and
+// what is still to ship.
+func (o *orderUseCase) GetCustomerTracking(ctx context.Context, customerID string, id primitive.ObjectID) (*domain.OrderTracking, error) {
+	order, err := o.GetCustomerOrder(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tracking := &domain.OrderTracking{
+		OrderID:   order.ID.Hex(),
+		Status:    order.Status,
+		Shipments: order.Shipments,
+		Unshipped: []domain.ShipmentLine{},
+	}
+	if tracking.Shipments == nil {
+		tracking.Shipments = []domain.Shipment{}
+	}
+	if refundableStatuses[order.Status] {
+		tracking.Unshipped = order.Unshipped()
+	}
+	return tracking, nil
+}
+
+// advanceFulfillment moves the order along fulfillmentSteps to the status
+// its shipments have reached. Orders already past it or off the path, such
+// as refunded ones, are left alone.
+func (o *orderUseCase) advanceFulfillment(ctx context.Context, order *domain.Order, actor string) (*domain.Order, error) {
+	target := slices.Index(fulfillmentSteps, order.FulfillmentStatus())
+	for step := slices.Index(fulfillmentSteps, order.Status); step >= 0 && step < target; step++ {
+		next, err := o.transition(ctx, order, fulfillmentSteps[step+1], actor, "shipments updated")
+		if errors.Is(err, ErrInvalidTransition) {
+			// moved concurrently, by another shipment or an admin
+			return o.GetOrderByID(ctx, order.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		order = next
+	}
+	return order, nil
+}
@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (m *memoryOrders) AddShipment(ctx context.Context, id primitive.ObjectID, shipment domain.Shipment) (*domain.Order, error) {
+	order, ok := m.orders[id]
+	if !ok {
+		return nil, nil
+	}
+	order.Shipments = append(slices.Clone(order.Shipments), shipment)
+	copied := *order
+	return &copied, nil
+}
+
+func (m *memoryOrders) UpdateShipment(ctx context.Context, id primitive.ObjectID, from string, shipment domain.Shipment) (*domain.Order, error) {
+	order := m.orders[id]
+	for n, stored := range order.Shipments {
+		if stored.ID != shipment.ID || stored.Status != from {
+			continue
+		}
+		order.Shipments = slices.Clone(order.Shipments)
+		order.Shipments[n] = shipment
+		copied := *order
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func TestShipmentsFulfillOrder(t *testing.T) {
+	ctx := context.Background()
+	uc, catalog := newTestUseCase()
+	order := paidTestOrder(t, uc)
+
+	first := domain.ShipmentInput{Lines: []domain.ShipmentLine{{ProductID: "p1", Quantity: 1}}}
+	shipped, err := uc.CreateShipment(ctx, order.ID, first, "admin")
+	if err != nil {
+		t.Fatalf("first shipment: %v", err)
+	}
+	if shipped.Status != domain.OrderPaid || len(shipped.Shipments) != 1 {
+		t.Errorf("after partial shipment: status %s with %d shipments, want paid with 1", shipped.Status, len(shipped.Shipments))
+	}
+	if serials := shipped.Shipments[0].Lines[0].SerialNumbers; len(serials) != 1 {
+		t.Errorf("picked serials = %v, want one", serials)
+	}
+
+	for name, input := range map[string]domain.ShipmentInput{
+		"too many":        {Lines: []domain.ShipmentLine{{ProductID: "p1", Quantity: 2}}},
+		"unknown line":    {Lines: []domain.ShipmentLine{{ProductID: "p2", VariantID: "v2", Quantity: 1}}},
+		"serial mismatch": {Lines: []domain.ShipmentLine{{ProductID: "p1", Quantity: 1, SerialNumbers: []string{"a", "b"}}}},
+	} {
+		if _, err := uc.CreateShipment(ctx, order.ID, input, "admin"); !errors.Is(err, ErrInvalidShipment) {
+			t.Errorf("%s: err = %v, want ErrInvalidShipment", name, err)
+		}
+	}
+
+	// without lines the rest of the order is shipped
+	fulfilled, err := uc.CreateShipment(ctx, order.ID, domain.ShipmentInput{}, "admin")
+	if err != nil {
+		t.Fatalf("second shipment: %v", err)
+	}
+	if fulfilled.Status != domain.OrderFulfilled || len(fulfilled.Unshipped()) != 0 {
+		t.Errorf("after shipping the rest: status %s, unshipped %+v; want fulfilled with nothing left", fulfilled.Status, fulfilled.Unshipped())
+	}
+	if got := catalog.shipped[order.ReservationID]; len(got) != 3 {
+		t.Errorf("shipped lines = %+v, want 3", got)
+	}
+	if _, err := uc.CreateShipment(ctx, order.ID, domain.ShipmentInput{}, "admin"); !errors.Is(err, ErrInvalidShipment) {
+		t.Errorf("nothing left: err = %v, want ErrInvalidShipment", err)
+	}
+
+	ids := []string{fulfilled.Shipments[0].ID, fulfilled.Shipments[1].ID}
+	if _, err := uc.UpdateShipment(ctx, order.ID, ids[0], domain.ShipmentUpdate{Status: domain.ShipmentShipped}, "admin"); !errors.Is(err, ErrInvalidShipment) {
+		t.Errorf("ship without carrier: err = %v, want ErrInvalidShipment", err)
+	}
+	if _, err := uc.UpdateShipment(ctx, order.ID, "missing", domain.ShipmentUpdate{}, "admin"); !errors.Is(err, ErrShipmentNotFound) {
+		t.Errorf("unknown shipment: err = %v, want ErrShipmentNotFound", err)
+	}
+
+	for _, id := range ids {
+		update := domain.ShipmentUpdate{Status: domain.ShipmentShipped, Carrier: "DHL", TrackingNumber: "TN-" + id}
+		if order, err = uc.UpdateShipment(ctx, order.ID, id, update, "admin"); err != nil {
+			t.Fatalf("ship %s: %v", id, err)
+		}
+	}
+	if order.Status != domain.OrderShipped || order.Shipments[0].ShippedAt == nil {
+		t.Errorf("after both left: status %s, want shipped", order.Status)
+	}
+
+	order, err = uc.UpdateShipment(ctx, order.ID, ids[0], domain.ShipmentUpdate{Status: domain.ShipmentDelivered}, "admin")
+	if err != nil {
+		t.Fatalf("deliver first: %v", err)
+	}
+	if order.Status != domain.OrderShipped {
+		t.Errorf("one of two delivered: status %s, want shipped", order.Status)
+	}
+	if _, err := uc.UpdateShipment(ctx, order.ID, ids[0], domain.ShipmentUpdate{Status: domain.ShipmentShipped}, "admin"); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("delivered back to shipped: err = %v, want ErrInvalidTransition", err)
+	}
+	order, err = uc.UpdateShipment(ctx, order.ID, ids[1], domain.ShipmentUpdate{Status: domain.ShipmentDelivered}, "admin")
+	if err != nil {
+		t.Fatalf("deliver second: %v", err)
+	}
+	if order.Status != domain.OrderDelivered {
+		t.Errorf("all delivered: status %s, want delivered", order.Status)
+	}
+
+	tracking, err := uc.GetCustomerTracking(ctx, "alice", order.ID)
+	if err != nil {
+		t.Fatalf("GetCustomerTracking: %v", err)
+	}
+	if len(tracking.Shipments) != 2 || len(tracking.Unshipped) != 0 {
+		t.Errorf("tracking = %+v, want two shipments and nothing unshipped", tracking)
+	}
+	if _, err := uc.GetCustomerTracking(ctx, "bob", order.ID); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("another customer's order: err = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestRefundReleasesUnshippedUnits(t *testing.T) {
+	ctx := context.Background()
+	uc, _ := newTestUseCase()
+	order := paidTestOrder(t, uc)
+
+	first := domain.ShipmentInput{Lines: []domain.ShipmentLine{{ProductID: "p1", Quantity: 1}}}
+	if _, err := uc.CreateShipment(ctx, order.ID, first, "admin"); err != nil {
+		t.Fatalf("CreateShipment: %v", err)
+	}
+
+	// the unit still in the warehouse is released rather than returned
+	one := domain.RefundInput{Lines: []domain.RefundLine{{ProductID: "p1", Quantity: 1}}}
+	refunded, err := uc.RefundOrder(ctx, order.ID, one, "admin")
+	if err != nil {
+		t.Fatalf("RefundOrder: %v", err)
+	}
+	if line := refunded.Refunds[0].Lines[0]; line.Released != 1 {
+		t.Errorf("refund line = %+v, want one released", line)
+	}
+
+	// the MacBook is all that is left to ship, so shipping it fulfills the order
+	fulfilled, err := uc.CreateShipment(ctx, order.ID, domain.ShipmentInput{}, "admin")
+	if err != nil {
+		t.Fatalf("ship the rest: %v", err)
+	}
+	if lines := fulfilled.Shipments[1].Lines; len(lines) != 1 || lines[0].ProductID != "p2" {
+		t.Errorf("second shipment = %+v, want only the MacBook", lines)
+	}
+	if fulfilled.Status != domain.OrderFulfilled {
+		t.Errorf("status %s, want fulfilled", fulfilled.Status)
+	}
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// issueInvoices issues the invoice and credit notes the order is due. The
+// payment or refund has gone through either way, so a failure is logged;
+// whatever is missing is issued when the order's invoices are next asked
+// for.
+func (o *orderUseCase) issueInvoices(ctx context.Context, order *domain.Order) {
+	if _, err := o.invoices.IssueInvoices(ctx, order); err != nil {
+		slog.Error(fmt.Sprintf("Failed to issue invoices of order %s: %s", order.ID.Hex(), err))
+	}
+}
+
+// GetOrderInvoices returns the invoice and credit notes of an order,
+// issuing any it is due that are missing, such as those of orders paid
+// before invoicing began.
+func (o *orderUseCase) GetOrderInvoices(ctx context.Context, id primitive.ObjectID) ([]domain.Invoice, error) {
+	order, err := o.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return o.invoices.IssueInvoices(ctx, order)
+}
+
+// GetCustomerInvoices returns the invoices of a customer's own order.
+func (o *orderUseCase) GetCustomerInvoices(ctx context.Context, customerID string, id primitive.ObjectID) ([]domain.Invoice, error) {
+	order, err := o.GetCustomerOrder(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	return o.invoices.IssueInvoices(ctx, order)
+}
+
+func (o *orderUseCase) GetOrderInvoice(ctx context.Context, id primitive.ObjectID, invoiceID string) (*domain.Invoice, error) {
+	invoices, err := o.GetOrderInvoices(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return invoiceByID(invoices, invoiceID)
+}
+
+func (o *orderUseCase) GetCustomerInvoice(ctx context.Context, customerID string, id primitive.ObjectID, invoiceID string) (*domain.Invoice, error) {
+	invoices, err := o.GetCustomerInvoices(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	return invoiceByID(invoices, invoiceID)
+}
+
+func invoiceByID(invoices []domain.Invoice, id string) (*domain.Invoice, error) {
+	for n := range invoices {
+		if invoices[n].ID.Hex() == id {
+			return &invoices[n], nil
+		}
+	}
+	return nil, ErrInvoiceNotFound
+}
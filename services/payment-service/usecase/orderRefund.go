@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidRefund is returned when a refund asks for more than the order
+// has left to refund or names lines the order does not have.
+var ErrInvalidRefund = errors.New("invalid refund")
+
+// refundableStatuses are the statuses of an order that has been paid for.
+var refundableStatuses = map[string]bool{
+	domain.OrderPaid:      true,
+	domain.OrderFulfilled: true,
+	domain.OrderShipped:   true,
+	domain.OrderDelivered: true,
+}
+
+// RefundOrder refunds part or all of a paid order through the payment
+// provider. The refund is recorded as pending before the provider is called,
+// which reserves its amount against concurrent refunds; it is marked failed
+// again if the provider refuses it. The order moves to refunded once its
+// whole total has been refunded.
+func (o *orderUseCase) RefundOrder(ctx context.Context, id primitive.ObjectID, input domain.RefundInput, actor string) (*domain.Order, error) {
+	order, err := o.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !refundableStatuses[order.Status] || order.PaymentIntentID == "" {
+		return nil, fmt.Errorf("%w: a %s order has no payment to refund", ErrInvalidTransition, order.Status)
+	}
+
+	refund, err := newRefund(order, input, actor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRefund, err)
+	}
+
+	updated, err := o.repo.AddRefund(ctx, order.ID, order.AmountRefunded, *refund)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, fmt.Errorf("%w: order was refunded concurrently, retry", ErrInvalidTransition)
+	}
+
+	result, err := o.provider.Refund(ctx, domain.RefundRequest{
+		PaymentIntentID: order.PaymentIntentID,
+		Amount:          refund.Amount,
+		Reason:          refund.Reason,
+		IdempotencyKey:  "refund-" + refund.ID,
+	})
+	// the provider may have moved money, so the outcome must be recorded
+	ctx = context.WithoutCancel(ctx)
+	if err != nil {
+		refund.Status = domain.RefundFailed
+		refund.UpdatedAt = time.Now()
+		if _, updateErr := o.repo.UpdateRefund(ctx, order.ID, *refund); updateErr != nil {
+			slog.Error(fmt.Sprintf("Failed to mark refund %s of order %s failed: %s", refund.ID, order.ID.Hex(), updateErr))
+		}
+		return nil, fmt.Errorf("%w: %s", ErrPaymentProvider, err)
+	}
+
+	refund.ProviderRefundID = result.ID
+	switch result.Status {
+	case "succeeded":
+		refund.Status = domain.RefundSucceeded
+	case "failed", "canceled":
+		refund.Status = domain.RefundFailed
+	default:
+		// the provider's webhook may already have confirmed it
+		if current, err := o.GetOrderByID(ctx, id); err == nil {
+			for _, stored := range current.Refunds {
+				if stored.ID == refund.ID {
+					refund.Status = stored.Status
+				}
+			}
+		}
+	}
+	if refund.Status != domain.RefundFailed {
+		o.restock(ctx, order, refund, input.Restock)
+	}
+	refund.UpdatedAt = time.Now()
+	if _, err := o.repo.UpdateRefund(ctx, order.ID, *refund); err != nil {
+		return nil, err
+	}
+
+	order, err = o.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	order, err = o.completeRefund(ctx, order, actor)
+	if err != nil {
+		return nil, err
+	}
+	o.issueInvoices(ctx, order)
+	// refunding the units still to ship may leave the rest fulfilled
+	return o.advanceFulfillment(ctx, order, actor)
+}
+
+// newRefund prices a refund from the order's lines. Without input lines it
+// covers whatever has not been refunded yet. Units still to ship are
+// refunded before shipped ones, unless the line names serial numbers, which
+// are those of shipped units.
+func newRefund(order *domain.Order, input domain.RefundInput, actor string) (*domain.Refund, error) {
+	remaining := order.Total - order.AmountRefunded
+	if remaining <= 0 {
+		return nil, errors.New("order is already fully refunded")
+	}
+
+	refund := &domain.Refund{
+		ID:        primitive.NewObjectID().Hex(),
+		Reason:    input.Reason,
+		Status:    domain.RefundPending,
+		Actor:     actor,
+		CreatedAt: time.Now(),
+	}
+	refund.UpdatedAt = refund.CreatedAt
+
+	if len(input.Lines) == 0 {
+		for _, item := range order.Items {
+			refunded := order.RefundedQuantity(item.ProductID, item.VariantID)
+			if left := item.Quantity - refunded; left > 0 {
+				refund.Lines = append(refund.Lines, domain.RefundLine{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: left, Amount: item.RefundAmount(refunded, left), Released: order.UnshippedQuantity(&item)})
+			}
+		}
+		refund.Amount = remaining
+		return refund, nil
+	}
+
+	requested := map[*domain.OrderItem]int64{}
+	released := map[*domain.OrderItem]int64{}
+	for _, line := range input.Lines {
+		item, err := order.FindItem(line.ProductID, line.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		if line.Quantity < 1 {
+			return nil, errors.New("refund quantity must be at least 1")
+		}
+		if len(line.SerialNumbers) > 0 && int64(len(line.SerialNumbers)) != line.Quantity {
+			return nil, fmt.Errorf("%d serial numbers given for %d units of %s", len(line.SerialNumbers), line.Quantity, item.Name)
+		}
+		refunded := order.RefundedQuantity(item.ProductID, item.VariantID) + requested[item]
+		requested[item] += line.Quantity
+		if left := item.Quantity - order.RefundedQuantity(item.ProductID, item.VariantID); requested[item] > left {
+			return nil, fmt.Errorf("only %d of %s can still be refunded", left, item.Name)
+		}
+
+		line.Released = 0
+		if len(line.SerialNumbers) == 0 {
+			line.Released = min(line.Quantity, order.UnshippedQuantity(item)-released[item])
+			released[item] += line.Released
+		}
+
+		// units are worth their share of the line after discounts
+		line.Amount = item.RefundAmount(refunded, line.Quantity)
+		refund.Lines = append(refund.Lines, line)
+		refund.Amount += line.Amount
+	}
+	if refund.Amount > remaining {
+		refund.Amount = remaining
+	}
+	return refund, nil
+}
+
+// restock releases the refunded units that had not shipped back to stock
+// and, when returned is set, takes those that had back into inventory as
+// returned. The money has already been returned, so a failure is logged and
+// left for an admin to fix.
+func (o *orderUseCase) restock(ctx context.Context, order *domain.Order, refund *domain.Refund, returned bool) {
+	if order.ReservationID == "" || len(refund.Lines) == 0 {
+		return
+	}
+
+	var release []domain.OrderItem
+	var shipped []domain.RefundLine
+	for _, line := range refund.Lines {
+		if line.Released > 0 {
+			release = append(release, domain.OrderItem{ProductID: line.ProductID, VariantID: line.VariantID, Quantity: line.Released})
+		}
+		if line.Quantity > line.Released {
+			line.Quantity -= line.Released
+			shipped = append(shipped, line)
+		}
+	}
+
+	if len(release) > 0 {
+		if err := o.catalog.CancelReservation(ctx, order.CustomerID, order.ReservationID, release); err != nil {
+			slog.Error(fmt.Sprintf("Failed to release refunded units of order %s: %s", order.ID.Hex(), err))
+		}
+	}
+	if !returned {
+		return
+	}
+	if len(shipped) > 0 {
+		if err := o.catalog.ReturnProducts(ctx, order.CustomerID, order.ReservationID, shipped); err != nil {
+			slog.Error(fmt.Sprintf("Failed to return refunded units of order %s: %s", order.ID.Hex(), err))
+			return
+		}
+	}
+	refund.Restocked = true
+}
+
+// completeRefund moves a fully refunded order to refunded. The transition
+// may already have been made by the provider's refund webhook.
+func (o *orderUseCase) completeRefund(ctx context.Context, order *domain.Order, actor string) (*domain.Order, error) {
+	if order.AmountRefunded < order.Total || order.Status == domain.OrderRefunded {
+		return order, nil
+	}
+	updated, err := o.transition(ctx, order, domain.OrderRefunded, actor, "fully refunded")
+	if errors.Is(err, ErrInvalidTransition) {
+		return o.GetOrderByID(ctx, order.ID)
+	}
+	return updated, err
+}
+
+// applyProviderRefund handles the provider's report of the total refunded on
+// an order's payment. Pending refunds the total covers are confirmed, and any
+// amount beyond the order's own refunds, such as a refund made in the
+// provider's dashboard, is recorded as a refund by the provider.
+func (o *orderUseCase) applyProviderRefund(ctx context.Context, order *domain.Order, event domain.PaymentEvent) error {
+	if event.AmountRefunded >= order.AmountRefunded {
+		for _, refund := range order.Refunds {
+			if refund.Status != domain.RefundPending {
+				continue
+			}
+			refund.Status = domain.RefundSucceeded
+			refund.UpdatedAt = time.Now()
+			if _, err := o.repo.UpdateRefund(ctx, order.ID, refund); err != nil {
+				return err
+			}
+		}
+	}
+
+	if event.AmountRefunded > order.AmountRefunded {
+		now := time.Now()
+		external := domain.Refund{
+			ID:        primitive.NewObjectID().Hex(),
+			Amount:    event.AmountRefunded - order.AmountRefunded,
+			Reason:    "refunded at payment provider",
+			Status:    domain.RefundSucceeded,
+			Actor:     PaymentActor,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		updated, err := o.repo.AddRefund(ctx, order.ID, order.AmountRefunded, external)
+		if err != nil {
+			return err
+		}
+		if updated == nil {
+			// not ErrInvalidTransition: the event must be retried
+			return fmt.Errorf("order %s was refunded concurrently", order.ID.Hex())
+		}
+		order = updated
+	}
+
+	if order.AmountRefunded < order.Total {
+		slog.Warn(fmt.Sprintf("Order %s partially refunded (%d of %d), status unchanged", order.ID.Hex(), order.AmountRefunded, order.Total))
+	} else if _, err := o.completeRefund(ctx, order, PaymentActor); err != nil {
+		return err
+	}
+	// the refunds confirmed or recorded above are due their credit notes
+	if refunded, err := o.GetOrderByID(ctx, order.ID); err == nil {
+		o.issueInvoices(ctx, refunded)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (m *memoryOrders) AddRefund(ctx context.Context, id primitive.ObjectID, refundedBefore int64, refund domain.Refund) (*domain.Order, error) {
+	order, ok := m.orders[id]
+	if !ok || order.AmountRefunded != refundedBefore {
+		return nil, nil
+	}
+	order.Refunds = append(slices.Clone(order.Refunds), refund)
+	order.AmountRefunded += refund.Amount
+	copied := *order
+	return &copied, nil
+}
+
+func (m *memoryOrders) UpdateRefund(ctx context.Context, id primitive.ObjectID, refund domain.Refund) (*domain.Order, error) {
+	order := m.orders[id]
+	for n, stored := range order.Refunds {
+		if stored.ID != refund.ID || stored.Status == domain.RefundFailed {
+			continue
+		}
+		order.Refunds = slices.Clone(order.Refunds)
+		order.Refunds[n] = refund
+		if refund.Status == domain.RefundFailed {
+			order.AmountRefunded -= refund.Amount
+		}
+		copied := *order
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *memoryCatalog) ReturnProducts(ctx context.Context, customerID, reservationID string, lines []domain.RefundLine) error {
+	m.returned[reservationID] = append(m.returned[reservationID], lines...)
+	return nil
+}
+
+func (m *memoryPayments) Refund(ctx context.Context, req domain.RefundRequest) (*domain.RefundResult, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.refunds = append(m.refunds, req)
+	return &domain.RefundResult{ID: "re_" + req.IdempotencyKey, Status: "succeeded"}, nil
+}
+
+// paidTestOrder places an order for two ThinkPads and a MacBook and pays
+// for it.
+func paidTestOrder(t *testing.T, uc *orderUseCase) *domain.Order {
+	t.Helper()
+	ctx := context.Background()
+	items := []domain.CheckoutItem{{ProductID: "p1", Quantity: 2}, {ProductID: "p2", VariantID: "v1", Quantity: 1}}
+	order, err := uc.PlaceOrder(ctx, "alice", domain.CheckoutInput{Items: items})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	paid := domain.PaymentEvent{Type: domain.PaymentCheckoutCompleted, OrderID: order.ID.Hex(), PaymentIntentID: "pi_" + order.ID.Hex(), Paid: true, Amount: order.Total, Currency: order.Currency}
+	if err := uc.HandlePaymentEvent(ctx, paid); err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	order, _ = uc.GetOrderByID(ctx, order.ID)
+	return order
+}
+
+func TestRefundOrderByLine(t *testing.T) {
+	ctx := context.Background()
+	uc, catalog := newTestUseCase()
+	provider := uc.provider.(*memoryPayments)
+	order := paidTestOrder(t, uc)
+
+	one := domain.RefundInput{Lines: []domain.RefundLine{{ProductID: "p1", Quantity: 1, SerialNumbers: []string{"SN-1"}}}, Reason: "damaged", Restock: true}
+	refunded, err := uc.RefundOrder(ctx, order.ID, one, "admin")
+	if err != nil {
+		t.Fatalf("RefundOrder: %v", err)
+	}
+	if refunded.AmountRefunded != 74999999 || refunded.Status != domain.OrderPaid {
+		t.Errorf("after one laptop: refunded %d, status %s; want 74999999, paid", refunded.AmountRefunded, refunded.Status)
+	}
+	refund := refunded.Refunds[0]
+	if refund.Status != domain.RefundSucceeded || refund.ProviderRefundID == "" || !refund.Restocked {
+		t.Errorf("refund = %+v, want succeeded and restocked", refund)
+	}
+	if got := catalog.returned[order.ReservationID]; len(got) != 1 || got[0].SerialNumbers[0] != "SN-1" {
+		t.Errorf("returned units = %+v, want SN-1", got)
+	}
+	if len(provider.refunds) != 1 || provider.refunds[0].PaymentIntentID != order.PaymentIntentID || provider.refunds[0].Amount != 74999999 {
+		t.Errorf("provider refunds = %+v", provider.refunds)
+	}
+
+	for name, input := range map[string]domain.RefundInput{
+		"too many":        {Lines: []domain.RefundLine{{ProductID: "p1", Quantity: 2}}},
+		"repeated line":   {Lines: []domain.RefundLine{{ProductID: "p1", Quantity: 1}, {ProductID: "p1", Quantity: 1}}},
+		"unknown line":    {Lines: []domain.RefundLine{{ProductID: "p2", VariantID: "v2", Quantity: 1}}},
+		"serial mismatch": {Lines: []domain.RefundLine{{ProductID: "p1", Quantity: 1, SerialNumbers: []string{"a", "b"}}}},
+	} {
+		if _, err := uc.RefundOrder(ctx, order.ID, input, "admin"); !errors.Is(err, ErrInvalidRefund) {
+			t.Errorf("%s: err = %v, want ErrInvalidRefund", name, err)
+		}
+	}
+
+	// without lines the rest of the order is refunded
+	refunded, err = uc.RefundOrder(ctx, order.ID, domain.RefundInput{}, "admin")
+	if err != nil {
+		t.Fatalf("refund the rest: %v", err)
+	}
+	if refunded.AmountRefunded != refunded.Total || refunded.Status != domain.OrderRefunded {
+		t.Errorf("after full refund: refunded %d of %d, status %s", refunded.AmountRefunded, refunded.Total, refunded.Status)
+	}
+	if lines := refunded.Refunds[1].Lines; len(lines) != 2 || lines[0].Quantity != 1 || lines[1].Quantity != 1 {
+		t.Errorf("remaining lines = %+v, want one of each", lines)
+	}
+	if _, err := uc.RefundOrder(ctx, order.ID, domain.RefundInput{}, "admin"); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("refunded order: err = %v, want ErrInvalidTransition", err)
+	}
+}
+
+func TestRefundOrderProviderFailure(t *testing.T) {
+	ctx := context.Background()
+	uc, _ := newTestUseCase()
+	provider := uc.provider.(*memoryPayments)
+	order := paidTestOrder(t, uc)
+
+	provider.err = errors.New("card expired")
+	if _, err := uc.RefundOrder(ctx, order.ID, domain.RefundInput{}, "admin"); !errors.Is(err, ErrPaymentProvider) {
+		t.Fatalf("err = %v, want ErrPaymentProvider", err)
+	}
+	stored, _ := uc.GetOrderByID(ctx, order.ID)
+	if stored.AmountRefunded != 0 || len(stored.Refunds) != 1 || stored.Refunds[0].Status != domain.RefundFailed {
+		t.Errorf("after failure: refunded %d, refunds %+v; want 0 and one failed", stored.AmountRefunded, stored.Refunds)
+	}
+
+	pending, _ := uc.PlaceOrder(ctx, "alice", domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}}})
+	if _, err := uc.RefundOrder(ctx, pending.ID, domain.RefundInput{}, "admin"); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("unpaid order: err = %v, want ErrInvalidTransition", err)
+	}
+}
+
+func TestProviderRefundWebhook(t *testing.T) {
+	ctx := context.Background()
+	uc, _ := newTestUseCase()
+	order := paidTestOrder(t, uc)
+
+	// a refund made in the provider's dashboard is recorded on the order
+	partial := domain.PaymentEvent{Type: domain.PaymentRefunded, PaymentIntentID: order.PaymentIntentID, Amount: order.Total, AmountRefunded: 1000}
+	if err := uc.HandlePaymentEvent(ctx, partial); err != nil {
+		t.Fatalf("partial refund: %v", err)
+	}
+	stored, _ := uc.GetOrderByID(ctx, order.ID)
+	if stored.AmountRefunded != 1000 || len(stored.Refunds) != 1 || stored.Refunds[0].Actor != PaymentActor || stored.Status != domain.OrderPaid {
+		t.Errorf("after partial webhook: %+v", stored)
+	}
+
+	// a redelivery of the same total changes nothing
+	if err := uc.HandlePaymentEvent(ctx, partial); err != nil {
+		t.Fatalf("repeated total: %v", err)
+	}
+	if stored, _ = uc.GetOrderByID(ctx, order.ID); len(stored.Refunds) != 1 {
+		t.Errorf("repeated total: %d refunds, want 1", len(stored.Refunds))
+	}
+
+	full := partial
+	full.AmountRefunded = order.Total
+	if err := uc.HandlePaymentEvent(ctx, full); err != nil {
+		t.Fatalf("full refund: %v", err)
+	}
+	stored, _ = uc.GetOrderByID(ctx, order.ID)
+	if stored.AmountRefunded != order.Total || stored.Status != domain.OrderRefunded {
+		t.Errorf("after full webhook: refunded %d, status %s", stored.AmountRefunded, stored.Status)
+	}
+}
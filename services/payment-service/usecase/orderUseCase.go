@@ -0,0 +1,549 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/pkg/pagination"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrOrderNotFound is returned when an order does not exist or belongs to
+	// another customer.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrInvalidTransition is returned when an order cannot move to the
+	// requested status.
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrInvalidOrder is returned when a new order fails validation.
+	ErrInvalidOrder = errors.New("invalid order")
+	// ErrPriceMismatch is returned when a client-supplied price or currency
+	// differs from the catalogue.
+	ErrPriceMismatch = errors.New("price mismatch")
+	// ErrUnsupportedCurrency is returned when a checkout asks for a currency
+	// orders cannot be placed in.
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrInsufficientStock is returned when the catalogue cannot reserve
+	// enough units for an order.
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrDuplicateEvent is returned for a payment event that has already
+	// been processed or is being processed.
+	ErrDuplicateEvent = errors.New("event already processed")
+	// ErrPaymentProvider is returned when the payment provider cannot open a
+	// checkout.
+	ErrPaymentProvider = errors.New("payment provider unavailable")
+)
+
+// eventLease is how long a claimed payment event may stay unfinished before
+// a redelivery is allowed to process it again.
+const eventLease = 5 * time.Minute
+
+// Catalog is the products service as seen by checkout: the source of truth
+// for product names and prices, and the owner of stock reservations.
+type Catalog interface {
+	// GetProduct returns the product priced in currency, or nil, nil when
+	// the product does not exist.
+	GetProduct(ctx context.Context, id, currency string) (*domain.CatalogProduct, error)
+	ReserveProducts(ctx context.Context, customerID string, items []domain.OrderItem) (string, error)
+	CancelReservation(ctx context.Context, customerID, reservationID string, items []domain.OrderItem) error
+	// CommitReservation keeps a paid order's units reserved for it until
+	// they ship.
+	CommitReservation(ctx context.Context, customerID, reservationID, orderID string) error
+	// ShipProducts sells the order's units that leave in shipment, filling
+	// in the serial numbers of the units picked where the shipment names
+	// none.
+	ShipProducts(ctx context.Context, customerID, reservationID, orderID string, shipment *domain.Shipment) error
+	// ReturnProducts takes refunded units of a sold reservation back into
+	// inventory as returned.
+	ReturnProducts(ctx context.Context, customerID, reservationID string, lines []domain.RefundLine) error
+}
+
+// Promotions prices the discounts of an order at checkout and counts the
+// uses of the promotions applied to it.
+type Promotions interface {
+	ApplyPromotions(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct, codes []string) error
+	RedeemPromotions(ctx context.Context, order *domain.Order) error
+	ReleasePromotions(ctx context.Context, order *domain.Order)
+}
+
+// Shipping quotes the delivery options of an order and charges the one the
+// customer chose.
+type Shipping interface {
+	QuoteShipping(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct) ([]domain.ShippingQuote, error)
+	ApplyShipping(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct, methodID string) error
+}
+
+// Taxes works out the tax on an order once its discounts are applied.
+type Taxes interface {
+	ApplyTax(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct) error
+}
+
+// Invoices issues the invoice of a paid order and the credit notes of its
+// refunds.
+type Invoices interface {
+	IssueInvoices(ctx context.Context, order *domain.Order) ([]domain.Invoice, error)
+}
+
+// PaymentProvider takes payments for orders through a hosted checkout page
+// and reports their outcome by webhook.
+type PaymentProvider interface {
+	CreateCheckout(ctx context.Context, order *domain.Order) (*domain.CheckoutSession, error)
+	// ParseWebhook verifies a webhook delivery and reduces it to a payment
+	// event. ok is false for events orders do not react to.
+	ParseWebhook(payload []byte, header http.Header) (event domain.PaymentEvent, ok bool, err error)
+	Refund(ctx context.Context, req domain.RefundRequest) (*domain.RefundResult, error)
+	CheckoutStatus(ctx context.Context, sessionID string) (*domain.CheckoutStatus, error)
+}
+
+// PaymentActor is recorded in the history of status changes made by payment
+// provider webhooks.
+const PaymentActor = "payment-provider"
+
+type OrderUseCase interface {
+	GetAllOrders(ctx context.Context, filter domain.OrderFilter, params pagination.Params) (*pagination.Page[domain.Order], error)
+	GetOrderByID(ctx context.Context, id primitive.ObjectID) (*domain.Order, error)
+	GetCustomerOrder(ctx context.Context, customerID string, id primitive.ObjectID) (*domain.Order, error)
+	ShippingOptions(ctx context.Context, customerID string, input domain.CheckoutInput) ([]domain.ShippingQuote, error)
+	PlaceOrder(ctx context.Context, customerID string, input domain.CheckoutInput) (*domain.Order, error)
+	Checkout(ctx context.Context, customerID string, input domain.CheckoutInput) (*domain.Order, *domain.CheckoutSession, error)
+	AttachCheckoutSession(ctx context.Context, id primitive.ObjectID, sessionID string) error
+	UpdateOrderStatus(ctx context.Context, id primitive.ObjectID, status, actor, reason string) (*domain.Order, error)
+	CancelCustomerOrder(ctx context.Context, customerID string, id primitive.ObjectID) (*domain.Order, error)
+	HandlePaymentEvent(ctx context.Context, event domain.PaymentEvent) error
+	SyncPayment(ctx context.Context, id primitive.ObjectID) (*domain.Order, error)
+	RefundOrder(ctx context.Context, id primitive.ObjectID, input domain.RefundInput, actor string) (*domain.Order, error)
+	CreateShipment(ctx context.Context, id primitive.ObjectID, input domain.ShipmentInput, actor string) (*domain.Order, error)
+	UpdateShipment(ctx context.Context, id primitive.ObjectID, shipmentID string, update domain.ShipmentUpdate, actor string) (*domain.Order, error)
+	GetCustomerTracking(ctx context.Context, customerID string, id primitive.ObjectID) (*domain.OrderTracking, error)
+	GetOrderInvoices(ctx context.Context, id primitive.ObjectID) ([]domain.Invoice, error)
+	GetCustomerInvoices(ctx context.Context, customerID string, id primitive.ObjectID) ([]domain.Invoice, error)
+	GetOrderInvoice(ctx context.Context, id primitive.ObjectID, invoiceID string) (*domain.Invoice, error)
+	GetCustomerInvoice(ctx context.Context, customerID string, id primitive.ObjectID, invoiceID string) (*domain.Invoice, error)
+}
+
+type orderUseCase struct {
+	repo            repository.OrderRepository
+	eventRepository repository.EventRepository
+	catalog         Catalog
+	promotions      Promotions
+	shipping        Shipping
+	taxes           Taxes
+	invoices        Invoices
+	provider        PaymentProvider
+	currencies      domain.Currencies
+}
+
+// NewOrderUseCase places orders in any of currencies, which must all be
+// currencies products-service can price in.
+func NewOrderUseCase(repo repository.OrderRepository, eventRepository repository.EventRepository, catalog Catalog, promotions Promotions, shipping Shipping, taxes Taxes, invoices Invoices, provider PaymentProvider, currencies domain.Currencies) *orderUseCase {
+	return &orderUseCase{
+		repo:            repo,
+		eventRepository: eventRepository,
+		catalog:         catalog,
+		promotions:      promotions,
+		shipping:        shipping,
+		taxes:           taxes,
+		invoices:        invoices,
+		provider:        provider,
+		currencies:      currencies,
+	}
+}
+
+func (o *orderUseCase) GetAllOrders(ctx context.Context, filter domain.OrderFilter, params pagination.Params) (*pagination.Page[domain.Order], error) {
+	return o.repo.GetAllOrders(ctx, filter, params)
+}
+
+func (o *orderUseCase) GetOrderByID(ctx context.Context, id primitive.ObjectID) (*domain.Order, error) {
+	order, err := o.repo.GetOrderByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrOrderNotFound
+	}
+	return order, err
+}
+
+// GetCustomerOrder returns the order only if it belongs to customerID, so
+// customers cannot probe for other customers' order IDs.
+func (o *orderUseCase) GetCustomerOrder(ctx context.Context, customerID string, id primitive.ObjectID) (*domain.Order, error) {
+	order, err := o.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.CustomerID != customerID {
+		return nil, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+// ShippingOptions returns the shipping methods the order input would be
+// offered at checkout, priced for it. Anonymous shoppers may ask too, so
+// customerID may be empty.
+func (o *orderUseCase) ShippingOptions(ctx context.Context, customerID string, input domain.CheckoutInput) ([]domain.ShippingQuote, error) {
+	if customerID == "" {
+		// the order is never stored, it only needs to validate
+		customerID = "anonymous"
+	}
+	order, products, err := o.buildOrder(ctx, customerID, input)
+	if err != nil {
+		return nil, err
+	}
+	return o.shipping.QuoteShipping(ctx, order, products)
+}
+
+// PlaceOrder prices items from the catalogue in the requested currency,
+// applies promotions, shipping and tax, reserves their stock and stores the
+// order as pending. Prices and currencies sent by the client are only
+// compared against the catalogue, never charged.
+func (o *orderUseCase) PlaceOrder(ctx context.Context, customerID string, input domain.CheckoutInput) (*domain.Order, error) {
+	order, products, err := o.buildOrder(ctx, customerID, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.shipping.ApplyShipping(ctx, order, products, input.ShippingMethodID); err != nil {
+		return nil, err
+	}
+	if err := o.taxes.ApplyTax(ctx, order, products); err != nil {
+		return nil, err
+	}
+
+	if err := o.promotions.RedeemPromotions(ctx, order); err != nil {
+		return nil, err
+	}
+	reservationID, err := o.catalog.ReserveProducts(ctx, customerID, order.Items)
+	if err != nil {
+		o.promotions.ReleasePromotions(context.WithoutCancel(ctx), order)
+		return nil, err
+	}
+	order.ReservationID = reservationID
+
+	order.Status = domain.OrderPending
+	order.History = []domain.StatusChange{{To: domain.OrderPending, Actor: customerID, Reason: "checkout", At: time.Now()}}
+	if err := o.repo.CreateOrder(ctx, order); err != nil {
+		o.releaseReservation(context.WithoutCancel(ctx), order)
+		o.promotions.ReleasePromotions(context.WithoutCancel(ctx), order)
+		return nil, err
+	}
+	return order, nil
+}
+
+// buildOrder prices the input's items and applies its promotions, returning
+// the validated order and the catalogue entries of its products.
+func (o *orderUseCase) buildOrder(ctx context.Context, customerID string, input domain.CheckoutInput) (*domain.Order, map[string]*domain.CatalogProduct, error) {
+	currency, ok := o.currencies.Resolve(input.Currency)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, strings.ToUpper(input.Currency))
+	}
+	order := &domain.Order{
+		// set here so promotion uses can be counted against the order
+		ID:              primitive.NewObjectID(),
+		CustomerID:      customerID,
+		Currency:        currency,
+		ShippingAddress: input.ShippingAddress,
+		BillingAddress:  input.BillingAddress,
+	}
+
+	products := map[string]*domain.CatalogProduct{}
+	for _, item := range input.Items {
+		line, err := o.priceItem(ctx, item, currency, products)
+		if err != nil {
+			return nil, nil, err
+		}
+		order.Items = append(order.Items, line)
+	}
+	if err := o.promotions.ApplyPromotions(ctx, order, products, input.CouponCodes); err != nil {
+		return nil, nil, err
+	}
+	if err := order.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidOrder, err)
+	}
+	return order, products, nil
+}
+
+// Checkout places the order and opens its payment session. When the
+// provider fails the order is cancelled, since it could never be paid.
+func (o *orderUseCase) Checkout(ctx context.Context, customerID string, input domain.CheckoutInput) (*domain.Order, *domain.CheckoutSession, error) {
+	order, err := o.PlaceOrder(ctx, customerID, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := o.provider.CreateCheckout(ctx, order)
+	if err != nil {
+		if _, cancelErr := o.transition(context.WithoutCancel(ctx), order, domain.OrderCancelled, "system", "checkout session failed"); cancelErr != nil {
+			slog.Error(fmt.Sprintf("Failed to cancel order %s: %s", order.ID.Hex(), cancelErr))
+		}
+		return nil, nil, fmt.Errorf("%w: %s", ErrPaymentProvider, err)
+	}
+
+	if err := o.AttachCheckoutSession(ctx, order.ID, session.ID); err != nil {
+		slog.Error(fmt.Sprintf("Failed to link order %s to checkout session: %s", order.ID.Hex(), err))
+	} else {
+		order.CheckoutSessionID = session.ID
+	}
+	return order, session, nil
+}
+
+// priceItem resolves a checkout line against the catalogue, caching products
+// so repeated lines cost one lookup.
+func (o *orderUseCase) priceItem(ctx context.Context, item domain.CheckoutItem, currency string, products map[string]*domain.CatalogProduct) (domain.OrderItem, error) {
+	if item.ProductID == "" {
+		return domain.OrderItem{}, fmt.Errorf("%w: every item needs a product ID", ErrInvalidOrder)
+	}
+
+	product, ok := products[item.ProductID]
+	if !ok {
+		var err error
+		product, err = o.catalog.GetProduct(ctx, item.ProductID, currency)
+		if err != nil {
+			return domain.OrderItem{}, err
+		}
+		products[item.ProductID] = product
+	}
+	if product == nil {
+		return domain.OrderItem{}, fmt.Errorf("%w: product %s not found", ErrInvalidOrder, item.ProductID)
+	}
+
+	name, unitPrice, err := product.Line(item.VariantID, currency)
+	if err != nil {
+		return domain.OrderItem{}, fmt.Errorf("%w: product %s: %s", ErrInvalidOrder, item.ProductID, err)
+	}
+
+	if item.Currency != "" && !strings.EqualFold(item.Currency, currency) {
+		return domain.OrderItem{}, fmt.Errorf("%w: %s is priced in %s, not %s", ErrPriceMismatch, name, strings.ToUpper(currency), strings.ToUpper(item.Currency))
+	}
+	if item.Price != nil && *item.Price != unitPrice {
+		return domain.OrderItem{}, fmt.Errorf("%w: %s costs %d, not %d", ErrPriceMismatch, name, unitPrice, *item.Price)
+	}
+
+	return domain.OrderItem{
+		ProductID: item.ProductID,
+		VariantID: item.VariantID,
+		Name:      name,
+		UnitPrice: unitPrice,
+		Quantity:  item.Quantity,
+	}, nil
+}
+
+func (o *orderUseCase) AttachCheckoutSession(ctx context.Context, id primitive.ObjectID, sessionID string) error {
+	return o.repo.SetCheckoutSessionID(ctx, id, sessionID)
+}
+
+// UpdateOrderStatus moves an order to status if the order state machine
+// allows it, recording actor and reason in the order history.
+func (o *orderUseCase) UpdateOrderStatus(ctx context.Context, id primitive.ObjectID, status, actor, reason string) (*domain.Order, error) {
+	order, err := o.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return o.transition(ctx, order, status, actor, reason)
+}
+
+// CancelCustomerOrder lets a customer cancel their own order before it is
+// paid.
+func (o *orderUseCase) CancelCustomerOrder(ctx context.Context, customerID string, id primitive.ObjectID) (*domain.Order, error) {
+	order, err := o.GetCustomerOrder(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != domain.OrderPending {
+		return nil, fmt.Errorf("%w: only pending orders can be cancelled, this order is %s", ErrInvalidTransition, order.Status)
+	}
+	return o.transition(ctx, order, domain.OrderCancelled, customerID, "cancelled by customer")
+}
+
+// HandlePaymentEvent applies a verified payment provider event to its order
+// once. A redelivered event returns ErrDuplicateEvent. It returns
+// ErrOrderNotFound when no order matches the event and ErrInvalidTransition
+// when the order has already moved past it; both are final, while other
+// errors release the event so the provider's retry can process it.
+func (o *orderUseCase) HandlePaymentEvent(ctx context.Context, event domain.PaymentEvent) error {
+	if event.ID == "" {
+		return o.applyPaymentEvent(ctx, event)
+	}
+
+	claimed, err := o.eventRepository.ClaimEvent(ctx, event.ID, event.Type, time.Now().Add(-eventLease))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrDuplicateEvent
+	}
+
+	err = o.applyPaymentEvent(ctx, event)
+	ctx = context.WithoutCancel(ctx)
+	if err != nil && !errors.Is(err, ErrOrderNotFound) && !errors.Is(err, ErrInvalidTransition) {
+		if releaseErr := o.eventRepository.ReleaseEvent(ctx, event.ID); releaseErr != nil {
+			slog.Error(fmt.Sprintf("Failed to release payment event %s: %s", event.ID, releaseErr))
+		}
+		return err
+	}
+	if completeErr := o.eventRepository.CompleteEvent(ctx, event.ID); completeErr != nil {
+		slog.Error(fmt.Sprintf("Failed to mark payment event %s processed: %s", event.ID, completeErr))
+	}
+	return err
+}
+
+// SyncPayment asks the payment provider for the state of a pending order's
+// checkout and applies it, for when its webhook was lost. Orders that are no
+// longer pending are returned unchanged.
+func (o *orderUseCase) SyncPayment(ctx context.Context, id primitive.ObjectID) (*domain.Order, error) {
+	order, err := o.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != domain.OrderPending {
+		return order, nil
+	}
+	if order.CheckoutSessionID == "" {
+		return nil, fmt.Errorf("%w: order has no checkout session", ErrInvalidTransition)
+	}
+
+	status, err := o.provider.CheckoutStatus(ctx, order.CheckoutSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentProvider, err)
+	}
+
+	event := domain.PaymentEvent{
+		SessionID:       status.SessionID,
+		OrderID:         order.ID.Hex(),
+		PaymentIntentID: status.PaymentIntentID,
+		Paid:            status.Paid,
+		Amount:          status.Amount,
+		Currency:        status.Currency,
+	}
+	switch status.Status {
+	case domain.CheckoutComplete:
+		event.Type = domain.PaymentCheckoutCompleted
+	case domain.CheckoutExpired:
+		event.Type = domain.PaymentCheckoutExpired
+	default:
+		return order, nil
+	}
+	if err := o.applyPaymentEvent(ctx, event); err != nil {
+		return nil, err
+	}
+	return o.GetOrderByID(ctx, id)
+}
+
+func (o *orderUseCase) applyPaymentEvent(ctx context.Context, event domain.PaymentEvent) error {
+	order, err := o.findPaymentOrder(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	switch event.Type {
+	case domain.PaymentCheckoutCompleted:
+		if !event.Paid {
+			// delayed payment methods confirm later with another event
+			return nil
+		}
+		if event.Amount != order.Total || !strings.EqualFold(event.Currency, order.Currency) {
+			// the order stays pending so staff can check the payment by hand
+			mismatch := fmt.Sprintf("paid %s, order total is %s", domain.FormatAmount(event.Amount, event.Currency), domain.FormatAmount(order.Total, order.Currency))
+			slog.Error(fmt.Sprintf("Checkout of order %s needs review: %s", order.ID.Hex(), mismatch))
+			return o.repo.SetPaymentDetails(ctx, order.ID, event.PaymentIntentID, mismatch)
+		}
+		if err := o.repo.SetPaymentDetails(ctx, order.ID, event.PaymentIntentID, ""); err != nil {
+			return err
+		}
+		paid, err := o.transition(ctx, order, domain.OrderPaid, PaymentActor, "checkout completed")
+		if err != nil {
+			return err
+		}
+		if paid.ReservationID != "" {
+			if err := o.catalog.CommitReservation(ctx, paid.CustomerID, paid.ReservationID, paid.ID.Hex()); err != nil {
+				// the order is paid either way; staff must allocate units by hand
+				slog.Error(fmt.Sprintf("Failed to commit reservation %s of paid order %s: %s", paid.ReservationID, paid.ID.Hex(), err))
+			}
+		}
+		o.issueInvoices(ctx, paid)
+		return nil
+
+	case domain.PaymentCheckoutExpired:
+		_, err := o.transition(ctx, order, domain.OrderCancelled, PaymentActor, "checkout expired")
+		return err
+
+	case domain.PaymentFailed:
+		// the order stays pending so the customer can retry until the
+		// checkout expires
+		return o.repo.SetPaymentDetails(ctx, order.ID, event.PaymentIntentID, event.FailureMessage)
+
+	case domain.PaymentRefunded:
+		return o.applyProviderRefund(ctx, order, event)
+	}
+
+	return fmt.Errorf("unknown payment event type %q", event.Type)
+}
+
+// findPaymentOrder matches an event to its order by checkout session, then by
+// the order reference given to the provider, then by payment intent. An
+// order found by reference must not belong to a different session.
+func (o *orderUseCase) findPaymentOrder(ctx context.Context, event domain.PaymentEvent) (*domain.Order, error) {
+	if event.SessionID != "" {
+		order, err := o.repo.GetOrderByCheckoutSessionID(ctx, event.SessionID)
+		if err != nil || order != nil {
+			return order, err
+		}
+	}
+
+	if id, err := primitive.ObjectIDFromHex(event.OrderID); err == nil {
+		order, err := o.repo.GetOrderByID(ctx, id)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+		if order != nil && (order.CheckoutSessionID == "" || event.SessionID == "" || order.CheckoutSessionID == event.SessionID) {
+			return order, nil
+		}
+	}
+
+	if event.PaymentIntentID != "" {
+		order, err := o.repo.GetOrderByPaymentIntentID(ctx, event.PaymentIntentID)
+		if err != nil || order != nil {
+			return order, err
+		}
+	}
+
+	return nil, ErrOrderNotFound
+}
+
+func (o *orderUseCase) transition(ctx context.Context, order *domain.Order, status, actor, reason string) (*domain.Order, error) {
+	if !domain.IsOrderStatus(status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidTransition, status)
+	}
+	if !domain.CanTransitionOrder(order.Status, status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, order.Status, status)
+	}
+
+	change := domain.StatusChange{From: order.Status, To: status, Actor: actor, Reason: reason, At: time.Now()}
+	updated, err := o.repo.TransitionOrder(ctx, order.ID, change)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, fmt.Errorf("%w: order status changed concurrently", ErrInvalidTransition)
+	}
+	if status == domain.OrderCancelled {
+		o.releaseReservation(ctx, updated)
+		o.promotions.ReleasePromotions(ctx, updated)
+	}
+	return updated, nil
+}
+
+// releaseReservation returns a pending order's stock. Failures are logged;
+// unreleased units are returned by the products-service reservation sweeper
+// when the reservation expires.
+func (o *orderUseCase) releaseReservation(ctx context.Context, order *domain.Order) {
+	if order.ReservationID == "" {
+		return
+	}
+	if err := o.catalog.CancelReservation(ctx, order.CustomerID, order.ReservationID, order.Items); err != nil {
+		slog.Error(fmt.Sprintf("Failed to release reservation %s of order %s: %s", order.ReservationID, order.ID.Hex(), err))
+	}
+}
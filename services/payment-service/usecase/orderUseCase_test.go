@@ -0,0 +1,585 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryOrders is an in-memory OrderRepository.
+type memoryOrders struct {
+	repository.OrderRepository
+
+	orders map[primitive.ObjectID]*domain.Order
+}
+
+func newMemoryOrders() *memoryOrders {
+	return &memoryOrders{orders: map[primitive.ObjectID]*domain.Order{}}
+}
+
+func (m *memoryOrders) CreateOrder(ctx context.Context, order *domain.Order) error {
+	if order.ID.IsZero() {
+		order.ID = primitive.NewObjectID()
+	}
+	stored := *order
+	m.orders[order.ID] = &stored
+	return nil
+}
+
+func (m *memoryOrders) GetOrderByID(ctx context.Context, id primitive.ObjectID) (*domain.Order, error) {
+	order, ok := m.orders[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *order
+	return &copied, nil
+}
+
+func (m *memoryOrders) GetOrderByCheckoutSessionID(ctx context.Context, sessionID string) (*domain.Order, error) {
+	for _, order := range m.orders {
+		if order.CheckoutSessionID == sessionID {
+			copied := *order
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryOrders) SetCheckoutSessionID(ctx context.Context, id primitive.ObjectID, sessionID string) error {
+	m.orders[id].CheckoutSessionID = sessionID
+	return nil
+}
+
+func (m *memoryOrders) GetOrderByPaymentIntentID(ctx context.Context, paymentIntentID string) (*domain.Order, error) {
+	for _, order := range m.orders {
+		if order.PaymentIntentID == paymentIntentID {
+			copied := *order
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryOrders) SetPaymentDetails(ctx context.Context, id primitive.ObjectID, paymentIntentID, paymentError string) error {
+	if paymentIntentID != "" {
+		m.orders[id].PaymentIntentID = paymentIntentID
+	}
+	m.orders[id].PaymentError = paymentError
+	return nil
+}
+
+func (m *memoryOrders) TransitionOrder(ctx context.Context, id primitive.ObjectID, change domain.StatusChange) (*domain.Order, error) {
+	order, ok := m.orders[id]
+	if !ok || order.Status != change.From {
+		return nil, nil
+	}
+	order.Status = change.To
+	order.History = append(order.History, change)
+	copied := *order
+	return &copied, nil
+}
+
+// memoryCatalog is an in-memory Catalog with unlimited stock unless a
+// product's stock is set. Products are priced in KZT and converted to the
+// currencies in rates.
+type memoryCatalog struct {
+	products  map[string]*domain.CatalogProduct
+	rates     map[string]float64
+	stock     map[string]int64
+	reserved  map[string][]domain.OrderItem
+	committed map[string]string
+	shipped   map[string][]domain.ShipmentLine
+	returned  map[string][]domain.RefundLine
+}
+
+func newMemoryCatalog(products ...domain.CatalogProduct) *memoryCatalog {
+	m := &memoryCatalog{products: map[string]*domain.CatalogProduct{}, rates: map[string]float64{"usd": 0.002}, stock: map[string]int64{}, reserved: map[string][]domain.OrderItem{}, committed: map[string]string{}, shipped: map[string][]domain.ShipmentLine{}, returned: map[string][]domain.RefundLine{}}
+	for n := range products {
+		m.products[products[n].ID] = &products[n]
+	}
+	return m
+}
+
+func (m *memoryCatalog) GetProduct(ctx context.Context, id, currency string) (*domain.CatalogProduct, error) {
+	product, ok := m.products[id]
+	rate, convert := m.rates[currency]
+	if !ok || !convert {
+		return product, nil
+	}
+
+	money := func(price float64) *domain.CatalogMoney {
+		amount, _ := domain.ToMinorUnits(price, "kzt")
+		return &domain.CatalogMoney{Amount: int64(math.Round(float64(amount) * rate)), Currency: currency}
+	}
+	converted := *product
+	converted.Money = money(product.Price)
+	converted.Variants = make([]domain.CatalogVariant, len(product.Variants))
+	for n, variant := range product.Variants {
+		variant.Money = money(variant.Price)
+		converted.Variants[n] = variant
+	}
+	return &converted, nil
+}
+
+func (m *memoryCatalog) ReserveProducts(ctx context.Context, customerID string, items []domain.OrderItem) (string, error) {
+	for _, item := range items {
+		if stock, ok := m.stock[item.ProductID]; ok && stock < item.Quantity {
+			return "", ErrInsufficientStock
+		}
+	}
+	id := primitive.NewObjectID().Hex()
+	m.reserved[id] = items
+	return id, nil
+}
+
+func (m *memoryCatalog) CancelReservation(ctx context.Context, customerID, reservationID string, items []domain.OrderItem) error {
+	delete(m.reserved, reservationID)
+	return nil
+}
+
+func (m *memoryCatalog) CommitReservation(ctx context.Context, customerID, reservationID, orderID string) error {
+	m.committed[reservationID] = orderID
+	return nil
+}
+
+// ShipProducts numbers the units it ships by reservation, so serials are
+// unique within an order.
+func (m *memoryCatalog) ShipProducts(ctx context.Context, customerID, reservationID, orderID string, shipment *domain.Shipment) error {
+	for n := range shipment.Lines {
+		line := &shipment.Lines[n]
+		for len(line.SerialNumbers) < int(line.Quantity) {
+			line.SerialNumbers = append(line.SerialNumbers, fmt.Sprintf("SN-%s-%d", line.ProductID, len(m.shipped[reservationID])+len(line.SerialNumbers)))
+		}
+	}
+	m.shipped[reservationID] = append(m.shipped[reservationID], shipment.Lines...)
+	return nil
+}
+
+var (
+	thinkpad = domain.CatalogProduct{ID: "p1", ModelName: "ThinkPad X1 Carbon", Price: 749999.99}
+	macbook  = domain.CatalogProduct{ID: "p2", ModelName: "MacBook Air", Price: 599990, Variants: []domain.CatalogVariant{
+		{ID: "v1", SKU: "MBA-8-256", Price: 599990},
+		{ID: "v2", SKU: "MBA-16-512", Price: 799990.5},
+	}}
+)
+
+// memoryEvents tracks claimed event IDs; a claim is held until released.
+type memoryEvents struct {
+	repository.EventRepository
+
+	status map[string]string
+}
+
+func newMemoryEvents() *memoryEvents {
+	return &memoryEvents{status: map[string]string{}}
+}
+
+func (m *memoryEvents) ClaimEvent(ctx context.Context, id, eventType string, staleBefore time.Time) (bool, error) {
+	if _, ok := m.status[id]; ok {
+		return false, nil
+	}
+	m.status[id] = domain.ClaimProcessing
+	return true, nil
+}
+
+func (m *memoryEvents) CompleteEvent(ctx context.Context, id string) error {
+	m.status[id] = domain.ClaimCompleted
+	return nil
+}
+
+func (m *memoryEvents) ReleaseEvent(ctx context.Context, id string) error {
+	delete(m.status, id)
+	return nil
+}
+
+// memoryPayments opens numbered checkout sessions and reports the statuses
+// set in status.
+type memoryPayments struct {
+	PaymentProvider
+
+	sessions int
+	err      error
+	status   map[string]*domain.CheckoutStatus
+	refunds  []domain.RefundRequest
+}
+
+func (m *memoryPayments) CreateCheckout(ctx context.Context, order *domain.Order) (*domain.CheckoutSession, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.sessions++
+	id := fmt.Sprintf("cs_%d", m.sessions)
+	return &domain.CheckoutSession{ID: id, URL: "https://pay.test/" + id}, nil
+}
+
+func (m *memoryPayments) CheckoutStatus(ctx context.Context, sessionID string) (*domain.CheckoutStatus, error) {
+	if status, ok := m.status[sessionID]; ok {
+		return status, nil
+	}
+	return &domain.CheckoutStatus{SessionID: sessionID, Status: domain.CheckoutOpen}, nil
+}
+
+// testOrderOption replaces one of the in-memory dependencies of
+// newTestOrderUseCase.
+type testOrderOption func(*orderUseCase)
+
+func withOrders(repo repository.OrderRepository) testOrderOption {
+	return func(o *orderUseCase) { o.repo = repo }
+}
+
+func withPromotions(promotions Promotions) testOrderOption {
+	return func(o *orderUseCase) { o.promotions = promotions }
+}
+
+func withShipping(shipping Shipping) testOrderOption {
+	return func(o *orderUseCase) { o.shipping = shipping }
+}
+
+func withTaxes(taxes Taxes) testOrderOption {
+	return func(o *orderUseCase) { o.taxes = taxes }
+}
+
+func withCurrencies(currencies domain.Currencies) testOrderOption {
+	return func(o *orderUseCase) { o.currencies = currencies }
+}
+
+// newTestOrderUseCase sells from catalog with in-memory orders, events,
+// promotions, shipping, taxes, invoices and payments, in kzt only.
+func newTestOrderUseCase(catalog Catalog, opts ...testOrderOption) *orderUseCase {
+	uc := NewOrderUseCase(newMemoryOrders(), newMemoryEvents(), catalog, NewPromotionUseCase(newMemoryPromotions()), newTestShippingUseCase(), newTestTaxUseCase(true), newTestInvoiceUseCase(), &memoryPayments{}, domain.NewCurrencies("kzt"))
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+func newTestUseCase() (*orderUseCase, *memoryCatalog) {
+	catalog := newMemoryCatalog(thinkpad, macbook)
+	return newTestOrderUseCase(catalog, withCurrencies(domain.NewCurrencies("KZT", "usd"))), catalog
+}
+
+func placeTestOrder(t *testing.T, uc *orderUseCase, customerID string) *domain.Order {
+	t.Helper()
+	order, err := uc.PlaceOrder(context.Background(), customerID, domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	return order
+}
+
+func TestPlaceOrderPricesFromCatalog(t *testing.T) {
+	uc, catalog := newTestUseCase()
+
+	items := []domain.CheckoutItem{
+		{ProductID: "p1", Quantity: 2},
+		{ProductID: "p2", VariantID: "v2", Quantity: 1},
+	}
+	shipping := &domain.Address{Name: "Ada", Line1: "1 Abay Ave", City: "Almaty", PostalCode: "050000", Country: "kz"}
+	order, err := uc.PlaceOrder(context.Background(), "alice", domain.CheckoutInput{Items: items, ShippingAddress: shipping})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	if order.Items[0].UnitPrice != 74999999 || order.Items[0].Total != 149999998 {
+		t.Errorf("ThinkPad priced %d x2 = %d, want 74999999 x2 = 149999998", order.Items[0].UnitPrice, order.Items[0].Total)
+	}
+	if order.Items[1].UnitPrice != 79999050 || order.Items[1].Name != "MacBook Air (MBA-16-512)" {
+		t.Errorf("variant line = %q at %d, want MacBook Air (MBA-16-512) at 79999050", order.Items[1].Name, order.Items[1].UnitPrice)
+	}
+	if order.Total != 149999998+79999050 || order.Currency != "kzt" {
+		t.Errorf("total %d %s, want %d kzt", order.Total, order.Currency, 149999998+79999050)
+	}
+	if order.Status != domain.OrderPending || len(order.History) != 1 || order.ShippingAddress.Country != "KZ" {
+		t.Errorf("got status %s, %d history entries, country %q", order.Status, len(order.History), order.ShippingAddress.Country)
+	}
+	if _, ok := catalog.reserved[order.ReservationID]; !ok {
+		t.Errorf("order reservation %q was not made in the catalog", order.ReservationID)
+	}
+}
+
+func TestPlaceOrderRejectsInvalidItems(t *testing.T) {
+	wrongPrice := int64(1)
+	rightPrice := int64(74999999)
+	tests := []struct {
+		name  string
+		items []domain.CheckoutItem
+		want  error
+	}{
+		{"client price", []domain.CheckoutItem{{ProductID: "p1", Quantity: 1, Price: &wrongPrice}}, ErrPriceMismatch},
+		{"client currency", []domain.CheckoutItem{{ProductID: "p1", Quantity: 1, Price: &rightPrice, Currency: "usd"}}, ErrPriceMismatch},
+		{"unknown product", []domain.CheckoutItem{{ProductID: "nope", Quantity: 1}}, ErrInvalidOrder},
+		{"missing variant", []domain.CheckoutItem{{ProductID: "p2", Quantity: 1}}, ErrInvalidOrder},
+		{"unknown variant", []domain.CheckoutItem{{ProductID: "p2", VariantID: "v9", Quantity: 1}}, ErrInvalidOrder},
+		{"zero quantity", []domain.CheckoutItem{{ProductID: "p1", Quantity: 0}}, ErrInvalidOrder},
+		{"no items", nil, ErrInvalidOrder},
+	}
+	for _, tt := range tests {
+		uc, catalog := newTestUseCase()
+		_, err := uc.PlaceOrder(context.Background(), "alice", domain.CheckoutInput{Items: tt.items})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		if len(catalog.reserved) != 0 {
+			t.Errorf("%s: stock was reserved for a rejected order", tt.name)
+		}
+	}
+
+	uc, _ := newTestUseCase()
+	if _, err := uc.PlaceOrder(context.Background(), "alice", domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 1, Price: &rightPrice, Currency: "KZT"}}}); err != nil {
+		t.Errorf("matching client price: %v", err)
+	}
+	if _, err := uc.PlaceOrder(context.Background(), "alice", domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}}, Currency: "eur"}); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("eur order: err = %v, want ErrUnsupportedCurrency", err)
+	}
+	order, err := uc.PlaceOrder(context.Background(), "alice", domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p2", VariantID: "v1", Quantity: 1}}, Currency: "USD"})
+	if err != nil || order.Currency != "usd" || order.Total != 119998 {
+		t.Errorf("usd order = %v, %v; want 119998 usd", order, err)
+	}
+}
+
+func TestPlaceOrderOutOfStock(t *testing.T) {
+	repo := newMemoryOrders()
+	catalog := newMemoryCatalog(thinkpad)
+	catalog.stock["p1"] = 1
+	uc := newTestOrderUseCase(catalog, withOrders(repo))
+
+	_, err := uc.PlaceOrder(context.Background(), "alice", domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 2}}})
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("err = %v, want ErrInsufficientStock", err)
+	}
+	if len(repo.orders) != 0 {
+		t.Errorf("%d orders stored, want none", len(repo.orders))
+	}
+}
+
+func TestCustomerCanOnlySeeAndCancelOwnOrders(t *testing.T) {
+	ctx := context.Background()
+	uc, catalog := newTestUseCase()
+	order := placeTestOrder(t, uc, "alice")
+
+	if _, err := uc.GetCustomerOrder(ctx, "bob", order.ID); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("other customer get: err = %v, want ErrOrderNotFound", err)
+	}
+	if _, err := uc.CancelCustomerOrder(ctx, "bob", order.ID); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("other customer cancel: err = %v, want ErrOrderNotFound", err)
+	}
+
+	cancelled, err := uc.CancelCustomerOrder(ctx, "alice", order.ID)
+	if err != nil {
+		t.Fatalf("CancelCustomerOrder: %v", err)
+	}
+	if cancelled.Status != domain.OrderCancelled || cancelled.History[1].Actor != "alice" {
+		t.Errorf("got status %s by %q, want cancelled by alice", cancelled.Status, cancelled.History[1].Actor)
+	}
+	if len(catalog.reserved) != 0 {
+		t.Errorf("cancelling did not release the reservation")
+	}
+}
+
+func TestOrderStatusTransitions(t *testing.T) {
+	ctx := context.Background()
+	uc, _ := newTestUseCase()
+	order := placeTestOrder(t, uc, "alice")
+	if err := uc.AttachCheckoutSession(ctx, order.ID, "cs_test_1"); err != nil {
+		t.Fatalf("AttachCheckoutSession: %v", err)
+	}
+
+	if _, err := uc.UpdateOrderStatus(ctx, order.ID, domain.OrderShipped, "admin", ""); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("pending -> shipped: err = %v, want ErrInvalidTransition", err)
+	}
+	completed := domain.PaymentEvent{Type: domain.PaymentCheckoutCompleted, SessionID: "cs_test_1", Paid: true, Amount: order.Total, Currency: order.Currency}
+	if err := uc.HandlePaymentEvent(ctx, completed); err != nil {
+		t.Fatalf("HandlePaymentEvent: %v", err)
+	}
+	if err := uc.HandlePaymentEvent(ctx, completed); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("paying twice: err = %v, want ErrInvalidTransition", err)
+	}
+	if _, err := uc.CancelCustomerOrder(ctx, "alice", order.ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("cancelling a paid order: err = %v, want ErrInvalidTransition", err)
+	}
+
+	for _, status := range []string{domain.OrderFulfilled, domain.OrderShipped, domain.OrderDelivered, domain.OrderRefunded} {
+		if _, err := uc.UpdateOrderStatus(ctx, order.ID, status, "admin", ""); err != nil {
+			t.Fatalf("-> %s: %v", status, err)
+		}
+	}
+	final, _ := uc.GetOrderByID(ctx, order.ID)
+	if len(final.History) != 6 {
+		t.Errorf("history has %d entries, want 6", len(final.History))
+	}
+	if _, err := uc.UpdateOrderStatus(ctx, order.ID, domain.OrderPaid, "admin", ""); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("refunded is terminal: err = %v, want ErrInvalidTransition", err)
+	}
+}
+
+func TestHandlePaymentEvent(t *testing.T) {
+	ctx := context.Background()
+	uc, catalog := newTestUseCase()
+	repo := uc.repo.(*memoryOrders)
+
+	// the webhook can arrive before the session ID is stored, so the order is
+	// found by its client reference
+	order := placeTestOrder(t, uc, "alice")
+	failed := domain.PaymentEvent{Type: domain.PaymentFailed, OrderID: order.ID.Hex(), PaymentIntentID: "pi_1", FailureMessage: "card declined"}
+	if err := uc.HandlePaymentEvent(ctx, failed); err != nil {
+		t.Fatalf("payment failed: %v", err)
+	}
+	if got := repo.orders[order.ID]; got.Status != domain.OrderPending || got.PaymentError != "card declined" {
+		t.Errorf("after failure: status %s, error %q; want pending, card declined", got.Status, got.PaymentError)
+	}
+
+	unpaid := domain.PaymentEvent{Type: domain.PaymentCheckoutCompleted, SessionID: "cs_1", OrderID: order.ID.Hex()}
+	if err := uc.HandlePaymentEvent(ctx, unpaid); err != nil || repo.orders[order.ID].Status != domain.OrderPending {
+		t.Errorf("unpaid completion: err %v, status %s; want pending", err, repo.orders[order.ID].Status)
+	}
+
+	// a payment that does not cover the order leaves it for review
+	short := domain.PaymentEvent{Type: domain.PaymentCheckoutCompleted, SessionID: "cs_1", OrderID: order.ID.Hex(), PaymentIntentID: "pi_1", Paid: true, Amount: order.Total - 1, Currency: order.Currency}
+	if err := uc.HandlePaymentEvent(ctx, short); err != nil {
+		t.Fatalf("short payment: %v", err)
+	}
+	if got := repo.orders[order.ID]; got.Status != domain.OrderPending || got.PaymentError == "" {
+		t.Errorf("after short payment: status %s, error %q; want pending with the mismatch", got.Status, got.PaymentError)
+	}
+	otherCurrency := domain.PaymentEvent{Type: domain.PaymentCheckoutCompleted, SessionID: "cs_1", OrderID: order.ID.Hex(), PaymentIntentID: "pi_1", Paid: true, Amount: order.Total, Currency: "usd"}
+	if err := uc.HandlePaymentEvent(ctx, otherCurrency); err != nil || repo.orders[order.ID].Status != domain.OrderPending {
+		t.Errorf("payment in another currency: err %v, status %s; want pending", err, repo.orders[order.ID].Status)
+	}
+
+	paid := domain.PaymentEvent{Type: domain.PaymentCheckoutCompleted, SessionID: "cs_1", OrderID: order.ID.Hex(), PaymentIntentID: "pi_1", Paid: true, Amount: order.Total, Currency: order.Currency}
+	if err := uc.HandlePaymentEvent(ctx, paid); err != nil {
+		t.Fatalf("completed: %v", err)
+	}
+	if got := repo.orders[order.ID]; got.Status != domain.OrderPaid || got.PaymentError != "" {
+		t.Errorf("after payment: status %s, error %q; want paid with no error", got.Status, got.PaymentError)
+	}
+	if catalog.committed[order.ReservationID] != order.ID.Hex() {
+		t.Error("paid order's reservation was not committed to it")
+	}
+
+	partial := domain.PaymentEvent{Type: domain.PaymentRefunded, PaymentIntentID: "pi_1", Amount: order.Total, AmountRefunded: 100}
+	if err := uc.HandlePaymentEvent(ctx, partial); err != nil || repo.orders[order.ID].Status != domain.OrderPaid {
+		t.Errorf("partial refund: err %v, status %s; want paid", err, repo.orders[order.ID].Status)
+	}
+	full := domain.PaymentEvent{Type: domain.PaymentRefunded, PaymentIntentID: "pi_1", Amount: order.Total, AmountRefunded: order.Total}
+	if err := uc.HandlePaymentEvent(ctx, full); err != nil || repo.orders[order.ID].Status != domain.OrderRefunded {
+		t.Errorf("full refund: err %v, status %s; want refunded", err, repo.orders[order.ID].Status)
+	}
+
+	expiring := placeTestOrder(t, uc, "bob")
+	if err := uc.AttachCheckoutSession(ctx, expiring.ID, "cs_2"); err != nil {
+		t.Fatalf("AttachCheckoutSession: %v", err)
+	}
+	// a reference to this order from another session must not match it
+	stray := domain.PaymentEvent{Type: domain.PaymentCheckoutCompleted, SessionID: "cs_other", OrderID: expiring.ID.Hex(), Paid: true}
+	if err := uc.HandlePaymentEvent(ctx, stray); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("mismatched session: err = %v, want ErrOrderNotFound", err)
+	}
+	if err := uc.HandlePaymentEvent(ctx, domain.PaymentEvent{Type: domain.PaymentCheckoutExpired, SessionID: "cs_2"}); err != nil {
+		t.Fatalf("expired: %v", err)
+	}
+	if repo.orders[expiring.ID].Status != domain.OrderCancelled {
+		t.Errorf("expired checkout: status %s, want cancelled", repo.orders[expiring.ID].Status)
+	}
+	if _, ok := catalog.reserved[expiring.ReservationID]; ok {
+		t.Error("expired checkout did not release its reservation")
+	}
+}
+
+func TestHandlePaymentEventOnce(t *testing.T) {
+	ctx := context.Background()
+	uc, catalog := newTestUseCase()
+	events := uc.eventRepository.(*memoryEvents)
+
+	order := placeTestOrder(t, uc, "alice")
+	paid := domain.PaymentEvent{ID: "evt_1", Type: domain.PaymentCheckoutCompleted, OrderID: order.ID.Hex(), PaymentIntentID: "pi_1", Paid: true, Amount: order.Total, Currency: order.Currency}
+	if err := uc.HandlePaymentEvent(ctx, paid); err != nil {
+		t.Fatalf("first delivery: %v", err)
+	}
+	if err := uc.HandlePaymentEvent(ctx, paid); !errors.Is(err, ErrDuplicateEvent) {
+		t.Errorf("redelivery: err = %v, want ErrDuplicateEvent", err)
+	}
+	if len(catalog.committed) != 1 {
+		t.Errorf("%d reservations committed, want one", len(catalog.committed))
+	}
+
+	// an event for an unknown order is final, so it is not retried
+	stray := domain.PaymentEvent{ID: "evt_2", Type: domain.PaymentCheckoutExpired, SessionID: "cs_missing"}
+	if err := uc.HandlePaymentEvent(ctx, stray); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("stray event: err = %v, want ErrOrderNotFound", err)
+	}
+	if events.status["evt_2"] != domain.ClaimCompleted {
+		t.Errorf("stray event claim is %q, want completed", events.status["evt_2"])
+	}
+
+	// a failed attempt releases its claim so the provider's retry is applied
+	failing := domain.PaymentEvent{ID: "evt_3", Type: "payment.unknown", OrderID: order.ID.Hex()}
+	if err := uc.HandlePaymentEvent(ctx, failing); err == nil {
+		t.Fatal("unknown event type: want error")
+	}
+	if _, ok := events.status["evt_3"]; ok {
+		t.Error("failed event kept its claim")
+	}
+}
+
+func TestCheckout(t *testing.T) {
+	ctx := context.Background()
+	uc, catalog := newTestUseCase()
+	repo := uc.repo.(*memoryOrders)
+	provider := uc.provider.(*memoryPayments)
+
+	order, session, err := uc.Checkout(ctx, "alice", domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if session.ID != "cs_1" || repo.orders[order.ID].CheckoutSessionID != "cs_1" {
+		t.Errorf("session %q, stored session %q; want cs_1", session.ID, repo.orders[order.ID].CheckoutSessionID)
+	}
+
+	// an order the provider cannot take payment for is cancelled
+	provider.err = errors.New("provider down")
+	if _, _, err := uc.Checkout(ctx, "bob", domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}}}); !errors.Is(err, ErrPaymentProvider) {
+		t.Fatalf("provider failure: err = %v, want ErrPaymentProvider", err)
+	}
+	for _, stored := range repo.orders {
+		if stored.CustomerID == "bob" && stored.Status != domain.OrderCancelled {
+			t.Errorf("order without a session is %s, want cancelled", stored.Status)
+		}
+	}
+	if len(catalog.reserved) != 1 {
+		t.Errorf("%d reservations held, want only alice's", len(catalog.reserved))
+	}
+}
+
+func TestSyncPayment(t *testing.T) {
+	ctx := context.Background()
+	uc, _ := newTestUseCase()
+	provider := uc.provider.(*memoryPayments)
+
+	order, session, err := uc.Checkout(ctx, "alice", domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if synced, err := uc.SyncPayment(ctx, order.ID); err != nil || synced.Status != domain.OrderPending {
+		t.Fatalf("open session: %v, %v; want pending", synced, err)
+	}
+
+	provider.status = map[string]*domain.CheckoutStatus{
+		session.ID: {SessionID: session.ID, Status: domain.CheckoutComplete, PaymentIntentID: "pi_1", Paid: true, Amount: order.Total, Currency: order.Currency},
+	}
+	synced, err := uc.SyncPayment(ctx, order.ID)
+	if err != nil || synced.Status != domain.OrderPaid || synced.PaymentIntentID != "pi_1" {
+		t.Fatalf("completed session: %+v, %v; want paid with pi_1", synced, err)
+	}
+	if again, err := uc.SyncPayment(ctx, order.ID); err != nil || again.Status != domain.OrderPaid {
+		t.Errorf("second sync: %v, %v; want the paid order unchanged", again, err)
+	}
+}
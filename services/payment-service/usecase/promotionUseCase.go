@@ -0,0 +1,316 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/pkg/pagination"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrPromotionNotFound is returned when a promotion does not exist.
+	ErrPromotionNotFound = errors.New("promotion not found")
+	// ErrInvalidPromotion is returned when a promotion's settings fail
+	// validation.
+	ErrInvalidPromotion = errors.New("invalid promotion")
+	// ErrInvalidCoupon is returned when a coupon code entered at checkout
+	// does not exist, has expired or used up its limits, or does not apply
+	// to the order.
+	ErrInvalidCoupon = errors.New("invalid coupon")
+)
+
+// maxCouponCodes caps the coupon codes one checkout may enter.
+const maxCouponCodes = 5
+
+type PromotionUseCase interface {
+	GetAllPromotions(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Promotion], error)
+	GetPromotionByID(ctx context.Context, id primitive.ObjectID) (*domain.Promotion, error)
+	CreatePromotion(ctx context.Context, promotion *domain.Promotion) error
+	UpdatePromotion(ctx context.Context, promotion *domain.Promotion) error
+	DeletePromotion(ctx context.Context, id primitive.ObjectID) error
+}
+
+type promotionUseCase struct {
+	repo repository.PromotionRepository
+}
+
+// NewPromotionUseCase manages promotions and, as the Promotions of checkout,
+// applies them to orders.
+func NewPromotionUseCase(repo repository.PromotionRepository) *promotionUseCase {
+	return &promotionUseCase{repo: repo}
+}
+
+func (p *promotionUseCase) GetAllPromotions(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Promotion], error) {
+	return p.repo.GetAllPromotions(ctx, params)
+}
+
+func (p *promotionUseCase) GetPromotionByID(ctx context.Context, id primitive.ObjectID) (*domain.Promotion, error) {
+	promotion, err := p.repo.GetPromotionByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrPromotionNotFound
+	}
+	return promotion, err
+}
+
+func (p *promotionUseCase) CreatePromotion(ctx context.Context, promotion *domain.Promotion) error {
+	if err := promotion.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPromotion, err)
+	}
+	return p.storeError(p.repo.CreatePromotion(ctx, promotion), promotion)
+}
+
+func (p *promotionUseCase) UpdatePromotion(ctx context.Context, promotion *domain.Promotion) error {
+	if err := promotion.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPromotion, err)
+	}
+	return p.storeError(p.repo.UpdatePromotion(ctx, promotion), promotion)
+}
+
+func (p *promotionUseCase) DeletePromotion(ctx context.Context, id primitive.ObjectID) error {
+	err := p.repo.DeletePromotion(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrPromotionNotFound
+	}
+	return err
+}
+
+func (p *promotionUseCase) storeError(err error, promotion *domain.Promotion) error {
+	switch {
+	case mongo.IsDuplicateKeyError(err):
+		return fmt.Errorf("%w: code %s is already in use", ErrInvalidPromotion, promotion.Code)
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrPromotionNotFound
+	}
+	return err
+}
+
+// ApplyPromotions discounts the order's items by the coupons entered and
+// the automatic promotions it qualifies for. products holds the catalogue
+// entry of every item, for the promotions' scopes.
+//
+// A promotion that is not stackable is applied alone. Entered coupons are
+// never dropped silently: a coupon that cannot be used fails the checkout,
+// and an exclusive coupon cannot be entered together with another. With
+// coupons, the stackable automatic promotions are added to them; without,
+// the order gets whichever of the automatic promotions saves the most:
+// all the stackable ones together, or one of the exclusive ones.
+func (p *promotionUseCase) ApplyPromotions(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct, codes []string) error {
+	now := time.Now()
+	covers := func(promotion *domain.Promotion, item *domain.OrderItem) bool {
+		product := products[item.ProductID]
+		return product != nil && promotion.Scope.Covers(item.ProductID, product.BrandID, product.CategoryIDs)
+	}
+
+	coupons, err := p.coupons(ctx, order, codes, now, covers)
+	if err != nil {
+		return err
+	}
+
+	automatic, err := p.repo.GetAutomaticPromotions(ctx, now)
+	if err != nil {
+		return err
+	}
+	var stackable, exclusive []*domain.Promotion
+	for n := range automatic {
+		promotion := &automatic[n]
+		if usableFor(promotion, order, now, covers) != nil || (promotion.MaxUses > 0 && promotion.Uses >= promotion.MaxUses) {
+			continue
+		}
+		if promotion.Stackable {
+			stackable = append(stackable, promotion)
+		} else {
+			exclusive = append(exclusive, promotion)
+		}
+	}
+
+	var options [][]*domain.Promotion
+	switch {
+	case len(coupons) == 1 && !coupons[0].Stackable:
+		options = [][]*domain.Promotion{coupons}
+	case len(coupons) > 0:
+		for _, coupon := range coupons {
+			if !coupon.Stackable {
+				return fmt.Errorf("%w: coupon %s cannot be combined with other coupons", ErrInvalidCoupon, coupon.Code)
+			}
+		}
+		options = [][]*domain.Promotion{append(coupons, stackable...)}
+	default:
+		options = append(options, stackable)
+		for _, promotion := range exclusive {
+			options = append(options, []*domain.Promotion{promotion})
+		}
+	}
+
+	var best []domain.OrderItem
+	var bestDiscounts []domain.AppliedDiscount
+	bestSaving := int64(-1)
+	for _, option := range options {
+		items, discounts := discountItems(order.Items, option, covers)
+		saving := int64(0)
+		for _, discount := range discounts {
+			saving += discount.Amount
+		}
+		if saving > bestSaving {
+			best, bestDiscounts, bestSaving = items, discounts, saving
+		}
+	}
+
+	for _, coupon := range coupons {
+		if !hasDiscount(bestDiscounts, coupon.ID) {
+			return fmt.Errorf("%w: coupon %s does not take anything off this order", ErrInvalidCoupon, coupon.Code)
+		}
+	}
+	order.Items, order.Discounts = best, bestDiscounts
+	return nil
+}
+
+// coupons looks up the entered codes and checks that each can be used on
+// the order.
+func (p *promotionUseCase) coupons(ctx context.Context, order *domain.Order, codes []string, now time.Time, covers func(*domain.Promotion, *domain.OrderItem) bool) ([]*domain.Promotion, error) {
+	seen := map[string]bool{}
+	var coupons []*domain.Promotion
+	for _, code := range codes {
+		code = domain.NormalizeCouponCode(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		if len(seen) > maxCouponCodes {
+			return nil, fmt.Errorf("%w: at most %d coupons can be used at once", ErrInvalidCoupon, maxCouponCodes)
+		}
+
+		coupon, err := p.repo.GetPromotionByCode(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+		if coupon == nil || !coupon.ActiveAt(now) {
+			return nil, fmt.Errorf("%w: coupon %s is not valid", ErrInvalidCoupon, code)
+		}
+		if coupon.MaxUses > 0 && coupon.Uses >= coupon.MaxUses {
+			return nil, fmt.Errorf("%w: coupon %s has been used up", ErrInvalidCoupon, code)
+		}
+		if err := usableFor(coupon, order, now, covers); err != nil {
+			return nil, fmt.Errorf("%w: coupon %s %s", ErrInvalidCoupon, code, err)
+		}
+		coupons = append(coupons, coupon)
+	}
+	return coupons, nil
+}
+
+// usableFor checks the promotion's conditions against the order.
+func usableFor(promotion *domain.Promotion, order *domain.Order, now time.Time, covers func(*domain.Promotion, *domain.OrderItem) bool) error {
+	if !promotion.ActiveAt(now) {
+		return errors.New("is not active")
+	}
+	if promotion.Type == domain.DiscountFixed && !strings.EqualFold(promotion.Currency, order.Currency) {
+		return fmt.Errorf("is only valid for orders in %s", strings.ToUpper(promotion.Currency))
+	}
+
+	subtotal := int64(0)
+	covered := false
+	for n := range order.Items {
+		subtotal += order.Items[n].UnitPrice * order.Items[n].Quantity
+		covered = covered || covers(promotion, &order.Items[n])
+	}
+	if subtotal < promotion.MinOrderValue {
+		return fmt.Errorf("requires an order of at least %d", promotion.MinOrderValue)
+	}
+	if !covered {
+		return errors.New("does not apply to any item in the order")
+	}
+	return nil
+}
+
+// discountItems applies promotions to a copy of items. Percentage discounts
+// are taken first, then fixed amounts, each from what is left of the lines
+// it covers and spread over them in proportion.
+func discountItems(items []domain.OrderItem, promotions []*domain.Promotion, covers func(*domain.Promotion, *domain.OrderItem) bool) ([]domain.OrderItem, []domain.AppliedDiscount) {
+	discounted := append([]domain.OrderItem(nil), items...)
+	ordered := append([]*domain.Promotion(nil), promotions...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Type == domain.DiscountPercentage && ordered[j].Type != domain.DiscountPercentage
+	})
+
+	var discounts []domain.AppliedDiscount
+	for _, promotion := range ordered {
+		weights := make([]int64, len(discounted))
+		base := int64(0)
+		for n := range discounted {
+			if covers(promotion, &discounted[n]) {
+				weights[n] = discounted[n].UnitPrice*discounted[n].Quantity - discounted[n].Discount
+				base += weights[n]
+			}
+		}
+
+		amount := promotion.Value
+		if promotion.Type == domain.DiscountPercentage {
+			amount = (base*promotion.Value + 50) / 100
+		}
+		amount = min(amount, base)
+		if amount <= 0 {
+			continue
+		}
+
+		for n, part := range domain.Allocate(amount, weights) {
+			discounted[n].Discount += part
+		}
+		discounts = append(discounts, domain.AppliedDiscount{PromotionID: promotion.ID, Code: promotion.Code, Name: promotion.Name, Amount: amount})
+	}
+	return discounted, discounts
+}
+
+func hasDiscount(discounts []domain.AppliedDiscount, promotionID primitive.ObjectID) bool {
+	for _, discount := range discounts {
+		if discount.PromotionID == promotionID {
+			return true
+		}
+	}
+	return false
+}
+
+// RedeemPromotions counts a use of every promotion applied to the order. If
+// one has reached a limit since it was applied, the uses already counted
+// are given back and the checkout fails.
+func (p *promotionUseCase) RedeemPromotions(ctx context.Context, order *domain.Order) error {
+	for n, discount := range order.Discounts {
+		promotion, err := p.repo.GetPromotionByID(ctx, discount.PromotionID)
+		if err == nil {
+			var ok bool
+			ok, err = p.repo.RedeemPromotion(ctx, promotion, order.CustomerID, order.ID)
+			if err == nil && !ok {
+				err = fmt.Errorf("%w: %s has reached its usage limit", ErrInvalidCoupon, discount.Name)
+			}
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			err = fmt.Errorf("%w: %s is no longer available", ErrInvalidCoupon, discount.Name)
+		}
+		if err != nil {
+			p.release(context.WithoutCancel(ctx), order, order.Discounts[:n])
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleasePromotions gives back the uses counted for an order that will not
+// be paid, such as a cancelled one.
+func (p *promotionUseCase) ReleasePromotions(ctx context.Context, order *domain.Order) {
+	p.release(ctx, order, order.Discounts)
+}
+
+func (p *promotionUseCase) release(ctx context.Context, order *domain.Order, discounts []domain.AppliedDiscount) {
+	for _, discount := range discounts {
+		if err := p.repo.ReleasePromotion(ctx, discount.PromotionID, order.CustomerID, order.ID); err != nil {
+			slog.Error(fmt.Sprintf("Failed to release promotion %s of order %s: %s", discount.PromotionID.Hex(), order.ID.Hex(), err))
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryPromotions is an in-memory PromotionRepository counting uses per
+// customer.
+type memoryPromotions struct {
+	repository.PromotionRepository
+
+	promotions []*domain.Promotion
+	uses       map[string][]primitive.ObjectID
+}
+
+func newMemoryPromotions(promotions ...domain.Promotion) *memoryPromotions {
+	m := &memoryPromotions{uses: map[string][]primitive.ObjectID{}}
+	for n := range promotions {
+		promotion := promotions[n]
+		promotion.ID = primitive.NewObjectID()
+		promotion.Active = true
+		m.promotions = append(m.promotions, &promotion)
+	}
+	return m
+}
+
+func (m *memoryPromotions) GetPromotionByID(ctx context.Context, id primitive.ObjectID) (*domain.Promotion, error) {
+	for _, promotion := range m.promotions {
+		if promotion.ID == id {
+			copied := *promotion
+			return &copied, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryPromotions) GetPromotionByCode(ctx context.Context, code string) (*domain.Promotion, error) {
+	for _, promotion := range m.promotions {
+		if promotion.Code == code {
+			copied := *promotion
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryPromotions) GetAutomaticPromotions(ctx context.Context, now time.Time) ([]domain.Promotion, error) {
+	var promotions []domain.Promotion
+	for _, promotion := range m.promotions {
+		if promotion.Code == "" && promotion.ActiveAt(now) {
+			promotions = append(promotions, *promotion)
+		}
+	}
+	return promotions, nil
+}
+
+func (m *memoryPromotions) RedeemPromotion(ctx context.Context, promotion *domain.Promotion, customerID string, orderID primitive.ObjectID) (bool, error) {
+	stored, _ := m.GetPromotionByID(ctx, promotion.ID)
+	key := promotion.ID.Hex() + ":" + customerID
+	if (stored.MaxUses > 0 && stored.Uses >= stored.MaxUses) ||
+		(stored.MaxUsesPerCustomer > 0 && int64(len(m.uses[key])) >= stored.MaxUsesPerCustomer) {
+		return false, nil
+	}
+	m.find(promotion.ID).Uses++
+	m.uses[key] = append(m.uses[key], orderID)
+	return true, nil
+}
+
+func (m *memoryPromotions) ReleasePromotion(ctx context.Context, promotionID primitive.ObjectID, customerID string, orderID primitive.ObjectID) error {
+	key := promotionID.Hex() + ":" + customerID
+	for n, id := range m.uses[key] {
+		if id == orderID {
+			m.uses[key] = append(m.uses[key][:n], m.uses[key][n+1:]...)
+			m.find(promotionID).Uses--
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *memoryPromotions) find(id primitive.ObjectID) *domain.Promotion {
+	for _, promotion := range m.promotions {
+		if promotion.ID == id {
+			return promotion
+		}
+	}
+	return nil
+}
+
+func newTestPromotionUseCase(promotions ...domain.Promotion) (*orderUseCase, *memoryPromotions) {
+	repo := newMemoryPromotions(promotions...)
+	catalog := newMemoryCatalog(thinkpad, macbook)
+	catalog.products["p1"].BrandID = "lenovo"
+	catalog.products["p2"].BrandID = "apple"
+	catalog.products["p2"].CategoryIDs = []string{"computers", "laptops"}
+	return newTestOrderUseCase(catalog, withPromotions(NewPromotionUseCase(repo))), repo
+}
+
+func TestPromotionsDiscountScopedLines(t *testing.T) {
+	uc, _ := newTestPromotionUseCase(
+		domain.Promotion{Name: "Laptop week", Type: domain.DiscountPercentage, Value: 10, Scope: domain.PromotionScope{CategoryIDs: []string{"computers"}}, Stackable: true},
+		domain.Promotion{Name: "Lenovo 5000", Code: "LENOVO", Type: domain.DiscountFixed, Value: 500000, Currency: "kzt", Scope: domain.PromotionScope{BrandIDs: []string{"lenovo"}}, Stackable: true},
+	)
+
+	items := []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}, {ProductID: "p2", VariantID: "v1", Quantity: 1}}
+	order, err := uc.PlaceOrder(context.Background(), "alice", domain.CheckoutInput{Items: items, CouponCodes: []string{" lenovo "}})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	if order.Items[0].Discount != 500000 || order.Items[1].Discount != 5999900 {
+		t.Errorf("line discounts %d and %d, want 500000 and 5999900", order.Items[0].Discount, order.Items[1].Discount)
+	}
+	if order.Discount != 6499900 || order.Total != order.Subtotal-6499900 || len(order.Discounts) != 2 {
+		t.Errorf("discount %d of %d in %d promotions, want 6499900 of %d in 2", order.Discount, order.Total, len(order.Discounts), order.Subtotal-6499900)
+	}
+}
+
+func TestPromotionsRejectUnusableCoupons(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion domain.Promotion
+		codes     []string
+	}{
+		{"unknown", domain.Promotion{Name: "Ten", Code: "TEN", Type: domain.DiscountPercentage, Value: 10}, []string{"ELEVEN"}},
+		{"minimum", domain.Promotion{Name: "Big", Code: "BIG", Type: domain.DiscountPercentage, Value: 10, MinOrderValue: 100000000}, []string{"BIG"}},
+		{"out of scope", domain.Promotion{Name: "Apple", Code: "APPLE", Type: domain.DiscountPercentage, Value: 10, Scope: domain.PromotionScope{BrandIDs: []string{"apple"}}}, []string{"APPLE"}},
+		{"currency", domain.Promotion{Name: "Dollars", Code: "USD", Type: domain.DiscountFixed, Value: 1000, Currency: "usd"}, []string{"USD"}},
+		{"used up", domain.Promotion{Name: "Once", Code: "ONCE", Type: domain.DiscountPercentage, Value: 10, MaxUses: 1, Uses: 1}, []string{"ONCE"}},
+	}
+	for _, tt := range tests {
+		uc, _ := newTestPromotionUseCase(tt.promotion)
+		_, err := uc.PlaceOrder(context.Background(), "alice", domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}}, CouponCodes: tt.codes})
+		if !errors.Is(err, ErrInvalidCoupon) {
+			t.Errorf("%s: err = %v, want ErrInvalidCoupon", tt.name, err)
+		}
+	}
+}
+
+func TestPromotionsStacking(t *testing.T) {
+	items := []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}}
+	ctx := context.Background()
+
+	// without coupons the better of the stackable set and each exclusive
+	// promotion wins
+	uc, _ := newTestPromotionUseCase(
+		domain.Promotion{Name: "Five", Type: domain.DiscountPercentage, Value: 5, Stackable: true},
+		domain.Promotion{Name: "Three", Type: domain.DiscountPercentage, Value: 3, Stackable: true},
+		domain.Promotion{Name: "Twenty", Type: domain.DiscountPercentage, Value: 20},
+		domain.Promotion{Name: "Exclusive", Code: "ALONE", Type: domain.DiscountPercentage, Value: 1},
+		domain.Promotion{Name: "Extra", Code: "EXTRA", Type: domain.DiscountPercentage, Value: 1, Stackable: true},
+	)
+	order, err := uc.PlaceOrder(ctx, "alice", domain.CheckoutInput{Items: items})
+	if err != nil || len(order.Discounts) != 1 || order.Discounts[0].Name != "Twenty" {
+		t.Fatalf("automatic promotions = %+v, %v; want Twenty alone", order.Discounts, err)
+	}
+
+	// an exclusive coupon replaces the automatic promotions
+	order, err = uc.PlaceOrder(ctx, "alice", domain.CheckoutInput{Items: items, CouponCodes: []string{"alone"}})
+	if err != nil || len(order.Discounts) != 1 || order.Discounts[0].Code != "ALONE" {
+		t.Fatalf("exclusive coupon = %+v, %v; want ALONE alone", order, err)
+	}
+
+	// a stackable coupon joins the stackable automatic promotions
+	order, err = uc.PlaceOrder(ctx, "alice", domain.CheckoutInput{Items: items, CouponCodes: []string{"EXTRA"}})
+	if err != nil || len(order.Discounts) != 3 {
+		t.Fatalf("stackable coupon = %+v, %v; want three promotions", order, err)
+	}
+
+	if _, err := uc.PlaceOrder(ctx, "alice", domain.CheckoutInput{Items: items, CouponCodes: []string{"ALONE", "EXTRA"}}); !errors.Is(err, ErrInvalidCoupon) {
+		t.Errorf("exclusive coupon with another: err = %v, want ErrInvalidCoupon", err)
+	}
+}
+
+func TestPromotionsRedeemAndRelease(t *testing.T) {
+	uc, repo := newTestPromotionUseCase(
+		domain.Promotion{Name: "Welcome", Code: "WELCOME", Type: domain.DiscountFixed, Value: 100000, Currency: "kzt", MaxUsesPerCustomer: 1},
+	)
+	ctx := context.Background()
+	input := domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}}, CouponCodes: []string{"WELCOME"}}
+
+	order, err := uc.PlaceOrder(ctx, "alice", input)
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if repo.promotions[0].Uses != 1 {
+		t.Fatalf("uses = %d, want 1", repo.promotions[0].Uses)
+	}
+	if _, err := uc.PlaceOrder(ctx, "alice", input); !errors.Is(err, ErrInvalidCoupon) {
+		t.Errorf("second use by alice: err = %v, want ErrInvalidCoupon", err)
+	}
+	if _, err := uc.PlaceOrder(ctx, "bob", input); err != nil {
+		t.Errorf("first use by bob: %v", err)
+	}
+
+	if _, err := uc.CancelCustomerOrder(ctx, "alice", order.ID); err != nil {
+		t.Fatalf("CancelCustomerOrder: %v", err)
+	}
+	if _, err := uc.PlaceOrder(ctx, "alice", input); err != nil {
+		t.Errorf("use after cancelling: %v", err)
+	}
+}
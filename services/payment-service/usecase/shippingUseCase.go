@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrShippingZoneNotFound is returned when a shipping zone does not
+	// exist.
+	ErrShippingZoneNotFound = errors.New("shipping zone not found")
+	// ErrInvalidShippingZone is returned when a shipping zone fails
+	// validation.
+	ErrInvalidShippingZone = errors.New("invalid shipping zone")
+	// ErrInvalidShipping is returned when a checkout has no shipping
+	// address, or no shipping method that can deliver the order there.
+	ErrInvalidShipping = errors.New("invalid shipping")
+)
+
+type ShippingUseCase interface {
+	GetAllShippingZones(ctx context.Context) ([]domain.ShippingZone, error)
+	GetShippingZoneByID(ctx context.Context, id primitive.ObjectID) (*domain.ShippingZone, error)
+	CreateShippingZone(ctx context.Context, zone *domain.ShippingZone) error
+	UpdateShippingZone(ctx context.Context, zone *domain.ShippingZone) error
+	DeleteShippingZone(ctx context.Context, id primitive.ObjectID) error
+}
+
+type shippingUseCase struct {
+	repo repository.ShippingZoneRepository
+}
+
+// NewShippingUseCase manages shipping zones and, as the Shipping of
+// checkout, prices delivery of orders. Shipping is only required once a
+// zone exists.
+func NewShippingUseCase(repo repository.ShippingZoneRepository) *shippingUseCase {
+	return &shippingUseCase{repo: repo}
+}
+
+func (s *shippingUseCase) GetAllShippingZones(ctx context.Context) ([]domain.ShippingZone, error) {
+	return s.repo.GetAllShippingZones(ctx)
+}
+
+func (s *shippingUseCase) GetShippingZoneByID(ctx context.Context, id primitive.ObjectID) (*domain.ShippingZone, error) {
+	zone, err := s.repo.GetShippingZoneByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrShippingZoneNotFound
+	}
+	return zone, err
+}
+
+func (s *shippingUseCase) CreateShippingZone(ctx context.Context, zone *domain.ShippingZone) error {
+	if err := s.validate(ctx, zone); err != nil {
+		return err
+	}
+	return s.repo.CreateShippingZone(ctx, zone)
+}
+
+func (s *shippingUseCase) UpdateShippingZone(ctx context.Context, zone *domain.ShippingZone) error {
+	if err := s.validate(ctx, zone); err != nil {
+		return err
+	}
+	err := s.repo.UpdateShippingZone(ctx, zone)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrShippingZoneNotFound
+	}
+	return err
+}
+
+func (s *shippingUseCase) DeleteShippingZone(ctx context.Context, id primitive.ObjectID) error {
+	err := s.repo.DeleteShippingZone(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrShippingZoneNotFound
+	}
+	return err
+}
+
+// validate checks the zone and that no other zone already covers its
+// countries, or the rest of the world when it lists none.
+func (s *shippingUseCase) validate(ctx context.Context, zone *domain.ShippingZone) error {
+	if err := zone.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidShippingZone, err)
+	}
+
+	zones, err := s.repo.GetAllShippingZones(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range zones {
+		if other.ID == zone.ID {
+			continue
+		}
+		if len(zone.Countries) == 0 && len(other.Countries) == 0 {
+			return fmt.Errorf("%w: zone %s already covers the rest of the world", ErrInvalidShippingZone, other.Name)
+		}
+		for _, country := range zone.Countries {
+			if other.Covers(country) {
+				return fmt.Errorf("%w: %s is already in zone %s", ErrInvalidShippingZone, country, other.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// QuoteShipping returns the methods that can deliver the order to its
+// shipping address and their fees. products holds the catalogue entry of
+// every item, for its weight. Methods priced by weight are left out when an
+// item's weight is unknown, and methods in another currency always are.
+func (s *shippingUseCase) QuoteShipping(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct) ([]domain.ShippingQuote, error) {
+	quotes, _, err := s.quotes(ctx, order, products)
+	return quotes, err
+}
+
+// ApplyShipping charges the order the fee of the shipping method chosen,
+// which must be one QuoteShipping offers. Without shipping zones orders are
+// not shipped and methodID must be empty.
+func (s *shippingUseCase) ApplyShipping(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct, methodID string) error {
+	quotes, shipped, err := s.quotes(ctx, order, products)
+	if err != nil {
+		return err
+	}
+	if !shipped {
+		if methodID != "" {
+			return fmt.Errorf("%w: no shipping methods are offered", ErrInvalidShipping)
+		}
+		return nil
+	}
+	if len(quotes) == 0 {
+		return fmt.Errorf("%w: no shipping method can deliver this order to %s", ErrInvalidShipping, order.ShippingAddress.Country)
+	}
+	if methodID == "" {
+		return fmt.Errorf("%w: choose a shipping method", ErrInvalidShipping)
+	}
+
+	for _, quote := range quotes {
+		if quote.MethodID.Hex() == methodID {
+			order.Shipping = &domain.OrderShipping{MethodID: quote.MethodID, Name: quote.Name, Amount: quote.Amount}
+			return order.Validate()
+		}
+	}
+	return fmt.Errorf("%w: shipping method %s is not available for this order", ErrInvalidShipping, methodID)
+}
+
+// quotes prices the methods of the zone serving the order. shipped is false
+// when there are no zones at all.
+func (s *shippingUseCase) quotes(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct) (quotes []domain.ShippingQuote, shipped bool, err error) {
+	zones, err := s.repo.GetAllShippingZones(ctx)
+	if err != nil || len(zones) == 0 {
+		return nil, false, err
+	}
+	if order.ShippingAddress == nil {
+		return nil, true, fmt.Errorf("%w: a shipping address is required", ErrInvalidShipping)
+	}
+	zone := domain.ZoneFor(zones, order.ShippingAddress.Country)
+	if zone == nil {
+		return nil, true, nil
+	}
+
+	var weight int64
+	weighed := true
+	for _, item := range order.Items {
+		grams, ok := int64(0), false
+		if product := products[item.ProductID]; product != nil {
+			grams, ok = product.Weight(item.VariantID)
+		}
+		weighed = weighed && ok
+		weight += grams * item.Quantity
+	}
+
+	quotes = []domain.ShippingQuote{}
+	for _, method := range zone.Methods {
+		if method.Currency != order.Currency || (method.Basis == domain.ShippingByWeight && !weighed) {
+			continue
+		}
+		if fee, ok := method.Fee(weight, order.Subtotal-order.Discount); ok {
+			quotes = append(quotes, domain.ShippingQuote{MethodID: method.ID, Name: method.Name, Amount: fee, Currency: method.Currency})
+		}
+	}
+	return quotes, true, nil
+}
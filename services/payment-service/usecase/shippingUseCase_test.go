@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryShippingZones is an in-memory ShippingZoneRepository.
+type memoryShippingZones struct {
+	repository.ShippingZoneRepository
+
+	zones []domain.ShippingZone
+}
+
+func (m *memoryShippingZones) GetAllShippingZones(ctx context.Context) ([]domain.ShippingZone, error) {
+	return m.zones, nil
+}
+
+func newTestShippingUseCase(zones ...domain.ShippingZone) *shippingUseCase {
+	repo := &memoryShippingZones{}
+	for _, zone := range zones {
+		zone.ID = primitive.NewObjectID()
+		if err := zone.Validate(); err != nil {
+			panic(err)
+		}
+		repo.zones = append(repo.zones, zone)
+	}
+	return NewShippingUseCase(repo)
+}
+
+func TestShippingQuotesAndCharges(t *testing.T) {
+	shipping := newTestShippingUseCase(domain.ShippingZone{Name: "Kazakhstan", Countries: []string{"kz"}, Methods: []domain.ShippingMethod{
+		{Name: "Courier", Basis: domain.ShippingByWeight, Currency: "KZT", Active: true, Rates: []domain.ShippingRate{{Min: 0, Max: 2000, Amount: 150000}, {Min: 2000, Amount: 300000}}},
+		{Name: "Post", Basis: domain.ShippingByPrice, Currency: "kzt", Active: true, Rates: []domain.ShippingRate{{Min: 0, Max: 50000000, Amount: 200000}, {Min: 50000000, Amount: 0}}},
+		{Name: "Air", Basis: domain.ShippingByPrice, Currency: "usd", Active: true, Rates: []domain.ShippingRate{{Amount: 2000}}},
+		{Name: "Retired", Basis: domain.ShippingByPrice, Currency: "kzt", Rates: []domain.ShippingRate{{Amount: 1}}},
+	}})
+	catalog := newMemoryCatalog(thinkpad, macbook)
+	weight := int64(1120)
+	catalog.products["p1"].WeightGrams = &weight
+	uc := newTestOrderUseCase(catalog, withShipping(shipping))
+	ctx := context.Background()
+	almaty := &domain.Address{Name: "A", Line1: "1 Abay Ave", City: "Almaty", PostalCode: "050000", Country: "KZ"}
+
+	// two laptops weigh 2240 g and cost well over the free post threshold
+	input := domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 2}}, ShippingAddress: almaty}
+	quotes, err := uc.ShippingOptions(ctx, "", input)
+	if err != nil {
+		t.Fatalf("ShippingOptions: %v", err)
+	}
+	if len(quotes) != 2 || quotes[0].Name != "Courier" || quotes[0].Amount != 300000 || quotes[1].Name != "Post" || quotes[1].Amount != 0 {
+		t.Fatalf("quotes = %+v, want Courier at 300000 and free Post", quotes)
+	}
+
+	if _, err := uc.PlaceOrder(ctx, "alice", input); !errors.Is(err, ErrInvalidShipping) {
+		t.Errorf("no method: err = %v, want ErrInvalidShipping", err)
+	}
+	input.ShippingMethodID = quotes[0].MethodID.Hex()
+	order, err := uc.PlaceOrder(ctx, "alice", input)
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if order.Shipping == nil || order.Shipping.Amount != 300000 || order.Total != 2*74999999+300000 {
+		t.Errorf("shipping %+v and total %d, want Courier at 300000 on top of %d", order.Shipping, order.Total, 2*74999999)
+	}
+
+	// the MacBook has no weight, so only the price-based method is offered
+	quotes, err = uc.ShippingOptions(ctx, "alice", domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p2", VariantID: "v1", Quantity: 1}}, ShippingAddress: almaty})
+	if err != nil || len(quotes) != 1 || quotes[0].Name != "Post" {
+		t.Errorf("unweighed quotes = %+v, %v; want Post alone", quotes, err)
+	}
+
+	berlin := &domain.Address{Name: "A", Line1: "1 Unter den Linden", City: "Berlin", PostalCode: "10117", Country: "DE"}
+	input.ShippingAddress = berlin
+	if _, err := uc.PlaceOrder(ctx, "alice", input); !errors.Is(err, ErrInvalidShipping) {
+		t.Errorf("outside every zone: err = %v, want ErrInvalidShipping", err)
+	}
+	input.ShippingAddress = nil
+	if _, err := uc.PlaceOrder(ctx, "alice", input); !errors.Is(err, ErrInvalidShipping) {
+		t.Errorf("without an address: err = %v, want ErrInvalidShipping", err)
+	}
+}
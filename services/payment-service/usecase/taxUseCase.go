@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrTaxRateNotFound is returned when a tax rate does not exist.
+	ErrTaxRateNotFound = errors.New("tax rate not found")
+	// ErrInvalidTaxRate is returned when a tax rate fails validation.
+	ErrInvalidTaxRate = errors.New("invalid tax rate")
+)
+
+// TaxCalculator works out the tax on an order. The built-in rate table is
+// one; an external tax service can take its place.
+type TaxCalculator interface {
+	CalculateTax(ctx context.Context, req domain.TaxRequest) (*domain.TaxResult, error)
+}
+
+type TaxUseCase interface {
+	GetAllTaxRates(ctx context.Context) ([]domain.TaxRate, error)
+	GetTaxRateByID(ctx context.Context, id primitive.ObjectID) (*domain.TaxRate, error)
+	CreateTaxRate(ctx context.Context, rate *domain.TaxRate) error
+	UpdateTaxRate(ctx context.Context, rate *domain.TaxRate) error
+	DeleteTaxRate(ctx context.Context, id primitive.ObjectID) error
+}
+
+type taxUseCase struct {
+	repo             repository.TaxRateRepository
+	calculator       TaxCalculator
+	pricesIncludeTax bool
+	defaultCountry   string
+}
+
+// NewTaxUseCase manages the tax table and, as the Taxes of checkout, taxes
+// orders with calculator. pricesIncludeTax says whether catalogue prices
+// already include tax. Orders without an address are taxed as if shipped
+// to defaultCountry.
+func NewTaxUseCase(repo repository.TaxRateRepository, calculator TaxCalculator, pricesIncludeTax bool, defaultCountry string) *taxUseCase {
+	return &taxUseCase{
+		repo:             repo,
+		calculator:       calculator,
+		pricesIncludeTax: pricesIncludeTax,
+		defaultCountry:   strings.ToUpper(defaultCountry),
+	}
+}
+
+func (t *taxUseCase) GetAllTaxRates(ctx context.Context) ([]domain.TaxRate, error) {
+	return t.repo.GetAllTaxRates(ctx)
+}
+
+func (t *taxUseCase) GetTaxRateByID(ctx context.Context, id primitive.ObjectID) (*domain.TaxRate, error) {
+	rate, err := t.repo.GetTaxRateByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTaxRateNotFound
+	}
+	return rate, err
+}
+
+func (t *taxUseCase) CreateTaxRate(ctx context.Context, rate *domain.TaxRate) error {
+	if err := rate.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidTaxRate, err)
+	}
+	return t.repo.CreateTaxRate(ctx, rate)
+}
+
+func (t *taxUseCase) UpdateTaxRate(ctx context.Context, rate *domain.TaxRate) error {
+	if err := rate.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidTaxRate, err)
+	}
+	err := t.repo.UpdateTaxRate(ctx, rate)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrTaxRateNotFound
+	}
+	return err
+}
+
+func (t *taxUseCase) DeleteTaxRate(ctx context.Context, id primitive.ObjectID) error {
+	err := t.repo.DeleteTaxRate(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrTaxRateNotFound
+	}
+	return err
+}
+
+// ApplyTax taxes the order's discounted lines by where it ships: the
+// shipping address, else the billing address, else the default country.
+// products holds the catalogue entry of every item, for its tax class. The
+// shipping fee is not taxed.
+func (t *taxUseCase) ApplyTax(ctx context.Context, order *domain.Order, products map[string]*domain.CatalogProduct) error {
+	destination := domain.Address{Country: t.defaultCountry}
+	switch {
+	case order.ShippingAddress != nil:
+		destination = *order.ShippingAddress
+	case order.BillingAddress != nil:
+		destination = *order.BillingAddress
+	}
+
+	req := domain.TaxRequest{Currency: order.Currency, Destination: destination, PricesIncludeTax: t.pricesIncludeTax}
+	for _, item := range order.Items {
+		line := domain.TaxLine{ProductID: item.ProductID, Quantity: item.Quantity, Amount: item.UnitPrice*item.Quantity - item.Discount}
+		if product := products[item.ProductID]; product != nil {
+			line.TaxClass = product.TaxClass
+		}
+		req.Lines = append(req.Lines, line)
+	}
+
+	result, err := t.calculator.CalculateTax(ctx, req)
+	if err != nil {
+		return fmt.Errorf("tax calculation failed: %w", err)
+	}
+	if err := order.SetTax(result, t.pricesIncludeTax); err != nil {
+		return fmt.Errorf("tax calculation failed: %w", err)
+	}
+	return nil
+}
+
+type rateTable struct {
+	repo repository.TaxRateRepository
+}
+
+// NewRateTable is the built-in TaxCalculator, charging the rates of the tax
+// table.
+func NewRateTable(repo repository.TaxRateRepository) *rateTable {
+	return &rateTable{repo: repo}
+}
+
+// CalculateTax charges every rate that matches a line's destination and
+// tax class. A class without a rate for the destination is not taxed there.
+func (r *rateTable) CalculateTax(ctx context.Context, req domain.TaxRequest) (*domain.TaxResult, error) {
+	rates, err := r.repo.GetTaxRatesByCountry(ctx, strings.ToUpper(req.Destination.Country))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.TaxResult{Lines: make([]int64, len(req.Lines))}
+	applied := map[primitive.ObjectID]int{}
+	for n, line := range req.Lines {
+		var matching []domain.TaxRate
+		var basisPoints []int64
+		for _, rate := range rates {
+			if rate.Applies(req.Destination, line.TaxClass) {
+				matching = append(matching, rate)
+				basisPoints = append(basisPoints, rate.Rate)
+			}
+		}
+
+		for i, amount := range domain.LineTaxes(line.Amount, basisPoints, req.PricesIncludeTax) {
+			rate := matching[i]
+			result.Lines[n] += amount
+			index, ok := applied[rate.ID]
+			if !ok {
+				index = len(result.Taxes)
+				applied[rate.ID] = index
+				result.Taxes = append(result.Taxes, domain.AppliedTax{Name: rate.Name, Country: rate.Country, State: rate.State, Rate: rate.Rate})
+			}
+			result.Taxes[index].Amount += amount
+		}
+	}
+	return result, nil
+}
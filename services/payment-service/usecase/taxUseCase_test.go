@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mephirious/group-project/services/payment-service/domain"
+	"github.com/mephirious/group-project/services/payment-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryTaxRates is an in-memory TaxRateRepository.
+type memoryTaxRates struct {
+	repository.TaxRateRepository
+
+	rates []domain.TaxRate
+}
+
+func newMemoryTaxRates(rates ...domain.TaxRate) *memoryTaxRates {
+	m := &memoryTaxRates{}
+	for _, rate := range rates {
+		rate.ID = primitive.NewObjectID()
+		if err := rate.Validate(); err != nil {
+			panic(err)
+		}
+		m.rates = append(m.rates, rate)
+	}
+	return m
+}
+
+func (m *memoryTaxRates) GetTaxRatesByCountry(ctx context.Context, country string) ([]domain.TaxRate, error) {
+	var rates []domain.TaxRate
+	for _, rate := range m.rates {
+		if rate.Country == country {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+func newTestTaxUseCase(pricesIncludeTax bool, rates ...domain.TaxRate) *taxUseCase {
+	repo := newMemoryTaxRates(rates...)
+	return NewTaxUseCase(repo, NewRateTable(repo), pricesIncludeTax, "KZ")
+}
+
+func newTaxedOrderUseCase(pricesIncludeTax bool, rates ...domain.TaxRate) *orderUseCase {
+	catalog := newMemoryCatalog(thinkpad, macbook)
+	catalog.products["p2"].TaxClass = "reduced"
+	return newTestOrderUseCase(catalog, withTaxes(newTestTaxUseCase(pricesIncludeTax, rates...)))
+}
+
+var testTaxRates = []domain.TaxRate{
+	{Name: "VAT", Country: "kz", Rate: 1200},
+	{Name: "GST", Country: "CA", Rate: 500},
+	{Name: "GST", Country: "CA", TaxClass: "reduced", Rate: 500},
+	{Name: "QST", Country: "CA", State: "QC", Rate: 997},
+}
+
+func TestTaxInclusivePrices(t *testing.T) {
+	uc := newTaxedOrderUseCase(true, testTaxRates...)
+
+	order, err := uc.PlaceOrder(context.Background(), "alice", domain.CheckoutInput{Items: []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	// 74999999 includes 12% VAT: 74999999 - 74999999/1.12 = 8035714
+	if order.Tax != 8035714 || order.Total != 74999999 || !order.TaxInclusive {
+		t.Errorf("tax %d of total %d, want 8035714 included in 74999999", order.Tax, order.Total)
+	}
+	if len(order.Taxes) != 1 || order.Taxes[0].Name != "VAT" || order.Taxes[0].Amount != 8035714 {
+		t.Errorf("taxes = %+v, want VAT of 8035714", order.Taxes)
+	}
+}
+
+func TestTaxAddedByDestinationAndClass(t *testing.T) {
+	uc := newTaxedOrderUseCase(false, testTaxRates...)
+	quebec := &domain.Address{Name: "A", Line1: "1 Rue", City: "Montreal", State: "qc", PostalCode: "H2X", Country: "ca"}
+
+	items := []domain.CheckoutItem{{ProductID: "p1", Quantity: 1}, {ProductID: "p2", VariantID: "v1", Quantity: 1}}
+	order, err := uc.PlaceOrder(context.Background(), "alice", domain.CheckoutInput{Items: items, ShippingAddress: quebec})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	// the laptop pays GST and QST, the reduced-class MacBook GST only
+	if order.Items[0].Tax != 3750000+7477500 || order.Items[1].Tax != 2999950 {
+		t.Errorf("line taxes %d and %d, want 11227500 and 2999950", order.Items[0].Tax, order.Items[1].Tax)
+	}
+	if order.Tax != 14227450 || order.Total != order.Subtotal+14227450 || order.Items[1].Total != 59999000+2999950 {
+		t.Errorf("tax %d of total %d, want 14227450 on top of %d", order.Tax, order.Total, order.Subtotal)
+	}
+	if len(order.Taxes) != 3 {
+		t.Errorf("taxes = %+v, want both GST rates and QST", order.Taxes)
+	}
+
+	// outside Quebec only GST applies
+	ontario := *quebec
+	ontario.State = "ON"
+	order, err = uc.PlaceOrder(context.Background(), "alice", domain.CheckoutInput{Items: items[:1], ShippingAddress: &ontario})
+	if err != nil || order.Tax != 3750000 {
+		t.Errorf("Ontario order = %v, %v; want tax of 3750000", order, err)
+	}
+}
@@ -0,0 +1,116 @@
+// Package notify delivers low-stock alerts to the outside world.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+)
+
+// Log writes alerts to the service log.
+type Log struct{}
+
+func (Log) NotifyLowStock(ctx context.Context, alert domain.StockAlert) error {
+	slog.Warn("Product is low on stock",
+		slog.String("product_id", alert.ProductID.Hex()),
+		slog.String("model_name", alert.ModelName),
+		slog.Int64("quantity", alert.Quantity),
+		slog.Int64("reorder_point", alert.ReorderPoint),
+		slog.Int64("reorder_quantity", alert.ReorderQuantity),
+	)
+	return nil
+}
+
+// Webhook posts each alert as JSON to URL.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *Webhook) NotifyLowStock(ctx context.Context, alert domain.StockAlert) error {
+	body, err := json.Marshal(map[string]interface{}{"event": "stock.low", "alert": alert})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Email sends each alert as a plain text mail through an SMTP server. Auth is
+// only used when Username is set.
+type Email struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (e *Email) NotifyLowStock(ctx context.Context, alert domain.StockAlert) error {
+	if len(e.To) == 0 {
+		return errors.New("email: no recipients")
+	}
+
+	subject := fmt.Sprintf("Low stock: %s", alert.ModelName)
+	body := fmt.Sprintf("%s (%s) has %d units in stock, at or below its reorder point of %d.\r\nOrder %d units to get back to the target level of %d.\r\n",
+		alert.ModelName, alert.ProductID.Hex(), alert.Quantity, alert.ReorderPoint, alert.ReorderQuantity, alert.TargetLevel)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s",
+		e.From, strings.Join(e.To, ", "), subject, body)
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+	if err := smtp.SendMail(fmt.Sprintf("%s:%d", e.Host, e.Port), auth, e.From, e.To, []byte(msg)); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return nil
+}
+
+// Notifier is anything that can deliver a low-stock alert.
+type Notifier interface {
+	NotifyLowStock(ctx context.Context, alert domain.StockAlert) error
+}
+
+// Multi delivers each alert through all of its notifiers, even when some of
+// them fail.
+type Multi []Notifier
+
+func (m Multi) NotifyLowStock(ctx context.Context, alert domain.StockAlert) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.NotifyLowStock(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
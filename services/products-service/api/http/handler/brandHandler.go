@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"github.com/mephirious/group-project/services/products-service/usecase"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -16,6 +17,12 @@ type BrandRequest struct {
 	BrandName string `json:"brand_name" binding:"required"`
 }
 
+var brandSort = pagination.Sort{
+	Fields:       []string{"brand_name", "created_at", "updated_at"},
+	DefaultField: "brand_name",
+	DefaultOrder: "asc",
+}
+
 type BrandHandler struct {
 	useCase usecase.BrandUseCase
 }
@@ -29,10 +36,18 @@ func NewBrandHandler(router *gin.Engine, useCase usecase.BrandUseCase) {
 	router.POST("/brands", handler.CreateBrand)
 	router.PUT("/brands/:id", handler.UpdateBrand)
 	router.DELETE("/brands/:id", handler.DeleteBrand)
+	router.GET("/brands/:id/usage", handler.GetBrandUsage)
 }
 
 func (b *BrandHandler) GetAllBrands(c *gin.Context) {
-	brands, err := b.useCase.GetAllBrands(c.Request.Context())
+	params, err := pagination.FromQuery(c, brandSort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	brands, err := b.useCase.GetAllBrands(c.Request.Context(), params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
@@ -164,9 +179,16 @@ func (b *BrandHandler) DeleteBrand(c *gin.Context) {
 		return
 	}
 
-	err = b.useCase.DeleteBrand(c.Request.Context(), objID)
+	opts, err := deleteOptionsFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	err = b.useCase.DeleteBrand(c.Request.Context(), objID, opts)
+	if err != nil {
+		c.JSON(deleteErrorStatus(err), gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
 		return
 	}
@@ -174,3 +196,22 @@ func (b *BrandHandler) DeleteBrand(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Brand deleted"})
 	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
 }
+
+func (b *BrandHandler) GetBrandUsage(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid brand ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+
+	usage, err := b.useCase.GetBrandUsage(c.Request.Context(), objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
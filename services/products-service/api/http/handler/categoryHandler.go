@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -14,6 +15,17 @@ import (
 
 type CategoryRequest struct {
 	CategoryName string `json:"category_name" binding:"required"`
+	Slug         string `json:"slug"`
+	ParentID     string `json:"parent_id"`
+}
+
+type MoveCategoryRequest struct {
+	ParentID string `json:"parent_id"`
+}
+
+type ReorderCategoriesRequest struct {
+	ParentID    string   `json:"parent_id"`
+	CategoryIDs []string `json:"category_ids" binding:"required"`
 }
 
 type CategoryHandler struct {
@@ -24,11 +36,16 @@ func NewCategoryHandler(router *gin.Engine, useCase usecase.CategoryUseCase) {
 	handler := &CategoryHandler{useCase: useCase}
 
 	router.GET("/categories", handler.GetAllCategories)
+	router.GET("/categories/tree", handler.GetCategoryTree)
 	router.GET("/categories/:id", handler.GetCategoryByID)
 	router.GET("/categories/name/:name", handler.GetCategoryByName)
+	router.GET("/categories/slug/:slug", handler.GetCategoryBySlug)
 	router.POST("/categories", handler.CreateCategory)
+	router.POST("/categories/reorder", handler.ReorderCategories)
+	router.POST("/categories/:id/move", handler.MoveCategory)
 	router.PUT("/categories/:id", handler.UpdateCategory)
 	router.DELETE("/categories/:id", handler.DeleteCategory)
+	router.GET("/categories/:id/usage", handler.GetCategoryUsage)
 }
 
 func (c *CategoryHandler) GetAllCategories(g *gin.Context) {
@@ -43,6 +60,18 @@ func (c *CategoryHandler) GetAllCategories(g *gin.Context) {
 	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
 }
 
+func (c *CategoryHandler) GetCategoryTree(g *gin.Context) {
+	tree, err := c.useCase.GetCategoryTree(g.Request.Context())
+	if err != nil {
+		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	g.JSON(http.StatusOK, tree)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
+}
+
 func (c *CategoryHandler) GetCategoryByID(g *gin.Context) {
 	id := g.Param("id")
 	objID, err := primitive.ObjectIDFromHex(id)
@@ -89,6 +118,18 @@ func (c *CategoryHandler) GetCategoryByName(g *gin.Context) {
 	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
 }
 
+func (c *CategoryHandler) GetCategoryBySlug(g *gin.Context) {
+	category, err := c.useCase.GetCategoryBySlug(g.Request.Context(), g.Param("slug"))
+	if err != nil {
+		g.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	g.JSON(http.StatusOK, category)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
+}
+
 func (c *CategoryHandler) CreateCategory(g *gin.Context) {
 	var req CategoryRequest
 	if err := g.ShouldBindJSON(&req); err != nil {
@@ -97,9 +138,18 @@ func (c *CategoryHandler) CreateCategory(g *gin.Context) {
 		return
 	}
 
+	parentID, err := parseOptionalCategoryID(req.ParentID)
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
 	category := domain.Category{
 		ID:           primitive.NewObjectID(),
 		CategoryName: req.CategoryName,
+		Slug:         req.Slug,
+		ParentID:     parentID,
 		CreatedAt:    time.Now(), // Ensure creation timestamp is set
 		UpdatedAt:    time.Now(),
 	}
@@ -123,14 +173,6 @@ func (c *CategoryHandler) UpdateCategory(g *gin.Context) {
 		return
 	}
 
-	// Fetch the existing category to preserve created_at
-	existingCategory, err := c.useCase.GetCategoryByID(g.Request.Context(), objID)
-	if err != nil {
-		g.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
-		slog.Error(fmt.Sprintf("Method %s failed: Category not found", g.Request.Method))
-		return
-	}
-
 	var req CategoryRequest
 	if err := g.ShouldBindJSON(&req); err != nil {
 		g.JSON(http.StatusBadRequest, gin.H{"error": "category_name is required"})
@@ -138,12 +180,12 @@ func (c *CategoryHandler) UpdateCategory(g *gin.Context) {
 		return
 	}
 
-	// Preserve created_at and update other fields
+	// Parent, path and position are kept by the use case; use the move
+	// endpoint to change them.
 	category := domain.Category{
 		ID:           objID,
 		CategoryName: req.CategoryName,
-		CreatedAt:    existingCategory.CreatedAt, // Preserve original created_at
-		UpdatedAt:    time.Now(),                 // Update timestamp
+		Slug:         req.Slug,
 	}
 
 	if err := c.useCase.UpdateCategory(g.Request.Context(), &category); err != nil {
@@ -156,6 +198,80 @@ func (c *CategoryHandler) UpdateCategory(g *gin.Context) {
 	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
 }
 
+func (c *CategoryHandler) MoveCategory(g *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(g.Param("id"))
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", g.Request.Method))
+		return
+	}
+
+	var req MoveCategoryRequest
+	if err := g.ShouldBindJSON(&req); err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	parentID, err := parseOptionalCategoryID(req.ParentID)
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	category, err := c.useCase.MoveCategory(g.Request.Context(), objID, parentID)
+	if errors.Is(err, usecase.ErrCategoryMoved) {
+		g.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	g.JSON(http.StatusOK, category)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
+}
+
+func (c *CategoryHandler) ReorderCategories(g *gin.Context) {
+	var req ReorderCategoriesRequest
+	if err := g.ShouldBindJSON(&req); err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "category_ids is required"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	parentID, err := parseOptionalCategoryID(req.ParentID)
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	ids := make([]primitive.ObjectID, len(req.CategoryIDs))
+	for i, raw := range req.CategoryIDs {
+		id, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+			slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+			return
+		}
+		ids[i] = id
+	}
+
+	if err := c.useCase.ReorderCategories(g.Request.Context(), parentID, ids); err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	g.JSON(http.StatusOK, gin.H{"message": "Categories reordered"})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
+}
+
 func (c *CategoryHandler) DeleteCategory(g *gin.Context) {
 	id := g.Param("id")
 	objID, err := primitive.ObjectIDFromHex(id)
@@ -165,9 +281,16 @@ func (c *CategoryHandler) DeleteCategory(g *gin.Context) {
 		return
 	}
 
-	err = c.useCase.DeleteCategory(g.Request.Context(), objID)
+	opts, err := deleteOptionsFromQuery(g)
 	if err != nil {
-		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	err = c.useCase.DeleteCategory(g.Request.Context(), objID, opts)
+	if err != nil {
+		g.JSON(deleteErrorStatus(err), gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
 		return
 	}
@@ -175,3 +298,34 @@ func (c *CategoryHandler) DeleteCategory(g *gin.Context) {
 	g.JSON(http.StatusOK, gin.H{"message": "Category deleted"})
 	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
 }
+
+func (c *CategoryHandler) GetCategoryUsage(g *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(g.Param("id"))
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", g.Request.Method))
+		return
+	}
+
+	usage, err := c.useCase.GetCategoryUsage(g.Request.Context(), objID)
+	if err != nil {
+		g.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	g.JSON(http.StatusOK, usage)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
+}
+
+// parseOptionalCategoryID treats an empty string as "no parent".
+func parseOptionalCategoryID(raw string) (*primitive.ObjectID, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := primitive.ObjectIDFromHex(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/usecase"
+)
+
+type ExchangeRateRequest struct {
+	Rate     float64         `json:"rate" binding:"required"`
+	Rounding domain.Rounding `json:"rounding"`
+}
+
+type CurrencyHandler struct {
+	useCase usecase.CurrencyUseCase
+}
+
+// NewCurrencyHandler serves the currencies products can be priced in. Any
+// of them can be asked for with the currency query parameter of the product
+// endpoints.
+func NewCurrencyHandler(router *gin.Engine, useCase usecase.CurrencyUseCase) {
+	handler := &CurrencyHandler{useCase: useCase}
+
+	router.GET("/currencies", handler.GetCurrencies)
+	router.PUT("/currencies/:currency", handler.SetExchangeRate)
+	router.DELETE("/currencies/:currency", handler.DeleteExchangeRate)
+}
+
+func (h *CurrencyHandler) GetCurrencies(c *gin.Context) {
+	currencies, err := h.useCase.GetCurrencies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, currencies)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (h *CurrencyHandler) SetExchangeRate(c *gin.Context) {
+	var req ExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	rate := domain.ExchangeRate{Currency: c.Param("currency"), Rate: req.Rate, Rounding: req.Rounding}
+	if err := h.useCase.SetExchangeRate(c.Request.Context(), &rate); err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (h *CurrencyHandler) DeleteExchangeRate(c *gin.Context) {
+	if err := h.useCase.DeleteExchangeRate(c.Request.Context(), c.Param("currency")); err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted"})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func currencyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrUnsupportedCurrency):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidPrice):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -8,19 +9,43 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"github.com/mephirious/group-project/services/products-service/usecase"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type InventoryRequest struct {
 	ProductID    string `json:"product_id" binding:"required"`
+	VariantID    string `json:"variant_id"`
 	SerialNumber string `json:"serial_number" binding:"required"`
-	Status       string `json:"status" binding:"required"`
+	Status       string `json:"status"`
+	LocationID   string `json:"location_id"`
+	Reason       string `json:"reason"`
 }
 
 type InventoryPOSTRequest struct {
 	ProductID    string `json:"product_id" binding:"required"`
+	VariantID    string `json:"variant_id"`
 	SerialNumber string `json:"serial_number" binding:"required"`
+	Status       string `json:"status"`
+	LocationID   string `json:"location_id"`
+}
+
+type InventoryTransitionRequest struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+var inventorySort = pagination.Sort{
+	Fields:       []string{"created_at", "updated_at", "serial_number", "status"},
+	DefaultField: "created_at",
+	DefaultOrder: "desc",
+}
+
+var movementSort = pagination.Sort{
+	Fields:       []string{"created_at"},
+	DefaultField: "created_at",
+	DefaultOrder: "desc",
 }
 
 type InventoryHandler struct {
@@ -35,17 +60,34 @@ func NewInventoryHandler(router *gin.Engine, useCase usecase.InventoryUseCase) {
 	router.GET("/inventories/product/:product_id", handler.GetInventoryByProductID)
 	router.GET("/inventories/serial/:serial_number", handler.GetInventoryBySerialNumber)
 	router.POST("/inventories", handler.CreateInventory)
+	router.POST("/inventories/receive", handler.ReceiveInventory)
 	router.PUT("/inventories/:id", handler.UpdateInventory)
+	router.POST("/inventories/:id/transition", handler.TransitionInventory)
+	router.GET("/inventories/:id/movements", handler.GetMovementsByInventoryID)
+	router.GET("/inventories/serial/:serial_number/movements", handler.GetMovementsBySerialNumber)
 	router.DELETE("/inventories/:id", handler.DeleteInventory)
 	router.GET("/inventories/product/:product_id/quantity", handler.GetProductQuantity)
+	router.GET("/inventories/product/:product_id/variant/:variant_id/quantity", handler.GetVariantQuantity)
+	router.GET("/inventories/reservations/metrics", handler.GetReservationMetrics)
+	router.GET("/inventories/reservations/:reservation_id", handler.GetReservationUnits)
 
 	router.POST("/payment/start", handler.StartPayment)
 	router.POST("/payment/cancel", handler.CancelPayment)
 	router.POST("/payment/success", handler.PaymentSuccess)
+	router.POST("/payment/commit", handler.PaymentCommit)
+	router.POST("/payment/ship", handler.PaymentShip)
+	router.POST("/payment/return", handler.PaymentReturn)
 }
 
 func (i *InventoryHandler) GetAllInventories(c *gin.Context) {
-	inventories, err := i.useCase.GetAllInventories(c.Request.Context())
+	params, err := pagination.FromQuery(c, inventorySort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	inventories, err := i.useCase.GetAllInventories(c.Request.Context(), params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
@@ -91,7 +133,14 @@ func (i *InventoryHandler) GetInventoryByProductID(c *gin.Context) {
 		return
 	}
 
-	inventories, err := i.useCase.GetInventoryByProductID(c.Request.Context(), objID)
+	params, err := pagination.FromQuery(c, inventorySort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	inventories, err := i.useCase.GetInventoryByProductID(c.Request.Context(), objID, params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
@@ -136,17 +185,37 @@ func (i *InventoryHandler) CreateInventory(c *gin.Context) {
 		slog.Error(fmt.Sprintf("Method %s failed: Invalid product ID", c.Request.Method))
 		return
 	}
+	variantID, err := parseOptionalID(req.VariantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid variant ID", c.Request.Method))
+		return
+	}
+	locationID, err := parseOptionalID(req.LocationID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid location ID", c.Request.Method))
+		return
+	}
 
 	inventory := domain.Inventory{
 		ID:           primitive.NewObjectID(),
 		ProductID:    productID,
+		VariantID:    variantID,
 		SerialNumber: req.SerialNumber,
-		Status:       "in_stock",
+		Status:       req.Status,
+		LocationID:   locationID,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
-	if err := i.useCase.CreateInventory(c.Request.Context(), &inventory); err != nil {
+	err = i.useCase.CreateInventory(c.Request.Context(), &inventory, actorFromRequest(c))
+	if errors.Is(err, usecase.ErrInvalidTransition) || errors.Is(err, usecase.ErrInvalidReference) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
 		return
@@ -178,16 +247,72 @@ func (i *InventoryHandler) UpdateInventory(c *gin.Context) {
 		slog.Error(fmt.Sprintf("Method %s failed: Invalid product ID", c.Request.Method))
 		return
 	}
+	variantID, err := parseOptionalID(req.VariantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid variant ID", c.Request.Method))
+		return
+	}
+	locationID, err := parseOptionalID(req.LocationID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid location ID", c.Request.Method))
+		return
+	}
 
 	inventory := domain.Inventory{
 		ID:           objID,
 		ProductID:    productID,
+		VariantID:    variantID,
 		SerialNumber: req.SerialNumber,
 		Status:       req.Status,
+		LocationID:   locationID,
 		UpdatedAt:    time.Now(),
 	}
 
-	if err := i.useCase.UpdateInventory(c.Request.Context(), &inventory); err != nil {
+	err = i.useCase.UpdateInventory(c.Request.Context(), &inventory, actorFromRequest(c), req.Reason)
+	if errors.Is(err, usecase.ErrInvalidReference) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if errors.Is(err, usecase.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, inventory)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (i *InventoryHandler) TransitionInventory(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+
+	var req InventoryTransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status is required"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	inventory, err := i.useCase.TransitionInventory(c.Request.Context(), objID, req.Status, actorFromRequest(c), req.Reason)
+	if errors.Is(err, usecase.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
 		return
@@ -197,6 +322,50 @@ func (i *InventoryHandler) UpdateInventory(c *gin.Context) {
 	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
 }
 
+func (i *InventoryHandler) GetMovementsByInventoryID(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inventory ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+	params, err := pagination.FromQuery(c, movementSort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	movements, err := i.useCase.GetMovementsByInventoryID(c.Request.Context(), objID, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, movements)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (i *InventoryHandler) GetMovementsBySerialNumber(c *gin.Context) {
+	params, err := pagination.FromQuery(c, movementSort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	movements, err := i.useCase.GetMovementsBySerialNumber(c.Request.Context(), c.Param("serial_number"), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, movements)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
 func (i *InventoryHandler) DeleteInventory(c *gin.Context) {
 	id := c.Param("id")
 	objID, err := primitive.ObjectIDFromHex(id)
@@ -232,8 +401,61 @@ func (i *InventoryHandler) GetProductQuantity(c *gin.Context) {
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
 		return
 	}
+	locations, err := i.useCase.GetProductStockByLocation(c.Request.Context(), objID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"product_id": productID, "quantity": quantity, "locations": locations})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (i *InventoryHandler) GetVariantQuantity(c *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+	variantID, err := primitive.ObjectIDFromHex(c.Param("variant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+
+	quantity, err := i.useCase.GetVariantQuantity(c.Request.Context(), productID, variantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"product_id": productID, "variant_id": variantID, "quantity": quantity})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (i *InventoryHandler) GetReservationMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, i.useCase.ReservationStats())
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+// GetReservationUnits lists the units held or sold under a reservation, for
+// staff picking an order.
+func (i *InventoryHandler) GetReservationUnits(c *gin.Context) {
+	units, err := i.useCase.GetReservationUnits(c.Request.Context(), c.Param("reservation_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if units == nil {
+		units = []domain.Inventory{}
+	}
 
-	c.JSON(http.StatusOK, gin.H{"product_id": productID, "quantity": quantity})
+	c.JSON(http.StatusOK, units)
 	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
 }
 
@@ -244,15 +466,27 @@ func (i *InventoryHandler) StartPayment(c *gin.Context) {
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
 		return
 	}
+	if order.CustomerID == "" {
+		order.CustomerID = c.GetHeader(userIDHeader)
+	}
 
-	err := i.useCase.ReserveProducts(c.Request.Context(), order)
+	reservation, err := i.useCase.ReserveProducts(c.Request.Context(), order)
+	if errors.Is(err, usecase.ErrInsufficientStock) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Products reserved"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Products reserved",
+		"reservation_id": reservation.ID,
+		"expires_at":     reservation.ExpiresAt,
+	})
 	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
 }
 
@@ -263,8 +497,21 @@ func (i *InventoryHandler) CancelPayment(c *gin.Context) {
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
 		return
 	}
+	if order.ReservationID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reservation_id is required"})
+		slog.Error(fmt.Sprintf("Method %s failed: missing reservation_id", c.Request.Method))
+		return
+	}
+	if order.CustomerID == "" {
+		order.CustomerID = c.GetHeader(userIDHeader)
+	}
 
 	err := i.useCase.CancelReservation(c.Request.Context(), order)
+	if errors.Is(err, usecase.ErrInsufficientStock) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
@@ -282,8 +529,21 @@ func (i *InventoryHandler) PaymentSuccess(c *gin.Context) {
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
 		return
 	}
+	if order.ReservationID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reservation_id is required"})
+		slog.Error(fmt.Sprintf("Method %s failed: missing reservation_id", c.Request.Method))
+		return
+	}
+	if order.CustomerID == "" {
+		order.CustomerID = c.GetHeader(userIDHeader)
+	}
 
 	err := i.useCase.MarkProductsAsSold(c.Request.Context(), order)
+	if errors.Is(err, usecase.ErrInsufficientStock) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
@@ -293,3 +553,115 @@ func (i *InventoryHandler) PaymentSuccess(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Products marked as sold"})
 	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
 }
+
+// PaymentCommit ties the reservation of a paid order to the order, so it no
+// longer expires while the order waits to ship.
+func (i *InventoryHandler) PaymentCommit(c *gin.Context) {
+	var order domain.Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if order.ReservationID == "" || order.OrderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reservation_id and order_id are required"})
+		slog.Error(fmt.Sprintf("Method %s failed: missing reservation_id or order_id", c.Request.Method))
+		return
+	}
+
+	err := i.useCase.CommitReservation(c.Request.Context(), order)
+	if errors.Is(err, usecase.ErrInsufficientStock) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reservation committed"})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+// PaymentShip sells the units of a committed reservation that leave in a
+// shipment and returns them, so the shipment can record the serial numbers
+// picked.
+func (i *InventoryHandler) PaymentShip(c *gin.Context) {
+	var order domain.Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if order.ReservationID == "" || order.OrderID == "" || order.ShipmentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reservation_id, order_id and shipment_id are required"})
+		slog.Error(fmt.Sprintf("Method %s failed: missing reservation_id, order_id or shipment_id", c.Request.Method))
+		return
+	}
+
+	units, err := i.useCase.ShipProducts(c.Request.Context(), order)
+	if errors.Is(err, usecase.ErrInsufficientStock) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Products shipped", "units": units})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+// PaymentReturn takes refunded units of a paid reservation back as returned.
+func (i *InventoryHandler) PaymentReturn(c *gin.Context) {
+	var order domain.Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if order.ReservationID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reservation_id is required"})
+		slog.Error(fmt.Sprintf("Method %s failed: missing reservation_id", c.Request.Method))
+		return
+	}
+
+	err := i.useCase.ReturnProducts(c.Request.Context(), order)
+	if errors.Is(err, usecase.ErrInsufficientStock) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Products returned"})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+// parseOptionalID returns the zero ObjectID for an empty string.
+func parseOptionalID(id string) (primitive.ObjectID, error) {
+	if id == "" {
+		return primitive.NilObjectID, nil
+	}
+	return primitive.ObjectIDFromHex(id)
+}
+
+// userIDHeader carries the authenticated user's ID, set by the gateway.
+const userIDHeader = "X-User-ID"
+
+// actorFromRequest names who made a request for the movement ledger.
+func actorFromRequest(c *gin.Context) string {
+	if id := c.GetHeader(userIDHeader); id != "" {
+		return id
+	}
+	return "anonymous"
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/usecase"
+)
+
+// maxReceiveBytes caps the size of a bulk receive upload.
+const maxReceiveBytes = 10 << 20
+
+// receiveFile returns the bulk receive payload and its format ("csv" or
+// "jsonl"). The payload is either the raw request body or the "file" field
+// of a multipart form. ?format= overrides the detected format.
+func receiveFile(c *gin.Context) (io.Reader, string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxReceiveBytes)
+
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	var (
+		body   io.Reader = c.Request.Body
+		format           = formatFromMediaType(mediaType)
+	)
+	if mediaType == "multipart/form-data" {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			return nil, "", errors.New("multipart upload must have a file field")
+		}
+		body = file
+		format = formatFromExtension(header.Filename)
+	}
+
+	if f := c.Query("format"); f != "" {
+		format = f
+	}
+	if format != "csv" && format != "jsonl" {
+		return nil, "", errors.New("format must be csv or jsonl")
+	}
+	return body, format, nil
+}
+
+func formatFromMediaType(mediaType string) string {
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return "csv"
+	case "application/x-ndjson", "application/jsonl", "application/json-lines", "application/x-jsonlines":
+		return "jsonl"
+	}
+	return ""
+}
+
+func formatFromExtension(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return "csv"
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	}
+	return ""
+}
+
+// parseReceiveCSV reads a CSV file with a header row naming the columns
+// product_id, model_name, variant_id and serial_number. Only serial_number
+// and one of product_id or model_name are required. Malformed lines are
+// returned as rows with ParseError set.
+func parseReceiveCSV(r io.Reader) ([]domain.ReceiveRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for n, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = n
+	}
+	if _, ok := columns["serial_number"]; !ok {
+		return nil, errors.New("header must include serial_number")
+	}
+	_, hasID := columns["product_id"]
+	_, hasName := columns["model_name"]
+	if !hasID && !hasName {
+		return nil, errors.New("header must include product_id or model_name")
+	}
+
+	field := func(record []string, name string) string {
+		n, ok := columns[name]
+		if !ok || n >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[n])
+	}
+
+	var rows []domain.ReceiveRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, domain.ReceiveRow{Line: parseErr.Line, ParseError: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		rows = append(rows, domain.ReceiveRow{
+			Line:         line,
+			ProductID:    field(record, "product_id"),
+			ModelName:    field(record, "model_name"),
+			VariantID:    field(record, "variant_id"),
+			SerialNumber: field(record, "serial_number"),
+		})
+	}
+	return rows, nil
+}
+
+// parseReceiveJSONLines reads one JSON object per line with the same fields
+// as the CSV columns. Blank lines are skipped.
+func parseReceiveJSONLines(r io.Reader) ([]domain.ReceiveRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	var rows []domain.ReceiveRow
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row := domain.ReceiveRow{}
+		if err := json.Unmarshal([]byte(text), &row); err != nil {
+			row.ParseError = "invalid JSON: " + err.Error()
+		}
+		row.Line = line
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (i *InventoryHandler) ReceiveInventory(c *gin.Context) {
+	body, format, err := receiveFile(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	var rows []domain.ReceiveRow
+	if format == "csv" {
+		rows, err = parseReceiveCSV(body)
+	} else {
+		rows, err = parseReceiveJSONLines(body)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	locationID, err := parseOptionalID(c.Query("location_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid location ID", c.Request.Method))
+		return
+	}
+
+	opts := domain.ReceiveOptions{
+		Status:     c.Query("status"),
+		LocationID: locationID,
+		DryRun:     c.Query("dry_run") == "true",
+		Actor:      actorFromRequest(c),
+	}
+	result, err := i.useCase.ReceiveInventory(c.Request.Context(), rows, opts)
+	if errors.Is(err, usecase.ErrTooManyRows) || errors.Is(err, usecase.ErrInvalidTransition) || errors.Is(err, usecase.ErrInvalidReference) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if err != nil {
+		// result, when set, reports the batches inserted before the failure
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "result": result})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	status := http.StatusCreated
+	if opts.DryRun {
+		status = http.StatusOK
+	}
+	c.JSON(status, result)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
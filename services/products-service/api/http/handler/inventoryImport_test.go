@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseReceiveCSV(t *testing.T) {
+	input := "\ufeffModel_Name, Serial_Number\n" +
+		"ThinkPad X1,SN-1\n" +
+		"\n" +
+		"ThinkPad X1,\"SN-2\n" +
+		"MacBook Air,SN-3\n"
+
+	rows, err := parseReceiveCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(rows) == 0 || rows[0].ModelName != "ThinkPad X1" || rows[0].SerialNumber != "SN-1" || rows[0].Line != 2 {
+		t.Fatalf("first row = %+v", rows)
+	}
+	if last := rows[len(rows)-1]; last.ParseError == "" {
+		t.Fatalf("expected the unterminated quote to be reported, got %+v", rows)
+	}
+}
+
+func TestParseReceiveCSVRequiresColumns(t *testing.T) {
+	if _, err := parseReceiveCSV(strings.NewReader("model_name\nThinkPad\n")); err == nil {
+		t.Fatal("expected an error without serial_number")
+	}
+	if _, err := parseReceiveCSV(strings.NewReader("serial_number\nSN-1\n")); err == nil {
+		t.Fatal("expected an error without product_id or model_name")
+	}
+}
+
+func TestParseReceiveJSONLines(t *testing.T) {
+	input := `{"product_id":"65f000000000000000000001","serial_number":"SN-1"}
+
+not json
+{"model_name":"ThinkPad","serial_number":"SN-2"}
+`
+	rows, err := parseReceiveJSONLines(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("rows = %+v, want 3", rows)
+	}
+	if rows[1].Line != 3 || rows[1].ParseError == "" {
+		t.Fatalf("row 2 = %+v, want a parse error on line 3", rows[1])
+	}
+	if rows[2].Line != 4 || rows[2].ModelName != "ThinkPad" {
+		t.Fatalf("row 3 = %+v", rows[2])
+	}
+}
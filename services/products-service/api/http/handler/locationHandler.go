@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
+	"github.com/mephirious/group-project/services/products-service/usecase"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LocationRequest struct {
+	Code    string `json:"code" binding:"required"`
+	Name    string `json:"name" binding:"required"`
+	Kind    string `json:"kind" binding:"required"`
+	Address string `json:"address"`
+}
+
+var locationSort = pagination.Sort{
+	Fields:       []string{"code", "name", "kind", "created_at"},
+	DefaultField: "code",
+	DefaultOrder: "asc",
+}
+
+type LocationHandler struct {
+	useCase usecase.LocationUseCase
+}
+
+func NewLocationHandler(router *gin.Engine, useCase usecase.LocationUseCase) {
+	handler := &LocationHandler{useCase: useCase}
+
+	router.GET("/locations", handler.GetAllLocations)
+	router.GET("/locations/:id", handler.GetLocationByID)
+	router.GET("/locations/:id/inventory", handler.GetLocationInventory)
+	router.POST("/locations", handler.CreateLocation)
+	router.PUT("/locations/:id", handler.UpdateLocation)
+	router.DELETE("/locations/:id", handler.DeleteLocation)
+}
+
+func (l *LocationHandler) GetAllLocations(c *gin.Context) {
+	params, err := pagination.FromQuery(c, locationSort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	locations, err := l.useCase.GetAllLocations(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, locations)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (l *LocationHandler) GetLocationByID(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+
+	location, err := l.useCase.GetLocationByID(c.Request.Context(), objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (l *LocationHandler) GetLocationInventory(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+	params, err := pagination.FromQuery(c, inventorySort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	inventories, err := l.useCase.GetLocationInventory(c.Request.Context(), objID, params)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, inventories)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (l *LocationHandler) CreateLocation(c *gin.Context) {
+	var req LocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code, name and kind are required"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	location := domain.Location{
+		ID:      primitive.NewObjectID(),
+		Code:    req.Code,
+		Name:    req.Name,
+		Kind:    req.Kind,
+		Address: req.Address,
+	}
+
+	if err := l.useCase.CreateLocation(c.Request.Context(), &location); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, location)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (l *LocationHandler) UpdateLocation(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+
+	var req LocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code, name and kind are required"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	location := domain.Location{
+		ID:      objID,
+		Code:    req.Code,
+		Name:    req.Name,
+		Kind:    req.Kind,
+		Address: req.Address,
+	}
+
+	if err := l.useCase.UpdateLocation(c.Request.Context(), &location); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (l *LocationHandler) DeleteLocation(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+
+	if err := l.useCase.DeleteLocation(c.Request.Context(), objID); err != nil {
+		c.JSON(deleteErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Location deleted"})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
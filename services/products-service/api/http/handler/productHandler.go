@@ -1,26 +1,48 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"github.com/mephirious/group-project/services/products-service/usecase"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ProductRequest struct {
-	ModelName      string                `json:"model_name" binding:"required"`
-	Specifications domain.Specifications `json:"specifications" binding:"required"`
-	Content        string                `json:"content" binding:"required"`
-	Images         []string              `json:"images" binding:"required"`
-	BrandID        string                `json:"brand_id" binding:"required"`
-	CategoryID     string                `json:"category_id" binding:"required"`
-	TypeID         string                `json:"type_id" binding:"required"`
-	Price          float64               `json:"price" binding:"required"`
+	ModelName      string                 `json:"model_name" binding:"required"`
+	Specifications domain.Specifications  `json:"specifications" binding:"required"`
+	Content        string                 `json:"content" binding:"required"`
+	Images         []string               `json:"images" binding:"required"`
+	BrandID        string                 `json:"brand_id" binding:"required"`
+	CategoryID     string                 `json:"category_id" binding:"required"`
+	TypeID         string                 `json:"type_id" binding:"required"`
+	Price          float64                `json:"price" binding:"required"`
+	Prices         []domain.Money         `json:"prices"`
+	Options        []domain.ProductOption `json:"options"`
+	Backorderable  bool                   `json:"backorderable"`
+	TaxClass       string                 `json:"tax_class"`
+}
+
+type VariantRequest struct {
+	SKU            string                `json:"sku" binding:"required"`
+	Options        map[string]string     `json:"options" binding:"required"`
+	Price          *float64              `json:"price"`
+	Prices         []domain.Money        `json:"prices"`
+	Specifications domain.Specifications `json:"specifications"`
+}
+
+var productSort = pagination.Sort{
+	Fields:       []string{"model_name", "price", "created_at", "updated_at"},
+	DefaultField: "model_name",
+	DefaultOrder: "asc",
 }
 
 type ProductHandler struct {
@@ -32,20 +54,38 @@ func NewProductHandler(router *gin.Engine, useCase usecase.ProductUseCase) {
 
 	router.GET("/products", handler.GetAllProducts)
 	router.GET("/products/:id", handler.GetProductByID)
+	router.GET("/products/:id/breadcrumb", handler.GetProductBreadcrumb)
 	router.GET("/products/model/:model_name", handler.GetProductByModelName)
 	router.POST("/products", handler.CreateProduct)
 	router.PUT("/products/:id", handler.UpdateProduct)
 	router.DELETE("/products/:id", handler.DeleteProduct)
+	router.GET("/products/sku/:sku", handler.GetProductBySKU)
+	router.GET("/products/:id/variants", handler.GetVariants)
+	router.POST("/products/:id/variants", handler.CreateVariant)
+	router.PUT("/products/:id/variants/:variant_id", handler.UpdateVariant)
+	router.DELETE("/products/:id/variants/:variant_id", handler.DeleteVariant)
 }
 
 func (h *ProductHandler) GetAllProducts(g *gin.Context) {
-	limit, _ := strconv.Atoi(g.DefaultQuery("limit", "10"))
-	skip, _ := strconv.Atoi(g.DefaultQuery("skip", "0"))
-	sortField := g.DefaultQuery("sortField", "model_name")
-	sortOrder := g.DefaultQuery("sortOrder", "asc")
-	search := g.DefaultQuery("search", "")
+	params, err := pagination.FromQuery(g, productSort)
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+	filter, err := productFilterFromQuery(g)
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
 
-	products, err := h.useCase.GetAllProducts(g.Request.Context(), limit, skip, sortField, sortOrder, search)
+	products, err := h.useCase.GetAllProducts(g.Request.Context(), params, filter, productViewOptionsFromQuery(g))
+	if errors.Is(err, usecase.ErrInvalidFilter) || errors.Is(err, usecase.ErrUnsupportedCurrency) {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
 	if err != nil {
 		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
@@ -65,7 +105,12 @@ func (h *ProductHandler) GetProductByID(g *gin.Context) {
 		return
 	}
 
-	product, err := h.useCase.GetProductByID(g.Request.Context(), objID)
+	product, err := h.useCase.GetProductByID(g.Request.Context(), objID, productViewOptionsFromQuery(g))
+	if errors.Is(err, usecase.ErrUnsupportedCurrency) {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
 	if err != nil {
 		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
@@ -82,6 +127,25 @@ func (h *ProductHandler) GetProductByID(g *gin.Context) {
 	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
 }
 
+func (h *ProductHandler) GetProductBreadcrumb(g *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(g.Param("id"))
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", g.Request.Method))
+		return
+	}
+
+	breadcrumb, err := h.useCase.GetProductBreadcrumb(g.Request.Context(), objID)
+	if err != nil {
+		g.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	g.JSON(http.StatusOK, breadcrumb)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
+}
+
 func (h *ProductHandler) GetProductByModelName(g *gin.Context) {
 	modelName := g.Param("model_name")
 
@@ -138,9 +202,18 @@ func (h *ProductHandler) CreateProduct(g *gin.Context) {
 		CategoryID:     categoryID,
 		TypeID:         typeID,
 		Price:          req.Price,
+		Prices:         req.Prices,
+		Options:        req.Options,
+		Backorderable:  req.Backorderable,
+		TaxClass:       req.TaxClass,
 	}
 
 	err = h.useCase.CreateProduct(g.Request.Context(), &product)
+	if errors.Is(err, usecase.ErrInvalidReference) || errors.Is(err, usecase.ErrInvalidPrice) {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
 	if err != nil {
 		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
@@ -209,9 +282,23 @@ func (h *ProductHandler) UpdateProduct(g *gin.Context) {
 		CategoryID:     categoryID,
 		TypeID:         typeID,
 		Price:          req.Price,
+		Prices:         req.Prices,
+		Options:        req.Options,
+		Backorderable:  req.Backorderable,
+		TaxClass:       req.TaxClass,
 	}
 
 	err = h.useCase.UpdateProduct(g.Request.Context(), &product)
+	if errors.Is(err, usecase.ErrInvalidReference) || errors.Is(err, usecase.ErrInvalidPrice) {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+	if errors.Is(err, usecase.ErrProductChanged) {
+		g.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
 	if err != nil {
 		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
@@ -254,3 +341,236 @@ func (h *ProductHandler) DeleteProduct(g *gin.Context) {
 	g.JSON(http.StatusOK, gin.H{"message": "Product deleted"})
 	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
 }
+
+func (h *ProductHandler) GetProductBySKU(g *gin.Context) {
+	sku := g.Param("sku")
+
+	product, variant, err := h.useCase.GetProductBySKU(g.Request.Context(), sku)
+	if err != nil {
+		g.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	g.JSON(http.StatusOK, gin.H{"product": product, "variant": variant})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
+}
+
+func (h *ProductHandler) GetVariants(g *gin.Context) {
+	id := g.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", g.Request.Method))
+		return
+	}
+
+	variants, err := h.useCase.GetVariants(g.Request.Context(), objID)
+	if errors.Is(err, usecase.ErrProductNotFound) {
+		g.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+	if err != nil {
+		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	g.JSON(http.StatusOK, variants)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
+}
+
+func (h *ProductHandler) CreateVariant(g *gin.Context) {
+	id := g.Param("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", g.Request.Method))
+		return
+	}
+
+	var req VariantRequest
+	if err := g.ShouldBindJSON(&req); err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "sku and options are required"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	variant := domain.Variant{
+		SKU:            req.SKU,
+		Options:        req.Options,
+		Price:          req.Price,
+		Prices:         req.Prices,
+		Specifications: req.Specifications,
+	}
+
+	if err := h.useCase.CreateVariant(g.Request.Context(), objID, &variant); err != nil {
+		g.JSON(variantErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	g.JSON(http.StatusCreated, variant)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
+}
+
+func (h *ProductHandler) UpdateVariant(g *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(g.Param("id"))
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", g.Request.Method))
+		return
+	}
+	variantID, err := primitive.ObjectIDFromHex(g.Param("variant_id"))
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", g.Request.Method))
+		return
+	}
+
+	var req VariantRequest
+	if err := g.ShouldBindJSON(&req); err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "sku and options are required"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	variant := domain.Variant{
+		ID:             variantID,
+		SKU:            req.SKU,
+		Options:        req.Options,
+		Price:          req.Price,
+		Prices:         req.Prices,
+		Specifications: req.Specifications,
+	}
+
+	if err := h.useCase.UpdateVariant(g.Request.Context(), objID, &variant); err != nil {
+		g.JSON(variantErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	g.JSON(http.StatusOK, variant)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
+}
+
+func (h *ProductHandler) DeleteVariant(g *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(g.Param("id"))
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", g.Request.Method))
+		return
+	}
+	variantID, err := primitive.ObjectIDFromHex(g.Param("variant_id"))
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", g.Request.Method))
+		return
+	}
+
+	if err := h.useCase.DeleteVariant(g.Request.Context(), objID, variantID); err != nil {
+		g.JSON(variantErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	g.JSON(http.StatusOK, gin.H{"message": "Variant deleted"})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
+}
+
+// productFilterFromQuery reads search, type_id, category_id or category (a
+// slug), in_stock=true and attribute filters. An attribute is filtered with
+// attr.<name>=v1,v2 for exact values and attr.<name>.min / attr.<name>.max
+// for number ranges.
+func productFilterFromQuery(g *gin.Context) (domain.ProductFilter, error) {
+	filter := domain.ProductFilter{Search: g.DefaultQuery("search", "")}
+
+	if typeID := g.Query("type_id"); typeID != "" {
+		objID, err := primitive.ObjectIDFromHex(typeID)
+		if err != nil {
+			return filter, errors.New("invalid type_id")
+		}
+		filter.TypeID = &objID
+	}
+	if categoryID := g.Query("category_id"); categoryID != "" {
+		objID, err := primitive.ObjectIDFromHex(categoryID)
+		if err != nil {
+			return filter, errors.New("invalid category_id")
+		}
+		filter.CategoryID = &objID
+	}
+	filter.CategorySlug = g.Query("category")
+	filter.InStockOnly = g.Query("in_stock") == "true"
+
+	attributes := map[string]*domain.AttributeFilter{}
+	var names []string
+	for key, values := range g.Request.URL.Query() {
+		if !strings.HasPrefix(key, "attr.") || len(values) == 0 {
+			continue
+		}
+		name := strings.TrimPrefix(key, "attr.")
+
+		var bound string
+		if strings.HasSuffix(name, ".min") || strings.HasSuffix(name, ".max") {
+			bound = name[len(name)-3:]
+			name = name[:len(name)-4]
+		}
+		if name == "" {
+			return filter, fmt.Errorf("invalid attribute filter %q", key)
+		}
+
+		attribute, ok := attributes[name]
+		if !ok {
+			attribute = &domain.AttributeFilter{Name: name}
+			attributes[name] = attribute
+			names = append(names, name)
+		}
+
+		switch bound {
+		case "":
+			for _, v := range strings.Split(values[0], ",") {
+				attribute.Values = append(attribute.Values, v)
+			}
+		default:
+			n, err := strconv.ParseFloat(values[0], 64)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be a number", key)
+			}
+			if bound == "min" {
+				attribute.Min = &n
+			} else {
+				attribute.Max = &n
+			}
+		}
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		filter.Attributes = append(filter.Attributes, *attributes[name])
+	}
+	return filter, nil
+}
+
+// productViewOptionsFromQuery reads ?include=availability.
+func productViewOptionsFromQuery(g *gin.Context) domain.ProductViewOptions {
+	opts := domain.ProductViewOptions{Currency: g.Query("currency")}
+	for _, part := range strings.Split(g.Query("include"), ",") {
+		if strings.TrimSpace(part) == "availability" {
+			opts.Availability = true
+		}
+	}
+	return opts
+}
+
+// variantErrorStatus maps a variant write error to its status: a concurrent
+// change to the product is a conflict, anything else a bad request.
+func variantErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrProductNotFound), errors.Is(err, usecase.ErrVariantNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrProductChanged):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
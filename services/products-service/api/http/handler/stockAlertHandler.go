@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
+	"github.com/mephirious/group-project/services/products-service/usecase"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type StockThresholdRequest struct {
+	ReorderPoint *int64 `json:"reorder_point" binding:"required"`
+	TargetLevel  *int64 `json:"target_level" binding:"required"`
+}
+
+var thresholdSort = pagination.Sort{
+	Fields:       []string{"updated_at", "reorder_point", "alerting"},
+	DefaultField: "updated_at",
+	DefaultOrder: "desc",
+}
+
+type StockAlertHandler struct {
+	useCase usecase.StockAlertUseCase
+}
+
+func NewStockAlertHandler(router *gin.Engine, useCase usecase.StockAlertUseCase) {
+	handler := &StockAlertHandler{useCase: useCase}
+
+	router.GET("/inventories/low-stock", handler.GetLowStock)
+	router.GET("/inventories/thresholds", handler.GetAllThresholds)
+	router.GET("/inventories/thresholds/:product_id", handler.GetThreshold)
+	router.PUT("/inventories/thresholds/:product_id", handler.SetThreshold)
+	router.DELETE("/inventories/thresholds/:product_id", handler.DeleteThreshold)
+}
+
+func (s *StockAlertHandler) GetLowStock(c *gin.Context) {
+	alerts, err := s.useCase.GetLowStock(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": alerts, "total": len(alerts)})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (s *StockAlertHandler) GetAllThresholds(c *gin.Context) {
+	params, err := pagination.FromQuery(c, thresholdSort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	thresholds, err := s.useCase.GetAllThresholds(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, thresholds)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (s *StockAlertHandler) GetThreshold(c *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+
+	threshold, err := s.useCase.GetThreshold(c.Request.Context(), productID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, threshold)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (s *StockAlertHandler) SetThreshold(c *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+
+	var req StockThresholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reorder_point and target_level are required"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	threshold := domain.StockThreshold{
+		ProductID:    productID,
+		ReorderPoint: *req.ReorderPoint,
+		TargetLevel:  *req.TargetLevel,
+	}
+	if err := threshold.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	err = s.useCase.SetThreshold(c.Request.Context(), &threshold)
+	if errors.Is(err, usecase.ErrInvalidReference) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, threshold)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (s *StockAlertHandler) DeleteThreshold(c *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+
+	if err := s.useCase.DeleteThreshold(c.Request.Context(), productID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Threshold deleted"})
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
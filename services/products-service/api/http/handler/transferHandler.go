@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
+	"github.com/mephirious/group-project/services/products-service/usecase"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TransferRequest struct {
+	FromLocationID string   `json:"from_location_id" binding:"required"`
+	ToLocationID   string   `json:"to_location_id" binding:"required"`
+	InventoryIDs   []string `json:"inventory_ids" binding:"required"`
+	Note           string   `json:"note"`
+}
+
+var transferSort = pagination.Sort{
+	Fields:       []string{"created_at", "updated_at", "status"},
+	DefaultField: "created_at",
+	DefaultOrder: "desc",
+}
+
+// TransferHandler serves stock transfers between locations, which are run by
+// the inventory use case.
+type TransferHandler struct {
+	useCase usecase.InventoryUseCase
+}
+
+func NewTransferHandler(router *gin.Engine, useCase usecase.InventoryUseCase) {
+	handler := &TransferHandler{useCase: useCase}
+
+	router.GET("/transfers", handler.GetAllTransfers)
+	router.GET("/transfers/:id", handler.GetTransferByID)
+	router.POST("/transfers", handler.CreateTransfer)
+	router.POST("/transfers/:id/complete", handler.CompleteTransfer)
+	router.POST("/transfers/:id/cancel", handler.CancelTransfer)
+}
+
+func (t *TransferHandler) GetAllTransfers(c *gin.Context) {
+	params, err := pagination.FromQuery(c, transferSort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	transfers, err := t.useCase.GetAllTransfers(c.Request.Context(), c.Query("status"), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, transfers)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (t *TransferHandler) GetTransferByID(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+
+	transfer, err := t.useCase.GetTransferByID(c.Request.Context(), objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (t *TransferHandler) CreateTransfer(c *gin.Context) {
+	var req TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from_location_id, to_location_id and inventory_ids are required"})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	transfer := domain.Transfer{Note: req.Note}
+	var err error
+	if transfer.FromLocationID, err = primitive.ObjectIDFromHex(req.FromLocationID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from_location_id"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid from_location_id", c.Request.Method))
+		return
+	}
+	if transfer.ToLocationID, err = primitive.ObjectIDFromHex(req.ToLocationID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to_location_id"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid to_location_id", c.Request.Method))
+		return
+	}
+	transfer.InventoryIDs = make([]primitive.ObjectID, len(req.InventoryIDs))
+	for n, raw := range req.InventoryIDs {
+		if transfer.InventoryIDs[n], err = primitive.ObjectIDFromHex(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid inventory ID %q", raw)})
+			slog.Error(fmt.Sprintf("Method %s failed: Invalid inventory ID", c.Request.Method))
+			return
+		}
+	}
+
+	err = t.useCase.CreateTransfer(c.Request.Context(), &transfer, actorFromRequest(c))
+	if err != nil {
+		c.JSON(transferErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, transfer)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+func (t *TransferHandler) CompleteTransfer(c *gin.Context) {
+	t.closeTransfer(c, t.useCase.CompleteTransfer)
+}
+
+func (t *TransferHandler) CancelTransfer(c *gin.Context) {
+	t.closeTransfer(c, t.useCase.CancelTransfer)
+}
+
+func (t *TransferHandler) closeTransfer(c *gin.Context, close func(ctx context.Context, id primitive.ObjectID, actor string) (*domain.Transfer, error)) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", c.Request.Method))
+		return
+	}
+
+	transfer, err := close(c.Request.Context(), objID, actorFromRequest(c))
+	if err != nil {
+		c.JSON(transferErrorStatus(err), gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", c.Request.Method, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", c.Request.Method))
+}
+
+// transferErrorStatus maps a transfer error to an HTTP status. Units or
+// transfers in the wrong state conflict; anything else the caller got wrong
+// is a bad request.
+func transferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidTransition):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrInvalidReference), errors.Is(err, usecase.ErrInvalidTransfer):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrTransferNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -13,7 +13,8 @@ import (
 )
 
 type TypeRequest struct {
-	TypeName string `json:"type_name" binding:"required"`
+	TypeName   string                       `json:"type_name" binding:"required"`
+	Attributes []domain.AttributeDefinition `json:"attributes"`
 }
 
 type TypeHandler struct {
@@ -29,6 +30,7 @@ func NewTypeHandler(router *gin.Engine, useCase usecase.TypeUseCase) {
 	router.POST("/types", handler.CreateType)
 	router.PUT("/types/:id", handler.UpdateType)
 	router.DELETE("/types/:id", handler.DeleteType)
+	router.GET("/types/:id/usage", handler.GetTypeUsage)
 }
 
 func (t *TypeHandler) GetAllTypes(g *gin.Context) {
@@ -98,10 +100,11 @@ func (t *TypeHandler) CreateType(g *gin.Context) {
 	}
 
 	typeEntity := domain.Type{
-		ID:        primitive.NewObjectID(),
-		TypeName:  req.TypeName,
-		CreatedAt: time.Now(), // Ensure creation timestamp is set
-		UpdatedAt: time.Now(),
+		ID:         primitive.NewObjectID(),
+		TypeName:   req.TypeName,
+		Attributes: req.Attributes,
+		CreatedAt:  time.Now(), // Ensure creation timestamp is set
+		UpdatedAt:  time.Now(),
 	}
 
 	if err := t.useCase.CreateType(g.Request.Context(), &typeEntity); err != nil {
@@ -140,10 +143,11 @@ func (t *TypeHandler) UpdateType(g *gin.Context) {
 
 	// Preserve created_at and update other fields
 	typeEntity := domain.Type{
-		ID:        objID,
-		TypeName:  req.TypeName,
-		CreatedAt: existingType.CreatedAt, // Preserve original created_at
-		UpdatedAt: time.Now(),             // Update timestamp
+		ID:         objID,
+		TypeName:   req.TypeName,
+		Attributes: req.Attributes,
+		CreatedAt:  existingType.CreatedAt, // Preserve original created_at
+		UpdatedAt:  time.Now(),             // Update timestamp
 	}
 
 	if err := t.useCase.UpdateType(g.Request.Context(), &typeEntity); err != nil {
@@ -165,9 +169,16 @@ func (t *TypeHandler) DeleteType(g *gin.Context) {
 		return
 	}
 
-	err = t.useCase.DeleteType(g.Request.Context(), objID)
+	opts, err := deleteOptionsFromQuery(g)
 	if err != nil {
-		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	err = t.useCase.DeleteType(g.Request.Context(), objID, opts)
+	if err != nil {
+		g.JSON(deleteErrorStatus(err), gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
 		return
 	}
@@ -175,3 +186,22 @@ func (t *TypeHandler) DeleteType(g *gin.Context) {
 	g.JSON(http.StatusOK, gin.H{"message": "Type deleted"})
 	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
 }
+
+func (t *TypeHandler) GetTypeUsage(g *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(g.Param("id"))
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type ID"})
+		slog.Error(fmt.Sprintf("Method %s failed: Invalid ID format", g.Request.Method))
+		return
+	}
+
+	usage, err := t.useCase.GetTypeUsage(g.Request.Context(), objID)
+	if err != nil {
+		g.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
+
+	g.JSON(http.StatusOK, usage)
+	slog.Info(fmt.Sprintf("Method %s finished successfully", g.Request.Method))
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/usecase"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// deleteOptionsFromQuery reads ?strategy=restrict|reassign|cascade and
+// ?reassign_to=<id> for brand, category and type deletes.
+func deleteOptionsFromQuery(g *gin.Context) (domain.DeleteOptions, error) {
+	opts := domain.DeleteOptions{Strategy: g.DefaultQuery("strategy", domain.DeleteRestrict)}
+	if raw := g.Query("reassign_to"); raw != "" {
+		id, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			return opts, errors.New("invalid reassign_to")
+		}
+		opts.ReassignTo = &id
+	}
+	return opts, opts.Validate()
+}
+
+// deleteErrorStatus maps a taxonomy delete error to an HTTP status.
+func deleteErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInUse):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrInvalidReference):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
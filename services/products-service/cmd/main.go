@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	db "github.com/mephirious/group-project/services/products-service/adapter/mongo"
+	"github.com/mephirious/group-project/services/products-service/adapter/notify"
 	"github.com/mephirious/group-project/services/products-service/api/http/handler"
 	"github.com/mephirious/group-project/services/products-service/config"
 	"github.com/mephirious/group-project/services/products-service/repository"
@@ -36,23 +37,50 @@ func main() {
 	defer db.DisconnectFromMongoDB(ctx, client)
 
 	database := client.Database(cfg.Database.Name)
+	productRepository := repository.NewProductRepository(database)
+	inventoryRepository := repository.NewInventoryRepository(database)
 	brandRepository := repository.NewBrandRepository(database)
-	brandUseCase := usecase.NewBrandUseCase(brandRepository)
+	brandUseCase := usecase.NewBrandUseCase(brandRepository, productRepository, inventoryRepository)
 	categoryRepository := repository.NewCategoryRepository(database)
-	categoryUseCase := usecase.NewCategoryUseCase(categoryRepository)
+	for _, r := range []interface{ EnsureIndexes(context.Context) error }{productRepository, categoryRepository} {
+		if err := r.EnsureIndexes(ctx); err != nil {
+			slog.Error(fmt.Sprintf("Error occured while creating indexes: %s", err))
+			os.Exit(1)
+		}
+	}
+	categoryUseCase := usecase.NewCategoryUseCase(categoryRepository, productRepository, inventoryRepository)
 	typeRepository := repository.NewTypeRepository(database)
-	typeUseCase := usecase.NewTypeUseCase(typeRepository)
-	inventoryRepository := repository.NewInventoryRepository(database)
-	inventoryUseCase := usecase.NewInventoryUseCase(inventoryRepository)
-	productRepository := repository.NewProductRepository(database)
-	productUseCase := usecase.NewProductUseCase(productRepository, categoryRepository, brandRepository, typeRepository)
+	typeUseCase := usecase.NewTypeUseCase(typeRepository, productRepository, inventoryRepository)
+	movementRepository := repository.NewMovementRepository(database)
+	locationRepository := repository.NewLocationRepository(database)
+	locationUseCase := usecase.NewLocationUseCase(locationRepository, inventoryRepository)
+	transferRepository := repository.NewTransferRepository(database)
+	inventoryUseCase := usecase.NewInventoryUseCase(inventoryRepository, productRepository, movementRepository, locationRepository, transferRepository, cfg.Reservations.TTL, cfg.Reservations.LocationOrder)
+	stockThresholdRepository := repository.NewStockThresholdRepository(database)
+	stockAlertUseCase := usecase.NewStockAlertUseCase(stockThresholdRepository, inventoryRepository, productRepository, stockNotifier(cfg))
+	exchangeRateRepository := repository.NewExchangeRateRepository(database)
+	currencyUseCase, err := usecase.NewCurrencyUseCase(exchangeRateRepository, cfg.Pricing.BaseCurrency)
+	if err != nil {
+		slog.Error(fmt.Sprintf("Invalid BASE_CURRENCY: %s", err))
+		os.Exit(1)
+	}
+	productUseCase := usecase.NewProductUseCase(productRepository, categoryRepository, brandRepository, typeRepository, inventoryRepository, stockThresholdRepository, currencyUseCase)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go usecase.StartReservationSweeper(jobsCtx, cfg.Reservations.SweepInterval, inventoryUseCase)
+	go usecase.StartStockMonitor(jobsCtx, cfg.StockAlerts.CheckInterval, stockAlertUseCase)
 
 	router := gin.Default()
 	handler.NewBrandHandler(router, brandUseCase)
 	handler.NewCategoryHandler(router, categoryUseCase)
 	handler.NewTypeHandler(router, typeUseCase)
 	handler.NewInventoryHandler(router, inventoryUseCase)
+	handler.NewLocationHandler(router, locationUseCase)
+	handler.NewTransferHandler(router, inventoryUseCase)
+	handler.NewStockAlertHandler(router, stockAlertUseCase)
 	handler.NewProductHandler(router, productUseCase)
+	handler.NewCurrencyHandler(router, currencyUseCase)
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
@@ -63,3 +91,36 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// stockNotifier builds the low-stock notifiers named in the config.
+func stockNotifier(cfg *config.Config) usecase.StockNotifier {
+	var notifiers notify.Multi
+	for _, name := range cfg.StockAlerts.Notifiers {
+		switch name {
+		case "log":
+			notifiers = append(notifiers, notify.Log{})
+		case "webhook":
+			if cfg.StockAlerts.WebhookURL == "" {
+				slog.Error("STOCK_ALERT_WEBHOOK_URL is not set, webhook stock alerts are disabled")
+				continue
+			}
+			notifiers = append(notifiers, notify.NewWebhook(cfg.StockAlerts.WebhookURL))
+		case "email":
+			if cfg.StockAlerts.SMTPHost == "" || len(cfg.StockAlerts.EmailTo) == 0 {
+				slog.Error("SMTP_HOST or STOCK_ALERT_EMAIL_TO is not set, email stock alerts are disabled")
+				continue
+			}
+			notifiers = append(notifiers, &notify.Email{
+				Host:     cfg.StockAlerts.SMTPHost,
+				Port:     cfg.StockAlerts.SMTPPort,
+				Username: cfg.StockAlerts.SMTPUsername,
+				Password: cfg.StockAlerts.SMTPPassword,
+				From:     cfg.StockAlerts.EmailFrom,
+				To:       cfg.StockAlerts.EmailTo,
+			})
+		default:
+			slog.Error(fmt.Sprintf("Unknown stock alert notifier %q", name))
+		}
+	}
+	return notifiers
+}
@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -20,6 +22,25 @@ type Config struct {
 	Logging struct {
 		Level string
 	}
+	Pricing struct {
+		BaseCurrency string
+	}
+	Reservations struct {
+		TTL           time.Duration
+		SweepInterval time.Duration
+		LocationOrder []string
+	}
+	StockAlerts struct {
+		CheckInterval time.Duration
+		Notifiers     []string
+		WebhookURL    string
+		SMTPHost      string
+		SMTPPort      int
+		SMTPUsername  string
+		SMTPPassword  string
+		EmailFrom     string
+		EmailTo       []string
+	}
 }
 
 func LoadConfig() (*Config, error) {
@@ -39,6 +60,50 @@ func LoadConfig() (*Config, error) {
 	config.Database.URI = os.Getenv("DATABASE_URI")
 	config.Database.Name = os.Getenv("DATABASE_NAME")
 	config.Logging.Level = os.Getenv("LOGGING_LEVEL")
+	config.Pricing.BaseCurrency = os.Getenv("BASE_CURRENCY")
+	if config.Pricing.BaseCurrency == "" {
+		config.Pricing.BaseCurrency = "KZT"
+	}
+	// reservations have to outlive payment-service's checkout sessions, which
+	// last at least 30 minutes, or a customer can pay for released units
+	config.Reservations.TTL = durationFromEnv("RESERVATION_TTL", 35*time.Minute)
+	config.Reservations.SweepInterval = durationFromEnv("RESERVATION_SWEEP_INTERVAL", time.Minute)
+	config.Reservations.LocationOrder = listFromEnv("RESERVATION_LOCATION_ORDER")
+
+	config.StockAlerts.CheckInterval = durationFromEnv("STOCK_CHECK_INTERVAL", 5*time.Minute)
+	config.StockAlerts.Notifiers = listFromEnv("STOCK_ALERT_NOTIFIERS")
+	if len(config.StockAlerts.Notifiers) == 0 {
+		config.StockAlerts.Notifiers = []string{"log"}
+	}
+	config.StockAlerts.WebhookURL = os.Getenv("STOCK_ALERT_WEBHOOK_URL")
+	config.StockAlerts.SMTPHost = os.Getenv("SMTP_HOST")
+	config.StockAlerts.SMTPPort, err = strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil {
+		config.StockAlerts.SMTPPort = 587
+	}
+	config.StockAlerts.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	config.StockAlerts.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	config.StockAlerts.EmailFrom = os.Getenv("STOCK_ALERT_EMAIL_FROM")
+	config.StockAlerts.EmailTo = listFromEnv("STOCK_ALERT_EMAIL_TO")
 
 	return config, nil
 }
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// listFromEnv reads a comma separated list, skipping empty entries.
+func listFromEnv(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
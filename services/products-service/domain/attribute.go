@@ -0,0 +1,211 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	AttributeString  = "string"
+	AttributeNumber  = "number"
+	AttributeBoolean = "boolean"
+)
+
+// WeightAttribute is the specification holding a product's shipping
+// weight, in the unit of its attribute definition.
+const WeightAttribute = "weight"
+
+// weightUnits are the weight units understood, in grams. A weight without a
+// unit is in kilograms.
+var weightUnits = map[string]float64{
+	"":   1000,
+	"g":  1,
+	"kg": 1000,
+	"lb": 453.59237,
+	"oz": 28.349523125,
+}
+
+// AttributeDefinition describes one specification attribute that products
+// of a Type may carry.
+type AttributeDefinition struct {
+	Name          string   `bson:"name" json:"name"`
+	DataType      string   `bson:"data_type" json:"data_type"`
+	Unit          string   `bson:"unit,omitempty" json:"unit,omitempty"`
+	AllowedValues []string `bson:"allowed_values,omitempty" json:"allowed_values,omitempty"`
+	Required      bool     `bson:"required" json:"required"`
+	Filterable    bool     `bson:"filterable" json:"filterable"`
+}
+
+// AttributeFilter narrows a product listing by a filterable attribute. Values
+// is an exact match on any of the values; Min and Max bound number attributes.
+type AttributeFilter struct {
+	Name   string
+	Values []interface{}
+	Min    *float64
+	Max    *float64
+}
+
+// ValidateAttributeDefinitions checks a Type's attribute schema.
+func ValidateAttributeDefinitions(definitions []AttributeDefinition) error {
+	seen := make(map[string]bool, len(definitions))
+	for _, d := range definitions {
+		if d.Name == "" {
+			return errors.New("attribute name is required")
+		}
+		if seen[d.Name] {
+			return fmt.Errorf("duplicate attribute %q", d.Name)
+		}
+		seen[d.Name] = true
+
+		switch d.DataType {
+		case AttributeString, AttributeNumber:
+		case AttributeBoolean:
+			if len(d.AllowedValues) > 0 {
+				return fmt.Errorf("attribute %q: boolean attributes cannot have allowed values", d.Name)
+			}
+		default:
+			return fmt.Errorf("attribute %q: unknown data type %q", d.Name, d.DataType)
+		}
+
+		for _, v := range d.AllowedValues {
+			if _, err := d.Parse(v); err != nil {
+				return fmt.Errorf("attribute %q: allowed value %q is not a %s", d.Name, v, d.DataType)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks product specifications against the Type's attribute schema
+// and normalises numbers to float64. A Type without a schema accepts anything.
+// When partial is set required attributes may be missing, which is how
+// variant overrides are checked.
+func (t *Type) Validate(specs Specifications, partial bool) (Specifications, error) {
+	if len(t.Attributes) == 0 {
+		return specs, nil
+	}
+
+	definitions := make(map[string]AttributeDefinition, len(t.Attributes))
+	for _, d := range t.Attributes {
+		definitions[d.Name] = d
+	}
+
+	normalised := make(Specifications, len(specs))
+	for name, value := range specs {
+		d, ok := definitions[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown attribute %q for type %s", name, t.TypeName)
+		}
+		v, err := d.Coerce(value)
+		if err != nil {
+			return nil, err
+		}
+		normalised[name] = v
+	}
+
+	if !partial {
+		for _, d := range t.Attributes {
+			if _, ok := normalised[d.Name]; d.Required && !ok {
+				return nil, fmt.Errorf("attribute %q is required for type %s", d.Name, t.TypeName)
+			}
+		}
+	}
+	return normalised, nil
+}
+
+// WeightGrams returns the weight in specs in whole grams. It is false when
+// the weight is missing, not a number or in an unknown unit.
+func (t *Type) WeightGrams(specs Specifications) (int64, bool) {
+	weight, ok := toFloat(specs[WeightAttribute])
+	if !ok || weight < 0 {
+		return 0, false
+	}
+	d, _ := t.Attribute(WeightAttribute)
+	grams, ok := weightUnits[strings.ToLower(d.Unit)]
+	if !ok {
+		return 0, false
+	}
+	return int64(math.Round(weight * grams)), true
+}
+
+func (t *Type) Attribute(name string) (AttributeDefinition, bool) {
+	for _, d := range t.Attributes {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return AttributeDefinition{}, false
+}
+
+// Coerce converts a decoded JSON/BSON value to the attribute's data type and
+// checks it against the allowed values.
+func (d AttributeDefinition) Coerce(value interface{}) (interface{}, error) {
+	var v interface{}
+	switch d.DataType {
+	case AttributeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("attribute %q must be a string", d.Name)
+		}
+		v = s
+	case AttributeNumber:
+		n, ok := toFloat(value)
+		if !ok {
+			return nil, fmt.Errorf("attribute %q must be a number", d.Name)
+		}
+		v = n
+	case AttributeBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("attribute %q must be a boolean", d.Name)
+		}
+		v = b
+	}
+
+	if len(d.AllowedValues) > 0 && !d.allows(v) {
+		return nil, fmt.Errorf("attribute %q must be one of %s", d.Name, strings.Join(d.AllowedValues, ", "))
+	}
+	return v, nil
+}
+
+// Parse converts a query string value to the attribute's data type.
+func (d AttributeDefinition) Parse(raw string) (interface{}, error) {
+	switch d.DataType {
+	case AttributeNumber:
+		return strconv.ParseFloat(raw, 64)
+	case AttributeBoolean:
+		return strconv.ParseBool(raw)
+	default:
+		return raw, nil
+	}
+}
+
+func (d AttributeDefinition) allows(v interface{}) bool {
+	for _, allowed := range d.AllowedValues {
+		parsed, err := d.Parse(allowed)
+		if err == nil && parsed == v {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,35 @@
+package domain
+
+// Availability statuses shown on product listings.
+const (
+	AvailabilityInStock    = "in_stock"
+	AvailabilityLowStock   = "low_stock"
+	AvailabilityOutOfStock = "out_of_stock"
+	AvailabilityBackorder  = "backorder"
+)
+
+// DefaultLowStockLevel is the stock count at or below which a product without
+// a reorder threshold shows as low on stock.
+const DefaultLowStockLevel = 3
+
+// Availability tells shoppers whether a product can be bought right now.
+// Quantity is the number of units in stock.
+type Availability struct {
+	Status   string `json:"status"`
+	Quantity int64  `json:"quantity"`
+}
+
+// NewAvailability describes quantity units in stock. Products that are out of
+// stock but accept backorders show as backorder rather than out of stock.
+func NewAvailability(quantity, lowLevel int64, backorderable bool) Availability {
+	a := Availability{Status: AvailabilityInStock, Quantity: quantity}
+	switch {
+	case quantity <= 0 && backorderable:
+		a.Status = AvailabilityBackorder
+	case quantity <= 0:
+		a.Status = AvailabilityOutOfStock
+	case quantity <= lowLevel:
+		a.Status = AvailabilityLowStock
+	}
+	return a
+}
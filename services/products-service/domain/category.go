@@ -1,14 +1,95 @@
 package domain
 
 import (
+	"strings"
 	"time"
+	"unicode"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Category is a node in the category tree. Path is the materialized path of
+// ancestor IDs, e.g. "/<root>/<parent>/", and "/" for a root category.
 type Category struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	CategoryName string             `bson:"category_name" json:"category_name"`
-	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	CategoryName string              `bson:"category_name" json:"category_name"`
+	Slug         string              `bson:"slug" json:"slug"`
+	ParentID     *primitive.ObjectID `bson:"parent_id" json:"parent_id"`
+	Path         string              `bson:"path" json:"path"`
+	Position     int                 `bson:"position" json:"position"`
+	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// CategoryNode is a category with its children, as returned by the tree
+// endpoint.
+type CategoryNode struct {
+	Category
+	Children []*CategoryNode `json:"children"`
+}
+
+// ChildPath is the Path of this category's direct children and the prefix
+// shared by the paths of all of its descendants.
+func (c *Category) ChildPath() string {
+	path := c.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + c.ID.Hex() + "/"
+}
+
+// AncestorIDs returns the IDs in Path, root first.
+func (c *Category) AncestorIDs() []primitive.ObjectID {
+	var ids []primitive.ObjectID
+	for _, part := range strings.Split(c.Path, "/") {
+		if id, err := primitive.ObjectIDFromHex(part); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// IsAncestorOf reports whether other lies in the subtree below c.
+func (c *Category) IsAncestorOf(other *Category) bool {
+	return strings.HasPrefix(other.Path, c.ChildPath())
+}
+
+// Slugify turns a name into a lowercase, hyphen separated URL segment.
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		if !hyphen && b.Len() > 0 {
+			b.WriteRune('-')
+			hyphen = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// BuildCategoryTree nests categories under their parents, ordered by
+// position. Categories whose parent is missing are treated as roots.
+func BuildCategoryTree(categories []Category) []*CategoryNode {
+	nodes := make(map[primitive.ObjectID]*CategoryNode, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &CategoryNode{Category: c, Children: []*CategoryNode{}}
+	}
+
+	roots := []*CategoryNode{}
+	for _, c := range categories {
+		node := nodes[c.ID]
+		if c.ParentID != nil {
+			if parent, ok := nodes[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
 }
@@ -9,8 +9,128 @@ import (
 type Inventory struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	ProductID    primitive.ObjectID `bson:"product_id" json:"product_id"`
+	VariantID    primitive.ObjectID `bson:"variant_id,omitempty" json:"variant_id,omitempty"`
 	SerialNumber string             `bson:"serial_number" json:"serial_number"`
 	Status       string             `bson:"status" json:"status"`
+	LocationID   primitive.ObjectID `bson:"location_id,omitempty" json:"location_id,omitempty"`
+	TransferID   primitive.ObjectID `bson:"transfer_id,omitempty" json:"transfer_id,omitempty"`
+	Reservation  *Reservation       `bson:"reservation,omitempty" json:"reservation,omitempty"`
+	Sale         *Sale              `bson:"sale,omitempty" json:"sale,omitempty"`
 	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
 }
+
+// Reservation records who holds a reserved unit and until when. Units whose
+// reservation has expired are returned to stock by the reservation sweeper.
+// Once the order is paid the reservation is committed to it: OrderID is set
+// and it no longer expires.
+type Reservation struct {
+	ID        string    `bson:"id" json:"id"`
+	Owner     string    `bson:"owner,omitempty" json:"owner,omitempty"`
+	OrderID   string    `bson:"order_id,omitempty" json:"order_id,omitempty"`
+	ExpiresAt time.Time `bson:"expires_at,omitempty" json:"expires_at"`
+}
+
+// Sale ties a sold unit to the order it was sold on and the shipment it
+// left in.
+type Sale struct {
+	OrderID    string `bson:"order_id" json:"order_id"`
+	ShipmentID string `bson:"shipment_id,omitempty" json:"shipment_id,omitempty"`
+}
+
+// UnitTransition moves a single unit of a product between statuses. When
+// LocationID is set only units at that location match, when ReservationID is
+// set only units held by that reservation match, and when SerialNumber is set
+// only that unit matches. Reservation and Sale are recorded on the unit when
+// set; the reservation is cleared when the unit goes back to in_stock.
+type UnitTransition struct {
+	ProductID     primitive.ObjectID
+	VariantID     primitive.ObjectID
+	LocationID    primitive.ObjectID
+	From          string
+	To            string
+	ReservationID string
+	SerialNumber  string
+	Reservation   *Reservation
+	Sale          *Sale
+}
+
+// Apply returns unit as it is after the transition.
+func (t UnitTransition) Apply(unit Inventory) Inventory {
+	unit.Status = t.To
+	switch {
+	case t.Reservation != nil:
+		unit.Reservation = t.Reservation
+	case t.To == StatusInStock:
+		unit.Reservation = nil
+	}
+	if t.Sale != nil {
+		unit.Sale = t.Sale
+	}
+	return unit
+}
+
+// ReservationStats reports what the reservation sweeper has done since start.
+type ReservationStats struct {
+	Sweeps         int64     `json:"sweeps"`
+	Failures       int64     `json:"failures"`
+	Released       int64     `json:"released"`
+	LastSweep      time.Time `json:"last_sweep"`
+	LastReleased   int64     `json:"last_released"`
+	ReservationTTL string    `json:"reservation_ttl"`
+}
+
+// Inventory unit statuses.
+const (
+	StatusReceived   = "received"
+	StatusInStock    = "in_stock"
+	StatusReserved   = "reserved"
+	StatusInTransit  = "in_transit"
+	StatusSold       = "sold"
+	StatusReturned   = "returned"
+	StatusDamaged    = "damaged"
+	StatusWrittenOff = "written_off"
+)
+
+// inventoryTransitions lists the statuses a unit may move to from each status.
+// written_off is terminal.
+var inventoryTransitions = map[string][]string{
+	StatusReceived:   {StatusInStock, StatusDamaged, StatusWrittenOff},
+	StatusInStock:    {StatusReserved, StatusInTransit, StatusDamaged, StatusWrittenOff},
+	StatusReserved:   {StatusInStock, StatusSold},
+	StatusInTransit:  {StatusInStock},
+	StatusSold:       {StatusReturned},
+	StatusReturned:   {StatusInStock, StatusDamaged, StatusWrittenOff},
+	StatusDamaged:    {StatusInStock, StatusWrittenOff},
+	StatusWrittenOff: {},
+}
+
+// IsInventoryStatus reports whether status is one of the known unit statuses.
+func IsInventoryStatus(status string) bool {
+	_, ok := inventoryTransitions[status]
+	return ok
+}
+
+// CanTransition reports whether a unit may move from one status to another.
+func CanTransition(from, to string) bool {
+	return contains(inventoryTransitions[from], to)
+}
+
+// InventoryMovement is an entry in the append-only ledger of unit status
+// changes. From is empty for the entry that records a unit's creation.
+// LocationID is where the unit is after the change; FromLocationID is only set
+// when the change also moved it from another location.
+type InventoryMovement struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	InventoryID    primitive.ObjectID `bson:"inventory_id" json:"inventory_id"`
+	ProductID      primitive.ObjectID `bson:"product_id" json:"product_id"`
+	SerialNumber   string             `bson:"serial_number" json:"serial_number"`
+	From           string             `bson:"from" json:"from"`
+	To             string             `bson:"to" json:"to"`
+	FromLocationID primitive.ObjectID `bson:"from_location_id,omitempty" json:"from_location_id,omitempty"`
+	LocationID     primitive.ObjectID `bson:"location_id,omitempty" json:"location_id,omitempty"`
+	Actor          string             `bson:"actor" json:"actor"`
+	Reason         string             `bson:"reason" json:"reason"`
+	ReservationID  string             `bson:"reservation_id,omitempty" json:"reservation_id,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of stock location.
+const (
+	LocationWarehouse = "warehouse"
+	LocationStore     = "store"
+)
+
+// Location is a place inventory units are kept, such as a warehouse or a
+// retail store. Code is a short unique handle used in configuration.
+type Location struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Code      string             `bson:"code" json:"code"`
+	Name      string             `bson:"name" json:"name"`
+	Kind      string             `bson:"kind" json:"kind"`
+	Address   string             `bson:"address,omitempty" json:"address,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+func (l Location) Validate() error {
+	if l.Code == "" || l.Name == "" {
+		return errors.New("code and name are required")
+	}
+	if l.Kind != LocationWarehouse && l.Kind != LocationStore {
+		return errors.New("kind must be warehouse or store")
+	}
+	return nil
+}
+
+// LocationStock is the number of in_stock units of a product at one
+// location. LocationID is zero for units that have no location yet.
+type LocationStock struct {
+	LocationID primitive.ObjectID `bson:"_id" json:"location_id,omitempty"`
+	Quantity   int64              `bson:"quantity" json:"quantity"`
+}
+
+// Transfer statuses.
+const (
+	TransferInTransit = "in_transit"
+	TransferCompleted = "completed"
+	TransferCancelled = "cancelled"
+)
+
+// Transfer moves units between two locations. While it is in transit its
+// units have status in_transit and stay at FromLocationID; completing it puts
+// them in stock at ToLocationID and cancelling it puts them back in stock at
+// FromLocationID.
+type Transfer struct {
+	ID             primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	FromLocationID primitive.ObjectID   `bson:"from_location_id" json:"from_location_id"`
+	ToLocationID   primitive.ObjectID   `bson:"to_location_id" json:"to_location_id"`
+	InventoryIDs   []primitive.ObjectID `bson:"inventory_ids" json:"inventory_ids"`
+	Status         string               `bson:"status" json:"status"`
+	Note           string               `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedBy      string               `bson:"created_by" json:"created_by"`
+	ClosedBy       string               `bson:"closed_by,omitempty" json:"closed_by,omitempty"`
+	CreatedAt      time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time            `bson:"updated_at" json:"updated_at"`
+	ClosedAt       *time.Time           `bson:"closed_at,omitempty" json:"closed_at,omitempty"`
+}
@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Money is an amount in the minor unit of an ISO 4217 currency, such as
+// tiyn for KZT or cents for USD.
+type Money struct {
+	Amount   int64  `bson:"amount" json:"amount"`
+	Currency string `bson:"currency" json:"currency"`
+}
+
+// zeroDecimalCurrencies have no minor unit.
+var zeroDecimalCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "JPY": true, "KMF": true,
+	"KRW": true, "MGA": true, "PYG": true, "RWF": true, "UGX": true, "VND": true,
+	"VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+// NormalizeCurrency returns the currency code in upper case, or an error
+// when it is not three letters.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", fmt.Errorf("currency %q is not an ISO 4217 code", code)
+	}
+	return code, nil
+}
+
+// CurrencyDecimals returns the number of digits of the currency's minor
+// unit.
+func CurrencyDecimals(currency string) int {
+	if zeroDecimalCurrencies[strings.ToUpper(currency)] {
+		return 0
+	}
+	return 2
+}
+
+// ToMinorUnits converts a major-unit amount to the currency's minor unit,
+// rounding half up. It works on the shortest decimal form of amount, the one
+// the price was entered as, so 1.005 becomes 101 even though the nearest
+// float is slightly below 1.005.
+func ToMinorUnits(amount float64, currency string) (int64, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) || amount < 0 {
+		return 0, errors.New("price must be a non-negative number")
+	}
+	decimals := CurrencyDecimals(currency)
+
+	whole, frac, _ := strings.Cut(strconv.FormatFloat(amount, 'f', -1, 64), ".")
+	frac += strings.Repeat("0", decimals+1)
+	minor, err := strconv.ParseInt(whole+frac[:decimals], 10, 64)
+	if err != nil {
+		return 0, errors.New("price is too large")
+	}
+	if frac[decimals] >= '5' {
+		minor++
+	}
+	return minor, nil
+}
+
+// Major returns the amount in major units, as Product.Price is given.
+func (m Money) Major() float64 {
+	return float64(m.Amount) / math.Pow10(CurrencyDecimals(m.Currency))
+}
+
+// ValidatePrices checks a price list: every currency a valid code other than
+// base, listed once, with a non-negative amount. Codes are normalised in
+// place.
+func ValidatePrices(prices []Money, base string) error {
+	seen := make(map[string]bool, len(prices))
+	for i := range prices {
+		code, err := NormalizeCurrency(prices[i].Currency)
+		if err != nil {
+			return err
+		}
+		if code == base {
+			return fmt.Errorf("the %s price is set with price, not prices", base)
+		}
+		if seen[code] {
+			return fmt.Errorf("duplicate price in %s", code)
+		}
+		if prices[i].Amount < 0 {
+			return errors.New("price must not be negative")
+		}
+		seen[code] = true
+		prices[i].Currency = code
+	}
+	return nil
+}
+
+// PriceIn returns the price listed for currency, if any.
+func PriceIn(prices []Money, currency string) (Money, bool) {
+	for _, price := range prices {
+		if price.Currency == currency {
+			return price, true
+		}
+	}
+	return Money{}, false
+}
+
+// Rounding modes of an exchange rate.
+const (
+	RoundNearest = "nearest"
+	RoundUp      = "up"
+	RoundDown    = "down"
+)
+
+// Rounding tidies converted prices: they are rounded to a multiple of
+// Increment minor units, e.g. 100 for whole dollars. An Increment of zero or
+// one only rounds to the minor unit.
+type Rounding struct {
+	Increment int64  `bson:"increment" json:"increment"`
+	Mode      string `bson:"mode" json:"mode"`
+}
+
+// Apply rounds a minor-unit amount.
+func (r Rounding) Apply(amount float64) int64 {
+	step := float64(max(r.Increment, 1))
+	units := amount / step
+	switch r.Mode {
+	case RoundUp:
+		// a hair of tolerance keeps float noise from pushing exact
+		// amounts up a step
+		units = math.Ceil(units - 1e-9)
+	case RoundDown:
+		units = math.Floor(units + 1e-9)
+	default:
+		units = math.Round(units)
+	}
+	return int64(units * step)
+}
+
+// ExchangeRate converts prices from the base currency into Currency. Rate
+// is how many major units of Currency one major unit of the base currency
+// is worth.
+type ExchangeRate struct {
+	Currency  string    `bson:"_id" json:"currency"`
+	Rate      float64   `bson:"rate" json:"rate"`
+	Rounding  Rounding  `bson:"rounding" json:"rounding"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Validate checks the rate and normalises its currency code.
+func (e *ExchangeRate) Validate(base string) error {
+	code, err := NormalizeCurrency(e.Currency)
+	if err != nil {
+		return err
+	}
+	if code == base {
+		return fmt.Errorf("%s is the base currency", base)
+	}
+	e.Currency = code
+	if math.IsNaN(e.Rate) || math.IsInf(e.Rate, 0) || e.Rate <= 0 {
+		return errors.New("rate must be a positive number")
+	}
+	if e.Rounding.Increment < 0 {
+		return errors.New("rounding increment must not be negative")
+	}
+	switch e.Rounding.Mode {
+	case "":
+		e.Rounding.Mode = RoundNearest
+	case RoundNearest, RoundUp, RoundDown:
+	default:
+		return errors.New("rounding mode must be nearest, up or down")
+	}
+	return nil
+}
+
+// Pricer prices products in one currency. Prices listed for the currency
+// are used as they are; other prices are converted from the base currency
+// at Rate, which is nil when the currency is the base currency.
+type Pricer struct {
+	Base     string
+	Currency string
+	Rate     *ExchangeRate
+}
+
+// Price returns what the product, or its variant when variant is set,
+// costs. A variant's own prices come first; a variant with its own base
+// price is converted from it rather than taking the product's listed
+// prices.
+func (p *Pricer) Price(product *Product, variant *Variant) (Money, error) {
+	if variant != nil {
+		if price, ok := PriceIn(variant.Prices, p.Currency); ok {
+			return price, nil
+		}
+		if variant.Price != nil {
+			return p.convert(*variant.Price)
+		}
+	}
+	if price, ok := PriceIn(product.Prices, p.Currency); ok {
+		return price, nil
+	}
+	return p.convert(product.Price)
+}
+
+func (p *Pricer) convert(price float64) (Money, error) {
+	amount, err := ToMinorUnits(price, p.Base)
+	if err != nil {
+		return Money{}, err
+	}
+	if p.Rate == nil {
+		return Money{Amount: amount, Currency: p.Base}, nil
+	}
+
+	scale := math.Pow10(CurrencyDecimals(p.Currency) - CurrencyDecimals(p.Base))
+	converted := p.Rate.Rounding.Apply(float64(amount) * p.Rate.Rate * scale)
+	return Money{Amount: converted, Currency: p.Currency}, nil
+}
+
+// Currencies lists the currencies products can be priced in: the base
+// currency and every currency with an exchange rate.
+type Currencies struct {
+	Base  string         `json:"base"`
+	Rates []ExchangeRate `json:"rates"`
+}
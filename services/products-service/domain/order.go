@@ -3,16 +3,28 @@ package domain
 import "time"
 
 type ProductOrder struct {
-	ID       string `json:"_id" bson:"_id"`
-	Name     string `json:"model_name" bson:"model_name"`
-	Price    int64  `json:"price" bson:"price"`
-	Quantity int64  `json:"quantity" bson:"quantity"`
-	Currency string `json:"currency" bson:"currency"`
+	ID        string `json:"_id" bson:"_id"`
+	VariantID string `json:"variant_id,omitempty" bson:"variant_id,omitempty"`
+	Name      string `json:"model_name" bson:"model_name"`
+	Price     int64  `json:"price" bson:"price"`
+	Quantity  int64  `json:"quantity" bson:"quantity"`
+	Currency  string `json:"currency" bson:"currency"`
+	// SerialNumbers, when set, names the exact units the line applies to and
+	// must have Quantity entries.
+	SerialNumbers []string `json:"serial_numbers,omitempty" bson:"serial_numbers,omitempty"`
 }
 
+// Order is the body of the /payment endpoints. ReservationID is returned by
+// /payment/start and ties cancel and success calls to the units it reserved.
+// OrderID and ShipmentID name the payment-service order and shipment that
+// commit and ship calls are for.
 type Order struct {
-	Products  []ProductOrder `bson:"products" json:"products"`
-	Amount    int64          `bson:"amount" json:"amount"`
-	Status    string         `bson:"status" json:"status"`
-	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
+	ReservationID string         `bson:"reservation_id,omitempty" json:"reservation_id,omitempty"`
+	OrderID       string         `bson:"order_id,omitempty" json:"order_id,omitempty"`
+	ShipmentID    string         `bson:"shipment_id,omitempty" json:"shipment_id,omitempty"`
+	CustomerID    string         `bson:"customer_id,omitempty" json:"customer_id,omitempty"`
+	Products      []ProductOrder `bson:"products" json:"products"`
+	Amount        int64          `bson:"amount" json:"amount"`
+	Status        string         `bson:"status" json:"status"`
+	CreatedAt     time.Time      `bson:"created_at" json:"created_at"`
 }
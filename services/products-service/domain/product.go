@@ -6,45 +6,92 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Product is a catalogue entry. Price is in the base currency; Prices lists
+// fixed prices in other currencies, which take the place of converting Price
+// at the exchange rate. TaxClass names the tax rates checkout charges on the
+// product, empty for the standard rate.
 type Product struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	ModelName      string             `bson:"model_name" json:"model_name"`
 	Price          float64            `bson:"price" json:"price"`
+	Prices         []Money            `bson:"prices,omitempty" json:"prices,omitempty"`
 	CategoryID     primitive.ObjectID `bson:"category_id" json:"category_id"`
 	BrandID        primitive.ObjectID `bson:"brand_id" json:"brand_id"`
 	TypeID         primitive.ObjectID `bson:"type_id" json:"type_id"`
 	Specifications Specifications     `bson:"specifications" json:"specifications"`
 	Content        string             `bson:"content" json:"content"`
 	Images         []string           `bson:"laptop_image" json:"images"`
+	Options        []ProductOption    `bson:"options" json:"options"`
+	Variants       []Variant          `bson:"variants" json:"variants"`
+	Backorderable  bool               `bson:"backorderable" json:"backorderable"`
+	TaxClass       string             `bson:"tax_class" json:"tax_class"`
 	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
-type Specifications struct {
-	CPU               string `bson:"cpu" json:"cpu"`
-	CPUCores          int    `bson:"cpu_cores" json:"cpu_cores"`
-	OperatingSystem   string `bson:"operating_system" json:"operating_system"`
-	ScreenSize        string `bson:"screen_size" json:"screen_size"`
-	ScreenRefreshRate string `bson:"screen_refresh_rate" json:"screen_refresh_rate"`
-	ScreenBrightness  string `bson:"screen_brightness" json:"screen_brightness"`
-	ScreenType        string `bson:"screen_type" json:"screen_type"`
-	Storage           string `bson:"storage" json:"storage"`
-	Battery           string `bson:"battery" json:"battery"`
-	RAM               string `bson:"ram" json:"ram"`
-	Dimensions        string `bson:"dimensions" json:"dimensions"`
-	Weight            string `bson:"weight" json:"weight"`
-}
+// Specifications holds a product's attributes. Which keys are allowed, and
+// their types, is defined by the AttributeDefinitions of the product's Type.
+type Specifications map[string]interface{}
 
+// ProductView is a product as shown to clients, with names resolved.
+// CategoryIDs holds the product's category and its ancestors, root first, so
+// clients can tell whether it falls under a category. Money is the price in
+// the currency the view was asked for, and Price the same in major units.
+// WeightGrams is the weight specification converted to grams, when it is a
+// number.
 type ProductView struct {
-	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ModelName      string             `bson:"model_name" json:"model_name"`
-	Price          float64            `bson:"price" json:"price"`
-	Category       string             `bson:"category" json:"category"`
-	Brand          string             `bson:"brand" json:"brand"`
-	Type           string             `bson:"type" json:"type"`
-	Specifications Specifications     `bson:"specifications" json:"specifications"`
-	Content        string             `bson:"content" json:"content"`
-	Images         []string           `bson:"laptop_image" json:"images"`
-	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+	ID             primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	ModelName      string               `bson:"model_name" json:"model_name"`
+	Price          float64              `bson:"price" json:"price"`
+	Money          Money                `bson:"-" json:"money"`
+	Prices         []Money              `bson:"prices,omitempty" json:"prices,omitempty"`
+	Category       string               `bson:"category" json:"category"`
+	CategoryIDs    []primitive.ObjectID `bson:"-" json:"category_ids"`
+	Brand          string               `bson:"brand" json:"brand"`
+	BrandID        primitive.ObjectID   `bson:"brand_id" json:"brand_id"`
+	Type           string               `bson:"type" json:"type"`
+	Specifications Specifications       `bson:"specifications" json:"specifications"`
+	Content        string               `bson:"content" json:"content"`
+	Images         []string             `bson:"laptop_image" json:"images"`
+	Options        []ProductOption      `bson:"options" json:"options"`
+	Variants       []VariantView        `bson:"variants" json:"variants"`
+	Backorderable  bool                 `bson:"backorderable" json:"backorderable"`
+	TaxClass       string               `bson:"tax_class" json:"tax_class"`
+	WeightGrams    *int64               `bson:"-" json:"weight_grams,omitempty"`
+	Availability   *Availability        `bson:"-" json:"availability,omitempty"`
+	CreatedAt      time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+// Merge returns a copy of s with every key of override applied on top.
+func (s Specifications) Merge(override Specifications) Specifications {
+	merged := make(Specifications, len(s)+len(override))
+	for k, v := range s {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// ProductFilter narrows a product listing. CategoryID and CategorySlug select
+// a category subtree; the use case expands them into CategoryIDs. InStockOnly
+// keeps products with units in stock, which the use case resolves into IDs.
+type ProductFilter struct {
+	Search       string
+	TypeID       *primitive.ObjectID
+	CategoryID   *primitive.ObjectID
+	CategorySlug string
+	CategoryIDs  []primitive.ObjectID
+	Attributes   []AttributeFilter
+	InStockOnly  bool
+	IDs          []primitive.ObjectID
+}
+
+// ProductViewOptions select the optional parts of a ProductView and the
+// currency it is priced in. An empty Currency means the base currency.
+type ProductViewOptions struct {
+	Availability bool
+	Currency     string
 }
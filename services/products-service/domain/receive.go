@@ -0,0 +1,41 @@
+package domain
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// ReceiveRow is one line of a bulk inventory receive file. The product is
+// given either by ID or by exact model name. ParseError is set when the line
+// could not be read at all.
+type ReceiveRow struct {
+	Line         int    `json:"line"`
+	ProductID    string `json:"product_id"`
+	ModelName    string `json:"model_name"`
+	VariantID    string `json:"variant_id"`
+	SerialNumber string `json:"serial_number"`
+	ParseError   string `json:"-"`
+}
+
+// ReceiveOptions control a bulk receive. Status is the status new units start
+// in and LocationID, when set, where they are kept; DryRun validates without
+// inserting anything.
+type ReceiveOptions struct {
+	Status     string
+	LocationID primitive.ObjectID
+	DryRun     bool
+	Actor      string
+}
+
+// ReceiveRowError explains why a row was rejected.
+type ReceiveRowError struct {
+	Line         int    `json:"line"`
+	SerialNumber string `json:"serial_number,omitempty"`
+	Error        string `json:"error"`
+}
+
+// ReceiveResult summarises a bulk receive.
+type ReceiveResult struct {
+	DryRun    bool              `json:"dry_run"`
+	TotalRows int               `json:"total_rows"`
+	ValidRows int               `json:"valid_rows"`
+	Inserted  int               `json:"inserted"`
+	Errors    []ReceiveRowError `json:"errors"`
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StockThreshold is a product's reorder policy. When its in_stock count falls
+// to ReorderPoint or below, it should be restocked up to TargetLevel.
+// Alerting records that an alert has been sent and not yet cleared by the
+// stock recovering, so each drop is reported once.
+type StockThreshold struct {
+	ProductID    primitive.ObjectID `bson:"_id" json:"product_id"`
+	ReorderPoint int64              `bson:"reorder_point" json:"reorder_point"`
+	TargetLevel  int64              `bson:"target_level" json:"target_level"`
+	Alerting     bool               `bson:"alerting" json:"alerting"`
+	AlertedAt    *time.Time         `bson:"alerted_at,omitempty" json:"alerted_at,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+func (t StockThreshold) Validate() error {
+	if t.ReorderPoint < 0 {
+		return errors.New("reorder_point must not be negative")
+	}
+	if t.TargetLevel <= t.ReorderPoint {
+		return errors.New("target_level must be above reorder_point")
+	}
+	return nil
+}
+
+// IsLow reports whether quantity units in stock call for a reorder.
+func (t StockThreshold) IsLow(quantity int64) bool {
+	return quantity <= t.ReorderPoint
+}
+
+// StockAlert describes a product at or below its reorder point.
+// ReorderQuantity is how many units bring it back to its target level.
+type StockAlert struct {
+	ProductID       primitive.ObjectID `json:"product_id"`
+	ModelName       string             `json:"model_name"`
+	Quantity        int64              `json:"quantity"`
+	ReorderPoint    int64              `json:"reorder_point"`
+	TargetLevel     int64              `json:"target_level"`
+	ReorderQuantity int64              `json:"reorder_quantity"`
+	DetectedAt      time.Time          `json:"detected_at"`
+}
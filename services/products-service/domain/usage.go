@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Strategies for deleting a brand, category or type that products still use.
+const (
+	DeleteRestrict = "restrict"
+	DeleteReassign = "reassign"
+	DeleteCascade  = "cascade"
+)
+
+// DeleteOptions says what happens to products that reference a taxonomy entry
+// being deleted. Restrict refuses the delete, Reassign moves the products to
+// ReassignTo and Cascade deletes them.
+type DeleteOptions struct {
+	Strategy   string
+	ReassignTo *primitive.ObjectID
+}
+
+func (o DeleteOptions) Validate() error {
+	switch o.Strategy {
+	case "", DeleteRestrict, DeleteCascade:
+		return nil
+	case DeleteReassign:
+		if o.ReassignTo == nil {
+			return errors.New("reassign_to is required when strategy is reassign")
+		}
+		return nil
+	default:
+		return errors.New("strategy must be one of restrict, reassign or cascade")
+	}
+}
+
+// Usage counts what refers to a brand, category or type.
+type Usage struct {
+	Products       int64 `json:"products"`
+	InventoryUnits int64 `json:"inventory_units"`
+	Subcategories  int64 `json:"subcategories,omitempty"`
+}
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProductOption is a single variant axis, e.g. RAM with values 16GB and 32GB.
+type ProductOption struct {
+	Name   string   `bson:"name" json:"name"`
+	Values []string `bson:"values" json:"values"`
+}
+
+// Variant is a purchasable configuration of a product. Price, Prices and
+// Specifications are overrides; unset fields fall back to the parent product.
+type Variant struct {
+	ID             primitive.ObjectID `bson:"_id" json:"id"`
+	SKU            string             `bson:"sku" json:"sku"`
+	Options        map[string]string  `bson:"options" json:"options"`
+	Price          *float64           `bson:"price,omitempty" json:"price,omitempty"`
+	Prices         []Money            `bson:"prices,omitempty" json:"prices,omitempty"`
+	Specifications Specifications     `bson:"specifications,omitempty" json:"specifications,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// VariantView is a variant with the parent product's values already applied.
+// Money is set when the view is priced in a currency.
+type VariantView struct {
+	ID             primitive.ObjectID `json:"id"`
+	SKU            string             `json:"sku"`
+	Options        map[string]string  `json:"options"`
+	Price          float64            `json:"price"`
+	Money          *Money             `json:"money,omitempty"`
+	Prices         []Money            `json:"prices,omitempty"`
+	Specifications Specifications     `json:"specifications"`
+	WeightGrams    *int64             `json:"weight_grams,omitempty"`
+}
+
+func (p *Product) FindVariant(id primitive.ObjectID) (*Variant, int) {
+	for i := range p.Variants {
+		if p.Variants[i].ID == id {
+			return &p.Variants[i], i
+		}
+	}
+	return nil, -1
+}
+
+// ResolveVariant merges the variant overrides onto the product.
+func (p *Product) ResolveVariant(v Variant) VariantView {
+	view := VariantView{
+		ID:             v.ID,
+		SKU:            v.SKU,
+		Options:        v.Options,
+		Price:          p.Price,
+		Prices:         v.Prices,
+		Specifications: p.Specifications,
+	}
+	if v.Price != nil {
+		view.Price = *v.Price
+	}
+	if len(v.Specifications) > 0 {
+		view.Specifications = view.Specifications.Merge(v.Specifications)
+	}
+	return view
+}
+
+func (p *Product) VariantViews() []VariantView {
+	views := make([]VariantView, len(p.Variants))
+	for i, v := range p.Variants {
+		views[i] = p.ResolveVariant(v)
+	}
+	return views
+}
+
+// ValidateOptions checks that the option axes are well formed.
+func ValidateOptions(options []ProductOption) error {
+	seen := make(map[string]bool, len(options))
+	for _, o := range options {
+		if o.Name == "" {
+			return errors.New("option name is required")
+		}
+		if seen[o.Name] {
+			return fmt.Errorf("duplicate option %q", o.Name)
+		}
+		seen[o.Name] = true
+		if len(o.Values) == 0 {
+			return fmt.Errorf("option %q has no values", o.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateVariant checks a variant against the product's option axes and
+// makes sure no other variant already uses the same combination.
+func (p *Product) ValidateVariant(v Variant) error {
+	if v.SKU == "" {
+		return errors.New("sku is required")
+	}
+	if v.Price != nil && *v.Price < 0 {
+		return errors.New("price must not be negative")
+	}
+	if len(v.Options) != len(p.Options) {
+		return fmt.Errorf("variant must set exactly the options %s", p.optionNames())
+	}
+	for _, o := range p.Options {
+		value, ok := v.Options[o.Name]
+		if !ok {
+			return fmt.Errorf("variant is missing option %q", o.Name)
+		}
+		if !contains(o.Values, value) {
+			return fmt.Errorf("invalid value %q for option %q", value, o.Name)
+		}
+	}
+
+	key := v.optionKey()
+	for _, other := range p.Variants {
+		if other.ID == v.ID {
+			continue
+		}
+		if other.SKU == v.SKU {
+			return fmt.Errorf("sku %q is already used by this product", v.SKU)
+		}
+		if other.optionKey() == key {
+			return errors.New("a variant with these options already exists")
+		}
+	}
+	return nil
+}
+
+func (p *Product) optionNames() string {
+	names := make([]string, len(p.Options))
+	for i, o := range p.Options {
+		names[i] = o.Name
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+func (v Variant) optionKey() string {
+	keys := make([]string, 0, len(v.Options))
+	for k := range v.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + v.Options[k]
+	}
+	return strings.Join(parts, ";")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
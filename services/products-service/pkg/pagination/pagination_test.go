@@ -0,0 +1,240 @@
+package pagination
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type item struct {
+	ID    primitive.ObjectID `bson:"_id"`
+	Price *int64             `bson:"price,omitempty"`
+}
+
+var priceSort = Sort{Fields: []string{"price"}, DefaultField: "price", DefaultOrder: "asc"}
+
+// compare orders two values of the fields used here the way MongoDB sorts
+// them, nulls first.
+func compare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		if a < b {
+			return -1
+		} else if a > b {
+			return 1
+		}
+		return 0
+	case primitive.ObjectID:
+		b := b.(primitive.ObjectID)
+		return compareIDs(a, b)
+	}
+	panic("unsupported value")
+}
+
+func compareIDs(a, b primitive.ObjectID) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func field(doc item, name string) interface{} {
+	if name == "_id" {
+		return doc.ID
+	}
+	if doc.Price == nil {
+		return nil
+	}
+	return *doc.Price
+}
+
+// rawValue converts a cursor value back to the Go value it was made from.
+func rawValue(v interface{}) interface{} {
+	raw, ok := v.(bson.RawValue)
+	if !ok {
+		return v
+	}
+	if n, ok := raw.Int64OK(); ok {
+		return n
+	}
+	return nil
+}
+
+// matches evaluates the subset of the MongoDB query language Query produces.
+func matches(doc item, query bson.M) bool {
+	for key, condition := range query {
+		switch key {
+		case "$or", "$and":
+			matched := false
+			for _, q := range condition.(bson.A) {
+				ok := matches(doc, q.(bson.M))
+				if key == "$and" && !ok {
+					return false
+				}
+				matched = matched || ok
+			}
+			if !matched {
+				return false
+			}
+		default:
+			value := field(doc, key)
+			ops, isOps := condition.(bson.M)
+			if !isOps {
+				if compare(value, rawValue(condition)) != 0 {
+					return false
+				}
+				continue
+			}
+			for op, operand := range ops {
+				operand = rawValue(operand)
+				switch op {
+				case "$ne":
+					if compare(value, operand) == 0 {
+						return false
+					}
+				case "$gt", "$lt":
+					// comparisons never match across null
+					if value == nil || operand == nil {
+						return false
+					}
+					if c := compare(value, operand); (op == "$gt" && c <= 0) || (op == "$lt" && c >= 0) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+// find runs query over docs in sort order and fetches one extra document,
+// like a FindOptions query.
+func find(docs []item, p Params, query bson.M) []item {
+	sorted := append([]item(nil), docs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		c := compare(field(sorted[i], p.SortField), field(sorted[j], p.SortField))
+		if c == 0 {
+			c = compareIDs(sorted[i].ID, sorted[j].ID)
+		}
+		return c*p.SortOrder < 0
+	})
+
+	var found []item
+	for _, doc := range sorted {
+		if matches(doc, query) && len(found) <= p.Limit {
+			found = append(found, doc)
+		}
+	}
+	return found
+}
+
+func price(n int64) *int64 {
+	return &n
+}
+
+func TestCursorPagesThroughMissingSortField(t *testing.T) {
+	var docs []item
+	for _, p := range []*int64{price(30), nil, price(10), price(20), nil, price(20), nil} {
+		docs = append(docs, item{ID: primitive.NewObjectID(), Price: p})
+	}
+
+	for _, order := range []string{"asc", "desc"} {
+		t.Run(order, func(t *testing.T) {
+			seen := map[primitive.ObjectID]bool{}
+			var last *item
+			token := ""
+			for pages := 0; ; pages++ {
+				if pages > len(docs) {
+					t.Fatal("paging did not finish")
+				}
+				params, err := Parse("2", token, "price", order, priceSort)
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				page, err := NewPage(find(docs, params, params.Query(bson.M{})), int64(len(docs)), params)
+				if err != nil {
+					t.Fatalf("NewPage: %v", err)
+				}
+				for _, doc := range page.Items {
+					if seen[doc.ID] {
+						t.Fatalf("%s listed twice", doc.ID.Hex())
+					}
+					seen[doc.ID] = true
+					if last != nil {
+						c := compare(field(*last, "price"), field(doc, "price"))
+						if c == 0 {
+							c = compareIDs(last.ID, doc.ID)
+						}
+						if c*params.SortOrder > 0 {
+							t.Errorf("%s listed out of order", doc.ID.Hex())
+						}
+					}
+					doc := doc
+					last = &doc
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				token = page.NextCursor
+			}
+			if len(seen) != len(docs) {
+				t.Errorf("listed %d of %d documents", len(seen), len(docs))
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	docs := []item{{ID: primitive.NewObjectID(), Price: price(10)}, {ID: primitive.NewObjectID(), Price: price(20)}}
+	params, err := Parse("1", "", "price", "desc", priceSort)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	page, err := NewPage(docs, 2, params)
+	if err != nil {
+		t.Fatalf("NewPage: %v", err)
+	}
+	if len(page.Items) != 1 || page.NextCursor == "" {
+		t.Fatalf("page = %+v, want one item and a cursor", page)
+	}
+
+	next, err := Parse("1", page.NextCursor, "price", "desc", priceSort)
+	if err != nil {
+		t.Fatalf("Parse of the issued cursor: %v", err)
+	}
+	if rawValue(next.cursor.Value) != int64(10) || next.cursor.ID != docs[0].ID {
+		t.Errorf("cursor = %+v, want it to resume after the first item", next.cursor)
+	}
+
+	if _, err := Parse("1", page.NextCursor, "price", "asc", priceSort); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor replayed in another order: err = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := Parse("1", "not-a-cursor", "price", "desc", priceSort); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("garbage cursor: err = %v, want ErrInvalidCursor", err)
+	}
+
+	last, err := NewPage(docs[1:], 2, next)
+	if err != nil {
+		t.Fatalf("NewPage: %v", err)
+	}
+	if last.NextCursor != "" {
+		t.Errorf("last page has cursor %q", last.NextCursor)
+	}
+}
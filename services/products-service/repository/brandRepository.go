@@ -5,13 +5,14 @@ import (
 	"time"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type BrandRepository interface {
-	GetAllBrands(ctx context.Context) ([]domain.Brand, error)
+	GetAllBrands(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Brand], error)
 	GetBrandByID(ctx context.Context, id primitive.ObjectID) (*domain.Brand, error)
 	GetBrandByName(ctx context.Context, name string) (*domain.Brand, error)
 	CreateBrand(ctx context.Context, brand *domain.Brand) error
@@ -29,10 +30,16 @@ func NewBrandRepository(db *mongo.Database) *brandRepository {
 	}
 }
 
-func (b *brandRepository) GetAllBrands(ctx context.Context) ([]domain.Brand, error) {
+func (b *brandRepository) GetAllBrands(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Brand], error) {
 	var brands []domain.Brand
 
-	cursor, err := b.collection.Find(ctx, bson.D{})
+	filter := bson.M{}
+	total, err := b.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := b.collection.Find(ctx, params.Query(filter), params.FindOptions())
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +50,7 @@ func (b *brandRepository) GetAllBrands(ctx context.Context) ([]domain.Brand, err
 		return nil, err
 	}
 
-	return brands, nil
+	return pagination.NewPage(brands, total, params)
 }
 
 func (b *brandRepository) GetBrandByID(ctx context.Context, id primitive.ObjectID) (*domain.Brand, error) {
@@ -2,23 +2,34 @@ package repository
 
 import (
 	"context"
+	"regexp"
 	"time"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type CategoryRepository interface {
 	GetAllCategories(ctx context.Context) ([]domain.Category, error)
 	GetCategoryByID(ctx context.Context, id primitive.ObjectID) (*domain.Category, error)
 	GetCategoryByName(ctx context.Context, name string) (*domain.Category, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error)
+	GetCategoriesByIDs(ctx context.Context, ids []primitive.ObjectID) ([]domain.Category, error)
+	GetChildren(ctx context.Context, parentID *primitive.ObjectID) ([]domain.Category, error)
+	GetDescendants(ctx context.Context, category *domain.Category) ([]domain.Category, error)
+	MoveCategory(ctx context.Context, category *domain.Category, parentID *primitive.ObjectID, path string, position int) (bool, error)
+	ConfirmPath(ctx context.Context, id primitive.ObjectID, path string) (bool, error)
+	SetPositions(ctx context.Context, ids []primitive.ObjectID) error
 	CreateCategory(ctx context.Context, category *domain.Category) error
 	UpdateCategory(ctx context.Context, category *domain.Category) error
 	DeleteCategory(ctx context.Context, id primitive.ObjectID) error
 }
 
+var categoryOrder = bson.D{{Key: "position", Value: 1}, {Key: "category_name", Value: 1}}
+
 type categoryRepository struct {
 	collection *mongo.Collection
 }
@@ -29,10 +40,20 @@ func NewCategoryRepository(db *mongo.Database) *categoryRepository {
 	}
 }
 
+// EnsureIndexes keeps category slugs unique. Categories created before
+// slugs existed have none and are left out of the index.
+func (c *categoryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := c.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"slug": bson.M{"$gt": ""}}),
+	})
+	return err
+}
+
 func (c *categoryRepository) GetAllCategories(ctx context.Context) ([]domain.Category, error) {
 	var categories []domain.Category
 
-	cursor, err := c.collection.Find(ctx, bson.D{})
+	cursor, err := c.collection.Find(ctx, bson.D{}, options.Find().SetSort(categoryOrder))
 	if err != nil {
 		return nil, err
 	}
@@ -79,6 +100,105 @@ func (c *categoryRepository) GetCategoryByName(ctx context.Context, name string)
 	return &category, nil
 }
 
+func (c *categoryRepository) GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	var category domain.Category
+	err := c.collection.FindOne(ctx, bson.M{"slug": slug}).Decode(&category)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (c *categoryRepository) GetCategoriesByIDs(ctx context.Context, ids []primitive.ObjectID) ([]domain.Category, error) {
+	return c.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+// GetChildren returns the direct children of parentID, or the root
+// categories when parentID is nil.
+func (c *categoryRepository) GetChildren(ctx context.Context, parentID *primitive.ObjectID) ([]domain.Category, error) {
+	return c.find(ctx, bson.M{"parent_id": parentID})
+}
+
+func (c *categoryRepository) GetDescendants(ctx context.Context, category *domain.Category) ([]domain.Category, error) {
+	return c.find(ctx, bson.M{"path": bson.M{"$regex": "^" + regexp.QuoteMeta(category.ChildPath())}})
+}
+
+// MoveCategory re-parents category and rewrites the materialized path of its
+// whole subtree. The category itself is only moved while it still sits at its
+// Path under its ParentID; otherwise nothing changes and it returns false.
+func (c *categoryRepository) MoveCategory(ctx context.Context, category *domain.Category, parentID *primitive.ObjectID, path string, position int) (bool, error) {
+	oldPrefix := category.ChildPath()
+	newPrefix := path + category.ID.Hex() + "/"
+
+	moved, err := c.collection.UpdateOne(ctx, bson.M{"_id": category.ID, "path": category.Path, "parent_id": category.ParentID}, bson.M{"$set": bson.M{
+		"parent_id":  parentID,
+		"path":       path,
+		"position":   position,
+		"updated_at": time.Now(),
+	}})
+	if err != nil || moved.MatchedCount == 0 {
+		return false, err
+	}
+
+	_, err = c.collection.UpdateMany(ctx,
+		bson.M{"path": bson.M{"$regex": "^" + regexp.QuoteMeta(oldPrefix)}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"path": bson.M{"$concat": bson.A{
+				newPrefix,
+				bson.M{"$substrCP": bson.A{"$path", len(oldPrefix), bson.M{"$strLenCP": "$path"}}},
+			}},
+			"updated_at": "$$NOW",
+		}}}},
+	)
+	return err == nil, err
+}
+
+// ConfirmPath touches the category id if it still has path, and reports
+// whether it did.
+func (c *categoryRepository) ConfirmPath(ctx context.Context, id primitive.ObjectID, path string) (bool, error) {
+	result, err := c.collection.UpdateOne(ctx, bson.M{"_id": id, "path": path}, bson.M{"$set": bson.M{"updated_at": time.Now()}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// SetPositions orders the given categories by their index in ids.
+func (c *categoryRepository) SetPositions(ctx context.Context, ids []primitive.ObjectID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, len(ids))
+	for i, id := range ids {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{"position": i, "updated_at": time.Now()}})
+	}
+
+	_, err := c.collection.BulkWrite(ctx, models)
+	return err
+}
+
+func (c *categoryRepository) find(ctx context.Context, filter bson.M) ([]domain.Category, error) {
+	cursor, err := c.collection.Find(ctx, filter, options.Find().SetSort(categoryOrder))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var categories []domain.Category
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
 func (c *categoryRepository) CreateCategory(ctx context.Context, category *domain.Category) error {
 	category.CreatedAt = time.Now()
 	category.UpdatedAt = time.Now()
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ExchangeRateRepository interface {
+	GetAllExchangeRates(ctx context.Context) ([]domain.ExchangeRate, error)
+	GetExchangeRate(ctx context.Context, currency string) (*domain.ExchangeRate, error)
+	SaveExchangeRate(ctx context.Context, rate *domain.ExchangeRate) error
+	DeleteExchangeRate(ctx context.Context, currency string) error
+}
+
+type exchangeRateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewExchangeRateRepository(db *mongo.Database) *exchangeRateRepository {
+	return &exchangeRateRepository{
+		collection: db.Collection("exchange_rates"),
+	}
+}
+
+func (e *exchangeRateRepository) GetAllExchangeRates(ctx context.Context) ([]domain.ExchangeRate, error) {
+	cursor, err := e.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rates := []domain.ExchangeRate{}
+	err = cursor.All(ctx, &rates)
+	if err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// GetExchangeRate returns nil, nil when there is no rate for the currency.
+func (e *exchangeRateRepository) GetExchangeRate(ctx context.Context, currency string) (*domain.ExchangeRate, error) {
+	var rate domain.ExchangeRate
+
+	err := e.collection.FindOne(ctx, bson.M{"_id": currency}).Decode(&rate)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+// SaveExchangeRate creates or replaces the rate of its currency.
+func (e *exchangeRateRepository) SaveExchangeRate(ctx context.Context, rate *domain.ExchangeRate) error {
+	_, err := e.collection.ReplaceOne(ctx, bson.M{"_id": rate.Currency}, rate, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DeleteExchangeRate returns mongo.ErrNoDocuments when there is no rate for
+// the currency.
+func (e *exchangeRateRepository) DeleteExchangeRate(ctx context.Context, currency string) error {
+	result, err := e.collection.DeleteOne(ctx, bson.M{"_id": currency})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
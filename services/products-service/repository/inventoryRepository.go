@@ -2,10 +2,10 @@ package repository
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -13,15 +13,33 @@ import (
 )
 
 type InventoryRepository interface {
-	GetAllInventories(ctx context.Context) ([]domain.Inventory, error)
+	GetAllInventories(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Inventory], error)
 	GetInventoryByID(ctx context.Context, id primitive.ObjectID) (*domain.Inventory, error)
-	GetInventoryByProductID(ctx context.Context, productID primitive.ObjectID) ([]domain.Inventory, error)
+	GetInventoryByProductID(ctx context.Context, productID primitive.ObjectID, params pagination.Params) (*pagination.Page[domain.Inventory], error)
 	GetInventoryBySerialNumber(ctx context.Context, serialNumber string) (*domain.Inventory, error)
+	GetInventoryByLocationID(ctx context.Context, locationID primitive.ObjectID, params pagination.Params) (*pagination.Page[domain.Inventory], error)
+	GetInventoriesByTransferID(ctx context.Context, transferID primitive.ObjectID) ([]domain.Inventory, error)
+	GetInventoriesByReservationID(ctx context.Context, reservationID string) ([]domain.Inventory, error)
 	CreateInventory(ctx context.Context, inventory *domain.Inventory) error
-	UpdateInventory(ctx context.Context, inventory *domain.Inventory) error
+	CreateInventories(ctx context.Context, inventories []domain.Inventory) error
+	GetExistingSerialNumbers(ctx context.Context, serialNumbers []string) ([]string, error)
+	UpdateInventory(ctx context.Context, inventory *domain.Inventory, status string, updatedAt time.Time) (bool, error)
 	DeleteInventory(ctx context.Context, id primitive.ObjectID) error
 	GetProductQuantity(ctx context.Context, productID primitive.ObjectID) (int64, error)
-	FindAndUpdateStatus(ctx context.Context, productID primitive.ObjectID, status string, limit int64) ([]domain.Inventory, error)
+	GetVariantQuantity(ctx context.Context, productID, variantID primitive.ObjectID) (int64, error)
+	GetProductStockByLocation(ctx context.Context, productID primitive.ObjectID) ([]domain.LocationStock, error)
+	GetProductQuantities(ctx context.Context, productIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error)
+	GetInStockProductIDs(ctx context.Context) ([]primitive.ObjectID, error)
+	CountUnitsByLocationID(ctx context.Context, locationID primitive.ObjectID) (int64, error)
+	CountVariantUnits(ctx context.Context, variantID primitive.ObjectID) (int64, error)
+	CountUnitsByProductIDs(ctx context.Context, productIDs []primitive.ObjectID) (int64, error)
+	TransitionUnit(ctx context.Context, transition domain.UnitTransition) (*domain.Inventory, error)
+	TransitionUnitByID(ctx context.Context, id primitive.ObjectID, from, to string) (*domain.Inventory, error)
+	RestoreUnit(ctx context.Context, unit domain.Inventory, status string) (bool, error)
+	ReleaseExpiredReservations(ctx context.Context, now time.Time) ([]domain.Inventory, error)
+	CommitReservation(ctx context.Context, reservationID, orderID string) (int64, error)
+	DispatchUnit(ctx context.Context, id, locationID, transferID primitive.ObjectID) (*domain.Inventory, error)
+	ReceiveTransfer(ctx context.Context, transferID, locationID primitive.ObjectID) (int64, error)
 }
 
 type inventoryRepository struct {
@@ -34,15 +52,37 @@ func NewInventoryRepository(db *mongo.Database) *inventoryRepository {
 	}
 }
 
-func (i *inventoryRepository) GetAllInventories(ctx context.Context) ([]domain.Inventory, error) {
-	var inventories []domain.Inventory
+func (i *inventoryRepository) GetAllInventories(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Inventory], error) {
+	return i.findPage(ctx, bson.M{}, params)
+}
+
+func (i *inventoryRepository) GetInventoryByID(ctx context.Context, id primitive.ObjectID) (*domain.Inventory, error) {
+	var inventory domain.Inventory
+
+	err := i.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	return &inventory, nil
+}
+
+func (i *inventoryRepository) GetInventoryByProductID(ctx context.Context, productID primitive.ObjectID, params pagination.Params) (*pagination.Page[domain.Inventory], error) {
+	return i.findPage(ctx, bson.M{"product_id": productID}, params)
+}
+
+func (i *inventoryRepository) GetInventoryByLocationID(ctx context.Context, locationID primitive.ObjectID, params pagination.Params) (*pagination.Page[domain.Inventory], error) {
+	return i.findPage(ctx, bson.M{"location_id": locationID}, params)
+}
 
-	cursor, err := i.collection.Find(ctx, bson.D{})
+func (i *inventoryRepository) GetInventoriesByTransferID(ctx context.Context, transferID primitive.ObjectID) ([]domain.Inventory, error) {
+	cursor, err := i.collection.Find(ctx, bson.M{"transfer_id": transferID})
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
+	var inventories []domain.Inventory
 	err = cursor.All(ctx, &inventories)
 	if err != nil {
 		return nil, err
@@ -51,21 +91,33 @@ func (i *inventoryRepository) GetAllInventories(ctx context.Context) ([]domain.I
 	return inventories, nil
 }
 
-func (i *inventoryRepository) GetInventoryByID(ctx context.Context, id primitive.ObjectID) (*domain.Inventory, error) {
-	var inventory domain.Inventory
+// GetInventoriesByReservationID returns the units a reservation holds or has
+// sold, ordered by serial number.
+func (i *inventoryRepository) GetInventoriesByReservationID(ctx context.Context, reservationID string) ([]domain.Inventory, error) {
+	cursor, err := i.collection.Find(ctx, bson.M{"reservation.id": reservationID}, options.Find().SetSort(bson.M{"serial_number": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
 
-	err := i.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&inventory)
+	var inventories []domain.Inventory
+	err = cursor.All(ctx, &inventories)
 	if err != nil {
 		return nil, err
 	}
 
-	return &inventory, nil
+	return inventories, nil
 }
 
-func (i *inventoryRepository) GetInventoryByProductID(ctx context.Context, productID primitive.ObjectID) ([]domain.Inventory, error) {
+func (i *inventoryRepository) findPage(ctx context.Context, filter bson.M, params pagination.Params) (*pagination.Page[domain.Inventory], error) {
 	var inventories []domain.Inventory
 
-	cursor, err := i.collection.Find(ctx, bson.M{"product_id": productID})
+	total, err := i.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := i.collection.Find(ctx, params.Query(filter), params.FindOptions())
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +128,7 @@ func (i *inventoryRepository) GetInventoryByProductID(ctx context.Context, produ
 		return nil, err
 	}
 
-	return inventories, nil
+	return pagination.NewPage(inventories, total, params)
 }
 
 func (i *inventoryRepository) GetInventoryBySerialNumber(ctx context.Context, serialNumber string) (*domain.Inventory, error) {
@@ -105,15 +157,56 @@ func (i *inventoryRepository) CreateInventory(ctx context.Context, inventory *do
 	return nil
 }
 
-func (i *inventoryRepository) UpdateInventory(ctx context.Context, inventory *domain.Inventory) error {
+func (i *inventoryRepository) CreateInventories(ctx context.Context, inventories []domain.Inventory) error {
+	if len(inventories) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	docs := make([]interface{}, len(inventories))
+	for n := range inventories {
+		inventories[n].CreatedAt = now
+		inventories[n].UpdatedAt = now
+		docs[n] = inventories[n]
+	}
+
+	_, err := i.collection.InsertMany(ctx, docs)
+	return err
+}
+
+// GetExistingSerialNumbers returns which of serialNumbers are already used by
+// a unit.
+func (i *inventoryRepository) GetExistingSerialNumbers(ctx context.Context, serialNumbers []string) ([]string, error) {
+	if len(serialNumbers) == 0 {
+		return nil, nil
+	}
+
+	values, err := i.collection.Distinct(ctx, "serial_number", bson.M{"serial_number": bson.M{"$in": serialNumbers}})
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			existing = append(existing, s)
+		}
+	}
+	return existing, nil
+}
+
+// UpdateInventory replaces the unit, provided it is still in status and has
+// not changed since updatedAt. It returns false when it has.
+func (i *inventoryRepository) UpdateInventory(ctx context.Context, inventory *domain.Inventory, status string, updatedAt time.Time) (bool, error) {
 	inventory.UpdatedAt = time.Now()
 
-	_, err := i.collection.UpdateOne(ctx, bson.M{"_id": inventory.ID}, bson.M{"$set": inventory})
+	filter := bson.M{"_id": inventory.ID, "status": status, "updated_at": updatedAt}
+	result, err := i.collection.UpdateOne(ctx, filter, bson.M{"$set": inventory})
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	return nil
+	return result.MatchedCount == 1, nil
 }
 
 func (i *inventoryRepository) DeleteInventory(ctx context.Context, id primitive.ObjectID) error {
@@ -127,7 +220,7 @@ func (i *inventoryRepository) DeleteInventory(ctx context.Context, id primitive.
 
 func (i *inventoryRepository) GetProductQuantity(ctx context.Context, productID primitive.ObjectID) (int64, error) {
 	pipeline := bson.A{
-		bson.M{"$match": bson.M{"product_id": productID, "status": "in_stock"}},
+		bson.M{"$match": bson.M{"product_id": productID, "status": domain.StatusInStock}},
 		bson.M{"$group": bson.M{
 			"_id":   nil,
 			"total": bson.M{"$sum": 1},
@@ -153,40 +246,278 @@ func (i *inventoryRepository) GetProductQuantity(ctx context.Context, productID
 	return result.Total, nil
 }
 
-func (i *inventoryRepository) FindAndUpdateStatus(ctx context.Context, productID primitive.ObjectID, status string, limit int64) ([]domain.Inventory, error) {
-	filterStatus := "in_stock"
-	if status == "in_stock" {
-		filterStatus = "reserved"
+func (i *inventoryRepository) GetVariantQuantity(ctx context.Context, productID, variantID primitive.ObjectID) (int64, error) {
+	return i.collection.CountDocuments(ctx, bson.M{"product_id": productID, "variant_id": variantID, "status": domain.StatusInStock})
+}
+
+// GetProductQuantities counts the in_stock units of each product. Products
+// without any are left out of the map.
+func (i *inventoryRepository) GetProductQuantities(ctx context.Context, productIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error) {
+	quantities := make(map[primitive.ObjectID]int64, len(productIDs))
+	if len(productIDs) == 0 {
+		return quantities, nil
 	}
 
-	filter := bson.M{"product_id": productID, "status": filterStatus}
-	update := bson.M{"$set": bson.M{"status": status}}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"product_id": bson.M{"$in": productIDs}, "status": domain.StatusInStock}},
+		bson.M{"$group": bson.M{
+			"_id":   "$product_id",
+			"total": bson.M{"$sum": 1},
+		}},
+	}
 
-	opts := options.Find().SetLimit(limit)
-	cursor, err := i.collection.Find(ctx, filter, opts)
+	cursor, err := i.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var inventories []domain.Inventory
-	if err := cursor.All(ctx, &inventories); err != nil {
+	var results []struct {
+		ProductID primitive.ObjectID `bson:"_id"`
+		Total     int64              `bson:"total"`
+	}
+	err = cursor.All(ctx, &results)
+	if err != nil {
 		return nil, err
 	}
 
-	var ids []primitive.ObjectID
-	for _, inventory := range inventories {
-		ids = append(ids, inventory.ID)
+	for _, r := range results {
+		quantities[r.ProductID] = r.Total
 	}
+	return quantities, nil
+}
 
-	if len(ids) == 0 {
-		return nil, fmt.Errorf("no inventory items found to update for product %s with status %s", productID.Hex(), filterStatus)
+// GetInStockProductIDs returns the IDs of products with at least one unit in
+// stock.
+func (i *inventoryRepository) GetInStockProductIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	values, err := i.collection.Distinct(ctx, "product_id", bson.M{"status": domain.StatusInStock})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
 	}
+	return ids, nil
+}
 
-	_, err = i.collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, update)
+// GetProductStockByLocation counts a product's in_stock units per location.
+func (i *inventoryRepository) GetProductStockByLocation(ctx context.Context, productID primitive.ObjectID) ([]domain.LocationStock, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"product_id": productID, "status": domain.StatusInStock}},
+		bson.M{"$group": bson.M{
+			"_id":      "$location_id",
+			"quantity": bson.M{"$sum": 1},
+		}},
+		bson.M{"$sort": bson.M{"quantity": -1}},
+	}
+
+	cursor, err := i.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	return inventories, nil
+	stock := []domain.LocationStock{}
+	err = cursor.All(ctx, &stock)
+	if err != nil {
+		return nil, err
+	}
+
+	return stock, nil
+}
+
+func (i *inventoryRepository) CountUnitsByLocationID(ctx context.Context, locationID primitive.ObjectID) (int64, error) {
+	return i.collection.CountDocuments(ctx, bson.M{"location_id": locationID})
+}
+
+func (i *inventoryRepository) CountVariantUnits(ctx context.Context, variantID primitive.ObjectID) (int64, error) {
+	return i.collection.CountDocuments(ctx, bson.M{"variant_id": variantID})
+}
+
+func (i *inventoryRepository) CountUnitsByProductIDs(ctx context.Context, productIDs []primitive.ObjectID) (int64, error) {
+	if len(productIDs) == 0 {
+		return 0, nil
+	}
+	return i.collection.CountDocuments(ctx, bson.M{"product_id": bson.M{"$in": productIDs}})
+}
+
+// TransitionUnit atomically moves one unit matching transition and returns
+// it as it was before the move, or nil when no unit matches.
+func (i *inventoryRepository) TransitionUnit(ctx context.Context, transition domain.UnitTransition) (*domain.Inventory, error) {
+	filter := bson.M{"product_id": transition.ProductID, "status": transition.From}
+	if !transition.VariantID.IsZero() {
+		filter["variant_id"] = transition.VariantID
+	}
+	if !transition.LocationID.IsZero() {
+		filter["location_id"] = transition.LocationID
+	}
+	if transition.ReservationID != "" {
+		filter["reservation.id"] = transition.ReservationID
+	}
+	if transition.SerialNumber != "" {
+		filter["serial_number"] = transition.SerialNumber
+	}
+
+	set := bson.M{"status": transition.To, "updated_at": time.Now()}
+	update := bson.M{"$set": set}
+	switch {
+	case transition.Reservation != nil:
+		set["reservation"] = transition.Reservation
+	case transition.To == domain.StatusInStock:
+		update["$unset"] = bson.M{"reservation": ""}
+	}
+	if transition.Sale != nil {
+		set["sale"] = transition.Sale
+	}
+
+	var inventory domain.Inventory
+	err := i.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&inventory)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &inventory, nil
+}
+
+// TransitionUnitByID moves the unit id from status from to status to and
+// returns it, or nil when the unit is no longer in from.
+func (i *inventoryRepository) TransitionUnitByID(ctx context.Context, id primitive.ObjectID, from, to string) (*domain.Inventory, error) {
+	update := bson.M{"$set": bson.M{"status": to, "updated_at": time.Now()}}
+
+	var inventory domain.Inventory
+	err := i.collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "status": from}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&inventory)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &inventory, nil
+}
+
+// RestoreUnit puts back the status, reservation and sale unit had before it
+// moved to status. It returns false when the unit is no longer in status.
+func (i *inventoryRepository) RestoreUnit(ctx context.Context, unit domain.Inventory, status string) (bool, error) {
+	set := bson.M{"status": unit.Status, "updated_at": time.Now()}
+	unset := bson.M{}
+	if unit.Reservation != nil {
+		set["reservation"] = unit.Reservation
+	} else {
+		unset["reservation"] = ""
+	}
+	if unit.Sale != nil {
+		set["sale"] = unit.Sale
+	} else {
+		unset["sale"] = ""
+	}
+
+	result, err := i.collection.UpdateOne(ctx, bson.M{"_id": unit.ID, "status": status}, bson.M{"$set": set, "$unset": unset})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// ReleaseExpiredReservations returns reserved units whose reservation expired
+// before now to stock. It returns the released units as they were before the
+// release, so callers can still see which reservation held them.
+func (i *inventoryRepository) ReleaseExpiredReservations(ctx context.Context, now time.Time) ([]domain.Inventory, error) {
+	expired := bson.M{"status": domain.StatusReserved, "reservation.expires_at": bson.M{"$lte": now}}
+
+	cursor, err := i.collection.Find(ctx, expired, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err = cursor.All(ctx, &docs)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"status": domain.StatusInStock, "updated_at": now},
+		"$unset": bson.M{"reservation": ""},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	var released []domain.Inventory
+	for _, doc := range docs {
+		// re-check the condition per unit: it may have been paid or cancelled
+		// since the find
+		filter := bson.M{"_id": doc.ID}
+		for k, v := range expired {
+			filter[k] = v
+		}
+
+		var inventory domain.Inventory
+		err := i.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&inventory)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return released, err
+		}
+		released = append(released, inventory)
+	}
+
+	return released, nil
+}
+
+// CommitReservation ties the reserved units of a reservation to orderID and
+// stops them expiring. It returns how many units the reservation still held.
+func (i *inventoryRepository) CommitReservation(ctx context.Context, reservationID, orderID string) (int64, error) {
+	filter := bson.M{"status": domain.StatusReserved, "reservation.id": reservationID}
+	update := bson.M{
+		"$set":   bson.M{"reservation.order_id": orderID, "updated_at": time.Now()},
+		"$unset": bson.M{"reservation.expires_at": ""},
+	}
+
+	result, err := i.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.MatchedCount, nil
+}
+
+// DispatchUnit puts the unit id, which must be in stock at locationID, in
+// transit under transferID. It returns nil when the unit is not available.
+func (i *inventoryRepository) DispatchUnit(ctx context.Context, id, locationID, transferID primitive.ObjectID) (*domain.Inventory, error) {
+	filter := bson.M{"_id": id, "status": domain.StatusInStock, "location_id": locationID}
+	update := bson.M{"$set": bson.M{"status": domain.StatusInTransit, "transfer_id": transferID, "updated_at": time.Now()}}
+
+	var inventory domain.Inventory
+	err := i.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&inventory)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &inventory, nil
+}
+
+// ReceiveTransfer puts the units still in transit under transferID in stock
+// at locationID.
+func (i *inventoryRepository) ReceiveTransfer(ctx context.Context, transferID, locationID primitive.ObjectID) (int64, error) {
+	update := bson.M{
+		"$set":   bson.M{"status": domain.StatusInStock, "location_id": locationID, "updated_at": time.Now()},
+		"$unset": bson.M{"transfer_id": ""},
+	}
+
+	result, err := i.collection.UpdateMany(ctx, bson.M{"transfer_id": transferID, "status": domain.StatusInTransit}, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
 }
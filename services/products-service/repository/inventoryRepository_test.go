@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testDatabase connects to the MongoDB in MONGO_TEST_URI and returns a
+// throwaway database that is dropped when the test ends.
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	db := client.Database(fmt.Sprintf("products_test_%s", primitive.NewObjectID().Hex()))
+	t.Cleanup(func() {
+		ctx := context.Background()
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return db
+}
+
+func TestTransitionUnitConcurrent(t *testing.T) {
+	const (
+		units   = 50
+		workers = 200
+	)
+
+	db := testDatabase(t)
+	repo := NewInventoryRepository(db)
+	ctx := context.Background()
+
+	productID := primitive.NewObjectID()
+	for n := 0; n < units; n++ {
+		err := repo.CreateInventory(ctx, &domain.Inventory{
+			ID:           primitive.NewObjectID(),
+			ProductID:    productID,
+			SerialNumber: fmt.Sprintf("SN-%03d", n),
+			Status:       "in_stock",
+		})
+		if err != nil {
+			t.Fatalf("create unit: %v", err)
+		}
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		claimed = map[primitive.ObjectID]int{}
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unit, err := repo.TransitionUnit(ctx, domain.UnitTransition{
+				ProductID: productID,
+				From:      "in_stock",
+				To:        "reserved",
+			})
+			if err != nil {
+				t.Errorf("transition: %v", err)
+				return
+			}
+			if unit == nil {
+				return
+			}
+			mu.Lock()
+			claimed[unit.ID]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(claimed) != units {
+		t.Fatalf("claimed %d distinct units, want %d", len(claimed), units)
+	}
+	for id, n := range claimed {
+		if n != 1 {
+			t.Fatalf("unit %s claimed %d times", id.Hex(), n)
+		}
+	}
+
+	left, err := repo.GetProductQuantity(ctx, productID)
+	if err != nil {
+		t.Fatalf("quantity: %v", err)
+	}
+	if left != 0 {
+		t.Fatalf("in_stock units left = %d, want 0", left)
+	}
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type LocationRepository interface {
+	GetAllLocations(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Location], error)
+	GetLocationByID(ctx context.Context, id primitive.ObjectID) (*domain.Location, error)
+	GetLocationByCode(ctx context.Context, code string) (*domain.Location, error)
+	GetLocationsByCodes(ctx context.Context, codes []string) ([]domain.Location, error)
+	CreateLocation(ctx context.Context, location *domain.Location) error
+	UpdateLocation(ctx context.Context, location *domain.Location) error
+	DeleteLocation(ctx context.Context, id primitive.ObjectID) error
+}
+
+type locationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLocationRepository(db *mongo.Database) *locationRepository {
+	return &locationRepository{
+		collection: db.Collection("locations"),
+	}
+}
+
+func (l *locationRepository) GetAllLocations(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Location], error) {
+	var locations []domain.Location
+
+	filter := bson.M{}
+	total, err := l.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := l.collection.Find(ctx, params.Query(filter), params.FindOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &locations)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(locations, total, params)
+}
+
+func (l *locationRepository) GetLocationByID(ctx context.Context, id primitive.ObjectID) (*domain.Location, error) {
+	var location domain.Location
+
+	err := l.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&location)
+	if err != nil {
+		return nil, err
+	}
+
+	return &location, nil
+}
+
+func (l *locationRepository) GetLocationByCode(ctx context.Context, code string) (*domain.Location, error) {
+	var location domain.Location
+
+	err := l.collection.FindOne(ctx, bson.M{"code": code}).Decode(&location)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &location, nil
+}
+
+// GetLocationsByCodes returns the locations with the given codes, in no
+// particular order. Unknown codes are skipped.
+func (l *locationRepository) GetLocationsByCodes(ctx context.Context, codes []string) ([]domain.Location, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := l.collection.Find(ctx, bson.M{"code": bson.M{"$in": codes}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var locations []domain.Location
+	err = cursor.All(ctx, &locations)
+	if err != nil {
+		return nil, err
+	}
+
+	return locations, nil
+}
+
+func (l *locationRepository) CreateLocation(ctx context.Context, location *domain.Location) error {
+	location.CreatedAt = time.Now()
+	location.UpdatedAt = time.Now()
+
+	_, err := l.collection.InsertOne(ctx, location)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (l *locationRepository) UpdateLocation(ctx context.Context, location *domain.Location) error {
+	location.UpdatedAt = time.Now()
+
+	_, err := l.collection.UpdateOne(ctx, bson.M{"_id": location.ID}, bson.M{"$set": location})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (l *locationRepository) DeleteLocation(ctx context.Context, id primitive.ObjectID) error {
+	_, err := l.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
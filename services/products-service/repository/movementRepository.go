@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MovementRepository stores the inventory movement ledger. Entries are only
+// ever appended.
+type MovementRepository interface {
+	CreateMovements(ctx context.Context, movements []domain.InventoryMovement) error
+	GetMovementsByInventoryID(ctx context.Context, inventoryID primitive.ObjectID, params pagination.Params) (*pagination.Page[domain.InventoryMovement], error)
+	GetMovementsBySerialNumber(ctx context.Context, serialNumber string, params pagination.Params) (*pagination.Page[domain.InventoryMovement], error)
+}
+
+type movementRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMovementRepository(db *mongo.Database) *movementRepository {
+	return &movementRepository{
+		collection: db.Collection("inventory_movements"),
+	}
+}
+
+func (m *movementRepository) CreateMovements(ctx context.Context, movements []domain.InventoryMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(movements))
+	for i := range movements {
+		if movements[i].ID.IsZero() {
+			movements[i].ID = primitive.NewObjectID()
+		}
+		docs[i] = movements[i]
+	}
+
+	_, err := m.collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (m *movementRepository) GetMovementsByInventoryID(ctx context.Context, inventoryID primitive.ObjectID, params pagination.Params) (*pagination.Page[domain.InventoryMovement], error) {
+	return m.findPage(ctx, bson.M{"inventory_id": inventoryID}, params)
+}
+
+func (m *movementRepository) GetMovementsBySerialNumber(ctx context.Context, serialNumber string, params pagination.Params) (*pagination.Page[domain.InventoryMovement], error) {
+	return m.findPage(ctx, bson.M{"serial_number": serialNumber}, params)
+}
+
+func (m *movementRepository) findPage(ctx context.Context, filter bson.M, params pagination.Params) (*pagination.Page[domain.InventoryMovement], error) {
+	var movements []domain.InventoryMovement
+
+	total, err := m.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := m.collection.Find(ctx, params.Query(filter), params.FindOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &movements)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(movements, total, params)
+}
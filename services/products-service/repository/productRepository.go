@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -13,14 +14,28 @@ import (
 )
 
 type ProductRepository interface {
-	GetAllProducts(ctx context.Context, limit, skip int, sortField, sortOrder, search string) ([]domain.Product, error)
+	GetAllProducts(ctx context.Context, params pagination.Params, filter domain.ProductFilter) (*pagination.Page[domain.Product], error)
 	GetProductByID(ctx context.Context, id primitive.ObjectID) (*domain.Product, error)
 	GetProductByName(ctx context.Context, name string) (*domain.Product, error)
+	GetProductBySKU(ctx context.Context, sku string) (*domain.Product, error)
 	CreateProduct(ctx context.Context, product *domain.Product) error
-	UpdateProduct(ctx context.Context, product *domain.Product) error
+	UpdateProduct(ctx context.Context, product *domain.Product, updatedAt time.Time) (bool, error)
+	AddVariant(ctx context.Context, productID primitive.ObjectID, updatedAt time.Time, variant domain.Variant) (bool, error)
+	UpdateVariant(ctx context.Context, productID primitive.ObjectID, updatedAt time.Time, variant domain.Variant) (bool, error)
+	RemoveVariant(ctx context.Context, productID primitive.ObjectID, updatedAt time.Time, variantID primitive.ObjectID) (bool, error)
 	DeleteProduct(ctx context.Context, id primitive.ObjectID) error
+	GetProductIDsByReference(ctx context.Context, field string, id primitive.ObjectID) ([]primitive.ObjectID, error)
+	ReassignProducts(ctx context.Context, field string, from, to primitive.ObjectID) (int64, error)
+	DeleteProductsByIDs(ctx context.Context, ids []primitive.ObjectID) (int64, error)
 }
 
+// Product fields that reference the brand, category and type collections.
+const (
+	BrandReference    = "brand_id"
+	CategoryReference = "category_id"
+	TypeReference     = "type_id"
+)
+
 type productRepository struct {
 	collection *mongo.Collection
 }
@@ -31,29 +46,26 @@ func NewProductRepository(db *mongo.Database) *productRepository {
 	}
 }
 
-func (p *productRepository) GetAllProducts(ctx context.Context, limit, skip int, sortField, sortOrder, search string) ([]domain.Product, error) {
-	var products []domain.Product
-
-	filter := bson.M{}
-	if search != "" {
-		filter["$text"] = bson.M{
-			"$search": search,
-		}
-	}
+// EnsureIndexes keeps variant SKUs unique across products. Products without
+// variants are left out of the index.
+func (p *productRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := p.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "variants.sku", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"variants.sku": bson.M{"$exists": true}}),
+	})
+	return err
+}
 
-	findOptions := options.Find()
-	findOptions.SetLimit(int64(limit))
-	findOptions.SetSkip(int64(skip))
+func (p *productRepository) GetAllProducts(ctx context.Context, params pagination.Params, filter domain.ProductFilter) (*pagination.Page[domain.Product], error) {
+	var products []domain.Product
 
-	if sortField != "" {
-		sortOrderValue := 1
-		if sortOrder == "desc" {
-			sortOrderValue = -1
-		}
-		findOptions.SetSort(bson.D{{Key: sortField, Value: sortOrderValue}})
+	query := productQuery(filter)
+	total, err := p.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, err
 	}
 
-	cursor, err := p.collection.Find(ctx, filter, findOptions)
+	cursor, err := p.collection.Find(ctx, params.Query(query), params.FindOptions())
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +76,7 @@ func (p *productRepository) GetAllProducts(ctx context.Context, limit, skip int,
 		return nil, err
 	}
 
-	return products, nil
+	return pagination.NewPage(products, total, params)
 }
 
 func (p *productRepository) GetProductByID(ctx context.Context, id primitive.ObjectID) (*domain.Product, error) {
@@ -101,6 +113,19 @@ func (p *productRepository) GetProductByName(ctx context.Context, name string) (
 	return &product, nil
 }
 
+func (p *productRepository) GetProductBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	var product domain.Product
+	err := p.collection.FindOne(ctx, bson.M{"variants.sku": sku}).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &product, nil
+}
+
 func (p *productRepository) CreateProduct(ctx context.Context, product *domain.Product) error {
 	product.CreatedAt = time.Now()
 	product.UpdatedAt = time.Now()
@@ -113,15 +138,54 @@ func (p *productRepository) CreateProduct(ctx context.Context, product *domain.P
 	return nil
 }
 
-func (p *productRepository) UpdateProduct(ctx context.Context, product *domain.Product) error {
+// UpdateProduct replaces the product, provided it has not changed since
+// updatedAt. It returns false when it has.
+func (p *productRepository) UpdateProduct(ctx context.Context, product *domain.Product, updatedAt time.Time) (bool, error) {
 	product.UpdatedAt = time.Now()
 
-	_, err := p.collection.UpdateOne(ctx, bson.M{"_id": product.ID}, bson.M{"$set": product})
+	result, err := p.collection.UpdateOne(ctx, bson.M{"_id": product.ID, "updated_at": updatedAt}, bson.M{"$set": product})
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	return nil
+	return result.MatchedCount == 1, nil
+}
+
+// AddVariant appends variant to the product, provided the product has not
+// changed since updatedAt. It returns false when it has.
+func (p *productRepository) AddVariant(ctx context.Context, productID primitive.ObjectID, updatedAt time.Time, variant domain.Variant) (bool, error) {
+	update := bson.M{
+		"$push": bson.M{"variants": variant},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	return p.updateVariants(ctx, bson.M{"_id": productID, "updated_at": updatedAt}, update)
+}
+
+// UpdateVariant replaces the product's variant with variant's ID, provided
+// the product has not changed since updatedAt. It returns false when it has.
+func (p *productRepository) UpdateVariant(ctx context.Context, productID primitive.ObjectID, updatedAt time.Time, variant domain.Variant) (bool, error) {
+	filter := bson.M{"_id": productID, "updated_at": updatedAt, "variants._id": variant.ID}
+	update := bson.M{"$set": bson.M{"variants.$": variant, "updated_at": time.Now()}}
+	return p.updateVariants(ctx, filter, update)
+}
+
+// RemoveVariant removes the variant variantID from the product, provided the
+// product has not changed since updatedAt. It returns false when it has.
+func (p *productRepository) RemoveVariant(ctx context.Context, productID primitive.ObjectID, updatedAt time.Time, variantID primitive.ObjectID) (bool, error) {
+	filter := bson.M{"_id": productID, "updated_at": updatedAt, "variants._id": variantID}
+	update := bson.M{
+		"$pull": bson.M{"variants": bson.M{"_id": variantID}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	return p.updateVariants(ctx, filter, update)
+}
+
+func (p *productRepository) updateVariants(ctx context.Context, filter, update bson.M) (bool, error) {
+	result, err := p.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
 }
 
 func (p *productRepository) DeleteProduct(ctx context.Context, id primitive.ObjectID) error {
@@ -132,3 +196,79 @@ func (p *productRepository) DeleteProduct(ctx context.Context, id primitive.Obje
 
 	return nil
 }
+
+// GetProductIDsByReference returns the IDs of products whose field (one of the
+// *Reference constants) is id.
+func (p *productRepository) GetProductIDsByReference(ctx context.Context, field string, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	cursor, err := p.collection.Find(ctx, bson.M{field: id}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(docs))
+	for i, d := range docs {
+		ids[i] = d.ID
+	}
+	return ids, nil
+}
+
+func (p *productRepository) ReassignProducts(ctx context.Context, field string, from, to primitive.ObjectID) (int64, error) {
+	result, err := p.collection.UpdateMany(ctx, bson.M{field: from}, bson.M{"$set": bson.M{
+		field:        to,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (p *productRepository) DeleteProductsByIDs(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	result, err := p.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func productQuery(filter domain.ProductFilter) bson.M {
+	query := bson.M{}
+	if filter.Search != "" {
+		query["$text"] = bson.M{
+			"$search": filter.Search,
+		}
+	}
+	if filter.TypeID != nil {
+		query["type_id"] = *filter.TypeID
+	}
+	if filter.CategoryIDs != nil {
+		query["category_id"] = bson.M{"$in": filter.CategoryIDs}
+	}
+	if filter.IDs != nil {
+		query["_id"] = bson.M{"$in": filter.IDs}
+	}
+
+	for _, attribute := range filter.Attributes {
+		condition := bson.M{}
+		if len(attribute.Values) > 0 {
+			condition["$in"] = attribute.Values
+		}
+		if attribute.Min != nil {
+			condition["$gte"] = *attribute.Min
+		}
+		if attribute.Max != nil {
+			condition["$lte"] = *attribute.Max
+		}
+		query["specifications."+attribute.Name] = condition
+	}
+
+	return query
+}
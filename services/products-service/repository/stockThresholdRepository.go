@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type StockThresholdRepository interface {
+	GetAllThresholds(ctx context.Context, params pagination.Params) (*pagination.Page[domain.StockThreshold], error)
+	ListThresholds(ctx context.Context) ([]domain.StockThreshold, error)
+	GetThreshold(ctx context.Context, productID primitive.ObjectID) (*domain.StockThreshold, error)
+	GetThresholdsByProductIDs(ctx context.Context, productIDs []primitive.ObjectID) ([]domain.StockThreshold, error)
+	SaveThreshold(ctx context.Context, threshold *domain.StockThreshold) error
+	DeleteThreshold(ctx context.Context, productID primitive.ObjectID) error
+	SetAlerting(ctx context.Context, productID primitive.ObjectID, alerting bool, at time.Time) (bool, error)
+}
+
+type stockThresholdRepository struct {
+	collection *mongo.Collection
+}
+
+func NewStockThresholdRepository(db *mongo.Database) *stockThresholdRepository {
+	return &stockThresholdRepository{
+		collection: db.Collection("stock_thresholds"),
+	}
+}
+
+func (s *stockThresholdRepository) GetAllThresholds(ctx context.Context, params pagination.Params) (*pagination.Page[domain.StockThreshold], error) {
+	var thresholds []domain.StockThreshold
+
+	filter := bson.M{}
+	total, err := s.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.collection.Find(ctx, params.Query(filter), params.FindOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &thresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(thresholds, total, params)
+}
+
+// ListThresholds returns every threshold, for the periodic stock check.
+func (s *stockThresholdRepository) ListThresholds(ctx context.Context) ([]domain.StockThreshold, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var thresholds []domain.StockThreshold
+	err = cursor.All(ctx, &thresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	return thresholds, nil
+}
+
+func (s *stockThresholdRepository) GetThreshold(ctx context.Context, productID primitive.ObjectID) (*domain.StockThreshold, error) {
+	var threshold domain.StockThreshold
+
+	err := s.collection.FindOne(ctx, bson.M{"_id": productID}).Decode(&threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	return &threshold, nil
+}
+
+func (s *stockThresholdRepository) GetThresholdsByProductIDs(ctx context.Context, productIDs []primitive.ObjectID) ([]domain.StockThreshold, error) {
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := s.collection.Find(ctx, bson.M{"_id": bson.M{"$in": productIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var thresholds []domain.StockThreshold
+	err = cursor.All(ctx, &thresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	return thresholds, nil
+}
+
+// SaveThreshold creates or replaces a product's reorder point and target
+// level. The alert state of an existing threshold is kept.
+func (s *stockThresholdRepository) SaveThreshold(ctx context.Context, threshold *domain.StockThreshold) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"reorder_point": threshold.ReorderPoint,
+			"target_level":  threshold.TargetLevel,
+			"updated_at":    now,
+		},
+		"$setOnInsert": bson.M{"alerting": false, "created_at": now},
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return s.collection.FindOneAndUpdate(ctx, bson.M{"_id": threshold.ProductID}, update, opts).Decode(threshold)
+}
+
+func (s *stockThresholdRepository) DeleteThreshold(ctx context.Context, productID primitive.ObjectID) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": productID})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetAlerting switches a threshold's alert state and reports whether it
+// changed, so that only one caller sees a given switch.
+func (s *stockThresholdRepository) SetAlerting(ctx context.Context, productID primitive.ObjectID, alerting bool, at time.Time) (bool, error) {
+	update := bson.M{"$set": bson.M{"alerting": alerting, "alerted_at": at}}
+	if !alerting {
+		update = bson.M{"$set": bson.M{"alerting": false}, "$unset": bson.M{"alerted_at": ""}}
+	}
+
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": productID, "alerting": !alerting}, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
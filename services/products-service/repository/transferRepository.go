@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TransferRepository interface {
+	GetAllTransfers(ctx context.Context, status string, params pagination.Params) (*pagination.Page[domain.Transfer], error)
+	GetTransferByID(ctx context.Context, id primitive.ObjectID) (*domain.Transfer, error)
+	CreateTransfer(ctx context.Context, transfer *domain.Transfer) error
+	CloseTransfer(ctx context.Context, id primitive.ObjectID, from, to, actor string) (*domain.Transfer, error)
+}
+
+type transferRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTransferRepository(db *mongo.Database) *transferRepository {
+	return &transferRepository{
+		collection: db.Collection("transfers"),
+	}
+}
+
+// GetAllTransfers lists transfers, only those with status when it is set.
+func (t *transferRepository) GetAllTransfers(ctx context.Context, status string, params pagination.Params) (*pagination.Page[domain.Transfer], error) {
+	var transfers []domain.Transfer
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	total, err := t.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := t.collection.Find(ctx, params.Query(filter), params.FindOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &transfers)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(transfers, total, params)
+}
+
+func (t *transferRepository) GetTransferByID(ctx context.Context, id primitive.ObjectID) (*domain.Transfer, error) {
+	var transfer domain.Transfer
+
+	err := t.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&transfer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transfer, nil
+}
+
+func (t *transferRepository) CreateTransfer(ctx context.Context, transfer *domain.Transfer) error {
+	transfer.CreatedAt = time.Now()
+	transfer.UpdatedAt = time.Now()
+
+	_, err := t.collection.InsertOne(ctx, transfer)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CloseTransfer moves the transfer id from status from to status to and
+// returns it, or nil when it is no longer in from. Moving it back to in
+// transit clears who closed it.
+func (t *transferRepository) CloseTransfer(ctx context.Context, id primitive.ObjectID, from, to, actor string) (*domain.Transfer, error) {
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"status": to, "closed_by": actor, "closed_at": now, "updated_at": now}}
+	if to == domain.TransferInTransit {
+		update = bson.M{
+			"$set":   bson.M{"status": to, "updated_at": now},
+			"$unset": bson.M{"closed_by": "", "closed_at": ""},
+		}
+	}
+
+	var transfer domain.Transfer
+	err := t.collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "status": from}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&transfer)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &transfer, nil
+}
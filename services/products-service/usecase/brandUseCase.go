@@ -3,33 +3,42 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"github.com/mephirious/group-project/services/products-service/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type BrandUseCase interface {
-	GetAllBrands(ctx context.Context) ([]domain.Brand, error)
+	GetAllBrands(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Brand], error)
 	GetBrandByID(ctx context.Context, id primitive.ObjectID) (*domain.Brand, error)
 	GetBrandByName(ctx context.Context, name string) (*domain.Brand, error)
 	CreateBrand(ctx context.Context, brand *domain.Brand) error
 	UpdateBrand(ctx context.Context, brand *domain.Brand) error
-	DeleteBrand(ctx context.Context, id primitive.ObjectID) error
+	DeleteBrand(ctx context.Context, id primitive.ObjectID, opts domain.DeleteOptions) error
+	GetBrandUsage(ctx context.Context, id primitive.ObjectID) (*domain.Usage, error)
 }
 
 type brandUseCase struct {
 	brandRepository repository.BrandRepository
+	products        productReferences
 }
 
-func NewBrandUseCase(repository repository.BrandRepository) *brandUseCase {
+func NewBrandUseCase(brandRepository repository.BrandRepository, productRepository repository.ProductRepository, inventoryRepository repository.InventoryRepository) *brandUseCase {
 	return &brandUseCase{
-		brandRepository: repository,
+		brandRepository: brandRepository,
+		products: productReferences{
+			field:               repository.BrandReference,
+			productRepository:   productRepository,
+			inventoryRepository: inventoryRepository,
+		},
 	}
 }
 
-func (b *brandUseCase) GetAllBrands(ctx context.Context) ([]domain.Brand, error) {
-	return b.brandRepository.GetAllBrands(ctx)
+func (b *brandUseCase) GetAllBrands(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Brand], error) {
+	return b.brandRepository.GetAllBrands(ctx, params)
 }
 
 func (b *brandUseCase) GetBrandByID(ctx context.Context, id primitive.ObjectID) (*domain.Brand, error) {
@@ -70,6 +79,30 @@ func (b *brandUseCase) UpdateBrand(ctx context.Context, brand *domain.Brand) err
 	return b.brandRepository.UpdateBrand(ctx, brand)
 }
 
-func (b *brandUseCase) DeleteBrand(ctx context.Context, id primitive.ObjectID) error {
+func (b *brandUseCase) DeleteBrand(ctx context.Context, id primitive.ObjectID, opts domain.DeleteOptions) error {
+	if _, err := b.brandRepository.GetBrandByID(ctx, id); err != nil {
+		return errors.New("brand not found")
+	}
+	if opts.Strategy == domain.DeleteReassign && opts.ReassignTo != nil {
+		if _, err := b.brandRepository.GetBrandByID(ctx, *opts.ReassignTo); err != nil {
+			return fmt.Errorf("%w: brand %s not found", ErrInvalidReference, opts.ReassignTo.Hex())
+		}
+	}
+	if err := b.products.release(ctx, id, opts); err != nil {
+		return err
+	}
+
 	return b.brandRepository.DeleteBrand(ctx, id)
 }
+
+func (b *brandUseCase) GetBrandUsage(ctx context.Context, id primitive.ObjectID) (*domain.Usage, error) {
+	if _, err := b.brandRepository.GetBrandByID(ctx, id); err != nil {
+		return nil, errors.New("brand not found")
+	}
+
+	usage, err := b.products.usage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
@@ -3,28 +3,46 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
 	"github.com/mephirious/group-project/services/products-service/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CategoryUseCase interface {
 	GetAllCategories(ctx context.Context) ([]domain.Category, error)
+	GetCategoryTree(ctx context.Context) ([]*domain.CategoryNode, error)
 	GetCategoryByID(ctx context.Context, id primitive.ObjectID) (*domain.Category, error)
 	GetCategoryByName(ctx context.Context, name string) (*domain.Category, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error)
 	CreateCategory(ctx context.Context, category *domain.Category) error
 	UpdateCategory(ctx context.Context, category *domain.Category) error
-	DeleteCategory(ctx context.Context, id primitive.ObjectID) error
+	MoveCategory(ctx context.Context, id primitive.ObjectID, parentID *primitive.ObjectID) (*domain.Category, error)
+	ReorderCategories(ctx context.Context, parentID *primitive.ObjectID, ids []primitive.ObjectID) error
+	DeleteCategory(ctx context.Context, id primitive.ObjectID, opts domain.DeleteOptions) error
+	GetCategoryUsage(ctx context.Context, id primitive.ObjectID) (*domain.Usage, error)
 }
 
+// ErrCategoryMoved is returned when the categories involved in a move were
+// moved by someone else at the same time. The move can be retried.
+var ErrCategoryMoved = errors.New("category tree changed concurrently, retry")
+
 type categoryUseCase struct {
 	categoryRepository repository.CategoryRepository
+	products           productReferences
 }
 
-func NewCategoryUseCase(repository repository.CategoryRepository) *categoryUseCase {
+func NewCategoryUseCase(categoryRepository repository.CategoryRepository, productRepository repository.ProductRepository, inventoryRepository repository.InventoryRepository) *categoryUseCase {
 	return &categoryUseCase{
-		categoryRepository: repository,
+		categoryRepository: categoryRepository,
+		products: productReferences{
+			field:               repository.CategoryReference,
+			productRepository:   productRepository,
+			inventoryRepository: inventoryRepository,
+		},
 	}
 }
 
@@ -32,6 +50,14 @@ func (c *categoryUseCase) GetAllCategories(ctx context.Context) ([]domain.Catego
 	return c.categoryRepository.GetAllCategories(ctx)
 }
 
+func (c *categoryUseCase) GetCategoryTree(ctx context.Context) ([]*domain.CategoryNode, error) {
+	categories, err := c.categoryRepository.GetAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return domain.BuildCategoryTree(categories), nil
+}
+
 func (c *categoryUseCase) GetCategoryByID(ctx context.Context, id primitive.ObjectID) (*domain.Category, error) {
 	category, err := c.categoryRepository.GetCategoryByID(ctx, id)
 	if err != nil {
@@ -54,22 +80,280 @@ func (c *categoryUseCase) GetCategoryByName(ctx context.Context, name string) (*
 	return category, nil
 }
 
+func (c *categoryUseCase) GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	category, err := c.categoryRepository.GetCategoryBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		return nil, errors.New("category not found")
+	}
+	return category, nil
+}
+
 func (c *categoryUseCase) CreateCategory(ctx context.Context, category *domain.Category) error {
-	existingCategory, err := c.categoryRepository.GetCategoryByName(ctx, category.CategoryName)
+	category.Path = "/"
+	if category.ParentID != nil {
+		parent, err := c.categoryRepository.GetCategoryByID(ctx, *category.ParentID)
+		if err != nil {
+			return errors.New("parent category not found")
+		}
+		category.Path = parent.ChildPath()
+	}
+
+	siblings, err := c.categoryRepository.GetChildren(ctx, category.ParentID)
 	if err != nil {
 		return err
 	}
-	if existingCategory != nil {
-		return errors.New("category already exists")
+	if err := checkSiblingName(siblings, category); err != nil {
+		return err
+	}
+	category.Position = len(siblings)
+
+	if err := c.assignSlug(ctx, category); err != nil {
+		return err
 	}
 
-	return c.categoryRepository.CreateCategory(ctx, category)
+	return slugWritten(c.categoryRepository.CreateCategory(ctx, category), category.Slug)
 }
 
+// UpdateCategory renames a category and changes its slug. Placement in the
+// tree is only changed through MoveCategory and ReorderCategories.
 func (c *categoryUseCase) UpdateCategory(ctx context.Context, category *domain.Category) error {
-	return c.categoryRepository.UpdateCategory(ctx, category)
+	existing, err := c.categoryRepository.GetCategoryByID(ctx, category.ID)
+	if err != nil {
+		return errors.New("category not found")
+	}
+
+	siblings, err := c.categoryRepository.GetChildren(ctx, existing.ParentID)
+	if err != nil {
+		return err
+	}
+	if err := checkSiblingName(siblings, category); err != nil {
+		return err
+	}
+
+	if category.Slug == "" && category.CategoryName == existing.CategoryName {
+		category.Slug = existing.Slug
+	}
+	if err := c.assignSlug(ctx, category); err != nil {
+		return err
+	}
+
+	category.ParentID = existing.ParentID
+	category.Path = existing.Path
+	category.Position = existing.Position
+	category.CreatedAt = existing.CreatedAt
+
+	return slugWritten(c.categoryRepository.UpdateCategory(ctx, category), category.Slug)
 }
 
-func (c *categoryUseCase) DeleteCategory(ctx context.Context, id primitive.ObjectID) error {
+// MoveCategory re-parents a category, appending it to the end of its new
+// siblings. A nil parentID makes it a root category.
+//
+// The cycle check reads the new parent's path, which a concurrent move can
+// change before the category is written. So the category is only moved from
+// where it was read, and the parent's path is confirmed afterwards; if the
+// parent has moved in the meantime the move is undone and ErrCategoryMoved
+// returned.
+func (c *categoryUseCase) MoveCategory(ctx context.Context, id primitive.ObjectID, parentID *primitive.ObjectID) (*domain.Category, error) {
+	category, err := c.categoryRepository.GetCategoryByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("category not found")
+	}
+
+	var parent *domain.Category
+	path := "/"
+	if parentID != nil {
+		if *parentID == id {
+			return nil, errors.New("a category cannot be its own parent")
+		}
+		parent, err = c.categoryRepository.GetCategoryByID(ctx, *parentID)
+		if err != nil {
+			return nil, errors.New("parent category not found")
+		}
+		if category.IsAncestorOf(parent) {
+			return nil, errors.New("cannot move a category below one of its descendants")
+		}
+		path = parent.ChildPath()
+	}
+
+	siblings, err := c.categoryRepository.GetChildren(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSiblingName(siblings, category); err != nil {
+		return nil, err
+	}
+	position := len(siblings)
+
+	moved, err := c.categoryRepository.MoveCategory(ctx, category, parentID, path, position)
+	if err != nil {
+		return nil, err
+	}
+	if !moved {
+		return nil, ErrCategoryMoved
+	}
+
+	placed := *category
+	placed.ParentID, placed.Path, placed.Position = parentID, path, position
+	if parent != nil {
+		confirmed, err := c.categoryRepository.ConfirmPath(ctx, parent.ID, parent.Path)
+		if err == nil && !confirmed {
+			err = ErrCategoryMoved
+		}
+		if err != nil {
+			// the undo has to run even if the request was cancelled
+			if undoErr := c.undoMove(context.WithoutCancel(ctx), category); undoErr != nil {
+				return nil, errors.Join(err, fmt.Errorf("failed to undo the move: %w", undoErr))
+			}
+			return nil, err
+		}
+	}
+	return &placed, nil
+}
+
+// undoMove puts category back under its old parent and at its old position.
+// Paths are read again, as the concurrent move that made the undo necessary
+// may have rewritten them.
+func (c *categoryUseCase) undoMove(ctx context.Context, category *domain.Category) error {
+	current, err := c.categoryRepository.GetCategoryByID(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+	path := "/"
+	if category.ParentID != nil {
+		parent, err := c.categoryRepository.GetCategoryByID(ctx, *category.ParentID)
+		if err != nil {
+			return err
+		}
+		path = parent.ChildPath()
+	}
+
+	moved, err := c.categoryRepository.MoveCategory(ctx, current, category.ParentID, path, category.Position)
+	if err == nil && !moved {
+		err = ErrCategoryMoved
+	}
+	return err
+}
+
+// ReorderCategories sets the order of the children of parentID. ids must list
+// every child exactly once.
+func (c *categoryUseCase) ReorderCategories(ctx context.Context, parentID *primitive.ObjectID, ids []primitive.ObjectID) error {
+	children, err := c.categoryRepository.GetChildren(ctx, parentID)
+	if err != nil {
+		return err
+	}
+	if len(ids) != len(children) {
+		return errors.New("category_ids must list every child category exactly once")
+	}
+
+	remaining := make(map[primitive.ObjectID]bool, len(children))
+	for _, child := range children {
+		remaining[child.ID] = true
+	}
+	for _, id := range ids {
+		if !remaining[id] {
+			return fmt.Errorf("category %s is not a child of this parent or is listed twice", id.Hex())
+		}
+		delete(remaining, id)
+	}
+
+	return c.categoryRepository.SetPositions(ctx, ids)
+}
+
+func (c *categoryUseCase) DeleteCategory(ctx context.Context, id primitive.ObjectID, opts domain.DeleteOptions) error {
+	if _, err := c.categoryRepository.GetCategoryByID(ctx, id); err != nil {
+		return errors.New("category not found")
+	}
+
+	children, err := c.categoryRepository.GetChildren(ctx, &id)
+	if err != nil {
+		return err
+	}
+	if len(children) > 0 {
+		return fmt.Errorf("%w: category has subcategories; move or delete them first", ErrInUse)
+	}
+
+	if opts.Strategy == domain.DeleteReassign && opts.ReassignTo != nil {
+		if _, err := c.categoryRepository.GetCategoryByID(ctx, *opts.ReassignTo); err != nil {
+			return fmt.Errorf("%w: category %s not found", ErrInvalidReference, opts.ReassignTo.Hex())
+		}
+	}
+	if err := c.products.release(ctx, id, opts); err != nil {
+		return err
+	}
+
 	return c.categoryRepository.DeleteCategory(ctx, id)
 }
+
+func (c *categoryUseCase) GetCategoryUsage(ctx context.Context, id primitive.ObjectID) (*domain.Usage, error) {
+	if _, err := c.categoryRepository.GetCategoryByID(ctx, id); err != nil {
+		return nil, errors.New("category not found")
+	}
+
+	usage, err := c.products.usage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	children, err := c.categoryRepository.GetChildren(ctx, &id)
+	if err != nil {
+		return nil, err
+	}
+	usage.Subcategories = int64(len(children))
+	return &usage, nil
+}
+
+// assignSlug validates an explicit slug or derives a unique one from the
+// category name.
+func (c *categoryUseCase) assignSlug(ctx context.Context, category *domain.Category) error {
+	if category.Slug != "" {
+		if domain.Slugify(category.Slug) != category.Slug {
+			return errors.New("slug may only contain lowercase letters, digits and hyphens")
+		}
+		existing, err := c.categoryRepository.GetCategoryBySlug(ctx, category.Slug)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.ID != category.ID {
+			return fmt.Errorf("slug %q is already in use", category.Slug)
+		}
+		return nil
+	}
+
+	base := domain.Slugify(category.CategoryName)
+	if base == "" {
+		return errors.New("cannot derive a slug from the category name")
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		existing, err := c.categoryRepository.GetCategoryBySlug(ctx, slug)
+		if err != nil {
+			return err
+		}
+		if existing == nil || existing.ID == category.ID {
+			category.Slug = slug
+			return nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// slugWritten reports a slug taken by a concurrent write, which the unique
+// slug index rejects after assignSlug found it free.
+func slugWritten(err error, slug string) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("slug %q is already in use", slug)
+	}
+	return err
+}
+
+func checkSiblingName(siblings []domain.Category, category *domain.Category) error {
+	for _, s := range siblings {
+		if s.ID != category.ID && strings.EqualFold(s.CategoryName, category.CategoryName) {
+			return errors.New("category already exists")
+		}
+	}
+	return nil
+}
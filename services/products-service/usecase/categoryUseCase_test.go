@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryCategories is an in-memory CategoryRepository that keeps
+// materialized paths like the Mongo one.
+type memoryCategories struct {
+	repository.CategoryRepository
+
+	categories []domain.Category
+}
+
+func (m *memoryCategories) GetAllCategories(ctx context.Context) ([]domain.Category, error) {
+	return append([]domain.Category(nil), m.categories...), nil
+}
+
+func (m *memoryCategories) GetCategoryByID(ctx context.Context, id primitive.ObjectID) (*domain.Category, error) {
+	for _, c := range m.categories {
+		if c.ID == id {
+			return &c, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryCategories) GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	for _, c := range m.categories {
+		if c.Slug == slug {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryCategories) GetChildren(ctx context.Context, parentID *primitive.ObjectID) ([]domain.Category, error) {
+	var children []domain.Category
+	for _, c := range m.categories {
+		if (c.ParentID == nil && parentID == nil) || (c.ParentID != nil && parentID != nil && *c.ParentID == *parentID) {
+			children = append(children, c)
+		}
+	}
+	return children, nil
+}
+
+func (m *memoryCategories) GetDescendants(ctx context.Context, category *domain.Category) ([]domain.Category, error) {
+	var descendants []domain.Category
+	for _, c := range m.categories {
+		if strings.HasPrefix(c.Path, category.ChildPath()) {
+			descendants = append(descendants, c)
+		}
+	}
+	return descendants, nil
+}
+
+func (m *memoryCategories) MoveCategory(ctx context.Context, category *domain.Category, parentID *primitive.ObjectID, path string, position int) (bool, error) {
+	oldPrefix, newPrefix := category.ChildPath(), path+category.ID.Hex()+"/"
+	for n := range m.categories {
+		c := &m.categories[n]
+		if c.ID != category.ID {
+			continue
+		}
+		if c.Path != category.Path {
+			return false, nil
+		}
+		c.ParentID, c.Path, c.Position = parentID, path, position
+		for n := range m.categories {
+			if d := &m.categories[n]; strings.HasPrefix(d.Path, oldPrefix) {
+				d.Path = newPrefix + d.Path[len(oldPrefix):]
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *memoryCategories) ConfirmPath(ctx context.Context, id primitive.ObjectID, path string) (bool, error) {
+	for _, c := range m.categories {
+		if c.ID == id {
+			return c.Path == path, nil
+		}
+	}
+	return false, nil
+}
+
+// add appends a category named name below parent, or a root when parent is
+// nil, and returns it.
+func (m *memoryCategories) add(name string, parent *domain.Category) domain.Category {
+	category := domain.Category{ID: primitive.NewObjectID(), CategoryName: name, Slug: domain.Slugify(name), Path: "/"}
+	if parent != nil {
+		category.ParentID, category.Path = &parent.ID, parent.ChildPath()
+	}
+	m.categories = append(m.categories, category)
+	return category
+}
+
+func (m *memoryCategories) path(id primitive.ObjectID) string {
+	c, _ := m.GetCategoryByID(context.Background(), id)
+	return c.Path
+}
+
+func TestGetCategoryTree(t *testing.T) {
+	repo := &memoryCategories{}
+	laptops := repo.add("Laptops", nil)
+	gaming := repo.add("Gaming", &laptops)
+	repo.add("Ultrabooks", &laptops)
+	repo.add("RGB", &gaming)
+	repo.add("Accessories", nil)
+	// a category whose parent is gone shows up as a root
+	orphanParent := primitive.NewObjectID()
+	repo.categories = append(repo.categories, domain.Category{ID: primitive.NewObjectID(), CategoryName: "Orphan", ParentID: &orphanParent, Path: "/" + orphanParent.Hex() + "/"})
+
+	tree, err := NewCategoryUseCase(repo, nil, nil).GetCategoryTree(context.Background())
+	if err != nil {
+		t.Fatalf("GetCategoryTree: %v", err)
+	}
+	var roots []string
+	for _, node := range tree {
+		roots = append(roots, node.CategoryName)
+	}
+	if strings.Join(roots, ",") != "Laptops,Accessories,Orphan" {
+		t.Fatalf("roots = %v, want Laptops, Accessories and Orphan", roots)
+	}
+	if children := tree[0].Children; len(children) != 2 || children[0].CategoryName != "Gaming" || len(children[0].Children) != 1 || children[0].Children[0].CategoryName != "RGB" {
+		t.Errorf("Laptops subtree = %+v, want Gaming with RGB, then Ultrabooks", children)
+	}
+}
+
+func TestMoveCategoryRejectsCycles(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryCategories{}
+	laptops := repo.add("Laptops", nil)
+	gaming := repo.add("Gaming", &laptops)
+	rgb := repo.add("RGB", &gaming)
+	uc := NewCategoryUseCase(repo, nil, nil)
+
+	if _, err := uc.MoveCategory(ctx, laptops.ID, &laptops.ID); err == nil {
+		t.Error("moving a category below itself: want error")
+	}
+	if _, err := uc.MoveCategory(ctx, laptops.ID, &rgb.ID); err == nil {
+		t.Error("moving a category below its grandchild: want error")
+	}
+	if got := repo.path(laptops.ID); got != "/" {
+		t.Errorf("Laptops path = %q after rejected moves, want /", got)
+	}
+
+	// moving Gaming to the root carries RGB along
+	moved, err := uc.MoveCategory(ctx, gaming.ID, nil)
+	if err != nil {
+		t.Fatalf("move to root: %v", err)
+	}
+	if moved.ParentID != nil || moved.Path != "/" {
+		t.Errorf("moved = %+v, want a root category", moved)
+	}
+	if got, want := repo.path(rgb.ID), "/"+gaming.ID.Hex()+"/"; got != want {
+		t.Errorf("RGB path = %q, want %q", got, want)
+	}
+}
+
+// movingCategories moves the category mover below target just before a move
+// confirms its parent's path, as a concurrent request that read target before
+// that move would.
+type movingCategories struct {
+	*memoryCategories
+	mover  primitive.ObjectID
+	target domain.Category
+}
+
+func (m movingCategories) ConfirmPath(ctx context.Context, id primitive.ObjectID, path string) (bool, error) {
+	mover, _ := m.GetCategoryByID(ctx, m.mover)
+	if _, err := m.MoveCategory(ctx, mover, &m.target.ID, m.target.ChildPath(), 0); err != nil {
+		return false, err
+	}
+	return m.memoryCategories.ConfirmPath(ctx, id, path)
+}
+
+func TestMoveCategoryUndoesRacingCycle(t *testing.T) {
+	repo := &memoryCategories{}
+	laptops := repo.add("Laptops", nil)
+	phones := repo.add("Phones", nil)
+
+	// Laptops goes below Phones while Phones goes below Laptops
+	racing := movingCategories{memoryCategories: repo, mover: phones.ID, target: laptops}
+	if _, err := NewCategoryUseCase(racing, nil, nil).MoveCategory(context.Background(), laptops.ID, &phones.ID); !errors.Is(err, ErrCategoryMoved) {
+		t.Fatalf("err = %v, want ErrCategoryMoved", err)
+	}
+	if got := repo.path(laptops.ID); got != "/" {
+		t.Errorf("Laptops path = %q, want the move undone", got)
+	}
+	if got, want := repo.path(phones.ID), laptops.ChildPath(); got != want {
+		t.Errorf("Phones path = %q, want %q", got, want)
+	}
+}
+
+func TestCategoryFilterIncludesDescendants(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryCategories{}
+	laptops := repo.add("Laptops", nil)
+	gaming := repo.add("Gaming", &laptops)
+	rgb := repo.add("RGB", &gaming)
+	repo.add("Accessories", nil)
+	uc := NewProductUseCase(nil, repo, nil, nil, nil, nil, nil)
+
+	filter := domain.ProductFilter{CategorySlug: "gaming"}
+	if err := uc.resolveCategoryFilter(ctx, &filter); err != nil {
+		t.Fatalf("resolveCategoryFilter: %v", err)
+	}
+	if len(filter.CategoryIDs) != 2 || filter.CategoryIDs[0] != gaming.ID || filter.CategoryIDs[1] != rgb.ID {
+		t.Errorf("category IDs = %v, want Gaming and RGB", filter.CategoryIDs)
+	}
+
+	filter = domain.ProductFilter{CategoryID: &laptops.ID}
+	if err := uc.resolveCategoryFilter(ctx, &filter); err != nil {
+		t.Fatalf("resolveCategoryFilter: %v", err)
+	}
+	if len(filter.CategoryIDs) != 3 {
+		t.Errorf("category IDs = %v, want Laptops and its two descendants", filter.CategoryIDs)
+	}
+
+	filter = domain.ProductFilter{CategorySlug: "tablets"}
+	if err := uc.resolveCategoryFilter(ctx, &filter); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("unknown slug: err = %v, want ErrInvalidFilter", err)
+	}
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/repository"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrUnsupportedCurrency is returned when prices are asked for in a
+	// currency that is neither the base currency nor has an exchange rate.
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrInvalidPrice is returned when a price list or exchange rate fails
+	// validation.
+	ErrInvalidPrice = errors.New("invalid price")
+)
+
+// Pricing gives the product use case the currencies to price in.
+type Pricing interface {
+	BaseCurrency() string
+	Pricer(ctx context.Context, currency string) (*domain.Pricer, error)
+}
+
+type CurrencyUseCase interface {
+	Pricing
+	GetCurrencies(ctx context.Context) (*domain.Currencies, error)
+	SetExchangeRate(ctx context.Context, rate *domain.ExchangeRate) error
+	DeleteExchangeRate(ctx context.Context, currency string) error
+}
+
+type currencyUseCase struct {
+	exchangeRateRepository repository.ExchangeRateRepository
+	base                   string
+}
+
+// NewCurrencyUseCase converts prices from base, the currency product prices
+// are stored in.
+func NewCurrencyUseCase(exchangeRateRepository repository.ExchangeRateRepository, base string) (*currencyUseCase, error) {
+	code, err := domain.NormalizeCurrency(base)
+	if err != nil {
+		return nil, err
+	}
+	return &currencyUseCase{
+		exchangeRateRepository: exchangeRateRepository,
+		base:                   code,
+	}, nil
+}
+
+func (c *currencyUseCase) BaseCurrency() string {
+	return c.base
+}
+
+func (c *currencyUseCase) GetCurrencies(ctx context.Context) (*domain.Currencies, error) {
+	rates, err := c.exchangeRateRepository.GetAllExchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Currencies{Base: c.base, Rates: rates}, nil
+}
+
+// Pricer returns a pricer for currency, or for the base currency when it is
+// empty.
+func (c *currencyUseCase) Pricer(ctx context.Context, currency string) (*domain.Pricer, error) {
+	if currency == "" {
+		return &domain.Pricer{Base: c.base, Currency: c.base}, nil
+	}
+	code, err := domain.NormalizeCurrency(currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, err)
+	}
+	if code == c.base {
+		return &domain.Pricer{Base: c.base, Currency: c.base}, nil
+	}
+
+	rate, err := c.exchangeRateRepository.GetExchangeRate(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if rate == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, code)
+	}
+	return &domain.Pricer{Base: c.base, Currency: code, Rate: rate}, nil
+}
+
+// SetExchangeRate creates or replaces the rate of a currency.
+func (c *currencyUseCase) SetExchangeRate(ctx context.Context, rate *domain.ExchangeRate) error {
+	if err := rate.Validate(c.base); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPrice, err)
+	}
+	rate.UpdatedAt = time.Now()
+	return c.exchangeRateRepository.SaveExchangeRate(ctx, rate)
+}
+
+// DeleteExchangeRate stops pricing in the currency. Prices listed in it are
+// kept on the products, for when a rate is set again.
+func (c *currencyUseCase) DeleteExchangeRate(ctx context.Context, currency string) error {
+	code, err := domain.NormalizeCurrency(currency)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnsupportedCurrency, err)
+	}
+	err = c.exchangeRateRepository.DeleteExchangeRate(ctx, code)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedCurrency, code)
+	}
+	return err
+}
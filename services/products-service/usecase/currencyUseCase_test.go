@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/repository"
+)
+
+// memoryRates is an in-memory ExchangeRateRepository.
+type memoryRates struct {
+	repository.ExchangeRateRepository
+
+	rates map[string]domain.ExchangeRate
+}
+
+func (m *memoryRates) GetExchangeRate(ctx context.Context, currency string) (*domain.ExchangeRate, error) {
+	rate, ok := m.rates[currency]
+	if !ok {
+		return nil, nil
+	}
+	return &rate, nil
+}
+
+func (m *memoryRates) SaveExchangeRate(ctx context.Context, rate *domain.ExchangeRate) error {
+	m.rates[rate.Currency] = *rate
+	return nil
+}
+
+func TestPricerConvertsAndPrefersListedPrices(t *testing.T) {
+	ctx := context.Background()
+	currencies, err := NewCurrencyUseCase(&memoryRates{rates: map[string]domain.ExchangeRate{}}, "kzt")
+	if err != nil {
+		t.Fatalf("NewCurrencyUseCase: %v", err)
+	}
+	// 1 KZT = 0.0021 USD, rounded up to whole dollars; 1 KZT = 0.31 JPY
+	if err := currencies.SetExchangeRate(ctx, &domain.ExchangeRate{Currency: "usd", Rate: 0.0021, Rounding: domain.Rounding{Increment: 100, Mode: domain.RoundUp}}); err != nil {
+		t.Fatalf("SetExchangeRate: %v", err)
+	}
+	if err := currencies.SetExchangeRate(ctx, &domain.ExchangeRate{Currency: "JPY", Rate: 0.31}); err != nil {
+		t.Fatalf("SetExchangeRate: %v", err)
+	}
+
+	variantPrice := 500000.0
+	product := &domain.Product{Price: 749999.99, Prices: []domain.Money{{Amount: 139900, Currency: "USD"}}}
+	variants := []domain.Variant{
+		{SKU: "listed", Prices: []domain.Money{{Amount: 149900, Currency: "USD"}}},
+		{SKU: "own price", Price: &variantPrice},
+		{SKU: "inherits"},
+	}
+
+	tests := []struct {
+		currency string
+		variant  *domain.Variant
+		want     domain.Money
+	}{
+		{"", nil, domain.Money{Amount: 74999999, Currency: "KZT"}},
+		{"usd", nil, domain.Money{Amount: 139900, Currency: "USD"}},
+		{"USD", &variants[0], domain.Money{Amount: 149900, Currency: "USD"}},
+		// 500000 KZT * 0.0021 = 1050 USD exactly, not rounded up
+		{"USD", &variants[1], domain.Money{Amount: 105000, Currency: "USD"}},
+		{"USD", &variants[2], domain.Money{Amount: 139900, Currency: "USD"}},
+		// 749999.99 KZT * 0.31 = 232499.9969 JPY
+		{"JPY", nil, domain.Money{Amount: 232500, Currency: "JPY"}},
+	}
+	for _, tt := range tests {
+		pricer, err := currencies.Pricer(ctx, tt.currency)
+		if err != nil {
+			t.Fatalf("Pricer(%q): %v", tt.currency, err)
+		}
+		got, err := pricer.Price(product, tt.variant)
+		if err != nil || got != tt.want {
+			t.Errorf("%q price of %v = %+v, %v; want %+v", tt.currency, tt.variant, got, err, tt.want)
+		}
+	}
+
+	if _, err := currencies.Pricer(ctx, "EUR"); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("EUR without a rate: err = %v, want ErrUnsupportedCurrency", err)
+	}
+	if err := currencies.SetExchangeRate(ctx, &domain.ExchangeRate{Currency: "KZT", Rate: 1}); !errors.Is(err, ErrInvalidPrice) {
+		t.Errorf("rate for the base currency: err = %v, want ErrInvalidPrice", err)
+	}
+}
@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// MaxReceiveRows caps the number of rows in one bulk receive.
+	MaxReceiveRows = 10000
+	// receiveBatchSize is the number of units inserted per InsertMany.
+	receiveBatchSize = 500
+)
+
+// ErrTooManyRows is returned when a bulk receive exceeds MaxReceiveRows.
+var ErrTooManyRows = errors.New("too many rows")
+
+// ReceiveInventory validates every row of a bulk receive before inserting
+// anything: the product and variant must exist and the serial number must be
+// unique both within the file and against existing units. Valid rows are
+// inserted in batches unless opts.DryRun is set; invalid rows are reported
+// in the result.
+func (i *inventoryUseCase) ReceiveInventory(ctx context.Context, rows []domain.ReceiveRow, opts domain.ReceiveOptions) (*domain.ReceiveResult, error) {
+	if len(rows) > MaxReceiveRows {
+		return nil, fmt.Errorf("%w: %d, at most %d per request", ErrTooManyRows, len(rows), MaxReceiveRows)
+	}
+	if opts.Status == "" {
+		opts.Status = domain.StatusInStock
+	}
+	if opts.Status != domain.StatusReceived && opts.Status != domain.StatusInStock {
+		return nil, fmt.Errorf("%w: new units must be %s or %s", ErrInvalidTransition, domain.StatusReceived, domain.StatusInStock)
+	}
+	if err := i.validateLocation(ctx, opts.LocationID); err != nil {
+		return nil, err
+	}
+
+	result := &domain.ReceiveResult{DryRun: opts.DryRun, TotalRows: len(rows), Errors: []domain.ReceiveRowError{}}
+	reject := func(row domain.ReceiveRow, err string) {
+		result.Errors = append(result.Errors, domain.ReceiveRowError{Line: row.Line, SerialNumber: row.SerialNumber, Error: err})
+	}
+
+	products := newProductLookup(i)
+	seen := make(map[string]int, len(rows))
+	candidates := make([]domain.Inventory, 0, len(rows))
+	lines := make([]domain.ReceiveRow, 0, len(rows))
+	for _, row := range rows {
+		if row.ParseError != "" {
+			reject(row, row.ParseError)
+			continue
+		}
+		row.SerialNumber = strings.TrimSpace(row.SerialNumber)
+		if row.SerialNumber == "" {
+			reject(row, "serial_number is required")
+			continue
+		}
+		if first, ok := seen[row.SerialNumber]; ok {
+			reject(row, fmt.Sprintf("duplicate serial number, first seen on line %d", first))
+			continue
+		}
+		seen[row.SerialNumber] = row.Line
+
+		unit, err := products.unit(ctx, row)
+		var invalid rowError
+		if errors.As(err, &invalid) {
+			reject(row, invalid.Error())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		unit.Status = opts.Status
+		unit.LocationID = opts.LocationID
+		candidates = append(candidates, unit)
+		lines = append(lines, row)
+	}
+
+	serials := make([]string, len(candidates))
+	for n, unit := range candidates {
+		serials[n] = unit.SerialNumber
+	}
+	existing, err := i.repo.GetExistingSerialNumbers(ctx, serials)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, s := range existing {
+		taken[s] = true
+	}
+
+	valid := candidates[:0]
+	for n, unit := range candidates {
+		if taken[unit.SerialNumber] {
+			reject(lines[n], "serial number already exists")
+			continue
+		}
+		valid = append(valid, unit)
+	}
+	result.ValidRows = len(valid)
+	sort.SliceStable(result.Errors, func(a, b int) bool { return result.Errors[a].Line < result.Errors[b].Line })
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	for start := 0; start < len(valid); start += receiveBatchSize {
+		end := min(start+receiveBatchSize, len(valid))
+		batch := valid[start:end]
+		if err := i.repo.CreateInventories(ctx, batch); err != nil {
+			return result, fmt.Errorf("inserted %d of %d units: %w", result.Inserted, len(valid), err)
+		}
+		result.Inserted += len(batch)
+
+		movements := make([]domain.InventoryMovement, len(batch))
+		for n, unit := range batch {
+			movements[n] = movement(unit, "", opts.Actor, "bulk receive")
+		}
+		i.record(ctx, movements...)
+	}
+
+	return result, nil
+}
+
+// rowError rejects a single row rather than the whole request.
+type rowError string
+
+func (e rowError) Error() string { return string(e) }
+
+// productLookup resolves receive rows to products, caching each product so
+// a file with many units of the same model costs one query per model.
+type productLookup struct {
+	u      *inventoryUseCase
+	byID   map[primitive.ObjectID]*domain.Product
+	byName map[string]*domain.Product
+}
+
+func newProductLookup(u *inventoryUseCase) *productLookup {
+	return &productLookup{
+		u:      u,
+		byID:   map[primitive.ObjectID]*domain.Product{},
+		byName: map[string]*domain.Product{},
+	}
+}
+
+// unit builds the inventory unit for row, or returns a rowError.
+func (l *productLookup) unit(ctx context.Context, row domain.ReceiveRow) (domain.Inventory, error) {
+	product, err := l.product(ctx, row)
+	if err != nil {
+		return domain.Inventory{}, err
+	}
+
+	unit := domain.Inventory{
+		ID:           primitive.NewObjectID(),
+		ProductID:    product.ID,
+		SerialNumber: row.SerialNumber,
+	}
+
+	if row.VariantID == "" {
+		if len(product.Variants) > 0 {
+			return unit, rowError("variant_id is required for products with variants")
+		}
+		return unit, nil
+	}
+	variantID, err := primitive.ObjectIDFromHex(row.VariantID)
+	if err != nil {
+		return unit, rowError("invalid variant_id")
+	}
+	if variant, _ := product.FindVariant(variantID); variant == nil {
+		return unit, rowError("variant not found for this product")
+	}
+	unit.VariantID = variantID
+	return unit, nil
+}
+
+func (l *productLookup) product(ctx context.Context, row domain.ReceiveRow) (*domain.Product, error) {
+	switch {
+	case row.ProductID != "":
+		id, err := primitive.ObjectIDFromHex(row.ProductID)
+		if err != nil {
+			return nil, rowError("invalid product_id")
+		}
+		if product, ok := l.byID[id]; ok {
+			return cached(product, "product not found")
+		}
+		product, err := l.u.productRepository.GetProductByID(ctx, id)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+		l.byID[id] = product
+		return cached(product, "product not found")
+
+	case row.ModelName != "":
+		key := strings.ToLower(strings.TrimSpace(row.ModelName))
+		if product, ok := l.byName[key]; ok {
+			return cached(product, "no product with this model name")
+		}
+		product, err := l.u.productRepository.GetProductByName(ctx, row.ModelName)
+		if err != nil {
+			return nil, err
+		}
+		// GetProductByName matches substrings; receiving needs the exact model
+		if product != nil && !strings.EqualFold(product.ModelName, strings.TrimSpace(row.ModelName)) {
+			product = nil
+		}
+		l.byName[key] = product
+		return cached(product, "no product with this model name")
+
+	default:
+		return nil, rowError("product_id or model_name is required")
+	}
+}
+
+func cached(product *domain.Product, notFound string) (*domain.Product, error) {
+	if product == nil {
+		return nil, rowError(notFound)
+	}
+	return product, nil
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryProducts is an in-memory ProductRepository for lookups by ID and name.
+type memoryProducts struct {
+	repository.ProductRepository
+
+	products []domain.Product
+}
+
+func (m *memoryProducts) GetProductByID(ctx context.Context, id primitive.ObjectID) (*domain.Product, error) {
+	for n := range m.products {
+		if m.products[n].ID == id {
+			return &m.products[n], nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// GetProductByName matches case-insensitive substrings like the Mongo one.
+func (m *memoryProducts) GetProductByName(ctx context.Context, name string) (*domain.Product, error) {
+	for n := range m.products {
+		if strings.Contains(strings.ToLower(m.products[n].ModelName), strings.ToLower(name)) {
+			return &m.products[n], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryInventory) GetExistingSerialNumbers(ctx context.Context, serialNumbers []string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var existing []string
+	for _, u := range m.units {
+		for _, s := range serialNumbers {
+			if u.SerialNumber == s {
+				existing = append(existing, s)
+			}
+		}
+	}
+	return existing, nil
+}
+
+func (m *memoryInventory) CreateInventories(ctx context.Context, inventories []domain.Inventory) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.units = append(m.units, inventories...)
+	return nil
+}
+
+func TestReceiveInventory(t *testing.T) {
+	laptop := domain.Product{ID: primitive.NewObjectID(), ModelName: "ThinkPad X1 Carbon"}
+	products := &memoryProducts{products: []domain.Product{laptop}}
+	repo := &memoryInventory{units: []domain.Inventory{{ID: primitive.NewObjectID(), ProductID: laptop.ID, SerialNumber: "SN-OLD", Status: "in_stock"}}}
+	uc := NewInventoryUseCase(repo, products, &memoryMovements{}, nil, nil, time.Minute, nil).(*inventoryUseCase)
+
+	rows := []domain.ReceiveRow{
+		{Line: 2, ProductID: laptop.ID.Hex(), SerialNumber: "SN-1"},
+		{Line: 3, ModelName: "thinkpad x1 carbon", SerialNumber: "SN-2"},
+		{Line: 4, ModelName: "ThinkPad", SerialNumber: "SN-3"},
+		{Line: 5, ProductID: laptop.ID.Hex(), SerialNumber: "SN-1"},
+		{Line: 6, ProductID: laptop.ID.Hex(), SerialNumber: "SN-OLD"},
+		{Line: 7, ProductID: primitive.NewObjectID().Hex(), SerialNumber: "SN-4"},
+		{Line: 8, ProductID: laptop.ID.Hex()},
+		{Line: 9, ParseError: "bare quote"},
+	}
+
+	dry, err := uc.ReceiveInventory(context.Background(), rows, domain.ReceiveOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if dry.ValidRows != 2 || dry.Inserted != 0 || len(repo.units) != 1 {
+		t.Fatalf("dry run: valid=%d inserted=%d units=%d, want 2, 0, 1", dry.ValidRows, dry.Inserted, len(repo.units))
+	}
+
+	wantLines := []int{4, 5, 6, 7, 8, 9}
+	if len(dry.Errors) != len(wantLines) {
+		t.Fatalf("errors = %+v, want lines %v", dry.Errors, wantLines)
+	}
+	for n, e := range dry.Errors {
+		if e.Line != wantLines[n] {
+			t.Fatalf("error %d on line %d, want %d (%+v)", n, e.Line, wantLines[n], dry.Errors)
+		}
+	}
+
+	result, err := uc.ReceiveInventory(context.Background(), rows, domain.ReceiveOptions{Status: domain.StatusReceived})
+	if err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	if result.Inserted != 2 || len(repo.units) != 3 {
+		t.Fatalf("inserted=%d units=%d, want 2 and 3", result.Inserted, len(repo.units))
+	}
+	if got := repo.count(domain.StatusReceived); got != 2 {
+		t.Fatalf("received units = %d, want 2", got)
+	}
+}
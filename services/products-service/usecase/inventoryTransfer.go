@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxTransferUnits caps how many units a single transfer may move.
+const MaxTransferUnits = 1000
+
+// ErrTransferNotFound is returned for an unknown transfer ID.
+var ErrTransferNotFound = errors.New("transfer not found")
+
+// ErrInvalidTransfer is returned when a requested transfer is malformed.
+var ErrInvalidTransfer = errors.New("invalid transfer")
+
+func (i *inventoryUseCase) GetAllTransfers(ctx context.Context, status string, params pagination.Params) (*pagination.Page[domain.Transfer], error) {
+	return i.transferRepository.GetAllTransfers(ctx, status, params)
+}
+
+func (i *inventoryUseCase) GetTransferByID(ctx context.Context, id primitive.ObjectID) (*domain.Transfer, error) {
+	transfer, err := i.transferRepository.GetTransferByID(ctx, id)
+	if err != nil {
+		return nil, ErrTransferNotFound
+	}
+	return transfer, nil
+}
+
+// CreateTransfer dispatches the transfer's units, all of which must be in
+// stock at its source location, and puts them in transit. Either every unit
+// is dispatched or none is.
+func (i *inventoryUseCase) CreateTransfer(ctx context.Context, transfer *domain.Transfer, actor string) error {
+	if transfer.FromLocationID == transfer.ToLocationID {
+		return fmt.Errorf("%w: source and destination must be different locations", ErrInvalidTransfer)
+	}
+	if len(transfer.InventoryIDs) == 0 {
+		return fmt.Errorf("%w: a transfer needs at least one unit", ErrInvalidTransfer)
+	}
+	if len(transfer.InventoryIDs) > MaxTransferUnits {
+		return fmt.Errorf("%w: a transfer may move at most %d units", ErrInvalidTransfer, MaxTransferUnits)
+	}
+	seen := make(map[primitive.ObjectID]bool, len(transfer.InventoryIDs))
+	for _, id := range transfer.InventoryIDs {
+		if seen[id] {
+			return fmt.Errorf("%w: unit %s is listed twice", ErrInvalidTransfer, id.Hex())
+		}
+		seen[id] = true
+	}
+	for _, id := range []primitive.ObjectID{transfer.FromLocationID, transfer.ToLocationID} {
+		if err := i.validateLocation(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	transfer.ID = primitive.NewObjectID()
+	transfer.Status = domain.TransferInTransit
+	transfer.CreatedBy = actor
+	transfer.ClosedBy, transfer.ClosedAt = "", nil
+
+	dispatched := make([]domain.Inventory, 0, len(transfer.InventoryIDs))
+	for _, id := range transfer.InventoryIDs {
+		unit, err := i.repo.DispatchUnit(ctx, id, transfer.FromLocationID, transfer.ID)
+		if err == nil && unit == nil {
+			err = fmt.Errorf("%w: unit %s is not in stock at the source location", ErrInvalidTransition, id.Hex())
+		}
+		if err != nil {
+			return i.undispatch(ctx, transfer, len(dispatched), err)
+		}
+		dispatched = append(dispatched, *unit)
+	}
+
+	if err := i.transferRepository.CreateTransfer(ctx, transfer); err != nil {
+		return i.undispatch(ctx, transfer, len(dispatched), err)
+	}
+
+	movements := make([]domain.InventoryMovement, len(dispatched))
+	for n, unit := range dispatched {
+		movements[n] = movement(unit, domain.StatusInStock, actor, transferReason(transfer, "dispatched"))
+	}
+	i.record(ctx, movements...)
+	return nil
+}
+
+// undispatch puts the units already dispatched for transfer back in stock at
+// its source after cause aborted the dispatch.
+func (i *inventoryUseCase) undispatch(ctx context.Context, transfer *domain.Transfer, dispatched int, cause error) error {
+	if dispatched == 0 {
+		return cause
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	if _, err := i.repo.ReceiveTransfer(ctx, transfer.ID, transfer.FromLocationID); err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to roll back %d dispatched units: %w", dispatched, err))
+	}
+	return cause
+}
+
+// CompleteTransfer puts the transfer's units in stock at its destination.
+func (i *inventoryUseCase) CompleteTransfer(ctx context.Context, id primitive.ObjectID, actor string) (*domain.Transfer, error) {
+	return i.closeTransfer(ctx, id, domain.TransferCompleted, actor)
+}
+
+// CancelTransfer puts the transfer's units back in stock at its source.
+func (i *inventoryUseCase) CancelTransfer(ctx context.Context, id primitive.ObjectID, actor string) (*domain.Transfer, error) {
+	return i.closeTransfer(ctx, id, domain.TransferCancelled, actor)
+}
+
+// closeTransfer ends an in-transit transfer with status. The transfer's
+// status is switched first, conditionally, so that a transfer is completed or
+// cancelled only once; if its units then cannot be moved the switch is
+// undone.
+func (i *inventoryUseCase) closeTransfer(ctx context.Context, id primitive.ObjectID, status, actor string) (*domain.Transfer, error) {
+	existing, err := i.transferRepository.GetTransferByID(ctx, id)
+	if err != nil {
+		return nil, ErrTransferNotFound
+	}
+
+	transfer, err := i.transferRepository.CloseTransfer(ctx, id, domain.TransferInTransit, status, actor)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, fmt.Errorf("%w: transfer is %s, not in transit", ErrInvalidTransition, existing.Status)
+	}
+
+	destination := transfer.ToLocationID
+	if status == domain.TransferCancelled {
+		destination = transfer.FromLocationID
+	}
+
+	units, err := i.repo.GetInventoriesByTransferID(ctx, id)
+	if err == nil {
+		_, err = i.repo.ReceiveTransfer(ctx, id, destination)
+	}
+	if err != nil {
+		if _, reopenErr := i.transferRepository.CloseTransfer(context.WithoutCancel(ctx), id, status, domain.TransferInTransit, ""); reopenErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to reopen transfer: %w", reopenErr))
+		}
+		return nil, err
+	}
+
+	movements := make([]domain.InventoryMovement, 0, len(units))
+	for _, unit := range units {
+		if unit.Status != domain.StatusInTransit {
+			continue
+		}
+		m := movement(unit, domain.StatusInTransit, actor, transferReason(transfer, status))
+		m.To = domain.StatusInStock
+		m.LocationID = destination
+		if destination != unit.LocationID {
+			m.FromLocationID = unit.LocationID
+		}
+		movements = append(movements, m)
+	}
+	i.record(ctx, movements...)
+	return transfer, nil
+}
+
+func transferReason(transfer *domain.Transfer, event string) string {
+	return fmt.Sprintf("transfer %s %s", transfer.ID.Hex(), event)
+}
@@ -0,0 +1,241 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryLocations is an in-memory LocationRepository.
+type memoryLocations struct {
+	repository.LocationRepository
+
+	locations []domain.Location
+}
+
+func (m *memoryLocations) GetLocationByID(ctx context.Context, id primitive.ObjectID) (*domain.Location, error) {
+	for n := range m.locations {
+		if m.locations[n].ID == id {
+			return &m.locations[n], nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryLocations) GetLocationsByCodes(ctx context.Context, codes []string) ([]domain.Location, error) {
+	var found []domain.Location
+	for _, l := range m.locations {
+		for _, code := range codes {
+			if l.Code == code {
+				found = append(found, l)
+			}
+		}
+	}
+	return found, nil
+}
+
+// memoryTransfers is an in-memory TransferRepository.
+type memoryTransfers struct {
+	repository.TransferRepository
+
+	transfers []domain.Transfer
+}
+
+func (m *memoryTransfers) CreateTransfer(ctx context.Context, transfer *domain.Transfer) error {
+	m.transfers = append(m.transfers, *transfer)
+	return nil
+}
+
+func (m *memoryTransfers) GetTransferByID(ctx context.Context, id primitive.ObjectID) (*domain.Transfer, error) {
+	for n := range m.transfers {
+		if m.transfers[n].ID == id {
+			transfer := m.transfers[n]
+			return &transfer, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryTransfers) CloseTransfer(ctx context.Context, id primitive.ObjectID, from, to, actor string) (*domain.Transfer, error) {
+	for n := range m.transfers {
+		if m.transfers[n].ID == id && m.transfers[n].Status == from {
+			m.transfers[n].Status, m.transfers[n].ClosedBy = to, actor
+			transfer := m.transfers[n]
+			return &transfer, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryInventory) DispatchUnit(ctx context.Context, id, locationID, transferID primitive.ObjectID) (*domain.Inventory, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for n := range m.units {
+		u := &m.units[n]
+		if u.ID == id && u.Status == domain.StatusInStock && u.LocationID == locationID {
+			u.Status, u.TransferID = domain.StatusInTransit, transferID
+			unit := *u
+			return &unit, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryInventory) GetInventoriesByTransferID(ctx context.Context, transferID primitive.ObjectID) ([]domain.Inventory, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var units []domain.Inventory
+	for _, u := range m.units {
+		if u.TransferID == transferID {
+			units = append(units, u)
+		}
+	}
+	return units, nil
+}
+
+func (m *memoryInventory) ReceiveTransfer(ctx context.Context, transferID, locationID primitive.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for i := range m.units {
+		u := &m.units[i]
+		if u.TransferID == transferID && u.Status == domain.StatusInTransit {
+			u.Status, u.LocationID, u.TransferID = domain.StatusInStock, locationID, primitive.NilObjectID
+			n++
+		}
+	}
+	return n, nil
+}
+
+// at puts the last count units of m at location.
+func (m *memoryInventory) at(location primitive.ObjectID, count int) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, count)
+	for n := 0; n < count; n++ {
+		u := &m.units[len(m.units)-count+n]
+		u.LocationID = location
+		ids[n] = u.ID
+	}
+	return ids
+}
+
+func (m *memoryInventory) countAt(location primitive.ObjectID, status string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, u := range m.units {
+		if u.LocationID == location && u.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func TestReserveProductsPrefersLocationOrder(t *testing.T) {
+	warehouse := domain.Location{ID: primitive.NewObjectID(), Code: "main"}
+	store := domain.Location{ID: primitive.NewObjectID(), Code: "store-1"}
+	other := primitive.NewObjectID()
+
+	productID := primitive.NewObjectID()
+	repo := &memoryInventory{}
+	repo.add(productID, 2)
+	repo.at(other, 2)
+	repo.add(productID, 2)
+	repo.at(store.ID, 2)
+	repo.add(productID, 1)
+	repo.at(warehouse.ID, 1)
+
+	locations := &memoryLocations{locations: []domain.Location{warehouse, store}}
+	uc := NewInventoryUseCase(repo, nil, &memoryMovements{}, locations, nil, time.Minute, []string{"main", "unknown", "store-1"})
+
+	if _, err := uc.ReserveProducts(context.Background(), orderOf(line(productID, 3))); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if got := repo.countAt(warehouse.ID, domain.StatusReserved); got != 1 {
+		t.Fatalf("reserved at main = %d, want 1", got)
+	}
+	if got := repo.countAt(store.ID, domain.StatusReserved); got != 2 {
+		t.Fatalf("reserved at store-1 = %d, want 2", got)
+	}
+
+	// the preferred locations are empty now, so the rest comes from anywhere
+	if _, err := uc.ReserveProducts(context.Background(), orderOf(line(productID, 2))); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if got := repo.countAt(other, domain.StatusReserved); got != 2 {
+		t.Fatalf("reserved at other location = %d, want 2", got)
+	}
+}
+
+func TestTransferLifecycle(t *testing.T) {
+	from := domain.Location{ID: primitive.NewObjectID(), Code: "main"}
+	to := domain.Location{ID: primitive.NewObjectID(), Code: "store-1"}
+	productID := primitive.NewObjectID()
+
+	repo := newMemoryInventory(productID, 3)
+	ids := repo.at(from.ID, 3)
+	ledger := &memoryMovements{}
+	transfers := &memoryTransfers{}
+	uc := NewInventoryUseCase(repo, nil, ledger, &memoryLocations{locations: []domain.Location{from, to}}, transfers, time.Minute, nil)
+	ctx := context.Background()
+
+	transfer := domain.Transfer{FromLocationID: from.ID, ToLocationID: to.ID, InventoryIDs: ids[:2]}
+	if err := uc.CreateTransfer(ctx, &transfer, "clerk"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if got := repo.countAt(from.ID, domain.StatusInTransit); got != 2 {
+		t.Fatalf("units in transit = %d, want 2", got)
+	}
+
+	if _, err := uc.CompleteTransfer(ctx, transfer.ID, "clerk"); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if got := repo.countAt(to.ID, domain.StatusInStock); got != 2 {
+		t.Fatalf("units in stock at destination = %d, want 2", got)
+	}
+	if _, err := uc.CancelTransfer(ctx, transfer.ID, "clerk"); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("cancel after complete: err = %v, want ErrInvalidTransition", err)
+	}
+
+	// two dispatched, two received
+	if got := len(ledger.movements); got != 4 {
+		t.Fatalf("ledger entries = %d, want 4", got)
+	}
+	for _, m := range ledger.movements[2:] {
+		if m.FromLocationID != from.ID || m.LocationID != to.ID || m.To != domain.StatusInStock {
+			t.Fatalf("unexpected receive movement %+v", m)
+		}
+	}
+}
+
+func TestCreateTransferIsAllOrNothing(t *testing.T) {
+	from := domain.Location{ID: primitive.NewObjectID(), Code: "main"}
+	to := domain.Location{ID: primitive.NewObjectID(), Code: "store-1"}
+	productID := primitive.NewObjectID()
+
+	repo := newMemoryInventory(productID, 2)
+	ids := repo.at(from.ID, 2)
+	repo.units[1].Status = domain.StatusDamaged
+	transfers := &memoryTransfers{}
+	uc := NewInventoryUseCase(repo, nil, &memoryMovements{}, &memoryLocations{locations: []domain.Location{from, to}}, transfers, time.Minute, nil)
+
+	transfer := domain.Transfer{FromLocationID: from.ID, ToLocationID: to.ID, InventoryIDs: ids}
+	if err := uc.CreateTransfer(context.Background(), &transfer, "clerk"); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("err = %v, want ErrInvalidTransition", err)
+	}
+	if got := repo.countAt(from.ID, domain.StatusInStock); got != 1 {
+		t.Fatalf("units in stock at source = %d, want 1", got)
+	}
+	if len(transfers.transfers) != 0 {
+		t.Fatalf("transfer was stored despite failing")
+	}
+}
@@ -2,56 +2,271 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"github.com/mephirious/group-project/services/products-service/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type InventoryUseCase interface {
-	GetAllInventories(ctx context.Context) ([]domain.Inventory, error)
+	GetAllInventories(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Inventory], error)
 	GetInventoryByID(ctx context.Context, id primitive.ObjectID) (*domain.Inventory, error)
-	GetInventoryByProductID(ctx context.Context, productID primitive.ObjectID) ([]domain.Inventory, error)
+	GetInventoryByProductID(ctx context.Context, productID primitive.ObjectID, params pagination.Params) (*pagination.Page[domain.Inventory], error)
 	GetInventoryBySerialNumber(ctx context.Context, serialNumber string) (*domain.Inventory, error)
-	CreateInventory(ctx context.Context, inventory *domain.Inventory) error
-	UpdateInventory(ctx context.Context, inventory *domain.Inventory) error
+	CreateInventory(ctx context.Context, inventory *domain.Inventory, actor string) error
+	UpdateInventory(ctx context.Context, inventory *domain.Inventory, actor, reason string) error
+	TransitionInventory(ctx context.Context, id primitive.ObjectID, status, actor, reason string) (*domain.Inventory, error)
+	ReceiveInventory(ctx context.Context, rows []domain.ReceiveRow, opts domain.ReceiveOptions) (*domain.ReceiveResult, error)
+	GetMovementsByInventoryID(ctx context.Context, id primitive.ObjectID, params pagination.Params) (*pagination.Page[domain.InventoryMovement], error)
+	GetMovementsBySerialNumber(ctx context.Context, serialNumber string, params pagination.Params) (*pagination.Page[domain.InventoryMovement], error)
 	DeleteInventory(ctx context.Context, id primitive.ObjectID) error
 	GetProductQuantity(ctx context.Context, productID primitive.ObjectID) (int64, error)
-	ReserveProducts(ctx context.Context, order domain.Order) error
+	GetVariantQuantity(ctx context.Context, productID, variantID primitive.ObjectID) (int64, error)
+	GetProductStockByLocation(ctx context.Context, productID primitive.ObjectID) ([]domain.LocationStock, error)
+	GetAllTransfers(ctx context.Context, status string, params pagination.Params) (*pagination.Page[domain.Transfer], error)
+	GetTransferByID(ctx context.Context, id primitive.ObjectID) (*domain.Transfer, error)
+	CreateTransfer(ctx context.Context, transfer *domain.Transfer, actor string) error
+	CompleteTransfer(ctx context.Context, id primitive.ObjectID, actor string) (*domain.Transfer, error)
+	CancelTransfer(ctx context.Context, id primitive.ObjectID, actor string) (*domain.Transfer, error)
+	ReserveProducts(ctx context.Context, order domain.Order) (*domain.Reservation, error)
 	CancelReservation(ctx context.Context, order domain.Order) error
 	MarkProductsAsSold(ctx context.Context, order domain.Order) error
+	CommitReservation(ctx context.Context, order domain.Order) error
+	ShipProducts(ctx context.Context, order domain.Order) ([]domain.Inventory, error)
+	GetReservationUnits(ctx context.Context, reservationID string) ([]domain.Inventory, error)
+	ReturnProducts(ctx context.Context, order domain.Order) error
+	ReleaseExpiredReservations(ctx context.Context) (int64, error)
+	ReservationStats() domain.ReservationStats
 }
 
+// ErrInsufficientStock is returned when an order line cannot be covered by
+// units in the required status.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrInvalidTransition is returned when a unit cannot move to the requested
+// status from its current one.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// SystemActor is recorded in the movement ledger for changes made by the
+// service itself, such as expired reservations.
+const SystemActor = "system"
+
 type inventoryUseCase struct {
-	repo repository.InventoryRepository
+	repo               repository.InventoryRepository
+	productRepository  repository.ProductRepository
+	movementRepository repository.MovementRepository
+	locationRepository repository.LocationRepository
+	transferRepository repository.TransferRepository
+	reservationTTL     time.Duration
+	locationOrder      []string
+	metrics            reservationMetrics
 }
 
-func NewInventoryUseCase(repo repository.InventoryRepository) InventoryUseCase {
-	return &inventoryUseCase{repo: repo}
+// NewInventoryUseCase creates the inventory use case. Reservations made by
+// ReserveProducts expire after reservationTTL and take units from the
+// locations in locationOrder, given by code, before any other location.
+func NewInventoryUseCase(repo repository.InventoryRepository, productRepository repository.ProductRepository, movementRepository repository.MovementRepository, locationRepository repository.LocationRepository, transferRepository repository.TransferRepository, reservationTTL time.Duration, locationOrder []string) InventoryUseCase {
+	return &inventoryUseCase{
+		repo:               repo,
+		productRepository:  productRepository,
+		movementRepository: movementRepository,
+		locationRepository: locationRepository,
+		transferRepository: transferRepository,
+		reservationTTL:     reservationTTL,
+		locationOrder:      locationOrder,
+	}
 }
 
-func (i *inventoryUseCase) GetAllInventories(ctx context.Context) ([]domain.Inventory, error) {
-	return i.repo.GetAllInventories(ctx)
+func (i *inventoryUseCase) GetAllInventories(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Inventory], error) {
+	return i.repo.GetAllInventories(ctx, params)
 }
 
 func (i *inventoryUseCase) GetInventoryByID(ctx context.Context, id primitive.ObjectID) (*domain.Inventory, error) {
 	return i.repo.GetInventoryByID(ctx, id)
 }
 
-func (i *inventoryUseCase) GetInventoryByProductID(ctx context.Context, productID primitive.ObjectID) ([]domain.Inventory, error) {
-	return i.repo.GetInventoryByProductID(ctx, productID)
+func (i *inventoryUseCase) GetInventoryByProductID(ctx context.Context, productID primitive.ObjectID, params pagination.Params) (*pagination.Page[domain.Inventory], error) {
+	return i.repo.GetInventoryByProductID(ctx, productID, params)
 }
 
 func (i *inventoryUseCase) GetInventoryBySerialNumber(ctx context.Context, serialNumber string) (*domain.Inventory, error) {
 	return i.repo.GetInventoryBySerialNumber(ctx, serialNumber)
 }
 
-func (i *inventoryUseCase) CreateInventory(ctx context.Context, inventory *domain.Inventory) error {
-	return i.repo.CreateInventory(ctx, inventory)
+// CreateInventory adds a unit, which starts out either received or in_stock.
+func (i *inventoryUseCase) CreateInventory(ctx context.Context, inventory *domain.Inventory, actor string) error {
+	if inventory.Status == "" {
+		inventory.Status = domain.StatusInStock
+	}
+	if inventory.Status != domain.StatusReceived && inventory.Status != domain.StatusInStock {
+		return fmt.Errorf("%w: new units must be %s or %s", ErrInvalidTransition, domain.StatusReceived, domain.StatusInStock)
+	}
+	if err := i.validateVariant(ctx, inventory); err != nil {
+		return err
+	}
+	if err := i.validateLocation(ctx, inventory.LocationID); err != nil {
+		return err
+	}
+	existing, err := i.repo.GetInventoryBySerialNumber(ctx, inventory.SerialNumber)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return errors.New("serial number already exists")
+	}
+	if err := i.repo.CreateInventory(ctx, inventory); err != nil {
+		return err
+	}
+
+	i.record(ctx, movement(*inventory, "", actor, "created"))
+	return nil
+}
+
+// UpdateInventory replaces a unit's product, variant and serial number. A
+// status change must be an allowed manual transition and is recorded in the
+// movement ledger. A unit without a location may be given one, but moving a
+// unit between locations takes a transfer. The update is conditional on the
+// unit being unchanged since it was read, so a concurrent reservation or
+// transition is never overwritten.
+func (i *inventoryUseCase) UpdateInventory(ctx context.Context, inventory *domain.Inventory, actor, reason string) error {
+	existing, err := i.repo.GetInventoryByID(ctx, inventory.ID)
+	if err != nil {
+		return errors.New("inventory not found")
+	}
+	if inventory.Status == "" {
+		inventory.Status = existing.Status
+	}
+	if inventory.Status != existing.Status {
+		if err := checkManualTransition(existing.Status, inventory.Status); err != nil {
+			return err
+		}
+	}
+	if err := i.validateVariant(ctx, inventory); err != nil {
+		return err
+	}
+	switch {
+	case inventory.LocationID.IsZero():
+		inventory.LocationID = existing.LocationID
+	case existing.LocationID.IsZero():
+		if err := i.validateLocation(ctx, inventory.LocationID); err != nil {
+			return err
+		}
+	case inventory.LocationID != existing.LocationID:
+		return fmt.Errorf("%w: units change location through a transfer", ErrInvalidTransition)
+	}
+
+	inventory.TransferID = existing.TransferID
+	inventory.Reservation = existing.Reservation
+	inventory.CreatedAt = existing.CreatedAt
+	updated, err := i.repo.UpdateInventory(ctx, inventory, existing.Status, existing.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return fmt.Errorf("%w: unit changed concurrently, retry", ErrInvalidTransition)
+	}
+
+	if inventory.Status != existing.Status {
+		i.record(ctx, movement(*inventory, existing.Status, actor, reason))
+	}
+	return nil
+}
+
+// TransitionInventory moves a single unit to status. The move is a
+// conditional update on the unit's current status, so it fails rather than
+// overwriting a concurrent change.
+func (i *inventoryUseCase) TransitionInventory(ctx context.Context, id primitive.ObjectID, status, actor, reason string) (*domain.Inventory, error) {
+	existing, err := i.repo.GetInventoryByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("inventory not found")
+	}
+	if err := checkManualTransition(existing.Status, status); err != nil {
+		return nil, err
+	}
+
+	unit, err := i.repo.TransitionUnitByID(ctx, id, existing.Status, status)
+	if err != nil {
+		return nil, err
+	}
+	if unit == nil {
+		return nil, fmt.Errorf("%w: unit changed status concurrently, retry", ErrInvalidTransition)
+	}
+
+	i.record(ctx, movement(*unit, existing.Status, actor, reason))
+	return unit, nil
+}
+
+func (i *inventoryUseCase) GetMovementsByInventoryID(ctx context.Context, id primitive.ObjectID, params pagination.Params) (*pagination.Page[domain.InventoryMovement], error) {
+	return i.movementRepository.GetMovementsByInventoryID(ctx, id, params)
+}
+
+func (i *inventoryUseCase) GetMovementsBySerialNumber(ctx context.Context, serialNumber string, params pagination.Params) (*pagination.Page[domain.InventoryMovement], error) {
+	return i.movementRepository.GetMovementsBySerialNumber(ctx, serialNumber, params)
+}
+
+// checkManualTransition validates a status change requested directly rather
+// than through the order flow or a transfer. Reserved and sold are owned by
+// the order flow: they can only be entered, and reserved only left, through
+// reservations. In transit is entered and left only through transfers.
+func checkManualTransition(from, to string) error {
+	if !domain.IsInventoryStatus(to) {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidTransition, to)
+	}
+	if to == domain.StatusReserved || to == domain.StatusSold || from == domain.StatusReserved {
+		return fmt.Errorf("%w: %s to %s is managed by the order flow", ErrInvalidTransition, from, to)
+	}
+	if to == domain.StatusInTransit || from == domain.StatusInTransit {
+		return fmt.Errorf("%w: %s to %s is managed by transfers", ErrInvalidTransition, from, to)
+	}
+	// units with a status from before the state machine may move anywhere
+	if domain.IsInventoryStatus(from) && !domain.CanTransition(from, to) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// validateVariant makes sure a unit of a product with variants is linked to
+// one of that product's variants.
+func (i *inventoryUseCase) validateVariant(ctx context.Context, inventory *domain.Inventory) error {
+	product, err := i.productRepository.GetProductByID(ctx, inventory.ProductID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("product not found")
+		}
+		return err
+	}
+
+	if inventory.VariantID.IsZero() {
+		if len(product.Variants) > 0 {
+			return errors.New("variant_id is required for products with variants")
+		}
+		return nil
+	}
+
+	if variant, _ := product.FindVariant(inventory.VariantID); variant == nil {
+		return errors.New("variant not found for this product")
+	}
+	return nil
 }
 
-func (i *inventoryUseCase) UpdateInventory(ctx context.Context, inventory *domain.Inventory) error {
-	return i.repo.UpdateInventory(ctx, inventory)
+// validateLocation makes sure a unit's location, when it has one, exists.
+func (i *inventoryUseCase) validateLocation(ctx context.Context, locationID primitive.ObjectID) error {
+	if locationID.IsZero() {
+		return nil
+	}
+	if _, err := i.locationRepository.GetLocationByID(ctx, locationID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("%w: location %s not found", ErrInvalidReference, locationID.Hex())
+		}
+		return err
+	}
+	return nil
 }
 
 func (i *inventoryUseCase) DeleteInventory(ctx context.Context, id primitive.ObjectID) error {
@@ -62,47 +277,314 @@ func (i *inventoryUseCase) GetProductQuantity(ctx context.Context, productID pri
 	return i.repo.GetProductQuantity(ctx, productID)
 }
 
-func (i *inventoryUseCase) ReserveProducts(ctx context.Context, order domain.Order) error {
-	for _, product := range order.Products {
-		productID, err := primitive.ObjectIDFromHex(product.ID)
-		if err != nil {
-			return err
-		}
+func (i *inventoryUseCase) GetVariantQuantity(ctx context.Context, productID, variantID primitive.ObjectID) (int64, error) {
+	return i.repo.GetVariantQuantity(ctx, productID, variantID)
+}
 
-		_, err = i.repo.FindAndUpdateStatus(ctx, productID, "reserved", product.Quantity)
-		if err != nil {
-			return err
-		}
+func (i *inventoryUseCase) GetProductStockByLocation(ctx context.Context, productID primitive.ObjectID) ([]domain.LocationStock, error) {
+	return i.repo.GetProductStockByLocation(ctx, productID)
+}
+
+// ReserveProducts reserves the order's units under a new reservation that
+// expires after the configured TTL unless it is cancelled or paid first.
+// Units are taken from the configured locations in order before any other.
+func (i *inventoryUseCase) ReserveProducts(ctx context.Context, order domain.Order) (*domain.Reservation, error) {
+	prefer, err := i.preferredLocations(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+
+	reservation := &domain.Reservation{
+		ID:        primitive.NewObjectID().Hex(),
+		Owner:     order.CustomerID,
+		ExpiresAt: time.Now().Add(i.reservationTTL),
+	}
+
+	_, err = i.transitionOrder(ctx, order, domain.UnitTransition{
+		From:        domain.StatusInStock,
+		To:          domain.StatusReserved,
+		Reservation: reservation,
+	}, prefer, "reserved for checkout")
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
 }
 
+// CancelReservation returns the reservation's reserved units to stock.
 func (i *inventoryUseCase) CancelReservation(ctx context.Context, order domain.Order) error {
-	for _, product := range order.Products {
-		productID, err := primitive.ObjectIDFromHex(product.ID)
+	if order.ReservationID == "" {
+		return errors.New("reservation_id is required")
+	}
+	_, err := i.transitionOrder(ctx, order, domain.UnitTransition{
+		From:          domain.StatusReserved,
+		To:            domain.StatusInStock,
+		ReservationID: order.ReservationID,
+	}, nil, "checkout cancelled")
+	return err
+}
+
+func (i *inventoryUseCase) MarkProductsAsSold(ctx context.Context, order domain.Order) error {
+	if order.ReservationID == "" {
+		return errors.New("reservation_id is required")
+	}
+	_, err := i.transitionOrder(ctx, order, domain.UnitTransition{
+		From:          domain.StatusReserved,
+		To:            domain.StatusSold,
+		ReservationID: order.ReservationID,
+	}, nil, "payment succeeded")
+	return err
+}
+
+// CommitReservation ties a paid order's reservation to the order so that it
+// no longer expires. Its units stay reserved until ShipProducts sells them.
+func (i *inventoryUseCase) CommitReservation(ctx context.Context, order domain.Order) error {
+	if order.ReservationID == "" || order.OrderID == "" {
+		return errors.New("reservation_id and order_id are required")
+	}
+	committed, err := i.repo.CommitReservation(ctx, order.ReservationID, order.OrderID)
+	if err != nil {
+		return err
+	}
+	if committed == 0 {
+		return fmt.Errorf("%w: reservation %s holds no reserved units", ErrInsufficientStock, order.ReservationID)
+	}
+	return nil
+}
+
+// ShipProducts sells the units of a committed reservation that leave in a
+// shipment, recording the order and shipment on each. Lines with serial
+// numbers sell exactly the units picked; other lines sell any of the
+// reservation's units of that product. It returns the units sold.
+func (i *inventoryUseCase) ShipProducts(ctx context.Context, order domain.Order) ([]domain.Inventory, error) {
+	if order.ReservationID == "" || order.OrderID == "" || order.ShipmentID == "" {
+		return nil, errors.New("reservation_id, order_id and shipment_id are required")
+	}
+	return i.transitionOrder(ctx, order, domain.UnitTransition{
+		From:          domain.StatusReserved,
+		To:            domain.StatusSold,
+		ReservationID: order.ReservationID,
+		Sale:          &domain.Sale{OrderID: order.OrderID, ShipmentID: order.ShipmentID},
+	}, nil, "shipped")
+}
+
+// GetReservationUnits returns the units a reservation holds or has sold, for
+// picking an order's serial numbers.
+func (i *inventoryUseCase) GetReservationUnits(ctx context.Context, reservationID string) ([]domain.Inventory, error) {
+	return i.repo.GetInventoriesByReservationID(ctx, reservationID)
+}
+
+// ReturnProducts moves sold units of a reservation to returned after a
+// refund. Lines with serial numbers return exactly those units; other lines
+// return any of the reservation's sold units of that product.
+func (i *inventoryUseCase) ReturnProducts(ctx context.Context, order domain.Order) error {
+	if order.ReservationID == "" {
+		return errors.New("reservation_id is required")
+	}
+	_, err := i.transitionOrder(ctx, order, domain.UnitTransition{
+		From:          domain.StatusSold,
+		To:            domain.StatusReturned,
+		ReservationID: order.ReservationID,
+	}, nil, "refunded")
+	return err
+}
+
+// ReleaseExpiredReservations returns units whose reservation has expired to
+// stock and records the sweep in the reservation metrics.
+func (i *inventoryUseCase) ReleaseExpiredReservations(ctx context.Context) (int64, error) {
+	released, err := i.repo.ReleaseExpiredReservations(ctx, time.Now())
+
+	movements := make([]domain.InventoryMovement, len(released))
+	for n, unit := range released {
+		movements[n] = movement(unit, domain.StatusReserved, SystemActor, "reservation expired")
+		movements[n].To = domain.StatusInStock
+	}
+	i.record(ctx, movements...)
+
+	i.metrics.record(int64(len(released)), err)
+	return int64(len(released)), err
+}
+
+func (i *inventoryUseCase) ReservationStats() domain.ReservationStats {
+	stats := i.metrics.snapshot()
+	stats.ReservationTTL = i.reservationTTL.String()
+	return stats
+}
+
+// transitionOrder applies transition to Quantity units of every order line,
+// all or nothing. Each unit is claimed with a single conditional update so
+// concurrent orders never get the same unit, and every claimed unit is logged
+// as it was before the move so that a failure part way through can be
+// compensated by restoring the logged units. Units are taken from the
+// locations in prefer, in order, before any other location. Once the whole
+// order has moved, the moves are added to the movement ledger with reason and
+// the moved units are returned.
+func (i *inventoryUseCase) transitionOrder(ctx context.Context, order domain.Order, transition domain.UnitTransition, prefer []primitive.ObjectID, reason string) ([]domain.Inventory, error) {
+	type line struct {
+		productID, variantID primitive.ObjectID
+		quantity             int64
+		serialNumbers        []string
+	}
+
+	lines := make([]line, len(order.Products))
+	for n, product := range order.Products {
+		productID, variantID, err := orderLineIDs(product)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if product.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity %d for product %s", product.Quantity, product.ID)
+		}
+		if len(product.SerialNumbers) > 0 && int64(len(product.SerialNumbers)) != product.Quantity {
+			return nil, fmt.Errorf("product %s lists %d serial numbers for quantity %d", product.ID, len(product.SerialNumbers), product.Quantity)
 		}
+		lines[n] = line{productID: productID, variantID: variantID, quantity: product.Quantity, serialNumbers: product.SerialNumbers}
+	}
 
-		_, err = i.repo.FindAndUpdateStatus(ctx, productID, "in_stock", product.Quantity)
-		if err != nil {
-			return err
+	var claimed, moved []domain.Inventory
+	for _, l := range lines {
+		transition.ProductID, transition.VariantID = l.productID, l.variantID
+		locations := prefer
+		for n := int64(0); n < l.quantity; n++ {
+			if len(l.serialNumbers) > 0 {
+				transition.SerialNumber = l.serialNumbers[n]
+			}
+			unit, err := i.claimUnit(ctx, transition, &locations)
+			if err == nil && unit == nil && transition.SerialNumber != "" {
+				err = fmt.Errorf("%w: unit %s of product %s is not %s", ErrInsufficientStock, transition.SerialNumber, l.productID.Hex(), transition.From)
+			}
+			if err == nil && unit == nil {
+				err = fmt.Errorf("%w: product %s has fewer than %d units %s", ErrInsufficientStock, l.productID.Hex(), l.quantity, transition.From)
+			}
+			if err != nil {
+				return nil, i.compensate(ctx, claimed, transition.To, err)
+			}
+			claimed = append(claimed, *unit)
+			moved = append(moved, transition.Apply(*unit))
 		}
+		transition.SerialNumber = ""
 	}
-	return nil
+
+	actor := order.CustomerID
+	if actor == "" {
+		actor = SystemActor
+	}
+	movements := make([]domain.InventoryMovement, len(moved))
+	for n, unit := range moved {
+		movements[n] = movement(unit, transition.From, actor, reason)
+		if movements[n].ReservationID == "" {
+			movements[n].ReservationID = transition.ReservationID
+		}
+	}
+	i.record(ctx, movements...)
+	return moved, nil
 }
 
-func (i *inventoryUseCase) MarkProductsAsSold(ctx context.Context, order domain.Order) error {
-	for _, product := range order.Products {
-		productID, err := primitive.ObjectIDFromHex(product.ID)
-		if err != nil {
-			return err
+// claimUnit applies transition to one unit and returns it as it was before,
+// trying the locations in order before falling back to any location.
+// Locations that have run out are dropped from locations so the next unit of
+// the same line skips them.
+func (i *inventoryUseCase) claimUnit(ctx context.Context, transition domain.UnitTransition, locations *[]primitive.ObjectID) (*domain.Inventory, error) {
+	for len(*locations) > 0 {
+		transition.LocationID = (*locations)[0]
+		unit, err := i.repo.TransitionUnit(ctx, transition)
+		if err != nil || unit != nil {
+			return unit, err
 		}
+		*locations = (*locations)[1:]
+	}
 
-		_, err = i.repo.FindAndUpdateStatus(ctx, productID, "sold", product.Quantity)
-		if err != nil {
-			return err
+	transition.LocationID = primitive.NilObjectID
+	return i.repo.TransitionUnit(ctx, transition)
+}
+
+// preferredLocations resolves the configured location order to IDs. Codes
+// that don't name a location are skipped.
+func (i *inventoryUseCase) preferredLocations(ctx context.Context) ([]primitive.ObjectID, error) {
+	if len(i.locationOrder) == 0 {
+		return nil, nil
+	}
+
+	locations, err := i.locationRepository.GetLocationsByCodes(ctx, i.locationOrder)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]primitive.ObjectID, len(locations))
+	for _, location := range locations {
+		byCode[location.Code] = location.ID
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(i.locationOrder))
+	for _, code := range i.locationOrder {
+		if id, ok := byCode[code]; ok {
+			ids = append(ids, id)
 		}
 	}
-	return nil
+	return ids, nil
+}
+
+// compensate restores the units in claimed, which moved to status to, to
+// their documents from before the move after cause aborted an order
+// transition, so they get back their reservation and sale as well as their
+// status.
+func (i *inventoryUseCase) compensate(ctx context.Context, claimed []domain.Inventory, to string, cause error) error {
+	// the rollback has to run even if the request that caused it was cancelled
+	ctx = context.WithoutCancel(ctx)
+
+	var errs []error
+	for _, unit := range claimed {
+		if _, err := i.repo.RestoreUnit(ctx, unit, to); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(cause, fmt.Errorf("failed to roll back %d units: %w", len(errs), errors.Join(errs...)))
+	}
+	return cause
+}
+
+// record appends movements to the ledger. The status changes they describe
+// have already happened, so a failure is logged rather than returned.
+func (i *inventoryUseCase) record(ctx context.Context, movements ...domain.InventoryMovement) {
+	if len(movements) == 0 {
+		return
+	}
+	if err := i.movementRepository.CreateMovements(context.WithoutCancel(ctx), movements); err != nil {
+		slog.Error(fmt.Sprintf("Recording %d inventory movements failed: %s", len(movements), err))
+	}
+}
+
+// movement describes unit having moved from status from to its current status.
+func movement(unit domain.Inventory, from, actor, reason string) domain.InventoryMovement {
+	m := domain.InventoryMovement{
+		InventoryID:  unit.ID,
+		ProductID:    unit.ProductID,
+		SerialNumber: unit.SerialNumber,
+		From:         from,
+		To:           unit.Status,
+		LocationID:   unit.LocationID,
+		Actor:        actor,
+		Reason:       reason,
+		CreatedAt:    time.Now(),
+	}
+	if unit.Reservation != nil {
+		m.ReservationID = unit.Reservation.ID
+	}
+	return m
+}
+
+func orderLineIDs(line domain.ProductOrder) (primitive.ObjectID, primitive.ObjectID, error) {
+	productID, err := primitive.ObjectIDFromHex(line.ID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, err
+	}
+
+	if line.VariantID == "" {
+		return productID, primitive.NilObjectID, nil
+	}
+	variantID, err := primitive.ObjectIDFromHex(line.VariantID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, err
+	}
+	return productID, variantID, nil
 }
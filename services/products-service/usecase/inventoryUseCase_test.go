@@ -0,0 +1,525 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryInventory is an in-memory InventoryRepository whose per-unit
+// transitions are atomic, like the conditional updates of the Mongo one.
+// Methods the tests don't need fall through to the nil embedded interface.
+type memoryInventory struct {
+	repository.InventoryRepository
+
+	mu    sync.Mutex
+	units []domain.Inventory
+}
+
+func newMemoryInventory(productID primitive.ObjectID, count int) *memoryInventory {
+	m := &memoryInventory{}
+	m.add(productID, count)
+	return m
+}
+
+func (m *memoryInventory) add(productID primitive.ObjectID, count int) {
+	for n := 0; n < count; n++ {
+		m.units = append(m.units, domain.Inventory{
+			ID:        primitive.NewObjectID(),
+			ProductID: productID,
+			Status:    "in_stock",
+		})
+	}
+}
+
+func (m *memoryInventory) TransitionUnit(ctx context.Context, t domain.UnitTransition) (*domain.Inventory, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for n := range m.units {
+		u := &m.units[n]
+		if u.ProductID != t.ProductID || u.Status != t.From {
+			continue
+		}
+		if !t.VariantID.IsZero() && u.VariantID != t.VariantID {
+			continue
+		}
+		if !t.LocationID.IsZero() && u.LocationID != t.LocationID {
+			continue
+		}
+		if t.ReservationID != "" && (u.Reservation == nil || u.Reservation.ID != t.ReservationID) {
+			continue
+		}
+		if t.SerialNumber != "" && u.SerialNumber != t.SerialNumber {
+			continue
+		}
+
+		unit := *u
+		*u = t.Apply(unit)
+		return &unit, nil
+	}
+	return nil, nil
+}
+
+func (m *memoryInventory) RestoreUnit(ctx context.Context, unit domain.Inventory, status string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.units {
+		if m.units[i].ID == unit.ID && m.units[i].Status == status {
+			m.units[i].Status, m.units[i].Reservation, m.units[i].Sale = unit.Status, unit.Reservation, unit.Sale
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryInventory) CommitReservation(ctx context.Context, reservationID, orderID string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for i := range m.units {
+		u := &m.units[i]
+		if u.Status == "reserved" && u.Reservation != nil && u.Reservation.ID == reservationID {
+			committed := *u.Reservation
+			committed.OrderID, committed.ExpiresAt = orderID, time.Time{}
+			u.Reservation = &committed
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memoryInventory) GetInventoryByID(ctx context.Context, id primitive.ObjectID) (*domain.Inventory, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, u := range m.units {
+		if u.ID == id {
+			return &u, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *memoryInventory) UpdateInventory(ctx context.Context, inventory *domain.Inventory, status string, updatedAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.units {
+		u := &m.units[i]
+		if u.ID == inventory.ID && u.Status == status && u.UpdatedAt.Equal(updatedAt) {
+			inventory.UpdatedAt = time.Now()
+			*u = *inventory
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryInventory) count(status string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, u := range m.units {
+		if u.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// memoryMovements is an in-memory MovementRepository.
+type memoryMovements struct {
+	repository.MovementRepository
+
+	mu        sync.Mutex
+	movements []domain.InventoryMovement
+}
+
+func (m *memoryMovements) CreateMovements(ctx context.Context, movements []domain.InventoryMovement) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.movements = append(m.movements, movements...)
+	return nil
+}
+
+func orderOf(lines ...domain.ProductOrder) domain.Order {
+	return domain.Order{Products: lines}
+}
+
+func line(productID primitive.ObjectID, quantity int64) domain.ProductOrder {
+	return domain.ProductOrder{ID: productID.Hex(), Quantity: quantity}
+}
+
+func TestReserveProductsConcurrent(t *testing.T) {
+	const (
+		units    = 100
+		workers  = 64
+		perOrder = 3
+	)
+
+	productID := primitive.NewObjectID()
+	repo := newMemoryInventory(productID, units)
+	uc := NewInventoryUseCase(repo, nil, &memoryMovements{}, nil, nil, time.Minute, nil)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := uc.ReserveProducts(context.Background(), orderOf(line(productID, perOrder)))
+			if err != nil && !errors.Is(err, ErrInsufficientStock) {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if want := units / perOrder; succeeded != want {
+		t.Fatalf("succeeded orders = %d, want %d", succeeded, want)
+	}
+	if got, want := repo.count("reserved"), succeeded*perOrder; got != want {
+		t.Fatalf("reserved units = %d, want %d", got, want)
+	}
+	if got, want := repo.count("in_stock"), units-succeeded*perOrder; got != want {
+		t.Fatalf("in_stock units = %d, want %d", got, want)
+	}
+}
+
+func TestReserveProductsRollsBackOnShortLine(t *testing.T) {
+	first, second := primitive.NewObjectID(), primitive.NewObjectID()
+	repo := newMemoryInventory(first, 5)
+	repo.add(second, 1)
+	uc := NewInventoryUseCase(repo, nil, &memoryMovements{}, nil, nil, time.Minute, nil)
+
+	_, err := uc.ReserveProducts(context.Background(), orderOf(line(first, 2), line(second, 2)))
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("err = %v, want ErrInsufficientStock", err)
+	}
+	if got := repo.count("reserved"); got != 0 {
+		t.Fatalf("reserved units after rollback = %d, want 0", got)
+	}
+	if got := repo.count("in_stock"); got != 6 {
+		t.Fatalf("in_stock units after rollback = %d, want 6", got)
+	}
+}
+
+func TestFailedTransitionRestoresUnits(t *testing.T) {
+	ctx := context.Background()
+	first, second := primitive.NewObjectID(), primitive.NewObjectID()
+	repo := newMemoryInventory(first, 2)
+	repo.add(second, 2)
+	uc := NewInventoryUseCase(repo, nil, &memoryMovements{}, nil, nil, time.Minute, nil)
+
+	reservation, err := uc.ReserveProducts(ctx, orderOf(line(first, 2), line(second, 1)))
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	unchanged := func(what string) {
+		t.Helper()
+		if got := repo.count("reserved"); got != 3 {
+			t.Fatalf("%s: reserved units = %d, want 3", what, got)
+		}
+		for _, u := range repo.units {
+			if u.Status != "reserved" {
+				continue
+			}
+			if u.Reservation == nil || u.Reservation.ID != reservation.ID || !u.Reservation.ExpiresAt.Equal(reservation.ExpiresAt) {
+				t.Fatalf("%s: unit %s has reservation %+v, want %+v", what, u.ID.Hex(), u.Reservation, reservation)
+			}
+			if u.Sale != nil {
+				t.Fatalf("%s: unit %s still has sale %+v", what, u.ID.Hex(), u.Sale)
+			}
+		}
+	}
+
+	// the first line moves, then the second asks for more than is held
+	short := orderOf(line(first, 2), line(second, 2))
+	short.ReservationID = reservation.ID
+	if err := uc.CancelReservation(ctx, short); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("cancel: err = %v, want ErrInsufficientStock", err)
+	}
+	unchanged("cancel rolled back")
+
+	short.OrderID, short.ShipmentID = "order-1", "shipment-1"
+	if _, err := uc.ShipProducts(ctx, short); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("ship: err = %v, want ErrInsufficientStock", err)
+	}
+	unchanged("ship rolled back")
+}
+
+func TestReserveProductsRejectsInvalidQuantity(t *testing.T) {
+	productID := primitive.NewObjectID()
+	repo := newMemoryInventory(productID, 1)
+	uc := NewInventoryUseCase(repo, nil, &memoryMovements{}, nil, nil, time.Minute, nil)
+
+	if _, err := uc.ReserveProducts(context.Background(), orderOf(line(productID, 0))); err == nil {
+		t.Fatal("expected an error for a zero quantity")
+	}
+	if got := repo.count("reserved"); got != 0 {
+		t.Fatalf("reserved units = %d, want 0", got)
+	}
+}
+
+func TestCancelReservationOnlyReleasesItsOwnUnits(t *testing.T) {
+	productID := primitive.NewObjectID()
+	repo := newMemoryInventory(productID, 4)
+	ledger := &memoryMovements{}
+	uc := NewInventoryUseCase(repo, nil, ledger, nil, nil, time.Minute, nil)
+
+	first, err := uc.ReserveProducts(context.Background(), orderOf(line(productID, 2)))
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if _, err := uc.ReserveProducts(context.Background(), orderOf(line(productID, 2))); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+
+	// without a reservation ID any reservation's units would match
+	order := orderOf(line(productID, 2))
+	if err := uc.CancelReservation(context.Background(), order); err == nil {
+		t.Fatal("cancel without a reservation: want error")
+	}
+	if err := uc.MarkProductsAsSold(context.Background(), order); err == nil {
+		t.Fatal("sell without a reservation: want error")
+	}
+	if got := repo.count("reserved"); got != 4 {
+		t.Fatalf("reserved units = %d, want 4", got)
+	}
+
+	order.ReservationID = first.ID
+	if err := uc.CancelReservation(context.Background(), order); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	if got := repo.count("reserved"); got != 2 {
+		t.Fatalf("reserved units = %d, want 2", got)
+	}
+	for _, u := range repo.units {
+		if u.Status == "reserved" && u.Reservation.ID == first.ID {
+			t.Fatalf("unit %s still held by the cancelled reservation", u.ID.Hex())
+		}
+	}
+
+	// two reservations of two units each, then two cancelled units
+	if got := len(ledger.movements); got != 6 {
+		t.Fatalf("ledger entries = %d, want 6", got)
+	}
+	for _, m := range ledger.movements[4:] {
+		if m.From != "reserved" || m.To != "in_stock" || m.ReservationID != first.ID {
+			t.Fatalf("unexpected cancel movement %+v", m)
+		}
+	}
+}
+
+func TestReturnProducts(t *testing.T) {
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+	repo := newMemoryInventory(productID, 3)
+	for n := range repo.units {
+		repo.units[n].SerialNumber = fmt.Sprintf("SN-%d", n)
+	}
+	uc := NewInventoryUseCase(repo, nil, &memoryMovements{}, nil, nil, time.Minute, nil)
+
+	reservation, err := uc.ReserveProducts(ctx, orderOf(line(productID, 2)))
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	order := orderOf(line(productID, 2))
+	order.ReservationID = reservation.ID
+	if err := uc.MarkProductsAsSold(ctx, order); err != nil {
+		t.Fatalf("sell: %v", err)
+	}
+	var sold []string
+	for _, u := range repo.units {
+		if u.Status == "sold" {
+			sold = append(sold, u.SerialNumber)
+		}
+	}
+
+	// the unsold unit cannot be returned against this reservation
+	stray := orderOf(domain.ProductOrder{ID: productID.Hex(), Quantity: 1, SerialNumbers: []string{"SN-2"}})
+	stray.ReservationID = reservation.ID
+	if err := uc.ReturnProducts(ctx, stray); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("unsold serial: err = %v, want ErrInsufficientStock", err)
+	}
+
+	one := orderOf(domain.ProductOrder{ID: productID.Hex(), Quantity: 1, SerialNumbers: sold[1:]})
+	one.ReservationID = reservation.ID
+	if err := uc.ReturnProducts(ctx, one); err != nil {
+		t.Fatalf("return by serial: %v", err)
+	}
+	for _, u := range repo.units {
+		if u.SerialNumber == sold[1] && u.Status != "returned" {
+			t.Errorf("%s is %s, want returned", u.SerialNumber, u.Status)
+		}
+	}
+
+	if err := uc.ReturnProducts(ctx, orderOf(line(productID, 1))); err == nil {
+		t.Error("return without a reservation: want error")
+	}
+	rest := orderOf(line(productID, 1))
+	rest.ReservationID = reservation.ID
+	if err := uc.ReturnProducts(ctx, rest); err != nil {
+		t.Fatalf("return by quantity: %v", err)
+	}
+	if got := repo.count("returned"); got != 2 {
+		t.Errorf("returned units = %d, want 2", got)
+	}
+}
+
+func TestShipProducts(t *testing.T) {
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+	repo := newMemoryInventory(productID, 4)
+	for n := range repo.units {
+		repo.units[n].SerialNumber = fmt.Sprintf("SN-%d", n)
+	}
+	uc := NewInventoryUseCase(repo, nil, &memoryMovements{}, nil, nil, time.Minute, nil)
+
+	reservation, err := uc.ReserveProducts(ctx, orderOf(line(productID, 3)))
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	order := orderOf(line(productID, 3))
+	order.ReservationID, order.OrderID = reservation.ID, "order-1"
+	if err := uc.CommitReservation(ctx, order); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	for _, u := range repo.units {
+		if u.Status == "reserved" && (u.Reservation.OrderID != "order-1" || !u.Reservation.ExpiresAt.IsZero()) {
+			t.Fatalf("committed unit %s has reservation %+v", u.SerialNumber, u.Reservation)
+		}
+	}
+
+	var picked string
+	for _, u := range repo.units {
+		if u.Status == "reserved" {
+			picked = u.SerialNumber
+		}
+	}
+	first := orderOf(domain.ProductOrder{ID: productID.Hex(), Quantity: 1, SerialNumbers: []string{picked}})
+	first.ReservationID, first.OrderID, first.ShipmentID = reservation.ID, "order-1", "shipment-1"
+	units, err := uc.ShipProducts(ctx, first)
+	if err != nil {
+		t.Fatalf("ship picked unit: %v", err)
+	}
+	if len(units) != 1 || units[0].SerialNumber != picked || units[0].Status != "sold" || units[0].Sale == nil || units[0].Sale.ShipmentID != "shipment-1" {
+		t.Fatalf("shipped units = %+v, want %s sold in shipment-1", units, picked)
+	}
+
+	// the in-stock unit is not the order's to ship
+	var stray string
+	for _, u := range repo.units {
+		if u.Status == "in_stock" {
+			stray = u.SerialNumber
+		}
+	}
+	wrong := orderOf(domain.ProductOrder{ID: productID.Hex(), Quantity: 1, SerialNumbers: []string{stray}})
+	wrong.ReservationID, wrong.OrderID, wrong.ShipmentID = reservation.ID, "order-1", "shipment-2"
+	if _, err := uc.ShipProducts(ctx, wrong); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("unreserved serial: err = %v, want ErrInsufficientStock", err)
+	}
+
+	rest := orderOf(line(productID, 2))
+	rest.ReservationID, rest.OrderID, rest.ShipmentID = reservation.ID, "order-1", "shipment-2"
+	if units, err = uc.ShipProducts(ctx, rest); err != nil || len(units) != 2 {
+		t.Fatalf("ship the rest: %d units, err %v", len(units), err)
+	}
+	if got := repo.count("sold"); got != 3 {
+		t.Errorf("sold units = %d, want 3", got)
+	}
+}
+
+// reservingInventory reserves a unit just after UpdateInventory has read it.
+type reservingInventory struct {
+	*memoryInventory
+}
+
+func (r reservingInventory) GetInventoryByID(ctx context.Context, id primitive.ObjectID) (*domain.Inventory, error) {
+	unit, err := r.memoryInventory.GetInventoryByID(ctx, id)
+	if err == nil {
+		_, err = r.TransitionUnit(ctx, domain.UnitTransition{ProductID: unit.ProductID, From: "in_stock", To: "reserved", SerialNumber: unit.SerialNumber, Reservation: &domain.Reservation{ID: "r1"}})
+	}
+	return unit, err
+}
+
+func TestUpdateInventoryKeepsConcurrentReservation(t *testing.T) {
+	ctx := context.Background()
+	laptop := domain.Product{ID: primitive.NewObjectID()}
+	repo := newMemoryInventory(laptop.ID, 1)
+	repo.units[0].SerialNumber = "SN-1"
+	products := &memoryProducts{products: []domain.Product{laptop}}
+
+	edit := repo.units[0]
+	edit.Status = "damaged"
+	uc := NewInventoryUseCase(reservingInventory{repo}, products, &memoryMovements{}, nil, nil, time.Minute, nil)
+	if err := uc.UpdateInventory(ctx, &edit, "admin", ""); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("err = %v, want ErrInvalidTransition", err)
+	}
+	if u := repo.units[0]; u.Status != "reserved" || u.Reservation == nil {
+		t.Fatalf("unit = %+v, want it still reserved", u)
+	}
+
+	// without a concurrent change the edit goes through
+	uc = NewInventoryUseCase(repo, products, &memoryMovements{}, nil, nil, time.Minute, nil)
+	edit = repo.units[0]
+	edit.SerialNumber = "SN-2"
+	if err := uc.UpdateInventory(ctx, &edit, "admin", ""); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if u := repo.units[0]; u.SerialNumber != "SN-2" || u.Status != "reserved" {
+		t.Errorf("unit = %+v, want SN-2 still reserved", u)
+	}
+}
+
+func TestCheckManualTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{domain.StatusReceived, domain.StatusInStock, true},
+		{domain.StatusInStock, domain.StatusDamaged, true},
+		{domain.StatusSold, domain.StatusReturned, true},
+		{domain.StatusReturned, domain.StatusInStock, true},
+		{domain.StatusDamaged, domain.StatusWrittenOff, true},
+		{domain.StatusInStock, domain.StatusReserved, false},
+		{domain.StatusReserved, domain.StatusInStock, false},
+		{domain.StatusInStock, domain.StatusSold, false},
+		{domain.StatusSold, domain.StatusInStock, false},
+		{domain.StatusWrittenOff, domain.StatusInStock, false},
+		{domain.StatusInStock, domain.StatusInTransit, false},
+		{domain.StatusInTransit, domain.StatusInStock, false},
+		{domain.StatusInStock, "lost", false},
+		{"available", domain.StatusInStock, true},
+	}
+	for _, tt := range tests {
+		err := checkManualTransition(tt.from, tt.to)
+		if tt.ok && err != nil {
+			t.Errorf("%s -> %s: unexpected error %v", tt.from, tt.to, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("%s -> %s: err = %v, want ErrInvalidTransition", tt.from, tt.to, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
+	"github.com/mephirious/group-project/services/products-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LocationUseCase interface {
+	GetAllLocations(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Location], error)
+	GetLocationByID(ctx context.Context, id primitive.ObjectID) (*domain.Location, error)
+	GetLocationInventory(ctx context.Context, id primitive.ObjectID, params pagination.Params) (*pagination.Page[domain.Inventory], error)
+	CreateLocation(ctx context.Context, location *domain.Location) error
+	UpdateLocation(ctx context.Context, location *domain.Location) error
+	DeleteLocation(ctx context.Context, id primitive.ObjectID) error
+}
+
+type locationUseCase struct {
+	locationRepository  repository.LocationRepository
+	inventoryRepository repository.InventoryRepository
+}
+
+func NewLocationUseCase(locationRepository repository.LocationRepository, inventoryRepository repository.InventoryRepository) *locationUseCase {
+	return &locationUseCase{
+		locationRepository:  locationRepository,
+		inventoryRepository: inventoryRepository,
+	}
+}
+
+func (l *locationUseCase) GetAllLocations(ctx context.Context, params pagination.Params) (*pagination.Page[domain.Location], error) {
+	return l.locationRepository.GetAllLocations(ctx, params)
+}
+
+func (l *locationUseCase) GetLocationByID(ctx context.Context, id primitive.ObjectID) (*domain.Location, error) {
+	location, err := l.locationRepository.GetLocationByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("location not found")
+	}
+	return location, nil
+}
+
+func (l *locationUseCase) GetLocationInventory(ctx context.Context, id primitive.ObjectID, params pagination.Params) (*pagination.Page[domain.Inventory], error) {
+	if _, err := l.locationRepository.GetLocationByID(ctx, id); err != nil {
+		return nil, errors.New("location not found")
+	}
+	return l.inventoryRepository.GetInventoryByLocationID(ctx, id, params)
+}
+
+func (l *locationUseCase) CreateLocation(ctx context.Context, location *domain.Location) error {
+	if err := location.Validate(); err != nil {
+		return err
+	}
+	if err := l.checkCode(ctx, location); err != nil {
+		return err
+	}
+
+	return l.locationRepository.CreateLocation(ctx, location)
+}
+
+func (l *locationUseCase) UpdateLocation(ctx context.Context, location *domain.Location) error {
+	existing, err := l.locationRepository.GetLocationByID(ctx, location.ID)
+	if err != nil {
+		return errors.New("location not found")
+	}
+	if err := location.Validate(); err != nil {
+		return err
+	}
+	if location.Code != existing.Code {
+		if err := l.checkCode(ctx, location); err != nil {
+			return err
+		}
+	}
+
+	location.CreatedAt = existing.CreatedAt
+	return l.locationRepository.UpdateLocation(ctx, location)
+}
+
+// DeleteLocation removes a location that no longer holds any units.
+func (l *locationUseCase) DeleteLocation(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := l.locationRepository.GetLocationByID(ctx, id); err != nil {
+		return errors.New("location not found")
+	}
+
+	units, err := l.inventoryRepository.CountUnitsByLocationID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if units > 0 {
+		return fmt.Errorf("%w: %d inventory units are at this location", ErrInUse, units)
+	}
+
+	return l.locationRepository.DeleteLocation(ctx, id)
+}
+
+func (l *locationUseCase) checkCode(ctx context.Context, location *domain.Location) error {
+	existing, err := l.locationRepository.GetLocationByCode(ctx, location.Code)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != location.ID {
+		return errors.New("location code already exists")
+	}
+	return nil
+}
@@ -3,79 +3,125 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"github.com/mephirious/group-project/services/products-service/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type ProductUseCase interface {
-	GetAllProducts(ctx context.Context, limit, skip int, sortField, sortOrder, search string) ([]domain.ProductView, error)
-	GetProductByID(ctx context.Context, id primitive.ObjectID) (*domain.ProductView, error)
+	GetAllProducts(ctx context.Context, params pagination.Params, filter domain.ProductFilter, opts domain.ProductViewOptions) (*pagination.Page[domain.ProductView], error)
+	GetProductByID(ctx context.Context, id primitive.ObjectID, opts domain.ProductViewOptions) (*domain.ProductView, error)
 	GetProductByName(ctx context.Context, name string) (*domain.ProductView, error)
+	GetProductBySKU(ctx context.Context, sku string) (*domain.ProductView, *domain.VariantView, error)
+	GetProductBreadcrumb(ctx context.Context, id primitive.ObjectID) ([]domain.Category, error)
 	CreateProduct(ctx context.Context, product *domain.Product) error
 	UpdateProduct(ctx context.Context, product *domain.Product) error
 	DeleteProduct(ctx context.Context, id primitive.ObjectID) error
+	GetVariants(ctx context.Context, productID primitive.ObjectID) ([]domain.VariantView, error)
+	CreateVariant(ctx context.Context, productID primitive.ObjectID, variant *domain.Variant) error
+	UpdateVariant(ctx context.Context, productID primitive.ObjectID, variant *domain.Variant) error
+	DeleteVariant(ctx context.Context, productID, variantID primitive.ObjectID) error
 }
 
+// ErrProductChanged is returned when a product changed between being read
+// and written. The request can be retried.
+var ErrProductChanged = errors.New("product changed concurrently, retry")
+
+// ErrProductNotFound and ErrVariantNotFound are returned by the variant
+// methods when the product or its variant does not exist.
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrVariantNotFound = errors.New("variant not found")
+)
+
+// ErrInvalidFilter is returned when a product listing filter does not match
+// the attribute schemas or names an unknown category.
+var ErrInvalidFilter = errors.New("invalid filter")
+
 type productUseCase struct {
-	productRepository  repository.ProductRepository
-	categoryRepository repository.CategoryRepository
-	brandRepository    repository.BrandRepository
-	typeRepository     repository.TypeRepository
+	productRepository        repository.ProductRepository
+	categoryRepository       repository.CategoryRepository
+	brandRepository          repository.BrandRepository
+	typeRepository           repository.TypeRepository
+	inventoryRepository      repository.InventoryRepository
+	stockThresholdRepository repository.StockThresholdRepository
+	pricing                  Pricing
 }
 
-func NewProductUseCase(productRepository repository.ProductRepository, categoryRepository repository.CategoryRepository, brandRepository repository.BrandRepository, typeRepository repository.TypeRepository) *productUseCase {
+func NewProductUseCase(productRepository repository.ProductRepository, categoryRepository repository.CategoryRepository, brandRepository repository.BrandRepository, typeRepository repository.TypeRepository, inventoryRepository repository.InventoryRepository, stockThresholdRepository repository.StockThresholdRepository, pricing Pricing) *productUseCase {
 	return &productUseCase{
-		productRepository:  productRepository,
-		categoryRepository: categoryRepository,
-		brandRepository:    brandRepository,
-		typeRepository:     typeRepository,
+		productRepository:        productRepository,
+		categoryRepository:       categoryRepository,
+		brandRepository:          brandRepository,
+		typeRepository:           typeRepository,
+		inventoryRepository:      inventoryRepository,
+		stockThresholdRepository: stockThresholdRepository,
+		pricing:                  pricing,
 	}
 }
 
-func (p *productUseCase) GetAllProducts(ctx context.Context, limit, skip int, sortField, sortOrder, search string) ([]domain.ProductView, error) {
-	products, err := p.productRepository.GetAllProducts(ctx, limit, skip, sortField, sortOrder, search)
+func (p *productUseCase) GetAllProducts(ctx context.Context, params pagination.Params, filter domain.ProductFilter, opts domain.ProductViewOptions) (*pagination.Page[domain.ProductView], error) {
+	pricer, err := p.pricing.Pricer(ctx, opts.Currency)
 	if err != nil {
 		return nil, err
 	}
-
-	// convert product to product view
-	productViews := make([]domain.ProductView, len(products))
-
-	// set category, brand, type names
-	for i, product := range products {
-		Category, err := p.categoryRepository.GetCategoryByID(ctx, product.CategoryID)
-		if err != nil {
-			Category = &domain.Category{CategoryName: "Unknown"}
-		}
-		Brand, err := p.brandRepository.GetBrandByID(ctx, product.BrandID)
+	if err := p.resolveCategoryFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+	if err := p.resolveAttributeFilters(ctx, &filter); err != nil {
+		return nil, err
+	}
+	if filter.InStockOnly {
+		ids, err := p.inventoryRepository.GetInStockProductIDs(ctx)
 		if err != nil {
-			Brand = &domain.Brand{BrandName: "Unknown"}
+			return nil, err
 		}
-		Type, err := p.typeRepository.GetTypeByID(ctx, product.TypeID)
+		// an empty, non-nil list matches nothing
+		filter.IDs = append([]primitive.ObjectID{}, ids...)
+	}
+
+	products, err := p.productRepository.GetAllProducts(ctx, params, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// convert product to product view
+	productViews := make([]domain.ProductView, len(products.Items))
+	for i := range products.Items {
+		productViews[i], err = p.toView(ctx, &products.Items[i], pricer)
 		if err != nil {
-			Type = &domain.Type{TypeName: "Unknown"}
+			return nil, err
 		}
-		productViews[i] = domain.ProductView{
-			ID:             product.ID,
-			ModelName:      product.ModelName,
-			Price:          product.Price,
-			Category:       Category.CategoryName,
-			Brand:          Brand.BrandName,
-			Type:           Type.TypeName,
-			Specifications: product.Specifications,
-			Content:        product.Content,
-			Images:         product.Images,
-			CreatedAt:      product.CreatedAt,
-			UpdatedAt:      product.UpdatedAt,
+	}
+	if opts.Availability {
+		if err := p.addAvailability(ctx, productViews); err != nil {
+			return nil, err
 		}
 	}
-	return productViews, nil
+
+	return &pagination.Page[domain.ProductView]{
+		Items:      productViews,
+		Total:      products.Total,
+		NextCursor: products.NextCursor,
+	}, nil
 }
 
-func (p *productUseCase) GetProductByID(ctx context.Context, id primitive.ObjectID) (*domain.ProductView, error) {
+func (p *productUseCase) GetProductByID(ctx context.Context, id primitive.ObjectID, opts domain.ProductViewOptions) (*domain.ProductView, error) {
+	pricer, err := p.pricing.Pricer(ctx, opts.Currency)
+	if err != nil {
+		return nil, err
+	}
 	product, err := p.productRepository.GetProductByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// the handler reports a nil product as 404
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -83,33 +129,54 @@ func (p *productUseCase) GetProductByID(ctx context.Context, id primitive.Object
 		return nil, errors.New("product not found")
 	}
 
-	// Get category, brand, type names
-	Category, err := p.categoryRepository.GetCategoryByID(ctx, product.CategoryID)
+	view, err := p.toView(ctx, product, pricer)
 	if err != nil {
-		Category = &domain.Category{CategoryName: "Unknown"}
+		return nil, err
 	}
-	Brand, err := p.brandRepository.GetBrandByID(ctx, product.BrandID)
+	views := []domain.ProductView{view}
+	if opts.Availability {
+		if err := p.addAvailability(ctx, views); err != nil {
+			return nil, err
+		}
+	}
+	return &views[0], nil
+}
+
+// GetProductBreadcrumb returns the product's category and its ancestors,
+// root first.
+func (p *productUseCase) GetProductBreadcrumb(ctx context.Context, id primitive.ObjectID) ([]domain.Category, error) {
+	product, err := p.productRepository.GetProductByID(ctx, id)
 	if err != nil {
-		Brand = &domain.Brand{BrandName: "Unknown"}
+		return nil, err
 	}
-	Type, err := p.typeRepository.GetTypeByID(ctx, product.TypeID)
+	if product == nil {
+		return nil, errors.New("product not found")
+	}
+
+	category, err := p.categoryRepository.GetCategoryByID(ctx, product.CategoryID)
 	if err != nil {
-		Type = &domain.Type{TypeName: "Unknown"}
+		return nil, errors.New("category not found")
 	}
 
-	return &domain.ProductView{
-		ID:             product.ID,
-		ModelName:      product.ModelName,
-		Price:          product.Price,
-		Category:       Category.CategoryName,
-		Brand:          Brand.BrandName,
-		Type:           Type.TypeName,
-		Specifications: product.Specifications,
-		Content:        product.Content,
-		Images:         product.Images,
-		CreatedAt:      product.CreatedAt,
-		UpdatedAt:      product.UpdatedAt,
-	}, nil
+	ancestorIDs := category.AncestorIDs()
+	breadcrumb := make([]domain.Category, 0, len(ancestorIDs)+1)
+	if len(ancestorIDs) > 0 {
+		ancestors, err := p.categoryRepository.GetCategoriesByIDs(ctx, ancestorIDs)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[primitive.ObjectID]domain.Category, len(ancestors))
+		for _, a := range ancestors {
+			byID[a.ID] = a
+		}
+		for _, id := range ancestorIDs {
+			if a, ok := byID[id]; ok {
+				breadcrumb = append(breadcrumb, a)
+			}
+		}
+	}
+
+	return append(breadcrumb, *category), nil
 }
 
 func (p *productUseCase) GetProductByName(ctx context.Context, name string) (*domain.ProductView, error) {
@@ -121,33 +188,32 @@ func (p *productUseCase) GetProductByName(ctx context.Context, name string) (*do
 		return nil, errors.New("product not found")
 	}
 
-	// Get category, brand, type names
-	Category, err := p.categoryRepository.GetCategoryByID(ctx, product.CategoryID)
+	view, err := p.toView(ctx, product, p.basePricer())
 	if err != nil {
-		Category = &domain.Category{CategoryName: "Unknown"}
+		return nil, err
 	}
-	Brand, err := p.brandRepository.GetBrandByID(ctx, product.BrandID)
+	return &view, nil
+}
+
+func (p *productUseCase) GetProductBySKU(ctx context.Context, sku string) (*domain.ProductView, *domain.VariantView, error) {
+	product, err := p.productRepository.GetProductBySKU(ctx, sku)
 	if err != nil {
-		Brand = &domain.Brand{BrandName: "Unknown"}
+		return nil, nil, err
 	}
-	Type, err := p.typeRepository.GetTypeByID(ctx, product.TypeID)
-	if err != nil {
-		Type = &domain.Type{TypeName: "Unknown"}
+	if product == nil {
+		return nil, nil, errors.New("variant not found")
 	}
 
-	return &domain.ProductView{
-		ID:             product.ID,
-		ModelName:      product.ModelName,
-		Price:          product.Price,
-		Category:       Category.CategoryName,
-		Brand:          Brand.BrandName,
-		Type:           Type.TypeName,
-		Specifications: product.Specifications,
-		Content:        product.Content,
-		Images:         product.Images,
-		CreatedAt:      product.CreatedAt,
-		UpdatedAt:      product.UpdatedAt,
-	}, nil
+	view, err := p.toView(ctx, product, p.basePricer())
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range view.Variants {
+		if view.Variants[i].SKU == sku {
+			return &view, &view.Variants[i], nil
+		}
+	}
+	return nil, nil, errors.New("variant not found")
 }
 
 func (p *productUseCase) CreateProduct(ctx context.Context, product *domain.Product) error {
@@ -158,6 +224,22 @@ func (p *productUseCase) CreateProduct(ctx context.Context, product *domain.Prod
 	if existingProduct != nil {
 		return errors.New("product already exists")
 	}
+	if err := p.validateReferences(ctx, product); err != nil {
+		return err
+	}
+	if err := domain.ValidateOptions(product.Options); err != nil {
+		return err
+	}
+	if err := domain.ValidatePrices(product.Prices, p.pricing.BaseCurrency()); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPrice, err)
+	}
+	product.TaxClass = strings.ToLower(strings.TrimSpace(product.TaxClass))
+	if err := p.validateSpecifications(ctx, product); err != nil {
+		return err
+	}
+
+	// variants are managed through their own endpoints
+	product.Variants = []domain.Variant{}
 
 	return p.productRepository.CreateProduct(ctx, product)
 }
@@ -170,8 +252,36 @@ func (p *productUseCase) UpdateProduct(ctx context.Context, product *domain.Prod
 	if existingProduct == nil {
 		return errors.New("product not found")
 	}
+	if err := p.validateReferences(ctx, product); err != nil {
+		return err
+	}
+	if err := domain.ValidateOptions(product.Options); err != nil {
+		return err
+	}
+	if err := domain.ValidatePrices(product.Prices, p.pricing.BaseCurrency()); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPrice, err)
+	}
+	product.TaxClass = strings.ToLower(strings.TrimSpace(product.TaxClass))
+	if err := p.validateSpecifications(ctx, product); err != nil {
+		return err
+	}
 
-	return p.productRepository.UpdateProduct(ctx, product)
+	// keep the existing variants, but only if they still fit the new options
+	product.Variants = existingProduct.Variants
+	for _, variant := range product.Variants {
+		if err := product.ValidateVariant(variant); err != nil {
+			return fmt.Errorf("variant %s no longer matches the product options: %w", variant.SKU, err)
+		}
+	}
+
+	updated, err := p.productRepository.UpdateProduct(ctx, product, existingProduct.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrProductChanged
+	}
+	return nil
 }
 
 func (p *productUseCase) DeleteProduct(ctx context.Context, id primitive.ObjectID) error {
@@ -185,3 +295,358 @@ func (p *productUseCase) DeleteProduct(ctx context.Context, id primitive.ObjectI
 
 	return p.productRepository.DeleteProduct(ctx, id)
 }
+
+func (p *productUseCase) GetVariants(ctx context.Context, productID primitive.ObjectID) ([]domain.VariantView, error) {
+	product, err := p.variantProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return product.VariantViews(), nil
+}
+
+func (p *productUseCase) CreateVariant(ctx context.Context, productID primitive.ObjectID, variant *domain.Variant) error {
+	product, err := p.variantProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if len(product.Options) == 0 {
+		return errors.New("product has no options to build variants from")
+	}
+
+	variant.ID = primitive.NewObjectID()
+	variant.CreatedAt = time.Now()
+	variant.UpdatedAt = time.Now()
+	if err := p.validateVariant(ctx, product, variant); err != nil {
+		return err
+	}
+
+	added, err := p.productRepository.AddVariant(ctx, productID, product.UpdatedAt, *variant)
+	return variantWritten(added, err, variant.SKU)
+}
+
+func (p *productUseCase) UpdateVariant(ctx context.Context, productID primitive.ObjectID, variant *domain.Variant) error {
+	product, err := p.variantProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	existing, _ := product.FindVariant(variant.ID)
+	if existing == nil {
+		return ErrVariantNotFound
+	}
+
+	variant.CreatedAt = existing.CreatedAt
+	variant.UpdatedAt = time.Now()
+	if err := p.validateVariant(ctx, product, variant); err != nil {
+		return err
+	}
+
+	updated, err := p.productRepository.UpdateVariant(ctx, productID, product.UpdatedAt, *variant)
+	return variantWritten(updated, err, variant.SKU)
+}
+
+func (p *productUseCase) DeleteVariant(ctx context.Context, productID, variantID primitive.ObjectID) error {
+	product, err := p.variantProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	existing, _ := product.FindVariant(variantID)
+	if existing == nil {
+		return ErrVariantNotFound
+	}
+
+	units, err := p.inventoryRepository.CountVariantUnits(ctx, variantID)
+	if err != nil {
+		return err
+	}
+	if units > 0 {
+		return fmt.Errorf("variant still has %d inventory units", units)
+	}
+
+	removed, err := p.productRepository.RemoveVariant(ctx, productID, product.UpdatedAt, variantID)
+	return variantWritten(removed, err, existing.SKU)
+}
+
+// variantProduct loads the product whose variants are being read or
+// written.
+func (p *productUseCase) variantProduct(ctx context.Context, productID primitive.ObjectID) (*domain.Product, error) {
+	product, err := p.productRepository.GetProductByID(ctx, productID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrProductNotFound
+	}
+	return product, err
+}
+
+// variantWritten turns the result of a conditional variant write into an
+// error. Each write is conditional on the product being unchanged since the
+// variant was validated against it; the unique SKU index catches another
+// product taking the SKU in the meantime.
+func variantWritten(written bool, err error, sku string) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("sku %q is already used by another product", sku)
+	}
+	if err != nil {
+		return err
+	}
+	if !written {
+		return ErrProductChanged
+	}
+	return nil
+}
+
+// validateVariant checks the variant against its product and makes sure the
+// SKU is not taken by a different product.
+func (p *productUseCase) validateVariant(ctx context.Context, product *domain.Product, variant *domain.Variant) error {
+	if err := product.ValidateVariant(*variant); err != nil {
+		return err
+	}
+	if err := domain.ValidatePrices(variant.Prices, p.pricing.BaseCurrency()); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPrice, err)
+	}
+
+	if len(variant.Specifications) > 0 {
+		typeEntity, err := p.typeRepository.GetTypeByID(ctx, product.TypeID)
+		if err != nil {
+			return errors.New("type not found")
+		}
+		specs, err := typeEntity.Validate(variant.Specifications, true)
+		if err != nil {
+			return err
+		}
+		variant.Specifications = specs
+	}
+
+	owner, err := p.productRepository.GetProductBySKU(ctx, variant.SKU)
+	if err != nil {
+		return err
+	}
+	if owner != nil && owner.ID != product.ID {
+		return fmt.Errorf("sku %q is already used by another product", variant.SKU)
+	}
+	return nil
+}
+
+// validateReferences makes sure the product's brand, category and type exist.
+func (p *productUseCase) validateReferences(ctx context.Context, product *domain.Product) error {
+	if _, err := p.brandRepository.GetBrandByID(ctx, product.BrandID); err != nil {
+		return fmt.Errorf("%w: brand %s not found", ErrInvalidReference, product.BrandID.Hex())
+	}
+	if _, err := p.categoryRepository.GetCategoryByID(ctx, product.CategoryID); err != nil {
+		return fmt.Errorf("%w: category %s not found", ErrInvalidReference, product.CategoryID.Hex())
+	}
+	if _, err := p.typeRepository.GetTypeByID(ctx, product.TypeID); err != nil {
+		return fmt.Errorf("%w: type %s not found", ErrInvalidReference, product.TypeID.Hex())
+	}
+	return nil
+}
+
+// validateSpecifications checks the product's specifications against the
+// attribute schema of its type.
+func (p *productUseCase) validateSpecifications(ctx context.Context, product *domain.Product) error {
+	typeEntity, err := p.typeRepository.GetTypeByID(ctx, product.TypeID)
+	if err != nil {
+		return errors.New("type not found")
+	}
+
+	specs, err := typeEntity.Validate(product.Specifications, false)
+	if err != nil {
+		return err
+	}
+	product.Specifications = specs
+	return nil
+}
+
+// resolveCategoryFilter expands the requested category into itself and all of
+// its descendants.
+func (p *productUseCase) resolveCategoryFilter(ctx context.Context, filter *domain.ProductFilter) error {
+	var category *domain.Category
+	switch {
+	case filter.CategoryID != nil:
+		c, err := p.categoryRepository.GetCategoryByID(ctx, *filter.CategoryID)
+		if err != nil {
+			return fmt.Errorf("%w: category not found", ErrInvalidFilter)
+		}
+		category = c
+	case filter.CategorySlug != "":
+		c, err := p.categoryRepository.GetCategoryBySlug(ctx, filter.CategorySlug)
+		if err != nil {
+			return err
+		}
+		if c == nil {
+			return fmt.Errorf("%w: category not found", ErrInvalidFilter)
+		}
+		category = c
+	default:
+		return nil
+	}
+
+	descendants, err := p.categoryRepository.GetDescendants(ctx, category)
+	if err != nil {
+		return err
+	}
+	filter.CategoryIDs = []primitive.ObjectID{category.ID}
+	for _, d := range descendants {
+		filter.CategoryIDs = append(filter.CategoryIDs, d.ID)
+	}
+	return nil
+}
+
+// resolveAttributeFilters checks each attribute filter against the schema of
+// the filtered type, or of any type when none is given, and converts the raw
+// query values to the attribute's data type.
+func (p *productUseCase) resolveAttributeFilters(ctx context.Context, filter *domain.ProductFilter) error {
+	if len(filter.Attributes) == 0 {
+		return nil
+	}
+
+	var types []domain.Type
+	if filter.TypeID != nil {
+		typeEntity, err := p.typeRepository.GetTypeByID(ctx, *filter.TypeID)
+		if err != nil {
+			return fmt.Errorf("%w: type not found", ErrInvalidFilter)
+		}
+		types = []domain.Type{*typeEntity}
+	} else {
+		var err error
+		types, err = p.typeRepository.GetAllTypes(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, attribute := range filter.Attributes {
+		definition, ok := findAttribute(types, attribute.Name)
+		if !ok || !definition.Filterable {
+			return fmt.Errorf("%w: attribute %q is not filterable", ErrInvalidFilter, attribute.Name)
+		}
+		if (attribute.Min != nil || attribute.Max != nil) && definition.DataType != domain.AttributeNumber {
+			return fmt.Errorf("%w: attribute %q does not support ranges", ErrInvalidFilter, attribute.Name)
+		}
+
+		for j, value := range attribute.Values {
+			raw, _ := value.(string)
+			parsed, err := definition.Parse(raw)
+			if err != nil {
+				return fmt.Errorf("%w: attribute %q must be a %s", ErrInvalidFilter, attribute.Name, definition.DataType)
+			}
+			filter.Attributes[i].Values[j] = parsed
+		}
+	}
+	return nil
+}
+
+func findAttribute(types []domain.Type, name string) (domain.AttributeDefinition, bool) {
+	for _, t := range types {
+		if definition, ok := t.Attribute(name); ok {
+			return definition, true
+		}
+	}
+	return domain.AttributeDefinition{}, false
+}
+
+// addAvailability fills in the availability of every view, counting stock for
+// all of them with one query. A product shows as low on stock at or below its
+// reorder point, or DefaultLowStockLevel when it has no threshold.
+func (p *productUseCase) addAvailability(ctx context.Context, views []domain.ProductView) error {
+	ids := make([]primitive.ObjectID, len(views))
+	for i, view := range views {
+		ids[i] = view.ID
+	}
+
+	quantities, err := p.inventoryRepository.GetProductQuantities(ctx, ids)
+	if err != nil {
+		return err
+	}
+	thresholds, err := p.stockThresholdRepository.GetThresholdsByProductIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	lowLevels := make(map[primitive.ObjectID]int64, len(thresholds))
+	for _, t := range thresholds {
+		lowLevels[t.ProductID] = t.ReorderPoint
+	}
+
+	for i := range views {
+		lowLevel, ok := lowLevels[views[i].ID]
+		if !ok {
+			lowLevel = domain.DefaultLowStockLevel
+		}
+		availability := domain.NewAvailability(quantities[views[i].ID], lowLevel, views[i].Backorderable)
+		views[i].Availability = &availability
+	}
+	return nil
+}
+
+// basePricer prices views in the base currency, which needs no exchange
+// rate.
+func (p *productUseCase) basePricer() *domain.Pricer {
+	base := p.pricing.BaseCurrency()
+	return &domain.Pricer{Base: base, Currency: base}
+}
+
+// toView resolves category, brand and type names for a product and prices
+// it and its variants with pricer.
+func (p *productUseCase) toView(ctx context.Context, product *domain.Product, pricer *domain.Pricer) (domain.ProductView, error) {
+	Category, err := p.categoryRepository.GetCategoryByID(ctx, product.CategoryID)
+	if err != nil {
+		Category = &domain.Category{CategoryName: "Unknown"}
+	}
+	categoryIDs := Category.AncestorIDs()
+	if !Category.ID.IsZero() {
+		categoryIDs = append(categoryIDs, Category.ID)
+	}
+	Brand, err := p.brandRepository.GetBrandByID(ctx, product.BrandID)
+	if err != nil {
+		Brand = &domain.Brand{BrandName: "Unknown"}
+	}
+	Type, err := p.typeRepository.GetTypeByID(ctx, product.TypeID)
+	if err != nil {
+		Type = &domain.Type{TypeName: "Unknown"}
+	}
+
+	money, err := pricer.Price(product, nil)
+	if err != nil {
+		return domain.ProductView{}, fmt.Errorf("product %s: %w", product.ID.Hex(), err)
+	}
+	variants := product.VariantViews()
+	for i := range variants {
+		variantMoney, err := pricer.Price(product, &product.Variants[i])
+		if err != nil {
+			return domain.ProductView{}, fmt.Errorf("variant %s: %w", product.Variants[i].SKU, err)
+		}
+		variants[i].Price = variantMoney.Major()
+		variants[i].Money = &variantMoney
+		if grams, ok := Type.WeightGrams(variants[i].Specifications); ok {
+			variants[i].WeightGrams = &grams
+		}
+	}
+	var weight *int64
+	if grams, ok := Type.WeightGrams(product.Specifications); ok {
+		weight = &grams
+	}
+
+	return domain.ProductView{
+		ID:             product.ID,
+		ModelName:      product.ModelName,
+		Price:          money.Major(),
+		Money:          money,
+		Prices:         product.Prices,
+		Category:       Category.CategoryName,
+		CategoryIDs:    categoryIDs,
+		Brand:          Brand.BrandName,
+		BrandID:        product.BrandID,
+		Type:           Type.TypeName,
+		Specifications: product.Specifications,
+		Content:        product.Content,
+		Images:         product.Images,
+		Options:        product.Options,
+		Variants:       variants,
+		Backorderable:  product.Backorderable,
+		TaxClass:       product.TaxClass,
+		WeightGrams:    weight,
+		CreatedAt:      product.CreatedAt,
+		UpdatedAt:      product.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,326 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
+	"github.com/mephirious/group-project/services/products-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (m *memoryProducts) GetProductBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	for n := range m.products {
+		for _, variant := range m.products[n].Variants {
+			if variant.SKU == sku {
+				return &m.products[n], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// writeVariants applies write to the variants of the product productID if it
+// has not changed since updatedAt, like the conditional updates of the Mongo
+// repository.
+func (m *memoryProducts) writeVariants(productID primitive.ObjectID, updatedAt time.Time, write func([]domain.Variant) ([]domain.Variant, bool)) (bool, error) {
+	for n := range m.products {
+		product := &m.products[n]
+		if product.ID != productID || !product.UpdatedAt.Equal(updatedAt) {
+			continue
+		}
+		variants, ok := write(append([]domain.Variant(nil), product.Variants...))
+		if !ok {
+			return false, nil
+		}
+		product.Variants, product.UpdatedAt = variants, time.Now()
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *memoryProducts) AddVariant(ctx context.Context, productID primitive.ObjectID, updatedAt time.Time, variant domain.Variant) (bool, error) {
+	return m.writeVariants(productID, updatedAt, func(variants []domain.Variant) ([]domain.Variant, bool) {
+		return append(variants, variant), true
+	})
+}
+
+func (m *memoryProducts) UpdateVariant(ctx context.Context, productID primitive.ObjectID, updatedAt time.Time, variant domain.Variant) (bool, error) {
+	return m.writeVariants(productID, updatedAt, func(variants []domain.Variant) ([]domain.Variant, bool) {
+		for n := range variants {
+			if variants[n].ID == variant.ID {
+				variants[n] = variant
+				return variants, true
+			}
+		}
+		return nil, false
+	})
+}
+
+func (m *memoryProducts) RemoveVariant(ctx context.Context, productID primitive.ObjectID, updatedAt time.Time, variantID primitive.ObjectID) (bool, error) {
+	return m.writeVariants(productID, updatedAt, func(variants []domain.Variant) ([]domain.Variant, bool) {
+		for n := range variants {
+			if variants[n].ID == variantID {
+				return append(variants[:n], variants[n+1:]...), true
+			}
+		}
+		return nil, false
+	})
+}
+
+func (m *memoryInventory) CountVariantUnits(ctx context.Context, variantID primitive.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for _, u := range m.units {
+		if u.VariantID == variantID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// changingProducts changes a product just after it has been read, as a
+// concurrent request would.
+type changingProducts struct {
+	*memoryProducts
+}
+
+func (c changingProducts) GetProductByID(ctx context.Context, id primitive.ObjectID) (*domain.Product, error) {
+	product, err := c.memoryProducts.GetProductByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	read := *product
+	product.UpdatedAt = product.UpdatedAt.Add(time.Second)
+	return &read, nil
+}
+
+func newTestProductUseCase(t *testing.T, products *memoryProducts) *productUseCase {
+	t.Helper()
+	currencies, err := NewCurrencyUseCase(&memoryRates{rates: map[string]domain.ExchangeRate{}}, "kzt")
+	if err != nil {
+		t.Fatalf("NewCurrencyUseCase: %v", err)
+	}
+	return NewProductUseCase(products, nil, nil, nil, &memoryInventory{}, nil, currencies)
+}
+
+func TestVariantWrites(t *testing.T) {
+	ctx := context.Background()
+	laptop := domain.Product{ID: primitive.NewObjectID(), Options: []domain.ProductOption{{Name: "ram", Values: []string{"16GB", "32GB"}}}, UpdatedAt: time.Now()}
+	products := &memoryProducts{products: []domain.Product{laptop}}
+	uc := newTestProductUseCase(t, products)
+
+	small := &domain.Variant{SKU: "X1-16", Options: map[string]string{"ram": "16GB"}}
+	if err := uc.CreateVariant(ctx, laptop.ID, small); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	large := &domain.Variant{SKU: "X1-32", Options: map[string]string{"ram": "32GB"}}
+	if err := uc.CreateVariant(ctx, laptop.ID, large); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	renamed := &domain.Variant{ID: small.ID, SKU: "X1-16-NEW", Options: map[string]string{"ram": "16GB"}}
+	if err := uc.UpdateVariant(ctx, laptop.ID, renamed); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := uc.DeleteVariant(ctx, laptop.ID, large.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if variants := products.products[0].Variants; len(variants) != 1 || variants[0].SKU != "X1-16-NEW" {
+		t.Fatalf("variants = %+v, want only X1-16-NEW", variants)
+	}
+
+	if err := uc.CreateVariant(ctx, primitive.NewObjectID(), &domain.Variant{SKU: "X2-16"}); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("create on a missing product: err = %v, want ErrProductNotFound", err)
+	}
+	if _, err := uc.GetVariants(ctx, primitive.NewObjectID()); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("variants of a missing product: err = %v, want ErrProductNotFound", err)
+	}
+	if err := uc.DeleteVariant(ctx, laptop.ID, large.ID); !errors.Is(err, ErrVariantNotFound) {
+		t.Errorf("delete of a deleted variant: err = %v, want ErrVariantNotFound", err)
+	}
+
+	// a variant validated against a product that has since changed is not written
+	racing := NewProductUseCase(changingProducts{products}, nil, nil, nil, &memoryInventory{}, nil, uc.pricing)
+	again := &domain.Variant{SKU: "X1-32", Options: map[string]string{"ram": "32GB"}}
+	if err := racing.CreateVariant(ctx, laptop.ID, again); !errors.Is(err, ErrProductChanged) {
+		t.Errorf("create on a changed product: err = %v, want ErrProductChanged", err)
+	}
+	if err := racing.DeleteVariant(ctx, laptop.ID, small.ID); !errors.Is(err, ErrProductChanged) {
+		t.Errorf("delete on a changed product: err = %v, want ErrProductChanged", err)
+	}
+	if variants := products.products[0].Variants; len(variants) != 1 {
+		t.Errorf("variants = %+v, want the one variant left alone", variants)
+	}
+}
+
+func TestAttributeFilters(t *testing.T) {
+	ctx := context.Background()
+	laptop := laptops()
+	phones := domain.Type{ID: primitive.NewObjectID(), TypeName: "Phones", Attributes: []domain.AttributeDefinition{
+		{Name: "dual_sim", DataType: domain.AttributeBoolean, Filterable: true},
+	}}
+	uc := NewProductUseCase(nil, nil, nil, &memoryTypes{types: []domain.Type{laptop, phones}}, nil, nil, nil)
+
+	least := 16.0
+	filter := domain.ProductFilter{TypeID: &laptop.ID, Attributes: []domain.AttributeFilter{
+		{Name: "ram", Values: []interface{}{"16", "32"}},
+		{Name: "ram", Min: &least},
+		{Name: "panel", Values: []interface{}{"OLED"}},
+	}}
+	if err := uc.resolveAttributeFilters(ctx, &filter); err != nil {
+		t.Fatalf("resolveAttributeFilters: %v", err)
+	}
+	if values := filter.Attributes[0].Values; values[0] != 16.0 || values[1] != 32.0 {
+		t.Errorf("ram values = %#v, want them parsed as numbers", values)
+	}
+	if values := filter.Attributes[2].Values; values[0] != "OLED" {
+		t.Errorf("panel values = %#v, want the string kept", values)
+	}
+
+	// without a type every type's schema is searched
+	filter = domain.ProductFilter{Attributes: []domain.AttributeFilter{{Name: "dual_sim", Values: []interface{}{"true"}}}}
+	if err := uc.resolveAttributeFilters(ctx, &filter); err != nil {
+		t.Fatalf("resolveAttributeFilters: %v", err)
+	}
+	if values := filter.Attributes[0].Values; values[0] != true {
+		t.Errorf("dual_sim values = %#v, want them parsed as booleans", values)
+	}
+
+	unknownType := primitive.NewObjectID()
+	tests := []struct {
+		name   string
+		filter domain.ProductFilter
+	}{
+		{"unknown type", domain.ProductFilter{TypeID: &unknownType, Attributes: []domain.AttributeFilter{{Name: "ram", Values: []interface{}{"16"}}}}},
+		{"not filterable", domain.ProductFilter{TypeID: &laptop.ID, Attributes: []domain.AttributeFilter{{Name: "touch", Values: []interface{}{"true"}}}}},
+		{"other type's attribute", domain.ProductFilter{TypeID: &laptop.ID, Attributes: []domain.AttributeFilter{{Name: "dual_sim", Values: []interface{}{"true"}}}}},
+		{"range on a string", domain.ProductFilter{TypeID: &laptop.ID, Attributes: []domain.AttributeFilter{{Name: "panel", Min: &least}}}},
+		{"unparsable number", domain.ProductFilter{TypeID: &laptop.ID, Attributes: []domain.AttributeFilter{{Name: "ram", Values: []interface{}{"lots"}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := uc.resolveAttributeFilters(ctx, &tt.filter); !errors.Is(err, ErrInvalidFilter) {
+				t.Errorf("err = %v, want ErrInvalidFilter", err)
+			}
+		})
+	}
+}
+
+// memoryBrands is an in-memory BrandRepository with no brands, so views show
+// products under the Unknown brand.
+type memoryBrands struct {
+	repository.BrandRepository
+}
+
+func (memoryBrands) GetBrandByID(ctx context.Context, id primitive.ObjectID) (*domain.Brand, error) {
+	return nil, mongo.ErrNoDocuments
+}
+
+// GetAllProducts ignores everything in the filter but IDs.
+func (m *memoryProducts) GetAllProducts(ctx context.Context, params pagination.Params, filter domain.ProductFilter) (*pagination.Page[domain.Product], error) {
+	wanted := make(map[primitive.ObjectID]bool, len(filter.IDs))
+	for _, id := range filter.IDs {
+		wanted[id] = true
+	}
+	page := &pagination.Page[domain.Product]{}
+	for _, product := range m.products {
+		if filter.IDs == nil || wanted[product.ID] {
+			page.Items = append(page.Items, product)
+		}
+	}
+	page.Total = int64(len(page.Items))
+	return page, nil
+}
+
+func (m *memoryInventory) GetInStockProductIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	quantities, _ := m.GetProductQuantities(ctx, nil)
+	var ids []primitive.ObjectID
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (m *memoryThresholds) GetThresholdsByProductIDs(ctx context.Context, productIDs []primitive.ObjectID) ([]domain.StockThreshold, error) {
+	var thresholds []domain.StockThreshold
+	for _, t := range m.thresholds {
+		for _, id := range productIDs {
+			if t.ProductID == id {
+				thresholds = append(thresholds, t)
+			}
+		}
+	}
+	return thresholds, nil
+}
+
+func TestProductAvailability(t *testing.T) {
+	ctx := context.Background()
+	reordered := domain.Product{ID: primitive.NewObjectID(), ModelName: "Reordered"}
+	plenty := domain.Product{ID: primitive.NewObjectID(), ModelName: "Plenty"}
+	few := domain.Product{ID: primitive.NewObjectID(), ModelName: "Few"}
+	backordered := domain.Product{ID: primitive.NewObjectID(), ModelName: "Backordered", Backorderable: true}
+	soldOut := domain.Product{ID: primitive.NewObjectID(), ModelName: "Sold out"}
+
+	products := &memoryProducts{products: []domain.Product{reordered, plenty, few, backordered, soldOut}}
+	inventory := &memoryInventory{}
+	inventory.add(reordered.ID, 5)
+	inventory.add(plenty.ID, 10)
+	inventory.add(few.ID, domain.DefaultLowStockLevel)
+	thresholds := &memoryThresholds{thresholds: []domain.StockThreshold{{ProductID: reordered.ID, ReorderPoint: 5}}}
+	base := newTestProductUseCase(t, products)
+	uc := NewProductUseCase(products, &memoryCategories{}, memoryBrands{}, &memoryTypes{}, inventory, thresholds, base.pricing)
+
+	page, err := uc.GetAllProducts(ctx, pagination.Params{}, domain.ProductFilter{}, domain.ProductViewOptions{Availability: true})
+	if err != nil {
+		t.Fatalf("GetAllProducts: %v", err)
+	}
+	want := map[string]domain.Availability{
+		"Reordered":   {Status: domain.AvailabilityLowStock, Quantity: 5},
+		"Plenty":      {Status: domain.AvailabilityInStock, Quantity: 10},
+		"Few":         {Status: domain.AvailabilityLowStock, Quantity: domain.DefaultLowStockLevel},
+		"Backordered": {Status: domain.AvailabilityBackorder},
+		"Sold out":    {Status: domain.AvailabilityOutOfStock},
+	}
+	for _, view := range page.Items {
+		if view.Availability == nil || *view.Availability != want[view.ModelName] {
+			t.Errorf("%s availability = %+v, want %+v", view.ModelName, view.Availability, want[view.ModelName])
+		}
+	}
+
+	page, err = uc.GetAllProducts(ctx, pagination.Params{}, domain.ProductFilter{}, domain.ProductViewOptions{})
+	if err != nil {
+		t.Fatalf("GetAllProducts: %v", err)
+	}
+	if page.Items[0].Availability != nil {
+		t.Error("availability was added without being asked for")
+	}
+
+	page, err = uc.GetAllProducts(ctx, pagination.Params{}, domain.ProductFilter{InStockOnly: true}, domain.ProductViewOptions{})
+	if err != nil {
+		t.Fatalf("GetAllProducts: %v", err)
+	}
+	if page.Total != 3 {
+		t.Errorf("in-stock products = %d, want the 3 with units in stock", page.Total)
+	}
+	for _, view := range page.Items {
+		if view.ID == backordered.ID || view.ID == soldOut.ID {
+			t.Errorf("%s is listed as in stock", view.ModelName)
+		}
+	}
+
+	// with nothing in stock the in-stock filter matches nothing, not everything
+	inventory.units = nil
+	page, err = uc.GetAllProducts(ctx, pagination.Params{}, domain.ProductFilter{InStockOnly: true}, domain.ProductViewOptions{})
+	if err != nil {
+		t.Fatalf("GetAllProducts: %v", err)
+	}
+	if page.Total != 0 {
+		t.Errorf("in-stock products = %d, want none", page.Total)
+	}
+}
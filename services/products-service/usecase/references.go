@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInUse is returned when deleting a brand, category or type that products
+// still reference and no reassign or cascade strategy was given.
+var ErrInUse = errors.New("still in use")
+
+// ErrInvalidReference is returned when a product points at a brand, category
+// or type that does not exist.
+var ErrInvalidReference = errors.New("invalid reference")
+
+// productReferences looks after the products that point at one kind of
+// taxonomy entry through field.
+type productReferences struct {
+	field               string
+	productRepository   repository.ProductRepository
+	inventoryRepository repository.InventoryRepository
+}
+
+func (r productReferences) usage(ctx context.Context, id primitive.ObjectID) (domain.Usage, error) {
+	productIDs, err := r.productRepository.GetProductIDsByReference(ctx, r.field, id)
+	if err != nil {
+		return domain.Usage{}, err
+	}
+	units, err := r.inventoryRepository.CountUnitsByProductIDs(ctx, productIDs)
+	if err != nil {
+		return domain.Usage{}, err
+	}
+	return domain.Usage{Products: int64(len(productIDs)), InventoryUnits: units}, nil
+}
+
+// release applies opts to the products referencing id so that id can be
+// deleted. The reassign target must already have been checked by the caller.
+func (r productReferences) release(ctx context.Context, id primitive.ObjectID, opts domain.DeleteOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	productIDs, err := r.productRepository.GetProductIDsByReference(ctx, r.field, id)
+	if err != nil {
+		return err
+	}
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	switch opts.Strategy {
+	case domain.DeleteReassign:
+		if *opts.ReassignTo == id {
+			return errors.New("cannot reassign products to the entry being deleted")
+		}
+		_, err := r.productRepository.ReassignProducts(ctx, r.field, id, *opts.ReassignTo)
+		return err
+	case domain.DeleteCascade:
+		units, err := r.inventoryRepository.CountUnitsByProductIDs(ctx, productIDs)
+		if err != nil {
+			return err
+		}
+		if units > 0 {
+			return fmt.Errorf("%w: %d products have %d inventory units", ErrInUse, len(productIDs), units)
+		}
+		_, err = r.productRepository.DeleteProductsByIDs(ctx, productIDs)
+		return err
+	default:
+		return fmt.Errorf("%w: %d products reference it", ErrInUse, len(productIDs))
+	}
+}
@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// reference returns the taxonomy entry product points at through field.
+func reference(product domain.Product, field string) primitive.ObjectID {
+	switch field {
+	case repository.BrandReference:
+		return product.BrandID
+	case repository.CategoryReference:
+		return product.CategoryID
+	default:
+		return product.TypeID
+	}
+}
+
+func (m *memoryProducts) GetProductIDsByReference(ctx context.Context, field string, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	var ids []primitive.ObjectID
+	for _, product := range m.products {
+		if reference(product, field) == id {
+			ids = append(ids, product.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (m *memoryProducts) ReassignProducts(ctx context.Context, field string, from, to primitive.ObjectID) (int64, error) {
+	var n int64
+	for i := range m.products {
+		product := &m.products[i]
+		if reference(*product, field) != from {
+			continue
+		}
+		switch field {
+		case repository.BrandReference:
+			product.BrandID = to
+		case repository.CategoryReference:
+			product.CategoryID = to
+		default:
+			product.TypeID = to
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (m *memoryProducts) DeleteProductsByIDs(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	deleted := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	kept := m.products[:0]
+	for _, product := range m.products {
+		if !deleted[product.ID] {
+			kept = append(kept, product)
+		}
+	}
+	n := int64(len(m.products) - len(kept))
+	m.products = kept
+	return n, nil
+}
+
+func (m *memoryInventory) CountUnitsByProductIDs(ctx context.Context, productIDs []primitive.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wanted := make(map[primitive.ObjectID]bool, len(productIDs))
+	for _, id := range productIDs {
+		wanted[id] = true
+	}
+	var n int64
+	for _, u := range m.units {
+		if wanted[u.ProductID] {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memoryTypes) DeleteType(ctx context.Context, id primitive.ObjectID) error {
+	for n := range m.types {
+		if m.types[n].ID == id {
+			m.types = append(m.types[:n], m.types[n+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestDeleteTypeStrategies(t *testing.T) {
+	ctx := context.Background()
+	laptop := laptops()
+	ultrabook := laptops()
+	ultrabook.TypeName = "Ultrabooks"
+	tablet := domain.Type{ID: primitive.NewObjectID(), TypeName: "Tablets", Attributes: []domain.AttributeDefinition{
+		{Name: "stylus", DataType: domain.AttributeBoolean, Required: true},
+	}}
+	x1 := domain.Product{ID: primitive.NewObjectID(), ModelName: "X1", TypeID: laptop.ID, Specifications: domain.Specifications{"ram": 16.0}}
+	t14 := domain.Product{ID: primitive.NewObjectID(), ModelName: "T14", TypeID: laptop.ID, Specifications: domain.Specifications{"ram": 32.0}}
+
+	setup := func() (*typeUseCase, *memoryTypes, *memoryProducts, *memoryInventory) {
+		types := &memoryTypes{types: []domain.Type{laptop, ultrabook, tablet}}
+		products := &memoryProducts{products: []domain.Product{x1, t14}}
+		inventory := &memoryInventory{}
+		return NewTypeUseCase(types, products, inventory), types, products, inventory
+	}
+	deleted := func(types *memoryTypes, id primitive.ObjectID) bool {
+		_, err := types.GetTypeByID(ctx, id)
+		return err != nil
+	}
+
+	t.Run("restrict", func(t *testing.T) {
+		uc, types, _, _ := setup()
+		for _, strategy := range []string{"", domain.DeleteRestrict} {
+			if err := uc.DeleteType(ctx, laptop.ID, domain.DeleteOptions{Strategy: strategy}); !errors.Is(err, ErrInUse) {
+				t.Errorf("strategy %q: err = %v, want ErrInUse", strategy, err)
+			}
+		}
+		if deleted(types, laptop.ID) {
+			t.Error("a type still in use was deleted")
+		}
+		if err := uc.DeleteType(ctx, tablet.ID, domain.DeleteOptions{}); err != nil {
+			t.Errorf("deleting an unused type: %v", err)
+		}
+	})
+
+	t.Run("reassign", func(t *testing.T) {
+		uc, types, products, _ := setup()
+		if err := uc.DeleteType(ctx, laptop.ID, domain.DeleteOptions{Strategy: domain.DeleteReassign}); err == nil {
+			t.Error("reassign without a target was accepted")
+		}
+		if err := uc.DeleteType(ctx, laptop.ID, domain.DeleteOptions{Strategy: domain.DeleteReassign, ReassignTo: &laptop.ID}); err == nil {
+			t.Error("reassign to the type being deleted was accepted")
+		}
+		unknown := primitive.NewObjectID()
+		if err := uc.DeleteType(ctx, laptop.ID, domain.DeleteOptions{Strategy: domain.DeleteReassign, ReassignTo: &unknown}); !errors.Is(err, ErrInvalidReference) {
+			t.Errorf("reassign to an unknown type: err = %v, want ErrInvalidReference", err)
+		}
+		// the products lack the tablet's required attribute
+		if err := uc.DeleteType(ctx, laptop.ID, domain.DeleteOptions{Strategy: domain.DeleteReassign, ReassignTo: &tablet.ID}); err == nil {
+			t.Error("reassign to a type the products do not fit was accepted")
+		}
+		if deleted(types, laptop.ID) || products.products[0].TypeID != laptop.ID {
+			t.Fatal("a refused reassign changed the type or its products")
+		}
+
+		if err := uc.DeleteType(ctx, laptop.ID, domain.DeleteOptions{Strategy: domain.DeleteReassign, ReassignTo: &ultrabook.ID}); err != nil {
+			t.Fatalf("reassign: %v", err)
+		}
+		for _, product := range products.products {
+			if product.TypeID != ultrabook.ID {
+				t.Errorf("%s type = %s, want the ultrabook type", product.ModelName, product.TypeID.Hex())
+			}
+		}
+		if !deleted(types, laptop.ID) {
+			t.Error("the type was not deleted after its products were reassigned")
+		}
+	})
+
+	t.Run("cascade", func(t *testing.T) {
+		uc, types, products, inventory := setup()
+		inventory.add(t14.ID, 1)
+		if err := uc.DeleteType(ctx, laptop.ID, domain.DeleteOptions{Strategy: domain.DeleteCascade}); !errors.Is(err, ErrInUse) {
+			t.Errorf("cascade over stocked products: err = %v, want ErrInUse", err)
+		}
+		if deleted(types, laptop.ID) || len(products.products) != 2 {
+			t.Fatal("a refused cascade deleted the type or its products")
+		}
+
+		inventory.units = nil
+		if err := uc.DeleteType(ctx, laptop.ID, domain.DeleteOptions{Strategy: domain.DeleteCascade}); err != nil {
+			t.Fatalf("cascade: %v", err)
+		}
+		if len(products.products) != 0 {
+			t.Errorf("products = %+v, want them deleted with their type", products.products)
+		}
+		if !deleted(types, laptop.ID) {
+			t.Error("the type was not deleted")
+		}
+	})
+}
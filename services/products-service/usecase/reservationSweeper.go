@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+)
+
+// StartReservationSweeper releases expired reservations every interval until
+// ctx is cancelled.
+func StartReservationSweeper(ctx context.Context, interval time.Duration, u InventoryUseCase) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			released, err := u.ReleaseExpiredReservations(ctx)
+			if err != nil {
+				slog.Error(fmt.Sprintf("Releasing expired reservations failed: %s", err))
+				continue
+			}
+			if released > 0 {
+				slog.Info("Released expired reservations", slog.Int64("units", released))
+			}
+		}
+	}
+}
+
+type reservationMetrics struct {
+	mu    sync.Mutex
+	stats domain.ReservationStats
+}
+
+func (m *reservationMetrics) record(released int64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stats.Sweeps++
+	m.stats.LastSweep = time.Now()
+	if err != nil {
+		m.stats.Failures++
+		return
+	}
+	m.stats.Released += released
+	m.stats.LastReleased = released
+}
+
+func (m *reservationMetrics) snapshot() domain.ReservationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
+	"github.com/mephirious/group-project/services/products-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// StockNotifier delivers low-stock alerts, for example by webhook or email.
+type StockNotifier interface {
+	NotifyLowStock(ctx context.Context, alert domain.StockAlert) error
+}
+
+type StockAlertUseCase interface {
+	GetAllThresholds(ctx context.Context, params pagination.Params) (*pagination.Page[domain.StockThreshold], error)
+	GetThreshold(ctx context.Context, productID primitive.ObjectID) (*domain.StockThreshold, error)
+	SetThreshold(ctx context.Context, threshold *domain.StockThreshold) error
+	DeleteThreshold(ctx context.Context, productID primitive.ObjectID) error
+	GetLowStock(ctx context.Context) ([]domain.StockAlert, error)
+	CheckStockLevels(ctx context.Context) (int, error)
+}
+
+type stockAlertUseCase struct {
+	thresholdRepository repository.StockThresholdRepository
+	inventoryRepository repository.InventoryRepository
+	productRepository   repository.ProductRepository
+	notifier            StockNotifier
+}
+
+func NewStockAlertUseCase(thresholdRepository repository.StockThresholdRepository, inventoryRepository repository.InventoryRepository, productRepository repository.ProductRepository, notifier StockNotifier) *stockAlertUseCase {
+	return &stockAlertUseCase{
+		thresholdRepository: thresholdRepository,
+		inventoryRepository: inventoryRepository,
+		productRepository:   productRepository,
+		notifier:            notifier,
+	}
+}
+
+func (s *stockAlertUseCase) GetAllThresholds(ctx context.Context, params pagination.Params) (*pagination.Page[domain.StockThreshold], error) {
+	return s.thresholdRepository.GetAllThresholds(ctx, params)
+}
+
+func (s *stockAlertUseCase) GetThreshold(ctx context.Context, productID primitive.ObjectID) (*domain.StockThreshold, error) {
+	threshold, err := s.thresholdRepository.GetThreshold(ctx, productID)
+	if err != nil {
+		return nil, errors.New("threshold not found")
+	}
+	return threshold, nil
+}
+
+func (s *stockAlertUseCase) SetThreshold(ctx context.Context, threshold *domain.StockThreshold) error {
+	if err := threshold.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.productRepository.GetProductByID(ctx, threshold.ProductID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("%w: product %s not found", ErrInvalidReference, threshold.ProductID.Hex())
+		}
+		return err
+	}
+
+	return s.thresholdRepository.SaveThreshold(ctx, threshold)
+}
+
+func (s *stockAlertUseCase) DeleteThreshold(ctx context.Context, productID primitive.ObjectID) error {
+	return s.thresholdRepository.DeleteThreshold(ctx, productID)
+}
+
+// GetLowStock lists the products currently at or below their reorder point,
+// those furthest from their target level first.
+func (s *stockAlertUseCase) GetLowStock(ctx context.Context) ([]domain.StockAlert, error) {
+	thresholds, quantities, err := s.levels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	alerts := []domain.StockAlert{}
+	for _, t := range thresholds {
+		if !t.IsLow(quantities[t.ProductID]) {
+			continue
+		}
+		alert, err := s.alert(ctx, t, quantities[t.ProductID], now)
+		if err != nil {
+			return nil, err
+		}
+		if alert != nil {
+			alerts = append(alerts, *alert)
+		}
+	}
+
+	sort.SliceStable(alerts, func(a, b int) bool { return alerts[a].ReorderQuantity > alerts[b].ReorderQuantity })
+	return alerts, nil
+}
+
+// CheckStockLevels compares every product's stock with its threshold and
+// notifies about products that have dropped to their reorder point since the
+// last check. A product is reported again only after its stock has recovered
+// above the reorder point. It returns the number of alerts sent.
+func (s *stockAlertUseCase) CheckStockLevels(ctx context.Context) (int, error) {
+	thresholds, quantities, err := s.levels(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	sent := 0
+	var errs []error
+	for _, t := range thresholds {
+		quantity := quantities[t.ProductID]
+		low := t.IsLow(quantity)
+		if low == t.Alerting {
+			continue
+		}
+
+		// switching the state first means concurrent checks can't both alert
+		changed, err := s.thresholdRepository.SetAlerting(ctx, t.ProductID, low, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !changed || !low {
+			continue
+		}
+
+		alert, err := s.alert(ctx, t, quantity, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if alert == nil {
+			continue
+		}
+		// the alert stays recorded as sent: retrying on every check would spam
+		// the notifiers that did get it
+		if err := s.notifier.NotifyLowStock(ctx, *alert); err != nil {
+			slog.Error(fmt.Sprintf("Low stock notification for product %s failed: %s", t.ProductID.Hex(), err))
+		}
+		sent++
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// levels loads every threshold together with the in_stock count of its
+// product.
+func (s *stockAlertUseCase) levels(ctx context.Context) ([]domain.StockThreshold, map[primitive.ObjectID]int64, error) {
+	thresholds, err := s.thresholdRepository.ListThresholds(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(thresholds))
+	for n, t := range thresholds {
+		ids[n] = t.ProductID
+	}
+	quantities, err := s.inventoryRepository.GetProductQuantities(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	return thresholds, quantities, nil
+}
+
+// alert describes threshold's product being low on stock, or returns nil if
+// the product no longer exists.
+func (s *stockAlertUseCase) alert(ctx context.Context, threshold domain.StockThreshold, quantity int64, at time.Time) (*domain.StockAlert, error) {
+	product, err := s.productRepository.GetProductByID(ctx, threshold.ProductID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.StockAlert{
+		ProductID:       threshold.ProductID,
+		ModelName:       product.ModelName,
+		Quantity:        quantity,
+		ReorderPoint:    threshold.ReorderPoint,
+		TargetLevel:     threshold.TargetLevel,
+		ReorderQuantity: max(threshold.TargetLevel-quantity, 0),
+		DetectedAt:      at,
+	}, nil
+}
+
+// StartStockMonitor checks stock levels against reorder thresholds every
+// interval until ctx is cancelled.
+func StartStockMonitor(ctx context.Context, interval time.Duration, u StockAlertUseCase) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := u.CheckStockLevels(ctx)
+			if err != nil {
+				slog.Error(fmt.Sprintf("Checking stock levels failed: %s", err))
+			}
+			if sent > 0 {
+				slog.Info("Sent low stock alerts", slog.Int("products", sent))
+			}
+		}
+	}
+}
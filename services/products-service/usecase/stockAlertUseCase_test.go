@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryThresholds is an in-memory StockThresholdRepository.
+type memoryThresholds struct {
+	repository.StockThresholdRepository
+
+	thresholds []domain.StockThreshold
+}
+
+func (m *memoryThresholds) ListThresholds(ctx context.Context) ([]domain.StockThreshold, error) {
+	return append([]domain.StockThreshold(nil), m.thresholds...), nil
+}
+
+func (m *memoryThresholds) SetAlerting(ctx context.Context, productID primitive.ObjectID, alerting bool, at time.Time) (bool, error) {
+	for n := range m.thresholds {
+		if m.thresholds[n].ProductID == productID && m.thresholds[n].Alerting != alerting {
+			m.thresholds[n].Alerting = alerting
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryInventory) GetProductQuantities(ctx context.Context, productIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	quantities := map[primitive.ObjectID]int64{}
+	for _, u := range m.units {
+		if u.Status == domain.StatusInStock {
+			quantities[u.ProductID]++
+		}
+	}
+	return quantities, nil
+}
+
+type recordingNotifier struct {
+	alerts []domain.StockAlert
+}
+
+func (r *recordingNotifier) NotifyLowStock(ctx context.Context, alert domain.StockAlert) error {
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func TestCheckStockLevelsAlertsOncePerDrop(t *testing.T) {
+	laptop := domain.Product{ID: primitive.NewObjectID(), ModelName: "ThinkPad X1 Carbon"}
+	repo := newMemoryInventory(laptop.ID, 5)
+	thresholds := &memoryThresholds{thresholds: []domain.StockThreshold{{ProductID: laptop.ID, ReorderPoint: 3, TargetLevel: 10}}}
+	notifier := &recordingNotifier{}
+	uc := NewStockAlertUseCase(thresholds, repo, &memoryProducts{products: []domain.Product{laptop}}, notifier)
+	ctx := context.Background()
+
+	check := func(wantSent int) {
+		t.Helper()
+		sent, err := uc.CheckStockLevels(ctx)
+		if err != nil {
+			t.Fatalf("check: %v", err)
+		}
+		if sent != wantSent {
+			t.Fatalf("sent %d alerts, want %d", sent, wantSent)
+		}
+	}
+
+	check(0)
+
+	repo.units[0].Status, repo.units[1].Status = domain.StatusSold, domain.StatusSold
+	check(1)
+	if a := notifier.alerts[0]; a.Quantity != 3 || a.ReorderQuantity != 7 || a.ModelName != laptop.ModelName {
+		t.Fatalf("unexpected alert %+v", a)
+	}
+
+	// still low: no repeat
+	repo.units[2].Status = domain.StatusSold
+	check(0)
+
+	// recovers, then drops again
+	repo.add(laptop.ID, 5)
+	check(0)
+	repo.units[3].Status = domain.StatusSold
+	for n := 5; n < 10; n++ {
+		repo.units[n].Status = domain.StatusSold
+	}
+	check(1)
+
+	low, err := uc.GetLowStock(ctx)
+	if err != nil {
+		t.Fatalf("low stock: %v", err)
+	}
+	if len(low) != 1 || low[0].Quantity != 1 {
+		t.Fatalf("low stock = %+v, want one product with 1 unit", low)
+	}
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
 	"github.com/mephirious/group-project/services/products-service/repository"
@@ -15,16 +16,23 @@ type TypeUseCase interface {
 	GetTypeByName(ctx context.Context, name string) (*domain.Type, error)
 	CreateType(ctx context.Context, typeEntity *domain.Type) error
 	UpdateType(ctx context.Context, typeEntity *domain.Type) error
-	DeleteType(ctx context.Context, id primitive.ObjectID) error
+	DeleteType(ctx context.Context, id primitive.ObjectID, opts domain.DeleteOptions) error
+	GetTypeUsage(ctx context.Context, id primitive.ObjectID) (*domain.Usage, error)
 }
 
 type typeUseCase struct {
 	typeRepository repository.TypeRepository
+	products       productReferences
 }
 
-func NewTypeUseCase(repository repository.TypeRepository) *typeUseCase {
+func NewTypeUseCase(typeRepository repository.TypeRepository, productRepository repository.ProductRepository, inventoryRepository repository.InventoryRepository) *typeUseCase {
 	return &typeUseCase{
-		typeRepository: repository,
+		typeRepository: typeRepository,
+		products: productReferences{
+			field:               repository.TypeReference,
+			productRepository:   productRepository,
+			inventoryRepository: inventoryRepository,
+		},
 	}
 }
 
@@ -62,14 +70,69 @@ func (t *typeUseCase) CreateType(ctx context.Context, typeEntity *domain.Type) e
 	if existingType != nil {
 		return errors.New("type already exists")
 	}
+	if err := domain.ValidateAttributeDefinitions(typeEntity.Attributes); err != nil {
+		return err
+	}
 
 	return t.typeRepository.CreateType(ctx, typeEntity)
 }
 
 func (t *typeUseCase) UpdateType(ctx context.Context, typeEntity *domain.Type) error {
+	if err := domain.ValidateAttributeDefinitions(typeEntity.Attributes); err != nil {
+		return err
+	}
+
 	return t.typeRepository.UpdateType(ctx, typeEntity)
 }
 
-func (t *typeUseCase) DeleteType(ctx context.Context, id primitive.ObjectID) error {
+func (t *typeUseCase) DeleteType(ctx context.Context, id primitive.ObjectID, opts domain.DeleteOptions) error {
+	if _, err := t.typeRepository.GetTypeByID(ctx, id); err != nil {
+		return errors.New("type not found")
+	}
+	if opts.Strategy == domain.DeleteReassign && opts.ReassignTo != nil {
+		if err := t.checkReassignTarget(ctx, id, *opts.ReassignTo); err != nil {
+			return err
+		}
+	}
+	if err := t.products.release(ctx, id, opts); err != nil {
+		return err
+	}
+
 	return t.typeRepository.DeleteType(ctx, id)
 }
+
+func (t *typeUseCase) GetTypeUsage(ctx context.Context, id primitive.ObjectID) (*domain.Usage, error) {
+	if _, err := t.typeRepository.GetTypeByID(ctx, id); err != nil {
+		return nil, errors.New("type not found")
+	}
+
+	usage, err := t.products.usage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// checkReassignTarget makes sure the target type exists and that every
+// product being moved still satisfies its attribute schema.
+func (t *typeUseCase) checkReassignTarget(ctx context.Context, id, targetID primitive.ObjectID) error {
+	target, err := t.typeRepository.GetTypeByID(ctx, targetID)
+	if err != nil {
+		return fmt.Errorf("%w: type %s not found", ErrInvalidReference, targetID.Hex())
+	}
+
+	productIDs, err := t.products.productRepository.GetProductIDsByReference(ctx, repository.TypeReference, id)
+	if err != nil {
+		return err
+	}
+	for _, productID := range productIDs {
+		product, err := t.products.productRepository.GetProductByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+		if _, err := target.Validate(product.Specifications, false); err != nil {
+			return fmt.Errorf("product %s does not fit type %s: %w", product.ModelName, target.TypeName, err)
+		}
+	}
+	return nil
+}
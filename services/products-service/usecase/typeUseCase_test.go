@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryTypes is an in-memory TypeRepository.
+type memoryTypes struct {
+	repository.TypeRepository
+
+	types []domain.Type
+}
+
+func (m *memoryTypes) GetAllTypes(ctx context.Context) ([]domain.Type, error) {
+	return append([]domain.Type(nil), m.types...), nil
+}
+
+func (m *memoryTypes) GetTypeByID(ctx context.Context, id primitive.ObjectID) (*domain.Type, error) {
+	for n := range m.types {
+		if m.types[n].ID == id {
+			return &m.types[n], nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryTypes) GetTypeByName(ctx context.Context, name string) (*domain.Type, error) {
+	for n := range m.types {
+		if strings.EqualFold(m.types[n].TypeName, name) {
+			return &m.types[n], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryTypes) CreateType(ctx context.Context, typeEntity *domain.Type) error {
+	typeEntity.ID = primitive.NewObjectID()
+	m.types = append(m.types, *typeEntity)
+	return nil
+}
+
+// laptops is a type with one attribute of each data type.
+func laptops() domain.Type {
+	return domain.Type{
+		ID:       primitive.NewObjectID(),
+		TypeName: "Laptops",
+		Attributes: []domain.AttributeDefinition{
+			{Name: "ram", DataType: domain.AttributeNumber, Unit: "GB", Required: true, Filterable: true},
+			{Name: "panel", DataType: domain.AttributeString, AllowedValues: []string{"IPS", "OLED"}, Filterable: true},
+			{Name: "touch", DataType: domain.AttributeBoolean},
+		},
+	}
+}
+
+func TestCreateTypeValidatesAttributes(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		attributes []domain.AttributeDefinition
+		wantErr    string
+	}{
+		{"valid", laptops().Attributes, ""},
+		{"unnamed", []domain.AttributeDefinition{{DataType: domain.AttributeString}}, "name is required"},
+		{"duplicate", []domain.AttributeDefinition{{Name: "ram", DataType: domain.AttributeNumber}, {Name: "ram", DataType: domain.AttributeString}}, "duplicate"},
+		{"unknown data type", []domain.AttributeDefinition{{Name: "ram", DataType: "integer"}}, "unknown data type"},
+		{"boolean with allowed values", []domain.AttributeDefinition{{Name: "touch", DataType: domain.AttributeBoolean, AllowedValues: []string{"true"}}}, "cannot have allowed values"},
+		{"allowed value of the wrong type", []domain.AttributeDefinition{{Name: "ram", DataType: domain.AttributeNumber, AllowedValues: []string{"16", "lots"}}}, "is not a number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewTypeUseCase(&memoryTypes{}, nil, nil)
+			err := uc.CreateType(ctx, &domain.Type{TypeName: "Laptops", Attributes: tt.attributes})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CreateType: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSpecifications(t *testing.T) {
+	ctx := context.Background()
+	laptop := laptops()
+	uc := NewProductUseCase(nil, nil, nil, &memoryTypes{types: []domain.Type{laptop}}, nil, nil, nil)
+
+	product := &domain.Product{TypeID: laptop.ID, Specifications: domain.Specifications{"ram": 16, "panel": "OLED", "touch": true}}
+	if err := uc.validateSpecifications(ctx, product); err != nil {
+		t.Fatalf("validateSpecifications: %v", err)
+	}
+	if ram, ok := product.Specifications["ram"].(float64); !ok || ram != 16 {
+		t.Errorf("ram = %#v, want it normalised to float64(16)", product.Specifications["ram"])
+	}
+
+	tests := []struct {
+		name    string
+		specs   domain.Specifications
+		wantErr string
+	}{
+		{"missing required", domain.Specifications{"panel": "IPS"}, `"ram" is required`},
+		{"unknown attribute", domain.Specifications{"ram": 8, "colour": "black"}, `unknown attribute "colour"`},
+		{"wrong data type", domain.Specifications{"ram": "8GB"}, "must be a number"},
+		{"not an allowed value", domain.Specifications{"ram": 8, "panel": "TN"}, "must be one of IPS, OLED"},
+		{"boolean as string", domain.Specifications{"ram": 8, "touch": "yes"}, "must be a boolean"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := uc.validateSpecifications(ctx, &domain.Product{TypeID: laptop.ID, Specifications: tt.specs})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// variant overrides may leave required attributes to the product
+	if _, err := laptop.Validate(domain.Specifications{"panel": "IPS"}, true); err != nil {
+		t.Errorf("partial Validate: %v", err)
+	}
+}
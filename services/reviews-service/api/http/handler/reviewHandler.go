@@ -4,15 +4,21 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"github.com/mephirious/group-project/services/products-service/usecase"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var reviewSort = pagination.Sort{
+	Fields:       []string{"created_at", "updated_at", "rating"},
+	DefaultField: "created_at",
+	DefaultOrder: "desc",
+}
+
 type ReviewHandler struct {
 	useCase usecase.ReviewUseCase
 }
@@ -81,10 +87,12 @@ func (h *ReviewHandler) CreateReview(g *gin.Context) {
 }
 
 func (h *ReviewHandler) GetAllReviews(g *gin.Context) {
-	limit, _ := strconv.Atoi(g.DefaultQuery("limit", "10"))
-	skip, _ := strconv.Atoi(g.DefaultQuery("skip", "0"))
-	sortField := g.DefaultQuery("sortField", "created_at")
-	sortOrder := g.DefaultQuery("sortOrder", "desc")
+	params, err := pagination.FromQuery(g, reviewSort)
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
+	}
 	verified := g.DefaultQuery("verified", "")
 
 	var verifiedPtr *bool
@@ -93,7 +101,7 @@ func (h *ReviewHandler) GetAllReviews(g *gin.Context) {
 		verifiedPtr = &verifiedVal
 	}
 
-	reviews, err := h.useCase.GetAllReviews(g.Request.Context(), limit, skip, sortField, sortOrder, verifiedPtr)
+	reviews, err := h.useCase.GetAllReviews(g.Request.Context(), params, verifiedPtr)
 	if err != nil {
 		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
@@ -139,13 +147,11 @@ func (h *ReviewHandler) GetReviewsByCustomerID(g *gin.Context) {
 		return
 	}
 
-	limit := 10
-	if l := g.Query("limit"); l != "" {
-		if limit, err = strconv.Atoi(l); err != nil {
-			g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
-			slog.Error(fmt.Sprintf("Method %s failed: Invalid limit", g.Request.Method))
-			return
-		}
+	params, err := pagination.FromQuery(g, reviewSort)
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
 	}
 
 	var verified *bool
@@ -154,7 +160,7 @@ func (h *ReviewHandler) GetReviewsByCustomerID(g *gin.Context) {
 		verified = &verifiedVal
 	}
 
-	reviews, err := h.useCase.GetReviewsByCustomerID(g.Request.Context(), objID, limit, verified)
+	reviews, err := h.useCase.GetReviewsByCustomerID(g.Request.Context(), objID, params, verified)
 	if err != nil {
 		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
@@ -174,13 +180,11 @@ func (h *ReviewHandler) GetReviewsByProductID(g *gin.Context) {
 		return
 	}
 
-	limit := 10
-	if l := g.Query("limit"); l != "" {
-		if limit, err = strconv.Atoi(l); err != nil {
-			g.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
-			slog.Error(fmt.Sprintf("Method %s failed: Invalid limit", g.Request.Method))
-			return
-		}
+	params, err := pagination.FromQuery(g, reviewSort)
+	if err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
+		return
 	}
 
 	var verified *bool
@@ -189,7 +193,7 @@ func (h *ReviewHandler) GetReviewsByProductID(g *gin.Context) {
 		verified = &verifiedVal
 	}
 
-	reviews, totalReviews, averageRating, err := h.useCase.GetReviewsByProductID(g.Request.Context(), objID, limit, verified)
+	reviews, averageRating, err := h.useCase.GetReviewsByProductID(g.Request.Context(), objID, params, verified)
 	if err != nil {
 		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		slog.Error(fmt.Sprintf("Method %s failed: %s", g.Request.Method, err))
@@ -197,8 +201,9 @@ func (h *ReviewHandler) GetReviewsByProductID(g *gin.Context) {
 	}
 
 	response := gin.H{
-		"reviews":        reviews,
-		"total":          totalReviews,
+		"items":          reviews.Items,
+		"total":          reviews.Total,
+		"next_cursor":    reviews.NextCursor,
 		"average_rating": averageRating,
 	}
 
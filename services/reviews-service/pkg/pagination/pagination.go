@@ -0,0 +1,235 @@
+// Package pagination implements cursor (keyset) paging for list endpoints.
+//
+// Every service keeps an identical copy of this package: the services are
+// separate modules that share no code, so a change here must be made to all
+// of the copies. The tests live with the products-service copy; the other
+// copies are only checked to match it.
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultLimit = 10
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidLimit     = fmt.Errorf("limit must be an integer between 1 and %d", MaxLimit)
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrInvalidSortField = errors.New("invalid sort field")
+	ErrInvalidSortOrder = errors.New("sortOrder must be asc or desc")
+)
+
+// Sort describes which fields a list endpoint may be ordered by.
+type Sort struct {
+	Fields       []string
+	DefaultField string
+	DefaultOrder string
+}
+
+// Params is a validated page request. The zero cursor means the first page.
+type Params struct {
+	Limit     int
+	SortField string
+	SortOrder int
+	cursor    *cursor
+}
+
+// Page is the envelope returned by every list endpoint.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// cursor is the decoded form of the opaque next_cursor token. It pins the
+// sort it was issued for so it cannot be replayed against a different order.
+type cursor struct {
+	SortField string             `bson:"f"`
+	SortOrder int                `bson:"o"`
+	Value     bson.RawValue      `bson:"v"`
+	ID        primitive.ObjectID `bson:"id"`
+}
+
+// FromQuery reads limit, cursor, sortField and sortOrder from the request
+// query and validates them against the given sort whitelist.
+func FromQuery(g *gin.Context, sort Sort) (Params, error) {
+	return Parse(
+		g.DefaultQuery("limit", strconv.Itoa(DefaultLimit)),
+		g.Query("cursor"),
+		g.DefaultQuery("sortField", sort.DefaultField),
+		g.DefaultQuery("sortOrder", sort.DefaultOrder),
+		sort,
+	)
+}
+
+func Parse(limit, token, sortField, sortOrder string, sort Sort) (Params, error) {
+	var params Params
+
+	l, err := strconv.Atoi(limit)
+	if err != nil || l < 1 || l > MaxLimit {
+		return params, ErrInvalidLimit
+	}
+	params.Limit = l
+
+	if !allowed(sortField, sort.Fields) {
+		return params, fmt.Errorf("%w: %q", ErrInvalidSortField, sortField)
+	}
+	params.SortField = sortField
+
+	switch sortOrder {
+	case "asc":
+		params.SortOrder = 1
+	case "desc":
+		params.SortOrder = -1
+	default:
+		return params, ErrInvalidSortOrder
+	}
+
+	if token != "" {
+		c, err := decode(token)
+		if err != nil {
+			return params, ErrInvalidCursor
+		}
+		if c.SortField != params.SortField || c.SortOrder != params.SortOrder {
+			return params, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidCursor)
+		}
+		params.cursor = c
+	}
+
+	return params, nil
+}
+
+// Query narrows filter to the documents that come after the cursor. The
+// original filter is left untouched so it can still be used for counting.
+func (p Params) Query(filter bson.M) bson.M {
+	if p.cursor == nil {
+		return filter
+	}
+
+	op := "$gt"
+	if p.SortOrder < 0 {
+		op = "$lt"
+	}
+
+	// Documents missing the sort field sort as null, before every value in
+	// ascending order and after them in descending order. Comparisons never
+	// match null, so those documents are paged through by _id alone.
+	var keyset bson.A
+	if p.cursor.Value.Type == bsontype.Null {
+		keyset = bson.A{bson.M{p.SortField: nil, "_id": bson.M{op: p.cursor.ID}}}
+		if p.SortOrder > 0 {
+			keyset = append(keyset, bson.M{p.SortField: bson.M{"$ne": nil}})
+		}
+	} else {
+		keyset = bson.A{
+			bson.M{p.SortField: bson.M{op: p.cursor.Value}},
+			bson.M{p.SortField: p.cursor.Value, "_id": bson.M{op: p.cursor.ID}},
+		}
+		if p.SortOrder < 0 {
+			keyset = append(keyset, bson.M{p.SortField: nil})
+		}
+	}
+
+	if _, ok := filter["$or"]; ok {
+		return bson.M{"$and": bson.A{filter, bson.M{"$or": keyset}}}
+	}
+
+	query := bson.M{"$or": keyset}
+	for k, v := range filter {
+		query[k] = v
+	}
+	return query
+}
+
+// FindOptions sorts by the requested field with _id as a tie breaker and
+// fetches one extra document so NewPage can tell whether a next page exists.
+func (p Params) FindOptions() *options.FindOptions {
+	return options.Find().
+		SetSort(bson.D{{Key: p.SortField, Value: p.SortOrder}, {Key: "_id", Value: p.SortOrder}}).
+		SetLimit(int64(p.Limit + 1))
+}
+
+// NewPage trims the look-ahead document returned by a FindOptions query and
+// issues the cursor for the following page.
+func NewPage[T any](items []T, total int64, p Params) (*Page[T], error) {
+	page := &Page[T]{Items: items, Total: total}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+
+	if len(items) <= p.Limit {
+		return page, nil
+	}
+	page.Items = items[:p.Limit]
+
+	data, err := bson.Marshal(page.Items[p.Limit-1])
+	if err != nil {
+		return nil, err
+	}
+	raw := bson.Raw(data)
+
+	id, ok := raw.Lookup("_id").ObjectIDOK()
+	if !ok {
+		return nil, errors.New("pagination: item has no ObjectID _id")
+	}
+
+	// an item without the sort field is continued from by _id, see Query
+	value, err := raw.LookupErr(strings.Split(p.SortField, ".")...)
+	if err != nil {
+		value = bson.RawValue{Type: bsontype.Null}
+	}
+
+	token, err := encode(&cursor{SortField: p.SortField, SortOrder: p.SortOrder, Value: value, ID: id})
+	if err != nil {
+		return nil, err
+	}
+	page.NextCursor = token
+
+	return page, nil
+}
+
+func encode(c *cursor) (string, error) {
+	data, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decode(token string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var c cursor
+	if err := bson.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if c.Value.Type == 0 || c.ID.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+func allowed(field string, fields []string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
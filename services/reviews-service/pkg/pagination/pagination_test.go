@@ -0,0 +1,28 @@
+package pagination
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// canonical is the copy of this package whose tests cover it; see the
+// package comment.
+const canonical = "../../../products-service/pkg/pagination/pagination.go"
+
+func TestMatchesCanonicalCopy(t *testing.T) {
+	want, err := os.ReadFile(canonical)
+	if os.IsNotExist(err) {
+		t.Skip("products-service is not checked out next to this module")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("pagination.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("pagination.go differs from %s; change every copy together", canonical)
+	}
+}
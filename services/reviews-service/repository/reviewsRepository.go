@@ -6,17 +6,17 @@ import (
 	"time"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ReviewRepository interface {
-	GetAllReviews(ctx context.Context, limit, skip int, sortField string, sortOrder string, verified *bool) ([]domain.Review, error)
+	GetAllReviews(ctx context.Context, params pagination.Params, verified *bool) (*pagination.Page[domain.Review], error)
 	GetReviewByID(ctx context.Context, id primitive.ObjectID) (*domain.Review, error)
-	GetReviewsByCustomerID(ctx context.Context, customerID primitive.ObjectID, limit int, verified *bool) ([]domain.Review, error)
-	GetReviewsByProductID(ctx context.Context, productID primitive.ObjectID, limit int, verified *bool) ([]domain.Review, error)
+	GetReviewsByCustomerID(ctx context.Context, customerID primitive.ObjectID, params pagination.Params, verified *bool) (*pagination.Page[domain.Review], error)
+	GetReviewsByProductID(ctx context.Context, productID primitive.ObjectID, params pagination.Params, verified *bool) (*pagination.Page[domain.Review], error)
 	UpdateReview(ctx context.Context, review *domain.Review) error
 	DeleteReview(ctx context.Context, id primitive.ObjectID) error
 	CreateReview(ctx context.Context, review *domain.Review) error
@@ -47,36 +47,13 @@ func (r *reviewRepository) CreateReview(ctx context.Context, review *domain.Revi
 	return nil
 }
 
-func (r *reviewRepository) GetAllReviews(ctx context.Context, limit, skip int, sortField string, sortOrder string, verified *bool) ([]domain.Review, error) {
-	var reviews []domain.Review
-
+func (r *reviewRepository) GetAllReviews(ctx context.Context, params pagination.Params, verified *bool) (*pagination.Page[domain.Review], error) {
 	filter := bson.M{}
 	if verified != nil {
 		filter["verified"] = *verified
 	}
 
-	sortValue := 1
-	if sortOrder == "desc" {
-		sortValue = -1
-	}
-	sortOptions := bson.D{{Key: sortField, Value: sortValue}}
-
-	opts := options.Find().
-		SetLimit(int64(limit)).
-		SetSkip(int64(skip)).
-		SetSort(sortOptions)
-
-	cursor, err := r.collection.Find(ctx, filter, opts)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	if err := cursor.All(ctx, &reviews); err != nil {
-		return nil, err
-	}
-
-	return reviews, nil
+	return r.findPage(ctx, filter, params)
 }
 
 func (r *reviewRepository) GetReviewByID(ctx context.Context, id primitive.ObjectID) (*domain.Review, error) {
@@ -93,26 +70,13 @@ func (r *reviewRepository) GetReviewByID(ctx context.Context, id primitive.Objec
 	return &review, nil
 }
 
-func (r *reviewRepository) GetReviewsByCustomerID(ctx context.Context, customerID primitive.ObjectID, limit int, verified *bool) ([]domain.Review, error) {
+func (r *reviewRepository) GetReviewsByCustomerID(ctx context.Context, customerID primitive.ObjectID, params pagination.Params, verified *bool) (*pagination.Page[domain.Review], error) {
 	filter := bson.M{"customer_id": customerID}
 	if verified != nil {
 		filter["verified"] = *verified
 	}
 
-	opts := options.Find().SetLimit(int64(limit))
-
-	cursor, err := r.collection.Find(ctx, filter, opts)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	var reviews []domain.Review
-	if err := cursor.All(ctx, &reviews); err != nil {
-		return nil, err
-	}
-
-	return reviews, nil
+	return r.findPage(ctx, filter, params)
 }
 
 func (r *reviewRepository) GetReviewsByCustomerAndProductIDs(ctx context.Context, productID primitive.ObjectID, customerID primitive.ObjectID) ([]domain.Review, error) {
@@ -132,28 +96,33 @@ func (r *reviewRepository) GetReviewsByCustomerAndProductIDs(ctx context.Context
 	return reviews, nil
 }
 
-func (r *reviewRepository) GetReviewsByProductID(ctx context.Context, productID primitive.ObjectID, limit int, verified *bool) ([]domain.Review, error) {
-	var reviews []domain.Review
-
+func (r *reviewRepository) GetReviewsByProductID(ctx context.Context, productID primitive.ObjectID, params pagination.Params, verified *bool) (*pagination.Page[domain.Review], error) {
 	filter := bson.M{"product_id": productID}
 	if verified != nil {
 		filter["verified"] = *verified
 	}
 
-	opts := options.Find().
-		SetLimit(int64(limit))
+	return r.findPage(ctx, filter, params)
+}
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
+func (r *reviewRepository) findPage(ctx context.Context, filter bson.M, params pagination.Params) (*pagination.Page[domain.Review], error) {
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.collection.Find(ctx, params.Query(filter), params.FindOptions())
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
+	var reviews []domain.Review
 	if err := cursor.All(ctx, &reviews); err != nil {
 		return nil, err
 	}
 
-	return reviews, nil
+	return pagination.NewPage(reviews, total, params)
 }
 
 func (r *reviewRepository) GetReviewStatsByProductID(ctx context.Context, productID primitive.ObjectID, verified *bool) (float64, error) {
@@ -6,15 +6,16 @@ import (
 	"fmt"
 
 	"github.com/mephirious/group-project/services/products-service/domain"
+	"github.com/mephirious/group-project/services/products-service/pkg/pagination"
 	"github.com/mephirious/group-project/services/products-service/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ReviewUseCase interface {
-	GetAllReviews(ctx context.Context, limit, skip int, sortField string, sortOrder string, verified *bool) ([]domain.Review, error)
+	GetAllReviews(ctx context.Context, params pagination.Params, verified *bool) (*pagination.Page[domain.Review], error)
 	GetReviewByID(ctx context.Context, id primitive.ObjectID) (*domain.Review, error)
-	GetReviewsByCustomerID(ctx context.Context, customerID primitive.ObjectID, limit int, verified *bool) ([]domain.Review, error)
-	GetReviewsByProductID(ctx context.Context, productID primitive.ObjectID, limit int, verified *bool) ([]domain.Review, int64, float64, error)
+	GetReviewsByCustomerID(ctx context.Context, customerID primitive.ObjectID, params pagination.Params, verified *bool) (*pagination.Page[domain.Review], error)
+	GetReviewsByProductID(ctx context.Context, productID primitive.ObjectID, params pagination.Params, verified *bool) (*pagination.Page[domain.Review], float64, error)
 	UpdateReview(ctx context.Context, review *domain.Review) error
 	DeleteReview(ctx context.Context, id primitive.ObjectID) error
 	CreateReview(ctx context.Context, review *domain.Review) error
@@ -42,8 +43,8 @@ func (u *reviewUseCase) CreateReview(ctx context.Context, review *domain.Review)
 	return u.reviewRepository.CreateReview(ctx, review)
 }
 
-func (u *reviewUseCase) GetAllReviews(ctx context.Context, limit, skip int, sortField, sortOrder string, verified *bool) ([]domain.Review, error) {
-	return u.reviewRepository.GetAllReviews(ctx, limit, skip, sortField, sortOrder, verified)
+func (u *reviewUseCase) GetAllReviews(ctx context.Context, params pagination.Params, verified *bool) (*pagination.Page[domain.Review], error) {
+	return u.reviewRepository.GetAllReviews(ctx, params, verified)
 }
 func (u *reviewUseCase) GetReviewByID(ctx context.Context, id primitive.ObjectID) (*domain.Review, error) {
 	review, err := u.reviewRepository.GetReviewByID(ctx, id)
@@ -56,32 +57,27 @@ func (u *reviewUseCase) GetReviewByID(ctx context.Context, id primitive.ObjectID
 	return review, nil
 }
 
-func (u *reviewUseCase) GetReviewsByCustomerID(ctx context.Context, customerID primitive.ObjectID, limit int, verified *bool) ([]domain.Review, error) {
-	return u.reviewRepository.GetReviewsByCustomerID(ctx, customerID, limit, verified)
+func (u *reviewUseCase) GetReviewsByCustomerID(ctx context.Context, customerID primitive.ObjectID, params pagination.Params, verified *bool) (*pagination.Page[domain.Review], error) {
+	return u.reviewRepository.GetReviewsByCustomerID(ctx, customerID, params, verified)
 }
 
-func (u *reviewUseCase) GetReviewsByProductID(ctx context.Context, productID primitive.ObjectID, limit int, verified *bool) ([]domain.Review, int64, float64, error) {
-	reviews, err := u.reviewRepository.GetReviewsByProductID(ctx, productID, limit, verified)
+func (u *reviewUseCase) GetReviewsByProductID(ctx context.Context, productID primitive.ObjectID, params pagination.Params, verified *bool) (*pagination.Page[domain.Review], float64, error) {
+	reviews, err := u.reviewRepository.GetReviewsByProductID(ctx, productID, params, verified)
 	if err != nil {
-		return nil, 0, 0, err
+		return nil, 0, err
 	}
 
 	averageRating, ok := ratingsData[productID]
 	if !ok {
 		averageRating, err = u.reviewRepository.GetReviewStatsByProductID(ctx, productID, verified)
 		if err != nil {
-			return nil, 0, 0, err
+			return nil, 0, err
 		}
 		ratingsData[productID] = averageRating
 		fmt.Printf("Average rating updated: %v: %v/5\n", productID, averageRating)
 	}
 
-	totalReviews, err := u.reviewRepository.GetAllReviewsCount(ctx, verified)
-	if err != nil {
-		return nil, 0, 0, err
-	}
-
-	return reviews, totalReviews, averageRating, nil
+	return reviews, averageRating, nil
 }
 
 func (u *reviewUseCase) UpdateReview(ctx context.Context, review *domain.Review) error {